@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/service"
+)
+
+// runVerifyAllVersions verifies every published version of pkg and prints
+// each result. It fails like a single verification would for the first
+// version that does not pass --strict or --allowed-publisher. progress is the
+// reporter the service was created with, finished once verification ends.
+// tmpl, when not nil, formats each result in place of the text output.
+func runVerifyAllVersions(
+	cmd *cobra.Command,
+	provenanceService *service.Service,
+	pkg domain.PackageIdentifier,
+	progress *progressReporter,
+	tmpl *template.Template,
+) error {
+	results, err := provenanceService.VerifyAllVersions(cmd.Context(), pkg.Name, pkg.Protocol)
+	progress.finish()
+	cmd.SilenceUsage = true
+	if results == nil && err != nil {
+		return &exitError{code: exitVerificationError, err: err}
+	}
+	// Per-version errors are reported in the results themselves
+
+	switch {
+	case verifyOutputFormat == "json":
+		outputs := make([]provenanceResultOutput, len(results))
+		for i, result := range results {
+			outputs[i] = newProvenanceResultOutput(result, collectWarnings(nil, result))
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(outputs); err != nil {
+			return fmt.Errorf("failed to encode provenance results: %w", err)
+		}
+	case tmpl != nil:
+		for _, result := range results {
+			if err := writeResultTemplate(cmd.OutOrStdout(), tmpl, result); err != nil {
+				return err
+			}
+			if explain {
+				printExplanation(cmd, result)
+			}
+			printWarnings(cmd, collectWarnings(nil, result))
+		}
+	default:
+		cmd.Printf("Verified %d versions of %s\n", len(results), pkg.Name)
+		for _, result := range results {
+			cmd.Println()
+			printProvenanceResult(cmd, result)
+			if explain {
+				printExplanation(cmd, result)
+			}
+			printWarnings(cmd, collectWarnings(nil, result))
+		}
+	}
+
+	for _, result := range results {
+		if err := provenanceExitError(result, strict); err != nil {
+			return versionExitError(result, err)
+		}
+		if err := publisherExitError(result, allowedPublishers, strict); err != nil {
+			return versionExitError(result, err)
+		}
+		if err := minAgeExitError(result, minAge, strict, time.Now()); err != nil {
+			return versionExitError(result, err)
+		}
+	}
+	return nil
+}
+
+// versionExitError prefixes an exit error with the version it is about.
+func versionExitError(result *domain.ProvenanceResult, err error) error {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return &exitError{code: exitErr.code, err: fmt.Errorf("version %s: %w", result.PackageID.Version, exitErr.err)}
+	}
+	return fmt.Errorf("version %s: %w", result.PackageID.Version, err)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Dockerfile")
+	if err := os.WriteFile(path, []byte("old contents that are longer\n"), 0o600); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+
+	if err := writeFileAtomic(path, []byte("FROM scratch\n"), 0o600); err != nil {
+		t.Fatalf("writeFileAtomic() error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if string(got) != "FROM scratch\n" {
+		t.Errorf("file contents = %q, want %q", got, "FROM scratch\n")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("file mode = %v, want 0600", mode)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory contains %d entries, want only the Dockerfile", len(entries))
+	}
+}
+
+func TestWriteFileAtomic_MissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "missing", "Dockerfile")
+	if err := writeFileAtomic(path, []byte("FROM scratch\n"), 0o600); err == nil {
+		t.Errorf("writeFileAtomic(%q) = nil, want error", path)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// baselineFormatVersion is the version of the baseline file format.
+const baselineFormatVersion = 1
+
+// provenanceBaseline is a saved set of provenance results to detect drift
+// against. Packages are keyed by protocol and name, without the version, so a
+// version bump is compared against the previous version's result.
+type provenanceBaseline struct {
+	Version  int                      `json:"version"`
+	Packages map[string]baselineEntry `json:"packages"`
+}
+
+// baselineEntry holds the stable fields of a provenance result. Volatile
+// details, such as timings and per-file statuses, are left out so that saving
+// an unchanged result produces an identical file.
+type baselineEntry struct {
+	Version             string `json:"version,omitempty"`
+	Status              string `json:"status"`
+	HasAttestations     bool   `json:"has_attestations,omitempty"`
+	HasSignatures       bool   `json:"has_signatures,omitempty"`
+	PublisherKind       string `json:"publisher_kind,omitempty"`
+	PublisherRepository string `json:"publisher_repository,omitempty"`
+	RepositoryURI       string `json:"repository_uri,omitempty"`
+}
+
+// baselineKey identifies a package in a baseline.
+func baselineKey(pkg domain.PackageIdentifier) string {
+	return string(pkg.Protocol) + ":" + pkg.Name
+}
+
+// newBaselineEntry extracts the stable fields of result.
+func newBaselineEntry(result *domain.ProvenanceResult) baselineEntry {
+	entry := baselineEntry{
+		Version:         result.PackageID.Version,
+		Status:          string(result.Status),
+		HasAttestations: result.HasAttestations,
+		HasSignatures:   result.HasSignatures,
+		RepositoryURI:   result.RepositoryURI,
+	}
+	if p := result.TrustedPublisher; p != nil {
+		entry.PublisherKind = p.Kind
+		entry.PublisherRepository = p.Repository
+	}
+	return entry
+}
+
+// readBaseline reads the baseline at path. A missing file is an empty baseline.
+func readBaseline(path string) (*provenanceBaseline, error) {
+	baseline := &provenanceBaseline{Version: baselineFormatVersion, Packages: make(map[string]baselineEntry)}
+	data, err := os.ReadFile(path) //#nosec G304 -- path is chosen by the user running the CLI
+	if errors.Is(err, os.ErrNotExist) {
+		return baseline, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	if err := json.Unmarshal(data, baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if baseline.Version != baselineFormatVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", baseline.Version, path)
+	}
+	if baseline.Packages == nil {
+		baseline.Packages = make(map[string]baselineEntry)
+	}
+	return baseline, nil
+}
+
+// writeBaseline writes baseline to path as indented JSON. Map keys are sorted
+// by encoding/json, so the output is stable.
+func writeBaseline(path string, baseline *provenanceBaseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write baseline to %s: %w", path, err)
+	}
+	return nil
+}
+
+// statusRank orders statuses by the strength of their evidence.
+func statusRank(status string) int {
+	switch domain.ProvenanceStatus(status) {
+	case domain.ProvenanceStatusVerified, domain.ProvenanceStatusTrustedPublisher:
+		return 4
+	case domain.ProvenanceStatusAttestations:
+		return 3
+	case domain.ProvenanceStatusSignatures:
+		return 2
+	case domain.ProvenanceStatusNone:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// baselineRegressions describes how current is worse than previous: a weaker
+// status, or a publisher that changed or disappeared.
+func baselineRegressions(previous, current baselineEntry) []string {
+	var regressions []string
+	if statusRank(current.Status) < statusRank(previous.Status) {
+		regressions = append(regressions, fmt.Sprintf("status regressed from %s to %s", previous.Status, current.Status))
+	}
+	if previous.PublisherRepository != "" && current.PublisherRepository != previous.PublisherRepository {
+		regressions = append(regressions, fmt.Sprintf("publisher repository changed from %q to %q",
+			previous.PublisherRepository, current.PublisherRepository))
+	}
+	if previous.PublisherKind != "" && current.PublisherKind != "" && current.PublisherKind != previous.PublisherKind {
+		regressions = append(regressions, fmt.Sprintf("publisher kind changed from %s to %s",
+			previous.PublisherKind, current.PublisherKind))
+	}
+	return regressions
+}
+
+// checkBaseline compares result against the baseline at baselinePath, when
+// given, and then records it in the baseline at savePath, when given. Either
+// may be the same file. Regressions are printed and fail with
+// exitBaselineRegression after the baseline has been saved.
+func checkBaseline(cmd *cobra.Command, result *domain.ProvenanceResult, baselinePath, savePath string) error {
+	key := baselineKey(result.PackageID)
+	current := newBaselineEntry(result)
+
+	var regressions []string
+	if baselinePath != "" {
+		baseline, err := readBaseline(baselinePath)
+		if err != nil {
+			return err
+		}
+		if previous, ok := baseline.Packages[key]; ok {
+			regressions = baselineRegressions(previous, current)
+		} else {
+			cmd.Printf("ℹ  %s is not in baseline %s\n", key, baselinePath)
+		}
+	}
+
+	if savePath != "" {
+		baseline, err := readBaseline(savePath)
+		if err != nil {
+			return err
+		}
+		baseline.Packages[key] = current
+		if err := writeBaseline(savePath, baseline); err != nil {
+			return err
+		}
+	}
+
+	if len(regressions) == 0 {
+		return nil
+	}
+	for _, regression := range regressions {
+		cmd.Printf("✗ Baseline regression: %s\n", regression)
+	}
+	return &exitError{code: exitBaselineRegression,
+		err: fmt.Errorf("%s regressed against baseline %s: %d change(s)", key, baselinePath, len(regressions))}
+}
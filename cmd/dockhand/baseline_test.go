@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestBaselineRegressions(t *testing.T) {
+	t.Parallel()
+
+	verified := baselineEntry{Status: "VERIFIED", PublisherKind: "GitHub", PublisherRepository: "upstash/context7"}
+	moved := baselineEntry{Status: "VERIFIED", PublisherKind: "GitHub", PublisherRepository: "fork/context7"}
+
+	tests := []struct {
+		name     string
+		previous baselineEntry
+		current  baselineEntry
+		want     int
+	}{
+		{"unchanged", verified, verified, 0},
+		{"improved", baselineEntry{Status: "SIGNATURES"}, verified, 0},
+		{"status regressed", verified, baselineEntry{Status: "NONE"}, 2},
+		{"publisher moved", verified, moved, 1},
+		{"error after none", baselineEntry{Status: "NONE"}, baselineEntry{Status: "ERROR"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := baselineRegressions(tt.previous, tt.current); len(got) != tt.want {
+				t.Errorf("baselineRegressions() = %q, want %d regression(s)", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBaseline(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	pkg := domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14"}
+	verified := &domain.ProvenanceResult{
+		PackageID:        pkg,
+		Status:           domain.ProvenanceStatusVerified,
+		HasAttestations:  true,
+		TrustedPublisher: &domain.TrustedPublisher{Kind: "GitHub", Repository: "upstash/context7"},
+		Details:          map[string]interface{}{"metadata_ms": 12},
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetErr(&bytes.Buffer{})
+
+	// Saving twice must give the same file, since timings are left out
+	if err := checkBaseline(cmd, verified, "", path); err != nil {
+		t.Fatalf("checkBaseline(save) error: %v", err)
+	}
+	first, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified.Details["metadata_ms"] = 99
+	if err := checkBaseline(cmd, verified, path, path); err != nil {
+		t.Fatalf("checkBaseline(unchanged) error: %v", err)
+	}
+	second, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("baseline changed without a result change:\n%s\n%s", first, second)
+	}
+
+	pkg.Version = "1.0.15"
+	none := &domain.ProvenanceResult{PackageID: pkg, Status: domain.ProvenanceStatusNone}
+	err = checkBaseline(cmd, none, path, "")
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitBaselineRegression {
+		t.Errorf("checkBaseline(regressed) = %v, want exit code %d", err, exitBaselineRegression)
+	}
+}
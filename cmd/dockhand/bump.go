@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/pkg/build"
+)
+
+func newBumpCmd() *cobra.Command {
+	var (
+		configs []string
+		to      string
+		latest  bool
+		noCheck bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bump",
+		Short: "Update spec.version in MCP server specs in place",
+		Long: `Bump sets spec.version of each spec given with -c, either to --to or, with
+--latest, to the newest release in the package's registry. Only the version
+is rewritten: comments, quoting, and the rest of the file are left as they
+are. A spec without spec.version gets one after spec.package.
+
+Before writing, the version is looked up in the registry, and a version that
+does not exist fails the spec; --no-check skips the lookup. npx, uvx, and go
+packages can be looked up. Every spec is attempted, and the command exits
+non-zero if any of them failed.`,
+		Example: `  # Pin a new version
+  dockhand bump -c npx/context7/spec.yaml --to 1.0.15
+
+  # Move several specs to their newest releases
+  dockhand bump -c npx/context7/spec.yaml -c uvx/fetch/spec.yaml --latest`,
+		RunE: withTimeout(func(cmd *cobra.Command, _ []string) error {
+			return runBump(cmd, configs, to, latest, noCheck)
+		}),
+	}
+
+	cmd.Flags().StringArrayVarP(&configs, "config", "c", nil, "Spec file to update (repeatable)")
+	cmd.Flags().StringVar(&to, "to", "", "Version to set, e.g. 2.0.0")
+	cmd.Flags().BoolVar(&latest, "latest", false, "Set the newest release in the package's registry")
+	cmd.Flags().BoolVar(&noCheck, "no-check", false, "Write --to without checking that the registry has it")
+	_ = cmd.MarkFlagRequired("config")
+	cmd.MarkFlagsOneRequired("to", "latest")
+	cmd.MarkFlagsMutuallyExclusive("to", "latest")
+	cmd.MarkFlagsMutuallyExclusive("latest", "no-check")
+
+	return cmd
+}
+
+// versionResolver looks up package versions in their registries
+type versionResolver interface {
+	ResolveVersion(ctx context.Context, pkg domain.PackageIdentifier) (string, error)
+}
+
+// runBump updates the version of every spec in configs.
+func runBump(cmd *cobra.Command, configs []string, to string, latest, noCheck bool) error {
+	var resolver versionResolver
+	if !noCheck {
+		svc, err := createProvenanceService(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to create provenance service: %w", err)
+		}
+		resolver = svc
+	}
+
+	requested := to
+	if latest {
+		requested = "latest"
+	}
+
+	failed := 0
+	for _, config := range configs {
+		if err := bumpSpec(cmd, resolver, config, requested); err != nil {
+			cmd.PrintErrf("✗ %s: %v\n", config, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d spec(s) failed to bump", failed, len(configs))
+	}
+	return nil
+}
+
+// bumpSpec sets the version of the spec at path to requested, resolved in the
+// registry unless resolver is nil.
+func bumpSpec(cmd *cobra.Command, resolver versionResolver, path, requested string) error {
+	spec, err := loadMCPServerSpec(path)
+	if err != nil {
+		return err
+	}
+	if spec.Metadata.Protocol == string(domain.ProtocolGo) && strings.Contains(spec.Spec.Package, "@") {
+		return fmt.Errorf("spec.package pins its version inline; edit it there")
+	}
+
+	version := requested
+	if resolver != nil {
+		pkg := domain.PackageIdentifier{
+			Protocol: domain.PackageProtocol(spec.Metadata.Protocol),
+			Name:     spec.Spec.Package,
+			Version:  requested,
+		}
+		if version, err = resolver.ResolveVersion(cmd.Context(), pkg); err != nil {
+			return fmt.Errorf("failed to look up %s@%s: %w", spec.Spec.Package, requested, err)
+		}
+	}
+
+	if version == spec.Spec.Version {
+		cmd.Printf("%s is already at %s\n", path, version)
+		return nil
+	}
+	// The version must be one the protocol scheme accepts; OCI specs have none
+	if spec.Metadata.Protocol != string(domain.ProtocolOCI) {
+		bumped := *spec
+		bumped.Spec.Version = version
+		if _, err := build.ProtocolScheme(&bumped); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(path) //#nosec G304 -- loadMCPServerSpec validated the path
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+	updated, err := setSpecVersion(data, version)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat spec: %w", err)
+	}
+	if err := writeFileAtomic(path, updated, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write spec: %w", err)
+	}
+
+	from := spec.Spec.Version
+	if from == "" {
+		from = "(unset)"
+	}
+	cmd.Printf("Bumped %s: %s → %s\n", path, from, version)
+	return nil
+}
+
+// setSpecVersion returns data, a spec document, with spec.version set to
+// version. The yaml.v3 node tree only locates the field: the new value is
+// spliced into the original bytes, so comments, quoting, and formatting
+// elsewhere are kept exactly. A missing spec.version is added on the line
+// after spec.package.
+func setSpecVersion(data []byte, version string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("spec is empty")
+	}
+	specNode := mappingValue(doc.Content[0], "spec")
+	if specNode == nil || specNode.Kind != yaml.MappingNode || specNode.Style&yaml.FlowStyle != 0 {
+		return nil, fmt.Errorf("spec must be a block mapping to be edited in place")
+	}
+
+	// Splice by line, keeping each line's own ending
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if versionNode := mappingValue(specNode, "version"); versionNode != nil {
+		if err := replaceScalar(lines, versionNode, version); err != nil {
+			return nil, err
+		}
+	} else {
+		packageKey, packageNode := mappingEntry(specNode, "package")
+		if packageNode == nil || packageNode.Kind != yaml.ScalarNode ||
+			packageNode.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			return nil, fmt.Errorf("spec.package must be a single-line value to add spec.version after it")
+		}
+		at := packageNode.Line - 1
+		ending := "\n"
+		if bytes.HasSuffix(lines[at], []byte("\r\n")) {
+			ending = "\r\n"
+		} else if !bytes.HasSuffix(lines[at], []byte("\n")) {
+			lines[at] = append(lines[at], '\n')
+		}
+		line := fmt.Sprintf("%sversion: %q%s", strings.Repeat(" ", packageKey.Column-1), version, ending)
+		lines = append(lines[:at+1], append([][]byte{[]byte(line)}, lines[at+1:]...)...)
+	}
+	updated := bytes.Join(lines, nil)
+
+	// Make sure the edit produced the document intended
+	var check MCPServerSpec
+	if err := yaml.Unmarshal(updated, &check); err != nil || check.Spec.Version != version {
+		return nil, fmt.Errorf("failed to set spec.version in place; edit the spec by hand")
+	}
+	return updated, nil
+}
+
+// replaceScalar replaces the single-line scalar node in lines with value,
+// quoted the way the node was.
+func replaceScalar(lines [][]byte, node *yaml.Node, value string) error {
+	var quote string
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		quote = `"`
+	case yaml.SingleQuotedStyle:
+		quote = "'"
+	case 0:
+	default:
+		return fmt.Errorf("spec.version must be a plain or quoted scalar to be edited in place")
+	}
+	if node.Kind != yaml.ScalarNode || node.Tag != "!!str" && node.Tag != "!!int" && node.Tag != "!!float" {
+		return fmt.Errorf("spec.version must be a scalar to be edited in place")
+	}
+
+	// Columns count characters, not bytes
+	line := []rune(string(lines[node.Line-1]))
+	start := node.Column - 1
+	old := quote + node.Value + quote
+	if start < 0 || start+len([]rune(old)) > len(line) || string(line[start:start+len([]rune(old))]) != old {
+		return fmt.Errorf("spec.version is written in a form that cannot be edited in place")
+	}
+	replaced := string(line[:start]) + quote + value + quote + string(line[start+len([]rune(old)):])
+	lines[node.Line-1] = []byte(replaced)
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	_, value := mappingEntry(mapping, key)
+	return value
+}
+
+// mappingEntry returns the key and value nodes of key in a mapping node, or nils.
+func mappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
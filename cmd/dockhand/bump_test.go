@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetSpecVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		spec    string
+		version string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "double-quoted with comments",
+			spec: `# Context7 MCP server
+metadata:
+  name: context7
+  protocol: npx
+
+spec:
+  package: "@upstash/context7-mcp"
+  version: "1.0.14"   # pinned after review
+  args: ["--transport", "stdio"]
+`,
+			version: "1.0.15",
+			want: `# Context7 MCP server
+metadata:
+  name: context7
+  protocol: npx
+
+spec:
+  package: "@upstash/context7-mcp"
+  version: "1.0.15"   # pinned after review
+  args: ["--transport", "stdio"]
+`,
+		},
+		{
+			name:    "plain",
+			spec:    "metadata:\n  name: fetch\n  protocol: uvx\nspec:\n    package: mcp-server-fetch\n    version: 2025.1.0\n",
+			version: "2025.4.7",
+			want:    "metadata:\n  name: fetch\n  protocol: uvx\nspec:\n    package: mcp-server-fetch\n    version: 2025.4.7\n",
+		},
+		{
+			name:    "single-quoted with CRLF",
+			spec:    "spec:\r\n  package: pkg\r\n  version: '1.0'\r\n",
+			version: "1.1",
+			want:    "spec:\r\n  package: pkg\r\n  version: '1.1'\r\n",
+		},
+		{
+			name:    "missing version",
+			spec:    "spec:\n  package: \"pkg\" # the npm package\n  args: [\"a\"]\n",
+			version: "2.0.0",
+			want:    "spec:\n  package: \"pkg\" # the npm package\n  version: \"2.0.0\"\n  args: [\"a\"]\n",
+		},
+		{
+			name:    "missing version at end of file",
+			spec:    "spec:\n  package: pkg",
+			version: "2.0.0",
+			want:    "spec:\n  package: pkg\n  version: \"2.0.0\"\n",
+		},
+		{
+			name:    "version in another section is untouched",
+			spec:    "metadata:\n  version: \"1.0.0\"\nspec:\n  package: pkg\n  version: \"1.0.0\"\n",
+			version: "1.2.0",
+			want:    "metadata:\n  version: \"1.0.0\"\nspec:\n  package: pkg\n  version: \"1.2.0\"\n",
+		},
+		{
+			name:    "flow mapping",
+			spec:    "spec: {package: pkg, version: 1.0.0}\n",
+			version: "2.0.0",
+			wantErr: "block mapping",
+		},
+		{
+			name:    "block scalar",
+			spec:    "spec:\n  package: pkg\n  version: |\n    1.0.0\n",
+			version: "2.0.0",
+			wantErr: "plain or quoted scalar",
+		},
+		{
+			name:    "no spec",
+			spec:    "metadata:\n  name: x\n",
+			version: "2.0.0",
+			wantErr: "block mapping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := setSpecVersion([]byte(tt.spec), tt.version)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("setSpecVersion() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setSpecVersion() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("setSpecVersion() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/npm"
+	"github.com/stacklok/dockyard/internal/provenance/pypi"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// digestSizes maps the artifact digest algorithms --artifact-digest accepts
+// to their length in bytes.
+var digestSizes = map[string]int{"sha256": 32, "sha384": 48, "sha512": 64}
+
+// bundleVerifyOutput is the JSON form of a `verify-provenance --bundle` result.
+type bundleVerifyOutput struct {
+	Bundle         string `json:"bundle"`
+	Artifact       string `json:"artifact,omitempty"`
+	ArtifactDigest string `json:"artifact_digest"`
+	Verified       bool   `json:"verified"`
+	Subject        string `json:"subject,omitempty"`
+	Signer         string `json:"signer,omitempty"`
+	Issuer         string `json:"issuer,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// parseArtifactDigest splits an algorithm:hex digest such as sha256:ab12...
+func parseArtifactDigest(value string) (algorithm string, digest []byte, err error) {
+	algorithm, hexDigest, ok := strings.Cut(value, ":")
+	size, known := digestSizes[strings.ToLower(algorithm)]
+	if !ok || !known {
+		return "", nil, fmt.Errorf("invalid artifact digest %q: must be sha256:, sha384:, or sha512: followed by hex", value)
+	}
+	digest, err = hex.DecodeString(hexDigest)
+	if err != nil || len(digest) != size {
+		return "", nil, fmt.Errorf("invalid artifact digest %q: want %d hex-encoded bytes", value, size)
+	}
+	return strings.ToLower(algorithm), digest, nil
+}
+
+// localArtifactDigest hashes a package file the way its registry's
+// attestations do: npm tarballs (.tgz) with sha512, anything else, such as
+// Python wheels and sdists, with sha256.
+func localArtifactDigest(path string) (algorithm string, digest []byte, err error) {
+	f, err := os.Open(path) //#nosec G304 -- path is chosen by the user running the CLI
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open local artifact: %w", err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(strings.ToLower(path), ".tgz") {
+		digest, err = npm.ArtifactDigest(f)
+		algorithm = "sha512"
+	} else {
+		digest, err = pypi.ArtifactDigest(f)
+		algorithm = "sha256"
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to hash local artifact: %w", err)
+	}
+	return algorithm, digest, nil
+}
+
+// bundleArtifactDigest returns the digest to verify --bundle against, from
+// --artifact-digest or by hashing --local-artifact.
+func bundleArtifactDigest() (algorithm string, digest []byte, err error) {
+	switch {
+	case bundlePath == "":
+		return "", nil, fmt.Errorf("--artifact-digest and --local-artifact require --bundle")
+	case localArtifact != "":
+		return localArtifactDigest(localArtifact)
+	case artifactDigest != "":
+		return parseArtifactDigest(artifactDigest)
+	default:
+		return "", nil, fmt.Errorf("--bundle requires --artifact-digest or --local-artifact")
+	}
+}
+
+// runVerifyBundle verifies a local Sigstore bundle against an artifact digest,
+// without contacting any package registry. The signer must match
+// --cert-identity-regexp and --cert-oidc-issuer, by default any GitHub
+// Actions workflow.
+func runVerifyBundle(cmd *cobra.Command) error {
+	algorithm, digest, err := bundleArtifactDigest()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(bundlePath) //#nosec G304 -- path is chosen by the user running the CLI
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+	identityPolicy, err := certificateIdentity().PolicyOption(sigstore.GitHubActionsIssuer, sigstore.AnyGitHubSANRegexp)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+	bundleVerifier, err := sharedBundleVerifier(cmd.Context())
+	if err != nil {
+		return &exitError{code: exitVerificationError, err: err}
+	}
+
+	out := bundleVerifyOutput{
+		Bundle:         bundlePath,
+		Artifact:       localArtifact,
+		ArtifactDigest: algorithm + ":" + hex.EncodeToString(digest),
+	}
+	verifyResult, verifyErr := bundleVerifier.VerifyBundle(data, algorithm, digest, identityPolicy)
+	if verifyErr == nil {
+		// The statement must describe the artifact, not merely be signed over its digest
+		var subject *sigstore.Subject
+		subject, verifyErr = sigstore.MatchSubject(verifyResult, algorithm, digest)
+		if subject != nil {
+			out.Subject = subject.Name
+		}
+	}
+	if verifyErr == nil {
+		out.Verified = true
+		if cert := verifyResult.Signature.Certificate; cert != nil {
+			out.Signer = cert.SubjectAlternativeName
+			out.Issuer = cert.Issuer
+		}
+	} else {
+		out.Error = verifyErr.Error()
+	}
+
+	if verifyOutputFormat == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return fmt.Errorf("failed to encode bundle verification result: %w", err)
+		}
+	} else {
+		printBundleVerifyOutput(cmd, out)
+	}
+
+	if verifyErr != nil {
+		return &exitError{code: exitVerificationError, err: fmt.Errorf("bundle verification failed: %w", verifyErr)}
+	}
+	return nil
+}
+
+// printBundleVerifyOutput prints a bundle verification result as text.
+func printBundleVerifyOutput(cmd *cobra.Command, out bundleVerifyOutput) {
+	cmd.Printf("Bundle: %s\n", out.Bundle)
+	if out.Artifact != "" {
+		cmd.Printf("Artifact: %s\n", out.Artifact)
+	}
+	cmd.Printf("Artifact digest: %s\n", out.ArtifactDigest)
+	if !out.Verified {
+		cmd.Printf("✗ Error: %s\n", out.Error)
+		return
+	}
+	cmd.Printf("✓✓ Bundle VERIFIED cryptographically!\n")
+	if out.Subject != "" {
+		cmd.Printf("  Subject: %s\n", out.Subject)
+	}
+	if out.Signer != "" {
+		cmd.Printf("  Signer: %s (issuer %s)\n", out.Signer, out.Issuer)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseArtifactDigest(t *testing.T) {
+	t.Parallel()
+
+	sha256Hex := strings.Repeat("ab", 32)
+	tests := []struct {
+		input         string
+		wantAlgorithm string
+		wantLen       int
+		wantErr       bool
+	}{
+		{"sha256:" + sha256Hex, "sha256", 32, false},
+		{"SHA256:" + sha256Hex, "sha256", 32, false},
+		{"sha512:" + strings.Repeat("cd", 64), "sha512", 64, false},
+		{"sha384:" + strings.Repeat("ef", 48), "sha384", 48, false},
+		{sha256Hex, "", 0, true},
+		{"md5:" + strings.Repeat("ab", 16), "", 0, true},
+		{"sha256:" + sha256Hex[:62], "", 0, true},
+		{"sha256:" + strings.Repeat("zz", 32), "", 0, true},
+		{"sha512:" + sha256Hex, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		algorithm, digest, err := parseArtifactDigest(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseArtifactDigest(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if algorithm != tt.wantAlgorithm || len(digest) != tt.wantLen {
+			t.Errorf("parseArtifactDigest(%q) = %s with %d bytes, want %s with %d", tt.input, algorithm, len(digest),
+				tt.wantAlgorithm, tt.wantLen)
+		}
+	}
+}
+
+func TestLocalArtifactDigest(t *testing.T) {
+	t.Parallel()
+
+	contents := []byte("package contents")
+	sha256Sum := sha256.Sum256(contents)
+	sha512Sum := sha512.Sum512(contents)
+
+	tests := []struct {
+		file          string
+		wantAlgorithm string
+		wantDigest    []byte
+	}{
+		{"pkg-1.0.0.tgz", "sha512", sha512Sum[:]},
+		{"PKG-1.0.0.TGZ", "sha512", sha512Sum[:]},
+		{"pkg-1.0.0-py3-none-any.whl", "sha256", sha256Sum[:]},
+		{"pkg-1.0.0.tar.gz", "sha256", sha256Sum[:]},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		if err := os.WriteFile(path, contents, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		algorithm, digest, err := localArtifactDigest(path)
+		if err != nil {
+			t.Errorf("localArtifactDigest(%s) error: %v", tt.file, err)
+			continue
+		}
+		if algorithm != tt.wantAlgorithm || !bytes.Equal(digest, tt.wantDigest) {
+			t.Errorf("localArtifactDigest(%s) = %s:%x, want %s:%x", tt.file, algorithm, digest, tt.wantAlgorithm, tt.wantDigest)
+		}
+	}
+
+	if _, _, err := localArtifactDigest(filepath.Join(dir, "missing.tgz")); err == nil {
+		t.Error("localArtifactDigest(missing) succeeded, want error")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultsFileName is the defaults file read from the working directory when
+// --config-defaults is not given.
+const defaultsFileName = ".dockyard.yaml"
+
+// cliDefaults is the content of a defaults file. Every field is optional;
+// values set here apply only to flags not given on the command line.
+type cliDefaults struct {
+	// Registry is the image repository prefix, as for --registry
+	Registry string `yaml:"registry"`
+	// Timeout bounds build and verify-provenance, as for --timeout
+	Timeout *time.Duration `yaml:"timeout"`
+	// HTTPTimeout is the timeout for each registry request made during provenance verification
+	HTTPTimeout *time.Duration `yaml:"http_timeout"`
+	// Provenance holds the provenance requirements for build
+	Provenance provenanceDefaults `yaml:"provenance"`
+}
+
+// provenanceDefaults are the provenance settings of a defaults file.
+type provenanceDefaults struct {
+	// Check requires provenance verification, as for --check-provenance
+	Check *bool `yaml:"check"`
+	// WarnOnNone warns about packages without provenance, as for --warn-no-provenance
+	WarnOnNone *bool `yaml:"warn_on_none"`
+}
+
+// loadDefaults reads the defaults file at path. When path is empty the
+// defaults file in the working directory is used if present; an explicitly
+// named file must exist. Unknown keys are rejected so typos do not go unnoticed.
+func loadDefaults(path string) (*cliDefaults, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultsFileName
+	}
+
+	data, err := os.ReadFile(path) //#nosec G304 -- path is chosen by the user running the CLI
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return &cliDefaults{}, nil
+		}
+		return nil, fmt.Errorf("failed to read defaults file: %w", err)
+	}
+
+	var defaults cliDefaults
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&defaults); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid defaults file %s: %w", path, err)
+	}
+	return &defaults, nil
+}
+
+// applyDefaults sets every flag of cmd that the defaults cover and that was not
+// given on the command line, giving the precedence flags > file > built-in
+// defaults. For the registry, $DOCKYARD_REGISTRY still takes priority over the file.
+func applyDefaults(cmd *cobra.Command, d *cliDefaults) error {
+	values := make(map[string]string)
+	if d.Registry != "" && os.Getenv(registryEnvVar) == "" {
+		values["registry"] = d.Registry
+	}
+	if d.Timeout != nil {
+		values["timeout"] = d.Timeout.String()
+	}
+	if d.Provenance.Check != nil {
+		values["check-provenance"] = strconv.FormatBool(*d.Provenance.Check)
+	}
+	if d.Provenance.WarnOnNone != nil {
+		values["warn-no-provenance"] = strconv.FormatBool(*d.Provenance.WarnOnNone)
+	}
+
+	for name, value := range values {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return fmt.Errorf("invalid default for --%s: %w", name, err)
+		}
+	}
+
+	if d.HTTPTimeout != nil {
+		httpTimeout = *d.HTTPTimeout
+	}
+	return nil
+}
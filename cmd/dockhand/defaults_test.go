@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+
+	valid := write("valid.yaml", `
+registry: registry.example.com/mcp
+timeout: 2m
+http_timeout: 10s
+provenance:
+  check: true
+  warn_on_none: false
+`)
+	d, err := loadDefaults(valid)
+	if err != nil {
+		t.Fatalf("loadDefaults(valid) error: %v", err)
+	}
+	if d.Registry != "registry.example.com/mcp" {
+		t.Errorf("Registry = %q, want registry.example.com/mcp", d.Registry)
+	}
+	if d.Timeout == nil || *d.Timeout != 2*time.Minute {
+		t.Errorf("Timeout = %v, want 2m", d.Timeout)
+	}
+	if d.HTTPTimeout == nil || *d.HTTPTimeout != 10*time.Second {
+		t.Errorf("HTTPTimeout = %v, want 10s", d.HTTPTimeout)
+	}
+	if d.Provenance.Check == nil || !*d.Provenance.Check {
+		t.Errorf("Provenance.Check = %v, want true", d.Provenance.Check)
+	}
+
+	empty := write("empty.yaml", "")
+	if _, err := loadDefaults(empty); err != nil {
+		t.Errorf("loadDefaults(empty) error: %v", err)
+	}
+
+	unknown := write("unknown.yaml", "regsitry: ghcr.io/myorg\n")
+	if _, err := loadDefaults(unknown); err == nil || !strings.Contains(err.Error(), "regsitry") {
+		t.Errorf("loadDefaults(unknown key) = %v, want error naming the key", err)
+	}
+
+	if _, err := loadDefaults(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("loadDefaults(missing explicit file) = nil error, want error")
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	t.Parallel()
+
+	var timeoutFlag time.Duration
+	var checkFlag, warnFlag bool
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().DurationVar(&timeoutFlag, "timeout", 0, "")
+	cmd.Flags().BoolVar(&checkFlag, "check-provenance", false, "")
+	cmd.Flags().BoolVar(&warnFlag, "warn-no-provenance", true, "")
+	if err := cmd.Flags().Parse([]string{"--check-provenance=false"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	fileTimeout := time.Minute
+	check, warn := true, false
+	d := &cliDefaults{
+		Timeout:    &fileTimeout,
+		Provenance: provenanceDefaults{Check: &check, WarnOnNone: &warn},
+	}
+	if err := applyDefaults(cmd, d); err != nil {
+		t.Fatalf("applyDefaults() error: %v", err)
+	}
+
+	if timeoutFlag != time.Minute {
+		t.Errorf("timeout = %v, want the file value 1m", timeoutFlag)
+	}
+	if checkFlag {
+		t.Error("check-provenance = true, want the command-line value false")
+	}
+	if warnFlag {
+		t.Error("warn-no-provenance = true, want the file value false")
+	}
+}
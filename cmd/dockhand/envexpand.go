@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/dockyard/pkg/build"
+)
+
+// decodeSpecYAML parses a spec into out. With --expand-env, environment
+// variable references in its values are substituted first, so that the
+// expanded values are what validation and image tags see.
+func decodeSpecYAML(data []byte, out any) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return decodeSpecNode(&root, out)
+}
+
+// decodeSpecNode decodes one parsed YAML document into out, expanding
+// environment variables first under --expand-env. The document must match the
+// spec schema, and keys out has no field for are rejected.
+func decodeSpecNode(root *yaml.Node, out any) error {
+	if err := checkSpecComplexity(root); err != nil {
+		return err
+	}
+	if expandEnv {
+		if err := expandEnvNode(root, os.LookupEnv, allowEmptyEnv); err != nil {
+			return err
+		}
+	}
+	// An empty document decodes to the zero spec, as yaml.Unmarshal would
+	if root.Kind == 0 {
+		return nil
+	}
+	if err := build.ValidateSchema(root); err != nil {
+		return err
+	}
+
+	// Node.Decode cannot reject unknown keys, so round-trip through a strict
+	// decoder; this catches a field the schema allows but out lacks
+	data, err := yaml.Marshal(root)
+	if err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return nil
+}
+
+// expandEnvNode substitutes $VAR and ${VAR} in every scalar value under node,
+// but not in mapping keys. Expanding parsed values rather than the raw file
+// keeps a variable's content from changing the YAML structure. A variable
+// lookup does not find is an error unless allowEmpty is set.
+func expandEnvNode(node *yaml.Node, lookup func(string) (string, bool), allowEmpty bool) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return nil
+		}
+		var missing []string
+		node.Value = os.Expand(node.Value, func(name string) string {
+			value, ok := lookup(name)
+			if !ok && !allowEmpty {
+				missing = append(missing, name)
+			}
+			return value
+		})
+		if len(missing) > 0 {
+			return fmt.Errorf("line %d: environment variable %s is not set (use --allow-empty-env to expand it to nothing)",
+				node.Line, strings.Join(missing, ", "))
+		}
+		// Resolve the tag again from the expanded value, as if it had been written out
+		node.Tag = ""
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := expandEnvNode(node.Content[i], lookup, allowEmpty); err != nil {
+				return err
+			}
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := expandEnvNode(child, lookup, allowEmpty); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpandEnvNode(t *testing.T) {
+	t.Parallel()
+
+	const specYAML = `metadata:
+  name: context7
+  protocol: npx
+spec:
+  package: "@upstash/context7-mcp"
+  version: ${MCP_VERSION}
+  args: ["--port", "$PORT"]
+`
+	env := map[string]string{"MCP_VERSION": "1.0.14", "PORT": "8080: {injected: true}"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	expand := func(t *testing.T, data string, lookup func(string) (string, bool), allowEmpty bool) (*MCPServerSpec, error) {
+		t.Helper()
+		var root yaml.Node
+		if err := yaml.Unmarshal([]byte(data), &root); err != nil {
+			t.Fatalf("yaml.Unmarshal() error: %v", err)
+		}
+		if err := expandEnvNode(&root, lookup, allowEmpty); err != nil {
+			return nil, err
+		}
+		var spec MCPServerSpec
+		if err := root.Decode(&spec); err != nil {
+			t.Fatalf("Decode() error: %v", err)
+		}
+		return &spec, nil
+	}
+
+	t.Run("defined", func(t *testing.T) {
+		t.Parallel()
+
+		spec, err := expand(t, specYAML, lookup, false)
+		if err != nil {
+			t.Fatalf("expandEnvNode() error: %v", err)
+		}
+		if spec.Spec.Version != "1.0.14" {
+			t.Errorf("spec.version = %q, want 1.0.14", spec.Spec.Version)
+		}
+		// A value is substituted as a string and cannot add YAML structure
+		if len(spec.Spec.Args) != 2 || spec.Spec.Args[1] != env["PORT"] {
+			t.Errorf("spec.args = %q, want [--port %q]", spec.Spec.Args, env["PORT"])
+		}
+	})
+
+	t.Run("undefined", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := expand(t, specYAML, func(string) (string, bool) { return "", false }, false)
+		if err == nil || !strings.Contains(err.Error(), "MCP_VERSION") || !strings.Contains(err.Error(), "line 6") {
+			t.Errorf("expandEnvNode() error = %v, want MCP_VERSION on line 6 reported", err)
+		}
+	})
+
+	t.Run("undefined allowed", func(t *testing.T) {
+		t.Parallel()
+
+		spec, err := expand(t, specYAML, func(string) (string, bool) { return "", false }, true)
+		if err != nil {
+			t.Fatalf("expandEnvNode() error: %v", err)
+		}
+		if spec.Spec.Version != "" {
+			t.Errorf("spec.version = %q, want empty", spec.Spec.Version)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/service"
+)
+
+// Process exit codes of verify-provenance. Any other failure, such as an
+// invalid flag or spec, exits with 1.
+const (
+	// exitVerified means the provenance was verified
+	exitVerified = 0
+	// exitAttestationsOnly means attestations, signatures, or a trusted
+	// publisher claim exist but were not verified
+	exitAttestationsOnly = 2
+	// exitNoProvenance means the package publishes no provenance
+	exitNoProvenance = 3
+	// exitVerificationError means verification itself failed
+	exitVerificationError = 4
+	// exitPublisherNotAllowed means the publisher is not in --allowed-publisher
+	exitPublisherNotAllowed = 5
+	// exitBaselineRegression means the result is worse than in --baseline
+	exitBaselineRegression = 6
+	// exitTooNew means the release was published less than --min-age ago
+	exitTooNew = 7
+	// exitProvenanceChanged means the result contradicts the provenance the spec records
+	exitProvenanceChanged = 8
+)
+
+// exitCodeHelp documents the exit codes in the verify-provenance help.
+const exitCodeHelp = `Exit codes:
+  0  VERIFIED, or any non-error status without --strict
+  1  invalid flags or spec
+  2  ATTESTATIONS, SIGNATURES, or TRUSTED_PUBLISHER found but not verified
+     (--strict only)
+  3  NONE: no provenance published (--strict only)
+  4  ERROR: verification failed (with --strict, also UNKNOWN or an attested
+     source ref that contradicts provenance.repository_ref)
+  5  publisher repository matches no --allowed-publisher
+  6  the result regressed against --baseline
+  7  the release was published less than --min-age ago
+  8  the result contradicts provenance.attestations in the spec
+     (--only-changed-provenance)`
+
+// exitError is an error that carries the process exit code to use for it.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// statusExitCode maps a provenance status to its exit code.
+func statusExitCode(status domain.ProvenanceStatus) int {
+	switch status {
+	case domain.ProvenanceStatusVerified:
+		return exitVerified
+	case domain.ProvenanceStatusAttestations, domain.ProvenanceStatusSignatures, domain.ProvenanceStatusTrustedPublisher:
+		return exitAttestationsOnly
+	case domain.ProvenanceStatusNone:
+		return exitNoProvenance
+	default:
+		return exitVerificationError
+	}
+}
+
+// provenanceExitError returns the error verify-provenance exits with for
+// result, or nil for a zero exit. An ERROR status always fails; with strict,
+// so does every status short of verified.
+func provenanceExitError(result *domain.ProvenanceResult, strict bool) error {
+	code := statusExitCode(result.Status)
+	switch {
+	case code == exitVerified:
+		return nil
+	case result.Status == domain.ProvenanceStatusError:
+		return &exitError{code: code, err: fmt.Errorf("provenance verification failed: %s", result.ErrorMessage)}
+	case strict:
+		return &exitError{code: code, err: fmt.Errorf(
+			"provenance status %s does not meet --strict, which requires %s", result.Status, domain.ProvenanceStatusVerified)}
+	default:
+		return nil
+	}
+}
+
+// publisherExitError checks the result's trusted publisher against the
+// --allowed-publisher globs. Without --strict a result with no publisher
+// information passes, as it would with no allowlist.
+func publisherExitError(result *domain.ProvenanceResult, allowed []string, strict bool) error {
+	err := service.ValidateRequirements(result, domain.ProvenanceRequirements{AllowNone: !strict, AllowedPublishers: allowed})
+	if err != nil && errors.Is(err, service.ErrPublisherNotAllowed) {
+		return &exitError{code: exitPublisherNotAllowed, err: err}
+	}
+	return nil
+}
+
+// refExitError fails verify-provenance under --strict when the attested
+// source ref contradicts the spec's repository_ref. Without --strict the
+// mismatch is only a warning.
+func refExitError(spec *MCPServerSpec, result *domain.ProvenanceResult, strict bool) error {
+	if !strict || spec == nil {
+		return nil
+	}
+	if w := refWarning(spec, result); w != nil {
+		return &exitError{code: exitVerificationError, err: fmt.Errorf(
+			"attested source ref %s does not match provenance.repository_ref %s", w.Actual, w.Expected)}
+	}
+	return nil
+}
+
+// specChangeExitError fails verify-provenance --only-changed-provenance when
+// the result contradicts a provenance claim the spec records.
+func specChangeExitError(spec *MCPServerSpec, result *domain.ProvenanceResult) error {
+	var changes []string
+	for _, check := range compareWithSpec(spec, result) {
+		if !check.ok {
+			changes = append(changes, check.message)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return &exitError{code: exitProvenanceChanged, err: fmt.Errorf(
+		"provenance of %s@%s changed from what the spec records: %s",
+		result.PackageID.Name, result.PackageID.Version, strings.Join(changes, "; "))}
+}
+
+// minAgeExitError checks how long ago the release was published against
+// --min-age, as of now. Without --strict a result with no publication time,
+// such as one for a Go module or an OCI image, passes.
+func minAgeExitError(result *domain.ProvenanceResult, minAge time.Duration, strict bool, now time.Time) error {
+	if minAge <= 0 {
+		return nil
+	}
+	publishedAt, ok := domain.PublishedAt(result.Details)
+	switch {
+	case !ok && strict:
+		return &exitError{code: exitTooNew, err: fmt.Errorf(
+			"the publication time of %s is unknown, so --min-age cannot be checked", result.PackageID.Name)}
+	case !ok:
+		return nil
+	}
+	if age := now.Sub(publishedAt); age < minAge {
+		return &exitError{code: exitTooNew, err: fmt.Errorf(
+			"%s@%s was published %s ago, less than --min-age %s",
+			result.PackageID.Name, result.PackageID.Version, age.Truncate(time.Minute), minAge)}
+	}
+	return nil
+}
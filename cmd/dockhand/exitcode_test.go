@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+func TestProvenanceExitError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status     domain.ProvenanceStatus
+		strict     bool
+		wantCode   int
+		wantFailed bool
+	}{
+		{domain.ProvenanceStatusVerified, false, exitVerified, false},
+		{domain.ProvenanceStatusVerified, true, exitVerified, false},
+		{domain.ProvenanceStatusTrustedPublisher, false, exitVerified, false},
+		{domain.ProvenanceStatusTrustedPublisher, true, exitAttestationsOnly, true},
+		{domain.ProvenanceStatusAttestations, false, exitVerified, false},
+		{domain.ProvenanceStatusAttestations, true, exitAttestationsOnly, true},
+		{domain.ProvenanceStatusSignatures, true, exitAttestationsOnly, true},
+		{domain.ProvenanceStatusNone, false, exitVerified, false},
+		{domain.ProvenanceStatusNone, true, exitNoProvenance, true},
+		{domain.ProvenanceStatusUnknown, false, exitVerified, false},
+		{domain.ProvenanceStatusUnknown, true, exitVerificationError, true},
+		{domain.ProvenanceStatusError, false, exitVerificationError, true},
+	}
+
+	for _, tt := range tests {
+		name := string(tt.status)
+		if tt.strict {
+			name += "/strict"
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := provenanceExitError(&domain.ProvenanceResult{Status: tt.status}, tt.strict)
+			if (err != nil) != tt.wantFailed {
+				t.Fatalf("provenanceExitError(%s, strict=%v) = %v, wantFailed %v", tt.status, tt.strict, err, tt.wantFailed)
+			}
+			if err == nil {
+				return
+			}
+			var exitErr *exitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("provenanceExitError(%s) = %T, want *exitError", tt.status, err)
+			}
+			if exitErr.code != tt.wantCode {
+				t.Errorf("provenanceExitError(%s, strict=%v) code = %d, want %d", tt.status, tt.strict, exitErr.code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPublisherExitError(t *testing.T) {
+	t.Parallel()
+
+	published := &domain.ProvenanceResult{
+		Status:           domain.ProvenanceStatusVerified,
+		TrustedPublisher: &domain.TrustedPublisher{Kind: "GitHub", Repository: "upstash/context7"},
+	}
+	unpublished := &domain.ProvenanceResult{Status: domain.ProvenanceStatusSignatures, HasSignatures: true}
+	// An npx result carries the publisher the verified certificate attests
+	npx := &domain.ProvenanceResult{
+		PackageID: domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14"},
+		Status:    domain.ProvenanceStatusVerified,
+		TrustedPublisher: sigstore.ExtractPublisherInfo(&verify.VerificationResult{
+			Signature: &verify.SignatureVerificationResult{Certificate: &certificate.Summary{
+				Extensions: certificate.Extensions{SourceRepositoryURI: "https://github.com/upstash/context7"},
+			}},
+		}),
+	}
+	// A quick-mode uvx result only has the publisher PyPI claims
+	claimed := &domain.ProvenanceResult{
+		PackageID:        domain.PackageIdentifier{Protocol: domain.ProtocolPyPI, Name: "mcp-clickhouse", Version: "0.1.0"},
+		Status:           domain.ProvenanceStatusAttestations,
+		TrustedPublisher: &domain.TrustedPublisher{Kind: "GitHub", Repository: "ClickHouse/mcp-clickhouse"},
+	}
+
+	tests := []struct {
+		name       string
+		result     *domain.ProvenanceResult
+		allowed    []string
+		strict     bool
+		wantFailed bool
+	}{
+		{"no allowlist", published, nil, true, false},
+		{"matching glob", published, []string{"stacklok/*", "upstash/*"}, false, false},
+		{"case-insensitive", published, []string{"Upstash/Context7"}, false, false},
+		{"no match", published, []string{"stacklok/*"}, false, true},
+		{"no publisher", unpublished, []string{"stacklok/*"}, false, false},
+		{"no publisher, strict", unpublished, []string{"stacklok/*"}, true, true},
+		{"npx matching glob", npx, []string{"upstash/*"}, true, false},
+		{"npx no match", npx, []string{"stacklok/*"}, false, true},
+		{"claimed publisher, strict", claimed, []string{"ClickHouse/*"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := publisherExitError(tt.result, tt.allowed, tt.strict)
+			if (err != nil) != tt.wantFailed {
+				t.Fatalf("publisherExitError() = %v, wantFailed %v", err, tt.wantFailed)
+			}
+			var exitErr *exitError
+			if err != nil && (!errors.As(err, &exitErr) || exitErr.code != exitPublisherNotAllowed) {
+				t.Errorf("publisherExitError() = %v, want exit code %d", err, exitPublisherNotAllowed)
+			}
+		})
+	}
+}
+
+func TestMinAgeExitError(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	published := func(ago time.Duration) *domain.ProvenanceResult {
+		details := make(map[string]interface{})
+		domain.RecordPublished(details, now.Add(-ago), now)
+		return &domain.ProvenanceResult{Status: domain.ProvenanceStatusVerified, Details: details}
+	}
+	undated := &domain.ProvenanceResult{Status: domain.ProvenanceStatusSignatures, Details: map[string]interface{}{}}
+
+	tests := []struct {
+		name       string
+		result     *domain.ProvenanceResult
+		minAge     time.Duration
+		strict     bool
+		wantFailed bool
+	}{
+		{"no minimum", published(time.Minute), 0, true, false},
+		{"old enough", published(30 * 24 * time.Hour), 72 * time.Hour, false, false},
+		{"too new", published(10 * time.Minute), 72 * time.Hour, false, true},
+		{"no publication time", undated, 72 * time.Hour, false, false},
+		{"no publication time, strict", undated, 72 * time.Hour, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := minAgeExitError(tt.result, tt.minAge, tt.strict, now)
+			if (err != nil) != tt.wantFailed {
+				t.Fatalf("minAgeExitError() = %v, wantFailed %v", err, tt.wantFailed)
+			}
+			var exitErr *exitError
+			if err != nil && (!errors.As(err, &exitErr) || exitErr.code != exitTooNew) {
+				t.Errorf("minAgeExitError() = %v, want exit code %d", err, exitTooNew)
+			}
+		})
+	}
+}
+
+func TestRefExitError(t *testing.T) {
+	t.Parallel()
+
+	spec := &MCPServerSpec{Provenance: MCPServerProvenance{RepositoryRef: "refs/tags/v1.0.0"}}
+	result := &domain.ProvenanceResult{TrustedPublisher: &domain.TrustedPublisher{
+		Claims: map[string]interface{}{domain.ClaimSourceRef: "refs/heads/dev"},
+	}}
+
+	if err := refExitError(spec, result, false); err != nil {
+		t.Errorf("refExitError() without --strict = %v, want nil", err)
+	}
+	if err := refExitError(nil, result, true); err != nil {
+		t.Errorf("refExitError() without a spec = %v, want nil", err)
+	}
+	err := refExitError(spec, result, true)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitVerificationError {
+		t.Errorf("refExitError() = %v, want exit code %d", err, exitVerificationError)
+	}
+}
+
+func TestSpecChangeExitError(t *testing.T) {
+	t.Parallel()
+
+	spec := &MCPServerSpec{Provenance: MCPServerProvenance{Attestations: &AttestationInfo{Available: true, Verified: true}}}
+
+	verified := &domain.ProvenanceResult{Status: domain.ProvenanceStatusVerified, HasAttestations: true}
+	if err := specChangeExitError(spec, verified); err != nil {
+		t.Errorf("specChangeExitError() for a matching result = %v, want nil", err)
+	}
+	if err := specChangeExitError(&MCPServerSpec{}, &domain.ProvenanceResult{}); err != nil {
+		t.Errorf("specChangeExitError() without recorded attestations = %v, want nil", err)
+	}
+
+	err := specChangeExitError(spec, &domain.ProvenanceResult{
+		PackageID: domain.PackageIdentifier{Name: "pkg", Version: "1.0.0"},
+		Status:    domain.ProvenanceStatusNone,
+	})
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitProvenanceChanged {
+		t.Fatalf("specChangeExitError() = %v, want exit code %d", err, exitProvenanceChanged)
+	}
+	if want := "pkg@1.0.0 changed from what the spec records: Attestations not found"; !strings.Contains(err.Error(), want) {
+		t.Errorf("specChangeExitError() = %q, want it to contain %q", err, want)
+	}
+}
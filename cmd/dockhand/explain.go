@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// printExplanation prints the decision path of a verification for --explain:
+// the artifacts found, the digest and certificate identity they were checked
+// against, the outcome of each check, and why the result has its status. It
+// reads only what the verifier recorded in the result's Details.
+func printExplanation(cmd *cobra.Command, result *domain.ProvenanceResult) {
+	d := result.Details
+	cmd.Println("\nExplanation:")
+
+	if digest, ok := d["artifact_digest"].(string); ok {
+		if source, ok := d["digest_source"].(string); ok {
+			cmd.Printf("  Artifact digest: %s (from %s)\n", digest, source)
+		} else {
+			cmd.Printf("  Artifact digest: %s\n", digest)
+		}
+	}
+	if digest, ok := d["image_digest"].(string); ok {
+		cmd.Printf("  Image digest: %s\n", digest)
+	}
+	if tarballErr, ok := d["tarball_error"].(string); ok {
+		cmd.Printf("  Artifact digest unavailable: %s\n", tarballErr)
+	}
+	if identity, ok := d["certificate_identity"].(string); ok {
+		cmd.Printf("  Certificate identity: %s\n", identity)
+	}
+	if warning, ok := d["identity_warning"].(string); ok {
+		cmd.Printf("  Identity warning: %s\n", warning)
+	}
+
+	printExplainedOutcomes(cmd, "Attestations", d["attestations"])
+	printExplainedOutcomes(cmd, "Bundles", d["bundles"])
+	printExplainedFiles(cmd, d)
+
+	if name, ok := d["subject_name"].(string); ok {
+		cmd.Printf("  Statement subject: %s %v\n", name, d["subject_digest"])
+	}
+	for _, key := range []string{"shasum_match", "integrity_match"} {
+		if match, ok := d[key].(bool); ok {
+			cmd.Printf("  %s: %t\n", key, match)
+		}
+	}
+	if expected, ok := d["expected_digest"].(string); ok {
+		cmd.Printf("  Expected digest: %s (matches: %v)\n", expected, d["digest_match"])
+	}
+	if hash, ok := d["module_hash"].(string); ok {
+		cmd.Printf("  Module hash: %s\n", hash)
+		cmd.Printf("  Checksum database (%v): %v\n", d["checksum_db"], d["checksum_db_hash"])
+	}
+	if verificationErr, ok := d["verification_error"].(string); ok {
+		cmd.Printf("  Verification error: %s\n", verificationErr)
+	}
+
+	cmd.Printf("  Decision: %s, %s\n", result.Status, explainDecision(result))
+}
+
+// printExplainedOutcomes prints a map of per-artifact statuses, such as the
+// npm attestations or OCI bundles, in name order.
+func printExplainedOutcomes(cmd *cobra.Command, title string, value interface{}) {
+	statuses, ok := value.(map[string]string)
+	if !ok || len(statuses) == 0 {
+		return
+	}
+	cmd.Printf("  %s:\n", title)
+	for _, name := range slices.Sorted(maps.Keys(statuses)) {
+		cmd.Printf("    %s: %s\n", name, statuses[name])
+	}
+}
+
+// printExplainedFiles prints the outcome of each PyPI release file with the
+// digest and certificate identity it was verified against.
+func printExplainedFiles(cmd *cobra.Command, d map[string]interface{}) {
+	files, ok := d["files"].(map[string]string)
+	if !ok || len(files) == 0 {
+		return
+	}
+	digests, _ := d["file_digests"].(map[string]string)
+	identities, _ := d["file_identities"].(map[string]string)
+
+	cmd.Println("  Files:")
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		cmd.Printf("    %s: %s\n", name, files[name])
+		if digest, ok := digests[name]; ok {
+			cmd.Printf("      digest: %s\n", digest)
+		}
+		if identity, ok := identities[name]; ok {
+			cmd.Printf("      certificate identity: %s\n", identity)
+		}
+	}
+}
+
+// explainDecision says why result has its status.
+func explainDecision(result *domain.ProvenanceResult) string {
+	switch result.Status {
+	case domain.ProvenanceStatusVerified:
+		return fmt.Sprintf("%d of %d attestation(s) verified against the artifact digest and certificate identity",
+			result.VerifiedAttestationCount, result.AttestationCount)
+	case domain.ProvenanceStatusAttestations:
+		if note, ok := result.Details["verification"].(string); ok {
+			return "verification " + note
+		}
+		if result.ErrorMessage != "" {
+			return "attestations were found but did not verify: " + result.ErrorMessage
+		}
+		if result.Details["provenance_attestation"] == "missing" {
+			return "no provenance attestation was found; other attestations do not show how the package was built"
+		}
+		return "attestations were found but none verified"
+	case domain.ProvenanceStatusSignatures:
+		if _, ok := result.Details["checksum_db"]; ok {
+			return "the module hash matches the Go checksum database"
+		}
+		return "the registry lists signatures in an older format that cannot be verified with Sigstore"
+	case domain.ProvenanceStatusTrustedPublisher:
+		return "a trusted publisher is recorded, but no attestation was verified"
+	case domain.ProvenanceStatusNone:
+		return "no attestations or signatures are published for this version"
+	default:
+		if result.ErrorMessage != "" {
+			return "verification did not complete: " + result.ErrorMessage
+		}
+		return "verification did not complete"
+	}
+}
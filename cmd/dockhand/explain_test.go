@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestPrintExplanation(t *testing.T) {
+	t.Parallel()
+
+	const sigstoreErr = "failed to verify certificate identity: no matching CertificateIdentity found"
+	tests := []struct {
+		name   string
+		result *domain.ProvenanceResult
+		want   []string
+	}{
+		{
+			name: "npm attestation failure",
+			result: &domain.ProvenanceResult{
+				Status:           domain.ProvenanceStatusAttestations,
+				HasAttestations:  true,
+				AttestationCount: 2,
+				ErrorMessage:     "provenance attestation verification failed: " + sigstoreErr,
+				Details: map[string]interface{}{
+					"artifact_digest":      "sha512:abcd",
+					"digest_source":        "registry",
+					"certificate_identity": "issuer https://token.actions.githubusercontent.com, SAN matching ^https://github.com/org/repo/",
+					"attestations":         map[string]string{"provenance": "failed: " + sigstoreErr, "publish": "verified"},
+					"verification_error":   sigstoreErr,
+				},
+			},
+			want: []string{
+				"Artifact digest: sha512:abcd (from registry)",
+				"Certificate identity: issuer https://token.actions.githubusercontent.com, SAN matching ^https://github.com/org/repo/",
+				"    provenance: failed: " + sigstoreErr,
+				"    publish: verified",
+				"Verification error: " + sigstoreErr,
+				"Decision: ATTESTATIONS, attestations were found but did not verify",
+			},
+		},
+		{
+			name: "pypi files",
+			result: &domain.ProvenanceResult{
+				Status:                   domain.ProvenanceStatusVerified,
+				AttestationCount:         1,
+				VerifiedAttestationCount: 1,
+				Details: map[string]interface{}{
+					"files":           map[string]string{"pkg-1.0-py3-none-any.whl": "verified (GitHub org/pkg)"},
+					"file_digests":    map[string]string{"pkg-1.0-py3-none-any.whl": "sha256:1234"},
+					"file_identities": map[string]string{"pkg-1.0-py3-none-any.whl": "issuer x, SAN matching y"},
+				},
+			},
+			want: []string{
+				"    pkg-1.0-py3-none-any.whl: verified (GitHub org/pkg)",
+				"      digest: sha256:1234",
+				"      certificate identity: issuer x, SAN matching y",
+				"Decision: VERIFIED, 1 of 1 attestation(s) verified",
+			},
+		},
+		{
+			name:   "no provenance",
+			result: &domain.ProvenanceResult{Status: domain.ProvenanceStatusNone, Details: map[string]interface{}{}},
+			want:   []string{"Decision: NONE, no attestations or signatures are published"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := &cobra.Command{}
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+			printExplanation(cmd, tt.result)
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("explanation lacks %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
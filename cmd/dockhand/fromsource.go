@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// Package manifests --from-source reads, by the protocol they belong to
+var sourceManifests = map[domain.PackageProtocol][]string{
+	domain.ProtocolNPM:  {"package.json"},
+	domain.ProtocolPyPI: {"pyproject.toml", "PKG-INFO"},
+}
+
+// specFromSource derives the spec of the package whose source is in dir from
+// its manifest: package.json for npx, or pyproject.toml or PKG-INFO for uvx.
+// The protocol is the one whose manifest is present; manifests of both are
+// ambiguous. The spec builds the published package at the manifest's version.
+func specFromSource(dir string) (*MCPServerSpec, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	var found []domain.PackageProtocol
+	for _, protocol := range []domain.PackageProtocol{domain.ProtocolNPM, domain.ProtocolPyPI} {
+		for _, manifest := range sourceManifests[protocol] {
+			if _, err := os.Stat(filepath.Join(dir, manifest)); err == nil {
+				found = append(found, protocol)
+				break
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no package.json, pyproject.toml, or PKG-INFO in %s", dir)
+	case 2:
+		return nil, fmt.Errorf("%s has both npm and Python manifests; cannot tell which package to build", dir)
+	}
+
+	var name, version string
+	switch found[0] {
+	case domain.ProtocolNPM:
+		name, version, err = readPackageJSON(filepath.Join(dir, "package.json"))
+	case domain.ProtocolPyPI:
+		name, version, err = readPythonProject(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	protocol := string(found[0])
+	return &MCPServerSpec{
+		Metadata: MCPServerMetadata{Name: defaultSpecName(protocol, name), Protocol: protocol},
+		Spec:     MCPServerPackageSpec{Package: name, Version: version},
+	}, nil
+}
+
+// readPackageJSON returns the package name and version of a package.json
+func readPackageJSON(path string) (name, version string, err error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- the source directory is chosen by the user running the CLI
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read package.json: %w", err)
+	}
+	var manifest struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", "", fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	if manifest.Name == "" || manifest.Version == "" {
+		return "", "", fmt.Errorf("package.json must set both name and version")
+	}
+	return manifest.Name, manifest.Version, nil
+}
+
+// readPythonProject returns the package name and version of the Python
+// project in dir. pyproject.toml is read first, from [project] or, for
+// Poetry, [tool.poetry]; a version it leaves dynamic comes from PKG-INFO.
+func readPythonProject(dir string) (name, version string, err error) {
+	name, version, dynamic, err := readPyproject(filepath.Join(dir, "pyproject.toml"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", "", err
+	}
+	if name != "" && version != "" {
+		return name, version, nil
+	}
+
+	pkgName, pkgVersion, pkgErr := readPKGInfo(filepath.Join(dir, "PKG-INFO"))
+	switch {
+	case pkgErr == nil:
+		// pyproject.toml names the package when both exist
+		if name == "" {
+			name = pkgName
+		}
+		return name, pkgVersion, nil
+	case !errors.Is(pkgErr, fs.ErrNotExist):
+		return "", "", pkgErr
+	case dynamic:
+		return "", "", fmt.Errorf("pyproject.toml declares the version dynamic and there is no PKG-INFO to read it from")
+	default:
+		return "", "", fmt.Errorf("pyproject.toml must set both the project name and version")
+	}
+}
+
+// readPyproject returns the name and version a pyproject.toml declares, and
+// whether it marks the version dynamic, as build backends computing it do.
+func readPyproject(path string) (name, version string, dynamic bool, err error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- the source directory is chosen by the user running the CLI
+	if err != nil {
+		return "", "", false, err
+	}
+	var manifest struct {
+		Project struct {
+			Name    string   `toml:"name"`
+			Version string   `toml:"version"`
+			Dynamic []string `toml:"dynamic"`
+		} `toml:"project"`
+		Tool struct {
+			Poetry struct {
+				Name    string `toml:"name"`
+				Version string `toml:"version"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	if err := toml.Unmarshal(data, &manifest); err != nil {
+		return "", "", false, fmt.Errorf("failed to parse pyproject.toml: %w", err)
+	}
+	if manifest.Project.Name != "" {
+		return manifest.Project.Name, manifest.Project.Version, slices.Contains(manifest.Project.Dynamic, "version"), nil
+	}
+	return manifest.Tool.Poetry.Name, manifest.Tool.Poetry.Version, false, nil
+}
+
+// readPKGInfo returns the Name and Version headers of a PKG-INFO file, the
+// core metadata an sdist carries.
+func readPKGInfo(path string) (name, version string, err error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- the source directory is chosen by the user running the CLI
+	if err != nil {
+		return "", "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// The headers end at the first blank line, before the description
+	for scanner.Scan() && scanner.Text() != "" {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "name":
+			name = strings.TrimSpace(value)
+		case "version":
+			version = strings.TrimSpace(value)
+		}
+	}
+	if name == "" || version == "" {
+		return "", "", fmt.Errorf("PKG-INFO must have both Name and Version headers")
+	}
+	return name, version, nil
+}
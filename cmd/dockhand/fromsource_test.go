@@ -0,0 +1,112 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpecFromSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantProto   string
+		wantName    string
+		wantPackage string
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "package.json",
+			files:       map[string]string{"package.json": `{"name":"@upstash/context7-mcp","version":"1.0.14","private":false}`},
+			wantProto:   "npx",
+			wantName:    "context7-mcp",
+			wantPackage: "@upstash/context7-mcp",
+			wantVersion: "1.0.14",
+		},
+		{
+			name:        "pyproject.toml",
+			files:       map[string]string{"pyproject.toml": "[project]\nname = \"mcp-server-fetch\"\nversion = \"2025.4.7\"\n"},
+			wantProto:   "uvx",
+			wantName:    "mcp-server-fetch",
+			wantPackage: "mcp-server-fetch",
+			wantVersion: "2025.4.7",
+		},
+		{
+			name:        "poetry",
+			files:       map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"mcp_clickhouse\"\nversion = \"0.1.0\"\n"},
+			wantProto:   "uvx",
+			wantName:    "mcp-clickhouse",
+			wantPackage: "mcp_clickhouse",
+			wantVersion: "0.1.0",
+		},
+		{
+			name: "dynamic version from PKG-INFO",
+			files: map[string]string{
+				"pyproject.toml": "[project]\nname = \"mcp-server-time\"\ndynamic = [\"version\"]\n",
+				"PKG-INFO":       "Metadata-Version: 2.1\nName: mcp_server_time\nVersion: 0.6.2\n\nVersion: 9.9.9 in the description\n",
+			},
+			wantProto:   "uvx",
+			wantName:    "mcp-server-time",
+			wantPackage: "mcp-server-time",
+			wantVersion: "0.6.2",
+		},
+		{
+			name:    "dynamic version without PKG-INFO",
+			files:   map[string]string{"pyproject.toml": "[project]\nname = \"mcp-server-time\"\ndynamic = [\"version\"]\n"},
+			wantErr: "declares the version dynamic",
+		},
+		{
+			name: "ambiguous",
+			files: map[string]string{
+				"package.json":   `{"name":"a","version":"1.0.0"}`,
+				"pyproject.toml": "[project]\nname = \"a\"\nversion = \"1.0.0\"\n",
+			},
+			wantErr: "both npm and Python manifests",
+		},
+		{
+			name:    "no manifest",
+			files:   map[string]string{"README.md": "# server\n"},
+			wantErr: "no package.json, pyproject.toml, or PKG-INFO",
+		},
+		{
+			name:    "no version",
+			files:   map[string]string{"package.json": `{"name":"a"}`},
+			wantErr: "must set both name and version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeSpec(t, dir, name, content)
+			}
+
+			spec, err := specFromSource(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("specFromSource() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("specFromSource() error: %v", err)
+			}
+			if spec.Metadata.Protocol != tt.wantProto || spec.Metadata.Name != tt.wantName ||
+				spec.Spec.Package != tt.wantPackage || spec.Spec.Version != tt.wantVersion {
+				t.Errorf("specFromSource() = %s %s %s@%s, want %s %s %s@%s",
+					spec.Metadata.Protocol, spec.Metadata.Name, spec.Spec.Package, spec.Spec.Version,
+					tt.wantProto, tt.wantName, tt.wantPackage, tt.wantVersion)
+			}
+		})
+	}
+
+	if _, err := specFromSource(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("specFromSource() of a missing directory succeeded")
+	}
+}
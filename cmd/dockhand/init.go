@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/pkg/build"
+)
+
+var (
+	// specNameRe matches server names, which are also directory and image names
+	specNameRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	// specNameSeparatorRe matches the runs of characters a derived name replaces with a dash
+	specNameSeparatorRe = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// initOptions are the flags of the init command
+type initOptions struct {
+	dir         string
+	protocol    string
+	pkg         string
+	name        string
+	version     string
+	description string
+	force       bool
+	noCheck     bool
+}
+
+func newInitCmd() *cobra.Command {
+	var opts initOptions
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a new MCP server spec",
+		Long: `Init scaffolds {protocol}/{name}/spec.yaml under --dir for a package, with
+its metadata, package, and version filled in and a commented provenance
+stanza to complete.
+
+The name defaults to the package name without its npm scope, Python extras,
+or Go module path, lowercased with other characters replaced by dashes. Without --version, the
+newest release is pinned. The package is looked up in its registry first, and
+a package or version that does not exist fails the command; --no-check skips
+the lookup. oci specs cannot be looked up and need --version.
+
+An existing spec is never overwritten unless --force is given.`,
+		Example: `  # Scaffold npx/context7-mcp/spec.yaml at the newest release
+  dockhand init --protocol npx --package @upstash/context7-mcp
+
+  # Pin a version and pick the name
+  dockhand init --protocol uvx --package mcp-server-fetch --version 2025.4.7 --name fetch`,
+		RunE: withTimeout(func(cmd *cobra.Command, _ []string) error {
+			return runInit(cmd, opts)
+		}),
+	}
+
+	cmd.Flags().StringVar(&opts.dir, "dir", ".", "Root directory of the spec repository")
+	cmd.Flags().StringVar(&opts.protocol, "protocol", "", "Protocol of the package: npx, uvx, go, or oci")
+	cmd.Flags().StringVar(&opts.pkg, "package", "", "Package name in its registry, e.g. @upstash/context7-mcp")
+	cmd.Flags().StringVar(&opts.name, "name", "", "Server name and directory (default derived from --package)")
+	cmd.Flags().StringVar(&opts.version, "version", "", "Version to pin (default the newest release)")
+	cmd.Flags().StringVar(&opts.description, "description", "", "Short description of the server")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Overwrite an existing spec")
+	cmd.Flags().BoolVar(&opts.noCheck, "no-check", false, "Do not look the package up in its registry")
+	_ = cmd.MarkFlagRequired("protocol")
+	_ = cmd.MarkFlagRequired("package")
+
+	return cmd
+}
+
+// runInit looks the package up, unless opts.noCheck is set, and writes its spec.
+func runInit(cmd *cobra.Command, opts initOptions) error {
+	cmd.SilenceUsage = true
+	var resolver versionResolver
+	if !opts.noCheck && opts.protocol != string(domain.ProtocolOCI) {
+		svc, err := createProvenanceService(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to create provenance service: %w", err)
+		}
+		resolver = svc
+	}
+	return initSpec(cmd, resolver, opts)
+}
+
+// initSpec writes the spec described by opts, resolving its version in the
+// registry unless resolver is nil.
+func initSpec(cmd *cobra.Command, resolver versionResolver, opts initOptions) error {
+	switch domain.PackageProtocol(opts.protocol) {
+	case domain.ProtocolNPM, domain.ProtocolPyPI, domain.ProtocolGo, domain.ProtocolOCI:
+	default:
+		return fmt.Errorf("unsupported protocol %q: must be npx, uvx, go, or oci", opts.protocol)
+	}
+	if opts.protocol == string(domain.ProtocolGo) && strings.Contains(opts.pkg, "@") {
+		return fmt.Errorf("give the version of a Go package with --version, not inline")
+	}
+	if opts.protocol == string(domain.ProtocolOCI) && opts.version == "" {
+		return fmt.Errorf("--version is required for oci specs")
+	}
+
+	name := opts.name
+	if name == "" {
+		name = defaultSpecName(opts.protocol, opts.pkg)
+	}
+	if !specNameRe.MatchString(name) {
+		return fmt.Errorf("invalid server name %q: use --name with lowercase letters, digits, and dashes", name)
+	}
+
+	version := opts.version
+	if resolver != nil {
+		requested := version
+		if requested == "" {
+			requested = "latest"
+		}
+		pkg := domain.PackageIdentifier{Protocol: domain.PackageProtocol(opts.protocol), Name: opts.pkg, Version: requested}
+		resolved, err := resolver.ResolveVersion(cmd.Context(), pkg)
+		if err != nil {
+			return fmt.Errorf("failed to look up %s@%s: %w", opts.pkg, requested, err)
+		}
+		version = resolved
+	}
+
+	spec := &MCPServerSpec{
+		Metadata: build.MCPServerMetadata{Name: name, Description: opts.description, Protocol: opts.protocol},
+		Spec:     build.MCPServerPackageSpec{Package: opts.pkg, Version: version},
+	}
+	data := renderSpec(spec)
+
+	// The scaffold must load like any other spec
+	var check MCPServerSpec
+	if err := decodeSpecYAML(data, &check); err != nil {
+		return fmt.Errorf("generated spec is invalid: %w", err)
+	}
+	if opts.protocol != string(domain.ProtocolOCI) {
+		if _, err := build.ProtocolScheme(&check); err != nil {
+			return err
+		}
+	}
+
+	rel := filepath.Join(opts.protocol, name, "spec.yaml")
+	path := filepath.Join(opts.dir, rel)
+	if _, err := os.Stat(path); err == nil && !opts.force {
+		return fmt.Errorf("%s already exists; use --force to overwrite it", path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to check for an existing spec: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create spec directory: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write spec: %w", err)
+	}
+
+	cmd.Printf("Created %s for %s@%s\n", path, opts.pkg, version)
+	cmd.Printf("Next: fill in the provenance stanza, then run dockhand verify-provenance -c %s\n", filepath.ToSlash(rel))
+	return nil
+}
+
+// defaultSpecName derives a server name from a package: the npm package
+// without its scope, the Python package without extras, or the last element
+// of a Go package path or image repository, lowercased and dashed.
+func defaultSpecName(protocol, pkg string) string {
+	name := pkg
+	switch domain.PackageProtocol(protocol) {
+	case domain.ProtocolPyPI:
+		name, _, _ = strings.Cut(name, "[")
+	case domain.ProtocolOCI:
+		name, _, _ = strings.Cut(name, "@")
+		name = name[strings.LastIndex(name, "/")+1:]
+		name, _, _ = strings.Cut(name, ":")
+	}
+	name = name[strings.LastIndex(name, "/")+1:]
+	return strings.Trim(specNameSeparatorRe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// renderSpec returns the scaffold of spec, laid out like the catalog's specs,
+// with a commented provenance stanza.
+func renderSpec(spec *MCPServerSpec) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s MCP Server Configuration\n", spec.Metadata.Name)
+	if url := packageURL(spec.Metadata.Protocol, spec.Spec.Package); url != "" {
+		fmt.Fprintf(&b, "# Package: %s\n", url)
+	}
+	if spec.Metadata.Protocol != string(domain.ProtocolOCI) {
+		fmt.Fprintf(&b, "# Will build as: %s\n", build.ImageTag(spec, build.DefaultRegistry))
+	}
+
+	fmt.Fprintf(&b, "\nmetadata:\n  name: %s\n", spec.Metadata.Name)
+	if spec.Metadata.Description != "" {
+		fmt.Fprintf(&b, "  description: %q\n", spec.Metadata.Description)
+	}
+	fmt.Fprintf(&b, "  protocol: %s\n", spec.Metadata.Protocol)
+
+	fmt.Fprintf(&b, "\nspec:\n  package: %q\n", spec.Spec.Package)
+	if spec.Spec.Version != "" {
+		fmt.Fprintf(&b, "  version: %q\n", spec.Spec.Version)
+	}
+
+	b.WriteString(`
+# Uncomment and fill in the repository the package is built from, then check
+# it with dockhand verify-provenance
+# provenance:
+#   repository_uri: "https://github.com/<owner>/<repo>"
+#   repository_ref: "refs/tags/<tag>"
+`)
+	return []byte(b.String())
+}
+
+// packageURL returns the registry page of a package, or "" when there is none.
+func packageURL(protocol, pkg string) string {
+	switch domain.PackageProtocol(protocol) {
+	case domain.ProtocolNPM:
+		return "https://www.npmjs.com/package/" + pkg
+	case domain.ProtocolPyPI:
+		name, _, _ := strings.Cut(pkg, "[")
+		return "https://pypi.org/project/" + name + "/"
+	case domain.ProtocolGo:
+		return "https://pkg.go.dev/" + pkg
+	}
+	return ""
+}
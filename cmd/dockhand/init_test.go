@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// fixedResolver resolves "latest" to latest and accepts only versions it knows.
+type fixedResolver struct {
+	latest   string
+	versions []string
+}
+
+func (r fixedResolver) ResolveVersion(_ context.Context, pkg domain.PackageIdentifier) (string, error) {
+	if pkg.Version == "latest" {
+		return r.latest, nil
+	}
+	for _, v := range r.versions {
+		if v == pkg.Version {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("version %s not found", pkg.Version)
+}
+
+func TestDefaultSpecName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		protocol string
+		pkg      string
+		want     string
+	}{
+		{"npx", "@upstash/context7-mcp", "context7-mcp"},
+		{"npx", "agentql-mcp", "agentql-mcp"},
+		{"uvx", "mcp-server-fetch[cli]", "mcp-server-fetch"},
+		{"uvx", "MCP_Clickhouse", "mcp-clickhouse"},
+		{"go", "github.com/org/tool/cmd/server", "server"},
+		{"oci", "ghcr.io/org/oci-mcp-server:1.4.0", "oci-mcp-server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			t.Parallel()
+			if got := defaultSpecName(tt.protocol, tt.pkg); got != tt.want {
+				t.Errorf("defaultSpecName(%q, %q) = %q, want %q", tt.protocol, tt.pkg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitSpec(t *testing.T) {
+	t.Parallel()
+
+	resolver := fixedResolver{latest: "1.0.15", versions: []string{"1.0.14", "1.0.15"}}
+	tests := []struct {
+		name        string
+		opts        initOptions
+		resolver    versionResolver
+		existing    bool
+		wantPath    string
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "latest",
+			opts:        initOptions{protocol: "npx", pkg: "@upstash/context7-mcp"},
+			resolver:    resolver,
+			wantPath:    "npx/context7-mcp/spec.yaml",
+			wantVersion: "1.0.15",
+		},
+		{
+			name:        "pinned with name",
+			opts:        initOptions{protocol: "npx", pkg: "@upstash/context7-mcp", version: "1.0.14", name: "context7"},
+			resolver:    resolver,
+			wantPath:    "npx/context7/spec.yaml",
+			wantVersion: "1.0.14",
+		},
+		{
+			name:     "unknown version",
+			opts:     initOptions{protocol: "npx", pkg: "@upstash/context7-mcp", version: "9.9.9"},
+			resolver: resolver,
+			wantErr:  "version 9.9.9 not found",
+		},
+		{
+			name:        "unchecked",
+			opts:        initOptions{protocol: "uvx", pkg: "mcp-server-fetch", version: "2025.4.7"},
+			wantPath:    "uvx/mcp-server-fetch/spec.yaml",
+			wantVersion: "2025.4.7",
+		},
+		{
+			name:     "existing",
+			opts:     initOptions{protocol: "uvx", pkg: "mcp-server-fetch", version: "2025.4.7"},
+			existing: true,
+			wantErr:  "already exists; use --force",
+		},
+		{
+			name:        "existing with force",
+			opts:        initOptions{protocol: "uvx", pkg: "mcp-server-fetch", version: "2025.4.7", force: true},
+			existing:    true,
+			wantPath:    "uvx/mcp-server-fetch/spec.yaml",
+			wantVersion: "2025.4.7",
+		},
+		{
+			name:    "invalid name",
+			opts:    initOptions{protocol: "npx", pkg: "pkg", version: "1.0.0", name: "../escape"},
+			wantErr: "invalid server name",
+		},
+		{
+			name:    "unsupported protocol",
+			opts:    initOptions{protocol: "cargo", pkg: "ripgrep", version: "14.1.0"},
+			wantErr: "unsupported protocol",
+		},
+		{
+			name:    "oci without version",
+			opts:    initOptions{protocol: "oci", pkg: "ghcr.io/org/server"},
+			wantErr: "--version is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			tt.opts.dir = dir
+			if tt.existing {
+				writeSpec(t, dir, "uvx/mcp-server-fetch/spec.yaml", "# hand-written\n")
+			}
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			cmd.SetOut(&strings.Builder{})
+			cmd.SetErr(&strings.Builder{})
+
+			err := initSpec(cmd, tt.resolver, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("initSpec() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if tt.existing {
+					data, _ := os.ReadFile(filepath.Join(dir, "uvx/mcp-server-fetch/spec.yaml"))
+					if string(data) != "# hand-written\n" {
+						t.Errorf("existing spec was overwritten:\n%s", data)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("initSpec() error: %v", err)
+			}
+
+			// The scaffold passes validate and records the version
+			if issues := validateSpecFile(dir, tt.wantPath); len(issues) > 0 {
+				t.Errorf("validateSpecFile() = %+v, want no issues", issues)
+			}
+			spec, err := readMCPServerSpec(filepath.Join(dir, tt.wantPath))
+			if err != nil {
+				t.Fatalf("readMCPServerSpec() error: %v", err)
+			}
+			if spec.Spec.Version != tt.wantVersion || spec.Spec.Package != tt.opts.pkg {
+				t.Errorf("spec = %s@%s, want %s@%s", spec.Spec.Package, spec.Spec.Version, tt.opts.pkg, tt.wantVersion)
+			}
+		})
+	}
+}
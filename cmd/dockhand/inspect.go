@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/dockyard/pkg/build"
+)
+
+// specInspection holds the build inputs dockhand derives from a spec.
+type specInspection struct {
+	ProtocolScheme string
+	ImageName      string
+	ImageTag       string
+	ParsedSpec     string
+}
+
+func newInspectCmd() *cobra.Command {
+	var cfgFile string
+	var imageRegistry string
+
+	cmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Show the build inputs dockhand derives from a spec",
+		Long: `Inspect loads an MCP server spec and prints the parsed spec, the protocol
+scheme passed to ToolHive, the cleaned image name, and the resulting image tag.
+
+It does not contact any registry or invoke ToolHive, so it is a quick way to
+troubleshoot why a scheme or tag came out the way it did.`,
+		Example: `  # Show the resolved inputs for a spec
+  dockhand inspect -c npx/context7/spec.yaml
+
+  # Show the image tag under a different registry prefix
+  dockhand inspect -c npx/context7/spec.yaml --registry registry.example.com/mcp`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runInspect(cmd, cfgFile, imageRegistry)
+		},
+	}
+
+	cmd.Flags().StringVarP(&cfgFile, "config", "c", "", "Path to the YAML configuration file (required)")
+	cmd.Flags().StringVar(&imageRegistry, "registry", "",
+		"Image repository prefix for the image tag (defaults to $"+registryEnvVar+" or "+defaultRegistry+")")
+	if err := cmd.MarkFlagRequired("config"); err != nil {
+		panic(fmt.Sprintf("failed to mark config flag as required: %v", err))
+	}
+
+	return cmd
+}
+
+// runInspect prints the inspection of the spec at cfgFile.
+func runInspect(cmd *cobra.Command, cfgFile, registryFlag string) error {
+	imageRegistry, err := resolveRegistry(registryFlag)
+	if err != nil {
+		return err
+	}
+
+	spec, err := loadMCPServerSpec(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	inspection, err := inspectSpec(spec, imageRegistry)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Spec: %s\n", cfgFile)
+	fmt.Fprintf(out, "Protocol scheme: %s\n", inspection.ProtocolScheme)
+	fmt.Fprintf(out, "Image name: %s\n", inspection.ImageName)
+	fmt.Fprintf(out, "Image tag: %s\n", inspection.ImageTag)
+	fmt.Fprintf(out, "\nParsed spec:\n%s", inspection.ParsedSpec)
+	return nil
+}
+
+// inspectSpec derives the build inputs for spec without any network access.
+func inspectSpec(spec *MCPServerSpec, imageRegistry string) (*specInspection, error) {
+	protocolScheme, err := build.ProtocolScheme(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed strings.Builder
+	enc := yaml.NewEncoder(&parsed)
+	enc.SetIndent(2)
+	if err := enc.Encode(spec); err != nil {
+		return nil, fmt.Errorf("failed to render parsed spec: %w", err)
+	}
+
+	return &specInspection{
+		ProtocolScheme: protocolScheme,
+		ImageName:      build.ImageName(spec),
+		ImageTag:       build.ImageTag(spec, imageRegistry),
+		ParsedSpec:     parsed.String(),
+	}, nil
+}
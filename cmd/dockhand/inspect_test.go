@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInspectSpec(t *testing.T) {
+	t.Parallel()
+
+	spec := &MCPServerSpec{
+		Metadata: MCPServerMetadata{Name: "context7", Protocol: "npx"},
+		Spec:     MCPServerPackageSpec{Package: "@upstash/context7-mcp", Version: "1.0.14"},
+	}
+
+	got, err := inspectSpec(spec, defaultRegistry)
+	if err != nil {
+		t.Fatalf("inspectSpec() error: %v", err)
+	}
+	if want := "npx://@upstash/context7-mcp@1.0.14"; got.ProtocolScheme != want {
+		t.Errorf("ProtocolScheme = %q, want %q", got.ProtocolScheme, want)
+	}
+	if want := "context7"; got.ImageName != want {
+		t.Errorf("ImageName = %q, want %q", got.ImageName, want)
+	}
+	if want := "ghcr.io/stacklok/dockyard/npx/context7:1.0.14"; got.ImageTag != want {
+		t.Errorf("ImageTag = %q, want %q", got.ImageTag, want)
+	}
+	if !strings.Contains(got.ParsedSpec, "package: '@upstash/context7-mcp'") {
+		t.Errorf("ParsedSpec missing package line:\n%s", got.ParsedSpec)
+	}
+}
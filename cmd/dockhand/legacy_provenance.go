@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// gitlabIssuer is the certificate issuer of GitLab CI, which legacy signer
+// identities may name alongside GitHub Actions.
+const gitlabIssuer = "https://gitlab.com"
+
+// legacyProvenanceFields returns the YAML names of the deprecated flat
+// provenance fields that p sets.
+func legacyProvenanceFields(p *MCPServerProvenance) []string {
+	var fields []string
+	for _, f := range []struct {
+		name  string
+		value string
+	}{
+		{"sigstore_url", p.SigstoreURL},
+		{"signer_identity", p.SignerIdentity},
+		{"runner_environment", p.RunnerEnvironment},
+		{"cert_issuer", p.CertIssuer},
+	} {
+		if f.value != "" {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
+}
+
+// migrateLegacyProvenance maps the deprecated flat provenance fields onto the
+// attestations structure so that older specs are verified the same way as new
+// ones, and returns the deprecated fields it found. Values already present in
+// the new fields are never overwritten, so new specs are left untouched.
+//
+// A signer identity such as
+// https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1
+// yields the publisher repository and workflow, plus the repository URI and
+// ref when those are unset. runner_environment has no equivalent and is dropped.
+func migrateLegacyProvenance(p *MCPServerProvenance) []string {
+	fields := legacyProvenanceFields(p)
+	if len(fields) == 0 || p.Attestations != nil {
+		return fields
+	}
+
+	publisher := &PublisherInfo{Kind: publisherKindForIssuer(p.CertIssuer)}
+	if repoURL, repo, workflow, ref, ok := parseSignerIdentity(p.SignerIdentity); ok {
+		publisher.Repository = repo
+		publisher.Workflow = workflow
+		if publisher.Kind == "" {
+			publisher.Kind = publisherKindForIssuer(sigstore.GitHubActionsIssuer)
+		}
+		if p.RepositoryURI == "" {
+			p.RepositoryURI = repoURL
+		}
+		if p.RepositoryRef == "" {
+			p.RepositoryRef = ref
+		}
+	}
+
+	// Legacy specs only recorded signing details for packages that were signed
+	info := &AttestationInfo{Available: p.SigstoreURL != "" || p.SignerIdentity != ""}
+	if publisher.Repository != "" {
+		info.Publisher = publisher
+	}
+	if info.Available || info.Publisher != nil {
+		p.Attestations = info
+	}
+	return fields
+}
+
+// publisherKindForIssuer returns the trusted publisher kind for a Fulcio
+// certificate issuer, or "" when the issuer is not a known CI provider.
+func publisherKindForIssuer(issuer string) string {
+	switch strings.TrimSuffix(issuer, "/") {
+	case sigstore.GitHubActionsIssuer:
+		return "GitHub"
+	case gitlabIssuer:
+		return "GitLab"
+	default:
+		return ""
+	}
+}
+
+// parseSignerIdentity splits a GitHub Actions workflow identity of the form
+// https://github.com/{owner}/{repo}/.github/workflows/{file}@{ref}.
+func parseSignerIdentity(identity string) (repoURL, repo, workflow, ref string, ok bool) {
+	rest, found := strings.CutPrefix(identity, "https://github.com/")
+	if !found {
+		return "", "", "", "", false
+	}
+	rest, ref, _ = strings.Cut(rest, "@")
+	repo, workflowPath, found := strings.Cut(rest, "/.github/workflows/")
+	if !found || strings.Count(repo, "/") != 1 || workflowPath == "" {
+		return "", "", "", "", false
+	}
+	return "https://github.com/" + repo, repo, workflowPath, ref, true
+}
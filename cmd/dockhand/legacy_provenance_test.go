@@ -0,0 +1,100 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadMCPServerSpec_LegacyProvenance(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeSpec(t, dir, "npx/spec/spec.yaml", `
+metadata:
+  name: legacy
+  protocol: npx
+spec:
+  package: legacy-mcp
+  version: "1.0.0"
+provenance:
+  sigstore_url: "https://rekor.sigstore.dev"
+  signer_identity: "https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.0.0"
+  cert_issuer: "https://token.actions.githubusercontent.com"
+  runner_environment: "github-hosted"
+`)
+	spec, err := readMCPServerSpec(filepath.Join(dir, "npx/spec/spec.yaml"))
+	if err != nil {
+		t.Fatalf("readMCPServerSpec() error: %v", err)
+	}
+
+	p := spec.Provenance
+	if p.RepositoryURI != "https://github.com/owner/repo" || p.RepositoryRef != "refs/tags/v1.0.0" {
+		t.Errorf("repository = %q @ %q, want https://github.com/owner/repo @ refs/tags/v1.0.0",
+			p.RepositoryURI, p.RepositoryRef)
+	}
+	want := &AttestationInfo{
+		Available: true,
+		Publisher: &PublisherInfo{Kind: "GitHub", Repository: "owner/repo", Workflow: "release.yml"},
+	}
+	if !reflect.DeepEqual(p.Attestations, want) {
+		t.Errorf("Attestations = %+v (publisher %+v), want %+v", p.Attestations, p.Attestations.Publisher, want)
+	}
+}
+
+func TestReadMCPServerSpec_NewProvenance(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeSpec(t, dir, "npx/spec/spec.yaml", `
+metadata:
+  name: current
+  protocol: npx
+spec:
+  package: current-mcp
+  version: "1.0.0"
+provenance:
+  repository_uri: "https://github.com/owner/current"
+  repository_ref: "refs/tags/v1.0.0"
+  attestations:
+    available: true
+    publisher:
+      kind: "GitHub"
+      repository: "owner/current"
+`)
+	spec, err := readMCPServerSpec(filepath.Join(dir, "npx/spec/spec.yaml"))
+	if err != nil {
+		t.Fatalf("readMCPServerSpec() error: %v", err)
+	}
+
+	want := MCPServerProvenance{
+		RepositoryURI: "https://github.com/owner/current",
+		RepositoryRef: "refs/tags/v1.0.0",
+		Attestations: &AttestationInfo{
+			Available: true,
+			Publisher: &PublisherInfo{Kind: "GitHub", Repository: "owner/current"},
+		},
+	}
+	if !reflect.DeepEqual(spec.Provenance, want) {
+		t.Errorf("Provenance = %+v, want %+v", spec.Provenance, want)
+	}
+}
+
+func TestMigrateLegacyProvenance_KeepsNewFields(t *testing.T) {
+	t.Parallel()
+
+	existing := &AttestationInfo{Available: true, Publisher: &PublisherInfo{Kind: "GitHub", Repository: "owner/new"}}
+	p := MCPServerProvenance{
+		RepositoryURI:  "https://github.com/owner/new",
+		Attestations:   existing,
+		SignerIdentity: "https://github.com/owner/old/.github/workflows/release.yml@refs/heads/main",
+	}
+	fields := migrateLegacyProvenance(&p)
+
+	if !reflect.DeepEqual(fields, []string{"signer_identity"}) {
+		t.Errorf("fields = %v, want [signer_identity]", fields)
+	}
+	if p.Attestations != existing || p.RepositoryURI != "https://github.com/owner/new" || p.RepositoryRef != "" {
+		t.Errorf("new fields were modified: %+v", p)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// specListEntry is one row of `dockhand list` output.
+type specListEntry struct {
+	Path     string `json:"path"`
+	Protocol string `json:"protocol,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Package  string `json:"package,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func newListCmd() *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all MCP server specifications in the repository",
+		Long: `List walks the npx/, uvx/, go/, and oci/ directories of the current working
+directory, parses every {protocol}/{name}/spec.yaml, and prints its protocol,
+name, package, and version.
+
+Specs that fail to load are reported individually and do not stop the listing;
+the command exits non-zero if any spec failed.`,
+		Example: `  # Print a table of all specs
+  dockhand list
+
+  # Emit JSON for use in scripts
+  dockhand list --output-format json`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runList(cmd, outputFormat)
+		},
+	}
+
+	cmd.Flags().StringVar(&outputFormat, "output-format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// runList loads every spec in the repository and prints a summary of each.
+func runList(cmd *cobra.Command, outputFormat string) error {
+	if outputFormat != "text" && outputFormat != "json" {
+		return fmt.Errorf("invalid output format %q, must be one of: text, json", outputFormat)
+	}
+
+	paths, err := discoverSpecFiles()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]specListEntry, 0, len(paths))
+	failed := 0
+	for _, path := range paths {
+		entry := specListEntry{Path: path}
+		spec, err := loadMCPServerSpec(path)
+		if err != nil {
+			entry.Error = err.Error()
+			failed++
+		} else {
+			entry.Protocol = spec.Metadata.Protocol
+			entry.Name = spec.Metadata.Name
+			entry.Package = spec.Spec.Package
+			entry.Version = spec.Spec.Version
+		}
+		entries = append(entries, entry)
+	}
+
+	if outputFormat == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			return fmt.Errorf("failed to encode spec list: %w", err)
+		}
+	} else {
+		printSpecTable(cmd, entries)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d spec(s) failed to load", failed, len(entries))
+	}
+	return nil
+}
+
+// printSpecTable renders successfully loaded specs as a table on stdout and
+// load failures on stderr.
+func printSpecTable(cmd *cobra.Command, entries []specListEntry) {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROTOCOL\tNAME\tPACKAGE\tVERSION")
+	for _, e := range entries {
+		if e.Error != "" {
+			continue
+		}
+		version := e.Version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Protocol, e.Name, e.Package, version)
+	}
+	_ = w.Flush()
+
+	for _, e := range entries {
+		if e.Error != "" {
+			cmd.PrintErrf("error: %s: %s\n", e.Path, e.Error)
+		}
+	}
+}
+
+// discoverSpecFiles returns the sorted paths of every {protocol}/{name}/spec.yaml
+// under the current working directory. Protocol directories that do not exist
+// are skipped.
+func discoverSpecFiles() ([]string, error) {
+	var paths []string
+	for _, protocol := range mcpProtocols {
+		matches, err := filepath.Glob(filepath.Join(protocol, "*", "spec.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %s/: %w", protocol, err)
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() {
+				paths = append(paths, filepath.ToSlash(m))
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
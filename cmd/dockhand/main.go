@@ -3,96 +3,161 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stacklok/toolhive-core/logging"
-	"github.com/stacklok/toolhive/pkg/container/images"
-	"github.com/stacklok/toolhive/pkg/runner"
-	"gopkg.in/yaml.v3"
 
 	"github.com/stacklok/dockyard/internal/provenance/domain"
-	"github.com/stacklok/dockyard/internal/provenance/npm"
+	"github.com/stacklok/dockyard/internal/provenance/gomod"
 	"github.com/stacklok/dockyard/internal/provenance/pypi"
 	"github.com/stacklok/dockyard/internal/provenance/service"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
 	skillpkg "github.com/stacklok/dockyard/internal/skills"
+	"github.com/stacklok/dockyard/internal/vuln"
+	"github.com/stacklok/dockyard/pkg/build"
 )
 
-// MCPServerSpec defines the structure of our YAML configuration files
-type MCPServerSpec struct {
-	// Metadata about the MCP server
-	Metadata MCPServerMetadata `yaml:"metadata"`
-	// Spec defines the package and build configuration
-	Spec MCPServerPackageSpec `yaml:"spec"`
-	// Provenance information for supply chain security
-	Provenance MCPServerProvenance `yaml:"provenance,omitempty"`
-}
-
-// MCPServerMetadata contains basic information about the MCP server
-type MCPServerMetadata struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description,omitempty"`
-	Protocol    string `yaml:"protocol"` // npx, uvx, go
-}
-
-// MCPServerPackageSpec defines the package to be containerized
-type MCPServerPackageSpec struct {
-	Package string   `yaml:"package"`           // e.g., "@upstash/context7-mcp"
-	Version string   `yaml:"version,omitempty"` // e.g., "1.0.14"
-	Args    []string `yaml:"args,omitempty"`    // Additional arguments for the package
-}
-
-// MCPServerProvenance contains supply chain provenance information
-type MCPServerProvenance struct {
-	// Expected source repository for verification
-	RepositoryURI string `yaml:"repository_uri,omitempty"`
-	RepositoryRef string `yaml:"repository_ref,omitempty"`
+// The spec types are defined by the build package, which generates
+// Dockerfiles from them.
+type (
+	MCPServerSpec        = build.MCPServerSpec
+	MCPServerMetadata    = build.MCPServerMetadata
+	MCPServerPackageSpec = build.MCPServerPackageSpec
+	MCPServerProvenance  = build.MCPServerProvenance
+	AttestationInfo      = build.AttestationInfo
+	PublisherInfo        = build.PublisherInfo
+)
 
-	// Attestation information
-	Attestations *AttestationInfo `yaml:"attestations,omitempty"`
+// defaultRegistry is the image repository prefix used when no override is given.
+const defaultRegistry = build.DefaultRegistry
 
-	// Legacy fields (kept for backwards compatibility)
-	SigstoreURL       string `yaml:"sigstore_url,omitempty"`
-	SignerIdentity    string `yaml:"signer_identity,omitempty"`
-	RunnerEnvironment string `yaml:"runner_environment,omitempty"`
-	CertIssuer        string `yaml:"cert_issuer,omitempty"`
-}
+// registryEnvVar names the environment variable that overrides defaultRegistry.
+const registryEnvVar = "DOCKYARD_REGISTRY"
 
-// AttestationInfo contains information about package attestations
-type AttestationInfo struct {
-	Available bool           `yaml:"available"`
-	Publisher *PublisherInfo `yaml:"publisher,omitempty"`
-	Verified  bool           `yaml:"verified,omitempty"`
-}
+// registryPrefixRe matches an image repository prefix: a registry host with an
+// optional port followed by zero or more lowercase path components.
+var registryPrefixRe = regexp.MustCompile(
+	`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?` +
+		`(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`,
+)
 
-// PublisherInfo contains trusted publisher information
-type PublisherInfo struct {
-	Kind       string `yaml:"kind"`       // e.g., "GitHub", "GitLab"
-	Repository string `yaml:"repository"` // e.g., "owner/repo"
-	Workflow   string `yaml:"workflow,omitempty"`
-}
+// mcpProtocols lists the protocols an MCP server spec may declare. Each one
+// is also the top-level directory its specs live under.
+var mcpProtocols = []string{"npx", "uvx", "go", "oci"}
 
 var (
 	// Global flags
-	verbose bool
+	verbose      bool
+	timeout      time.Duration
+	defaultsFile string
+	// logLevel is raised to debug by --verbose
+	logLevel slog.LevelVar
+	// httpTimeout is the per-request registry timeout, set from the defaults file
+	httpTimeout time.Duration
+	// tufMirror and tufRoot select a private Sigstore deployment
+	tufMirror string
+	tufRoot   string
+	// tufTimeout bounds fetching the trusted root before the cached one is used
+	tufTimeout time.Duration
+	// expandEnv substitutes environment variables in spec values; allowEmptyEnv
+	// lets undefined ones expand to nothing instead of failing
+	expandEnv     bool
+	allowEmptyEnv bool
+	// npmRegistry is the npm registry to verify packages from; npmToken is sent
+	// to registries matching npmTokenHost
+	npmRegistry  string
+	npmToken     string
+	npmTokenHost string
+	// pypiArtifactKind limits PyPI verification to wheels or sdists
+	pypiArtifactKind string
 
 	// Build command flags
 	configFile string
 	outputTag  string
 	output     string
+	registry   string
+	platforms  []string
+	preview    bool
+	reportPath string
+	// attestPath is where build writes an in-toto statement about the image
+	attestPath string
+	// writeAlongside writes the Dockerfile next to the spec; force lets it overwrite
+	writeAlongside bool
+	force          bool
+	// buildMulti builds every spec in a multi-document config
+	buildMulti bool
+	// caCertPath is a CA certificate for the build, overriding spec.ca_cert
+	caCertPath string
+	// serverArgs are appended to spec.args
+	serverArgs []string
+	// fromSource builds the package whose source is in this directory instead of a spec
+	fromSource string
+	// blockVulnerable fails the build of a package version OSV knows vulnerabilities in
+	blockVulnerable bool
+	// warnVulnerable lists the vulnerabilities OSV knows in the package version without failing
+	warnVulnerable bool
 
 	// Verify command flags
 	checkProvenance    bool
 	warnOnNoProvenance bool
+	verifyOutputFormat string
+	verifyPackage      string
+	verifyVersion      string
+	verifyProtocol     string
+	strict             bool
+	// verifyPURL is the Package URL of the package to verify, e.g. from an SBOM
+	verifyPURL string
+	// verifyDir is a directory whose specs are all verified, e.g. the catalog root
+	verifyDir string
+	// resultTemplate formats each result with text/template instead of the text output
+	resultTemplate string
+	// certIdentityRegexp and certOIDCIssuer override the expected signer
+	certIdentityRegexp string
+	certOIDCIssuer     string
+	// allowedPublishers are the publisher repository globs to accept
+	allowedPublishers []string
+	// minAge fails releases published more recently than this
+	minAge time.Duration
+	// attestationType is the npm attestation type that must verify
+	attestationType string
+	// quickVerify reports PyPI publisher claims without verifying them
+	quickVerify bool
+	// verifyIntegrity downloads npm tarballs to check them against dist.integrity
+	verifyIntegrity bool
+	// allowVCS fetches private Go modules from their repositories instead of the module proxy
+	allowVCS bool
+	// explain prints how each verification reached its status
+	explain bool
+	// machineFooter ends the text output with a key=value summary line on stdout
+	machineFooter bool
+	// allVersions verifies every published version instead of one
+	allVersions bool
+	// bundlePath verifies a local bundle instead of a package, against
+	// artifactDigest or the digest of the localArtifact file
+	bundlePath     string
+	artifactDigest string
+	localArtifact  string
+	// onlyChangedProvenance fails only when the result contradicts the spec's provenance claims
+	onlyChangedProvenance bool
+	// baselinePath and saveBaselinePath are the baselines to compare with and update
+	baselinePath     string
+	saveBaselinePath string
 )
 
 func main() {
 	// Initialize the logger
-	slog.SetDefault(logging.New(logging.WithFormat(logging.FormatText)))
+	slog.SetDefault(logging.New(logging.WithFormat(logging.FormatText), logging.WithLevel(&logLevel)))
 
 	rootCmd := &cobra.Command{
 		Use:   "dockhand",
@@ -103,10 +168,25 @@ to build container images from protocol schemes (npx://, uvx://, go://).
 It simplifies the process of packaging MCP (Model Context Protocol) servers 
 into container images for easy deployment and distribution.`,
 		Version: "0.1.0",
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if verbose {
+				logLevel.Set(slog.LevelDebug)
+			}
+
+			defaults, err := loadDefaults(defaultsFile)
+			if err != nil {
+				return err
+			}
+			return applyDefaults(cmd, defaults)
+		},
 	}
 
 	// Add global flags
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0,
+		"Maximum time for build and verify-provenance, e.g. 2m (0 disables the limit)")
+	rootCmd.PersistentFlags().StringVar(&defaultsFile, "config-defaults", "",
+		"Path to a file of default flag values (defaults to "+defaultsFileName+" if present)")
 
 	// Add build command
 	buildCmd := &cobra.Command{
@@ -118,7 +198,11 @@ and generates a Dockerfile or builds a container image using ToolHive.
 The configuration file should follow the structure:
   {protocol}/{name}/spec.yaml
 
-Where protocol is one of: npx, uvx, or go`,
+Where protocol is one of: npx, uvx, or go
+
+With --from-source instead of a spec, the package and version are read from
+the package.json (npx) or pyproject.toml or PKG-INFO (uvx) in a source
+directory, and the published package at that version is built.`,
 		Example: `  # Generate a Dockerfile to stdout
   dockhand build -c npx/context7/spec.yaml
 
@@ -126,19 +210,74 @@ Where protocol is one of: npx, uvx, or go`,
   dockhand build -c npx/context7/spec.yaml -o Dockerfile
 
   # Generate with custom tag
-  dockhand build -c npx/context7/spec.yaml -t myregistry/myimage:v1.0.0`,
-		RunE: runBuild,
+  dockhand build -c npx/context7/spec.yaml -t myregistry/myimage:v1.0.0
+
+  # Generate a Dockerfile for docker buildx multi-platform builds
+  dockhand build -c npx/context7/spec.yaml --platform linux/amd64 --platform linux/arm64
+
+  # Preview the resolved build inputs and provenance without writing anything
+  dockhand build -c npx/context7/spec.yaml --preview
+
+  # Record what was built for CI
+  dockhand build -c npx/context7/spec.yaml -o Dockerfile --report build-report.json
+
+  # Write npx/context7/Dockerfile, replacing an existing one
+  dockhand build -c npx/context7/spec.yaml --write-alongside --force
+
+  # Tag the image under a different registry prefix
+  dockhand build -c npx/context7/spec.yaml --registry registry.example.com/mcp
+
+  # Build every spec in a catalog, writing one Dockerfile per server to dockerfiles/
+  dockhand build --multi -c servers.yaml -o dockerfiles
+
+  # Build the version of a local package checkout, with no spec
+  dockhand build --from-source ./my-mcp-server
+
+  # Refuse to build a package version with known vulnerabilities
+  dockhand build -c npx/context7/spec.yaml --block-vulnerable`,
+		RunE: withTimeout(runBuild),
 	}
 
 	// Add build command flags
 	buildCmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to the YAML configuration file (required)")
 	buildCmd.Flags().StringVarP(&outputTag, "tag", "t", "", "Custom container image tag (optional)")
 	buildCmd.Flags().StringVarP(&output, "output", "o", "", "Output file for Dockerfile (optional, defaults to stdout)")
+	buildCmd.Flags().StringVar(&registry, "registry", "",
+		"Image repository prefix for generated tags (defaults to $"+registryEnvVar+" or "+defaultRegistry+")")
+	buildCmd.Flags().StringArrayVar(&platforms, "platform", nil,
+		"Target platform for a multi-platform Dockerfile, e.g. linux/amd64 (repeatable)")
+	buildCmd.Flags().BoolVar(&preview, "preview", false,
+		"Print the resolved protocol scheme, image tag, and provenance status without generating a Dockerfile")
+	buildCmd.Flags().StringVar(&reportPath, "report", "",
+		"Write a JSON report of the spec, image tag, protocol scheme, provenance status, and output to this file")
+	buildCmd.Flags().StringVar(&attestPath, "attest", "",
+		"Write an unsigned in-toto statement about the image, its package, and the package provenance to this file")
+	buildCmd.Flags().BoolVar(&writeAlongside, "write-alongside", false,
+		"Write the Dockerfile next to the spec, as {protocol}/{name}/Dockerfile, instead of to stdout or --output")
+	buildCmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing Dockerfile with --write-alongside")
+	buildCmd.MarkFlagsMutuallyExclusive("output", "write-alongside")
+	buildCmd.Flags().BoolVar(&buildMulti, "multi", false,
+		"Build every spec in a multi-document YAML file, writing {name}.Dockerfile for each to the --output directory")
+	for _, flag := range []string{"tag", "preview", "report", "attest", "write-alongside"} {
+		buildCmd.MarkFlagsMutuallyExclusive("multi", flag)
+	}
+	buildCmd.Flags().StringArrayVar(&serverArgs, "arg", nil,
+		"Argument passed to the MCP server on every start, after spec.args (repeatable)")
+	buildCmd.Flags().StringVar(&caCertPath, "ca-cert", "",
+		"PEM CA certificate to trust when the image is built, e.g. for a TLS-intercepting proxy (overrides spec.ca_cert)")
 	buildCmd.Flags().BoolVar(&checkProvenance, "check-provenance", false, "Check package provenance before building")
+	buildCmd.Flags().BoolVar(&blockVulnerable, "block-vulnerable", false,
+		"Fail when the OSV database lists known vulnerabilities in the package version")
+	buildCmd.Flags().BoolVar(&warnVulnerable, "warn-vulnerable", false,
+		"List the known vulnerabilities OSV has for the package version without failing the build")
+	buildCmd.MarkFlagsMutuallyExclusive("block-vulnerable", "warn-vulnerable")
 	buildCmd.Flags().BoolVar(&warnOnNoProvenance, "warn-no-provenance", true, "Warn if provenance is not available (default: true)")
-	if err := buildCmd.MarkFlagRequired("config"); err != nil {
-		// This should never fail for a valid flag name
-		panic(fmt.Sprintf("failed to mark config flag as required: %v", err))
+	buildCmd.Flags().StringVar(&fromSource, "from-source", "",
+		"Build the package whose package.json, pyproject.toml, or PKG-INFO is in this directory, at its version, without a spec")
+	addVerifierFlags(buildCmd)
+	buildCmd.MarkFlagsOneRequired("config", "from-source")
+	for _, flag := range []string{"config", "multi", "write-alongside"} {
+		buildCmd.MarkFlagsMutuallyExclusive("from-source", flag)
 	}
 
 	// Add verify-provenance command
@@ -147,19 +286,119 @@ Where protocol is one of: npx, uvx, or go`,
 		Short: "Verify provenance for an MCP server package",
 		Long: `Verify checks if a package has provenance attestations or signatures
 available from the package registry. This helps ensure supply chain security
-by verifying the authenticity and origin of the package.`,
+by verifying the authenticity and origin of the package.
+
+` + exitCodeHelp,
 		Example: `  # Verify provenance for a package
   dockhand verify-provenance -c npx/context7/spec.yaml
 
   # Verify with verbose output
-  dockhand verify-provenance -c uvx/mcp-clickhouse/spec.yaml -v`,
-		RunE: runVerifyProvenance,
+  dockhand verify-provenance -c uvx/mcp-clickhouse/spec.yaml -v
+
+  # Fail unless the provenance is verified, e.g. to gate a pipeline
+  dockhand verify-provenance -c npx/context7/spec.yaml --strict
+
+  # Verify a package by its coordinates, without a spec file
+  dockhand verify-provenance --package @upstash/context7-mcp --version 1.0.14 --protocol npx
+
+  # Verify a package named by a Package URL, e.g. from an SBOM
+  dockhand verify-provenance --purl pkg:npm/%40upstash/context7-mcp@1.0.14
+
+  # Verify the packages of every spec in the catalog, whatever their protocol
+  dockhand verify-provenance --dir .
+
+  # Emit the result, including per-phase timings, as JSON
+  dockhand verify-provenance -c npx/context7/spec.yaml --output-format json
+
+  # Only accept packages published from repositories of one organization
+  dockhand verify-provenance -c npx/context7/spec.yaml --allowed-publisher 'upstash/*'
+
+  # Detect drift against the results of an earlier run, then update them
+  dockhand verify-provenance -c npx/context7/spec.yaml --baseline baseline.json --save-baseline baseline.json
+
+  # Require a signature from a specific release workflow
+  dockhand verify-provenance -c npx/context7/spec.yaml \
+    --cert-identity-regexp '^https://github.com/upstash/context7/\.github/workflows/release\.yml@'
+
+  # Fail only if the registry no longer matches the provenance the spec records
+  dockhand verify-provenance -c npx/context7/spec.yaml --only-changed-provenance`,
+		RunE: withTimeout(runVerifyProvenance),
 	}
 
-	verifyCmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to the YAML configuration file (required)")
-	if err := verifyCmd.MarkFlagRequired("config"); err != nil {
-		panic(fmt.Sprintf("failed to mark config flag as required: %v", err))
+	verifyCmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to the YAML configuration file")
+	verifyCmd.Flags().StringVar(&verifyOutputFormat, "output-format", "text", "Output format: text or json")
+	verifyCmd.Flags().StringVar(&resultTemplate, "template", "",
+		"Go text/template executed against each result instead of the text output, e.g. '{{.PackageID.Name}} {{.Status}}', "+
+			"or a built-in template: "+strings.Join(builtinTemplateNames(), ", "))
+	verifyCmd.Flags().BoolVar(&strict, "strict", false, "Exit non-zero unless the provenance is fully verified")
+	verifyCmd.Flags().StringVar(&verifyPackage, "package", "",
+		"Package name to verify instead of a spec file, e.g. @upstash/context7-mcp")
+	verifyCmd.Flags().StringVar(&verifyVersion, "version", "", "Package version to verify (with --package)")
+	verifyCmd.Flags().StringVar(&verifyProtocol, "protocol", "",
+		"Package protocol to verify: npx, uvx, go, oci, or one added by a verifier plugin (with --package)")
+	verifyCmd.Flags().StringVar(&verifyPURL, "purl", "",
+		"Package URL of the package to verify instead of a spec file, e.g. pkg:npm/%40upstash/context7-mcp@1.0.14")
+	verifyCmd.Flags().StringVar(&verifyDir, "dir", "",
+		"Verify the package of every spec.yaml under this directory, of any protocol, instead of one spec")
+	verifyCmd.Flags().StringVar(&certIdentityRegexp, "cert-identity-regexp", "",
+		"Regexp the signing certificate's identity must match, replacing the default policy")
+	verifyCmd.Flags().StringVar(&certOIDCIssuer, "cert-oidc-issuer", "",
+		"OIDC issuer the signing certificate must name (default: GitHub Actions)")
+	verifyCmd.Flags().StringVar(&baselinePath, "baseline", "",
+		"Baseline file to compare the result with, failing on a weaker status or a changed publisher")
+	verifyCmd.Flags().StringVar(&saveBaselinePath, "save-baseline", "",
+		"Record the result in this baseline file, creating it if needed")
+	verifyCmd.Flags().StringVar(&attestationType, "attestation-type", "",
+		"npm attestation type that must verify, e.g. https://slsa.dev/provenance/v1 or publish; others are ignored")
+	verifyCmd.Flags().StringArrayVar(&allowedPublishers, "allowed-publisher", nil,
+		"Publisher repository glob to accept, e.g. myorg/* (repeatable; default: any publisher)")
+	verifyCmd.Flags().DurationVar(&minAge, "min-age", 0,
+		"Fail versions published less than this long ago, e.g. 72h (npx and uvx record the publication time)")
+	verifyCmd.Flags().BoolVar(&quickVerify, "quick", false,
+		"PyPI only: report the publisher claimed by the provenance without downloading files or verifying signatures")
+	verifyCmd.Flags().BoolVar(&quickVerify, "no-crypto", false, "Alias for --quick")
+	verifyCmd.Flags().BoolVar(&explain, "explain", false,
+		"Print how verification reached its status: the artifacts found, the digest and certificate identity "+
+			"they were checked against, and the exact Sigstore error of a failure")
+	verifyCmd.Flags().BoolVar(&machineFooter, "machine-footer", false,
+		"End the text output with a parseable line on stdout, e.g. RESULT status=VERIFIED attestations=1 publisher=owner/repo")
+	verifyCmd.Flags().BoolVar(&verifyIntegrity, "verify-integrity", false,
+		"npx only: download each tarball and check it against the registry's dist.integrity and dist.shasum")
+	verifyCmd.Flags().BoolVar(&allVersions, "all-versions", false,
+		"Verify every published version of the package instead of one (npx and uvx)")
+	verifyCmd.Flags().BoolVar(&onlyChangedProvenance, "only-changed-provenance", false,
+		"Exit non-zero only when the result contradicts provenance.attestations in the spec, e.g. to review catalog changes")
+	for _, flag := range []string{"strict", "allowed-publisher", "min-age", "all-versions"} {
+		verifyCmd.MarkFlagsMutuallyExclusive("only-changed-provenance", flag)
+	}
+	verifyCmd.MarkFlagsMutuallyExclusive("all-versions", "version")
+	verifyCmd.MarkFlagsMutuallyExclusive("machine-footer", "all-versions")
+	verifyCmd.MarkFlagsMutuallyExclusive("all-versions", "baseline")
+	verifyCmd.MarkFlagsMutuallyExclusive("all-versions", "save-baseline")
+	for _, flag := range []string{"config", "package", "version", "protocol", "all-versions"} {
+		verifyCmd.MarkFlagsMutuallyExclusive("purl", flag)
 	}
+	for _, flag := range []string{
+		"config", "package", "version", "protocol", "purl", "all-versions", "baseline", "save-baseline",
+		"only-changed-provenance", "machine-footer",
+	} {
+		verifyCmd.MarkFlagsMutuallyExclusive("dir", flag)
+	}
+	verifyCmd.Flags().StringVar(&bundlePath, "bundle", "",
+		"Verify this local Sigstore bundle against --artifact-digest or --local-artifact instead of a package, "+
+			"without registry access")
+	verifyCmd.Flags().StringVar(&artifactDigest, "artifact-digest", "",
+		"Digest of the artifact the --bundle signs, e.g. sha256:<hex>")
+	verifyCmd.Flags().StringVar(&localArtifact, "local-artifact", "",
+		"Local package file (.tgz, .whl, .tar.gz) the --bundle signs, hashed instead of giving --artifact-digest")
+	verifyCmd.MarkFlagsMutuallyExclusive("artifact-digest", "local-artifact")
+	for _, flag := range []string{
+		"config", "package", "version", "protocol", "all-versions", "baseline", "save-baseline", "template", "explain",
+		"min-age", "only-changed-provenance", "machine-footer", "purl", "dir",
+	} {
+		verifyCmd.MarkFlagsMutuallyExclusive("bundle", flag)
+	}
+	addVerifierFlags(verifyCmd)
 
 	// Add build-skill command
 	var skillConfigFile string
@@ -215,71 +454,198 @@ and validates the SKILL.md without packaging. Useful for PR checks.`,
 	}
 
 	// Add commands to root
-	rootCmd.AddCommand(buildCmd, verifyCmd, buildSkillCmd, validateSkillCmd)
+	rootCmd.AddCommand(buildCmd, verifyCmd, buildSkillCmd, validateSkillCmd, newListCmd(), newValidateCmd(), newInspectCmd(),
+		newRefreshCmd(), newSelftestCmd(), newBumpCmd(), newInitCmd())
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
 func runBuild(cmd *cobra.Command, _ []string) error {
-	// Read and parse the YAML configuration
-	spec, err := loadMCPServerSpec(configFile)
+	imageRegistry, err := resolveRegistry(registry)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
+	}
+	if err := validatePlatforms(platforms); err != nil {
+		return err
+	}
+	if err := build.ValidateArgs(serverArgs); err != nil {
+		return fmt.Errorf("invalid --arg: %w", err)
+	}
+	if caCertPath != "" {
+		if err := build.ValidateCACert(caCertPath); err != nil {
+			return err
+		}
+	}
+	if buildMulti {
+		return runBuildMulti(cmd, imageRegistry)
 	}
 
-	// Check provenance if requested
-	if checkProvenance || warnOnNoProvenance {
-		provenanceService, err := createProvenanceService()
+	// Read and parse the YAML configuration, or derive it from the source
+	var spec *MCPServerSpec
+	specPath := configFile
+	if fromSource != "" {
+		spec, err = specFromSource(fromSource)
 		if err != nil {
-			return fmt.Errorf("failed to create provenance service: %w", err)
+			return fmt.Errorf("failed to read package source: %w", err)
 		}
-
-		pkg := domain.PackageIdentifier{
-			Protocol: domain.PackageProtocol(spec.Metadata.Protocol),
-			Name:     spec.Spec.Package,
-			Version:  spec.Spec.Version,
+		specPath = fromSource
+		cmd.Printf("Building %s %s@%s from %s\n", spec.Metadata.Protocol, spec.Spec.Package, spec.Spec.Version, fromSource)
+	} else {
+		spec, err = loadMCPServerSpec(configFile)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
 		}
+	}
 
-		ctx := context.Background()
-		result, err := provenanceService.VerifyProvenance(ctx, pkg)
-		if err != nil && checkProvenance {
-			return fmt.Errorf("provenance verification failed: %w", err)
+	if preview {
+		return runBuildPreview(cmd, spec, imageRegistry)
+	}
+
+	// Resolve the destination before any work, so a refused overwrite fails fast
+	outputPath := output
+	if writeAlongside {
+		outputPath, err = alongsideDockerfilePath(configFile, force)
+		if err != nil {
+			return err
 		}
+	}
 
-		// Print provenance status
-		if result != nil {
-			cmd.Printf("Provenance check: %s\n", result.Status)
-			if result.Status == domain.ProvenanceStatusNone && warnOnNoProvenance {
-				cmd.Printf("⚠  Warning: Package has no provenance information\n")
-			}
+	report := &buildReport{
+		Spec:      specPath,
+		ImageTag:  resolveImageTag(spec, outputTag, imageRegistry),
+		Platforms: platforms,
+		Output:    reportOutputStdout,
+	}
+
+	// Check provenance if requested; the attestation records it too
+	var provenanceResult *domain.ProvenanceResult
+	if checkProvenance || warnOnNoProvenance || attestPath != "" {
+		provenanceResult, err = checkBuildProvenance(cmd, spec)
+		if err != nil {
+			return err
+		}
+		if provenanceResult != nil {
+			report.ProvenanceStatus = string(provenanceResult.Status)
 		}
 	}
+	if err := runVulnerabilityCheck(cmd, spec); err != nil {
+		return err
+	}
 
 	// Generate Dockerfile
-	ctx := context.Background()
-	dockerfile, err := generateDockerfile(ctx, spec, outputTag)
+	dockerfile, err := build.GenerateDockerfile(cmd.Context(), spec,
+		build.WithImageTag(outputTag), build.WithRegistry(imageRegistry), build.WithCACertPath(caCertPath),
+		build.WithArgs(serverArgs...))
 	if err != nil {
 		return fmt.Errorf("failed to generate Dockerfile: %w", err)
 	}
+	dockerfile = applyPlatforms(dockerfile, platforms)
+	// GenerateDockerfile has validated the package reference already
+	report.ProtocolScheme, _ = build.ProtocolScheme(spec)
 
 	// Output Dockerfile
-	if output != "" {
+	if outputPath != "" {
 		// Write to file
-		if err := os.WriteFile(output, []byte(dockerfile), 0600); err != nil {
-			return fmt.Errorf("failed to write Dockerfile to %s: %w", output, err)
+		if err := writeFileAtomic(outputPath, []byte(dockerfile), 0600); err != nil {
+			return fmt.Errorf("failed to write Dockerfile to %s: %w", outputPath, err)
 		}
-		cmd.Printf("Dockerfile written to: %s\n", output)
+		cmd.Printf("Dockerfile written to: %s\n", outputPath)
+		report.Output = reportOutputFile
+		report.DockerfilePath = outputPath
 	} else {
 		// Output to stdout using cobra's command
 		cmd.Print(dockerfile)
 	}
 
+	if attestPath != "" {
+		if err := writeBuildAttestation(cmd, attestPath, spec, report, dockerfile, provenanceResult); err != nil {
+			return err
+		}
+	}
+	if reportPath != "" {
+		return writeBuildReport(reportPath, report)
+	}
 	return nil
 }
 
+// alongsideDockerfilePath returns the path of the Dockerfile next to the spec
+// at configPath, which loadMCPServerSpec has validated to be
+// {protocol}/{name}/spec.yaml. An existing Dockerfile is only replaced with force.
+func alongsideDockerfilePath(configPath string, force bool) (string, error) {
+	dockerfilePath := filepath.Join(filepath.Dir(configPath), "Dockerfile")
+	if force {
+		return dockerfilePath, nil
+	}
+	if _, err := os.Stat(dockerfilePath); err == nil {
+		return "", fmt.Errorf("%s already exists, use --force to overwrite it", dockerfilePath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to check %s: %w", dockerfilePath, err)
+	}
+	return dockerfilePath, nil
+}
+
+// checkBuildProvenance verifies the provenance of the spec's package before a
+// build and prints its status. Verification errors only fail the build with
+// --check-provenance; otherwise the returned status may be empty.
+func checkBuildProvenance(cmd *cobra.Command, spec *MCPServerSpec) (*domain.ProvenanceResult, error) {
+	provenanceService, err := createProvenanceService(cmd.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provenance service: %w", err)
+	}
+
+	pkg := domain.PackageIdentifier{
+		Protocol: domain.PackageProtocol(spec.Metadata.Protocol),
+		Name:     spec.Spec.Package,
+		Version:  spec.Spec.Version,
+	}
+
+	result, err := provenanceService.VerifyProvenance(cmd.Context(), pkg)
+	if err != nil && checkProvenance {
+		return nil, fmt.Errorf("provenance verification failed: %w", err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+	if err := checkSpecPins(spec, result); err != nil {
+		return nil, err
+	}
+
+	// Print provenance status
+	cmd.Printf("Provenance check: %s\n", result.Status)
+	if result.Status == domain.ProvenanceStatusNone && warnOnNoProvenance {
+		cmd.Printf("⚠  Warning: Package has no provenance information\n")
+	}
+	return result, nil
+}
+
+// withTimeout wraps a command's RunE so that it runs under the --timeout
+// deadline, available to the command through cmd.Context(). Errors caused by
+// the deadline expiring are reported as a timeout.
+func withTimeout(run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if timeout <= 0 {
+			return run(cmd, args)
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+		defer cancel()
+		cmd.SetContext(ctx)
+
+		err := run(cmd, args)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("operation timed out after %s: %w", timeout, err)
+		}
+		return err
+	}
+}
+
 // validateConfigPath ensures the config path is safe and within expected directories
 func validateConfigPath(configPath string) error {
 	// Clean the path to prevent directory traversal
@@ -291,7 +657,7 @@ func validateConfigPath(configPath string) error {
 	}
 
 	// Ensure it's in one of the expected directories
-	validPrefixes := []string{"npx/", "uvx/", "go/", "skills/"}
+	validPrefixes := []string{"npx/", "uvx/", "go/", "oci/", "skills/"}
 	for _, prefix := range validPrefixes {
 		if strings.HasPrefix(cleanPath, prefix) {
 			// Validate the structure: {type}/{name}/spec.yaml
@@ -302,7 +668,8 @@ func validateConfigPath(configPath string) error {
 		}
 	}
 
-	return fmt.Errorf("config file must follow the structure: {type}/{name}/spec.yaml where type is npx/, uvx/, go/, or skills/")
+	return fmt.Errorf(
+		"config file must follow the structure: {type}/{name}/spec.yaml where type is npx/, uvx/, go/, oci/, or skills/")
 }
 
 // loadMCPServerSpec reads and parses a YAML configuration file
@@ -312,214 +679,419 @@ func loadMCPServerSpec(configPath string) (*MCPServerSpec, error) {
 		return nil, fmt.Errorf("invalid config path: %w", err)
 	}
 
-	// #nosec G304 - Path is validated above to prevent directory traversal
-	data, err := os.ReadFile(configPath)
+	spec, err := readMCPServerSpec(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateProtocolDirectory(configPath, spec.Metadata.Protocol); err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
+
+	return spec, nil
+}
+
+// validateProtocolDirectory ensures the {protocol} directory a spec lives in
+// agrees with the protocol it declares, since both feed the image tag.
+func validateProtocolDirectory(configPath, protocol string) error {
+	dir := strings.Split(filepath.ToSlash(filepath.Clean(configPath)), "/")[0]
+	if dir != protocol {
+		return fmt.Errorf("protocol directory %q does not match metadata.protocol %q", dir, protocol)
+	}
+	return nil
+}
+
+// readMCPServerSpec reads and validates the spec at path. Callers are
+// responsible for validating the path itself.
+func readMCPServerSpec(path string) (*MCPServerSpec, error) {
+	data, err := readSpecFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	var spec MCPServerSpec
-	if err := yaml.Unmarshal(data, &spec); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	if err := decodeSpecYAML(data, &spec); err != nil {
+		return nil, err
+	}
+	if err := validateMCPServerSpec(&spec, path); err != nil {
+		return nil, err
 	}
+	return &spec, nil
+}
 
+// validateMCPServerSpec checks the required fields and protocol of a decoded
+// spec read from path, and migrates deprecated provenance fields.
+func validateMCPServerSpec(spec *MCPServerSpec, path string) error {
 	// Validate required fields
 	if spec.Metadata.Name == "" {
-		return nil, fmt.Errorf("metadata.name is required")
+		return fmt.Errorf("metadata.name is required")
 	}
 	if spec.Metadata.Protocol == "" {
-		return nil, fmt.Errorf("metadata.protocol is required")
+		return fmt.Errorf("metadata.protocol is required")
 	}
 	if spec.Spec.Package == "" {
-		return nil, fmt.Errorf("spec.package is required")
+		return fmt.Errorf("spec.package is required")
 	}
 
 	// Validate protocol
-	validProtocols := []string{"npx", "uvx", "go"}
-	isValid := false
-	for _, p := range validProtocols {
-		if spec.Metadata.Protocol == p {
-			isValid = true
-			break
-		}
+	if !slices.Contains(mcpProtocols, spec.Metadata.Protocol) {
+		return fmt.Errorf("invalid protocol %s, must be one of: %v", spec.Metadata.Protocol, mcpProtocols)
 	}
-	if !isValid {
-		return nil, fmt.Errorf("invalid protocol %s, must be one of: %v", spec.Metadata.Protocol, validProtocols)
-	}
-
-	return &spec, nil
-}
 
-// generateDockerfile generates a Dockerfile using toolhive's library
-func generateDockerfile(ctx context.Context, spec *MCPServerSpec, customTag string) (string, error) {
-	// Create the protocol scheme string
-	packageRef := spec.Spec.Package
-	if spec.Spec.Version != "" {
-		packageRef = fmt.Sprintf("%s@%s", packageRef, spec.Spec.Version)
+	if err := build.ValidateArgs(spec.Spec.Args); err != nil {
+		return fmt.Errorf("spec.args: %w", err)
 	}
-	protocolScheme := fmt.Sprintf("%s://%s", spec.Metadata.Protocol, packageRef)
-
-	// Generate the container image tag
-	imageTag := customTag
-	if imageTag == "" {
-		imageTag = generateImageTag(spec)
+	if err := build.ValidateCustomizations(&spec.Spec); err != nil {
+		return err
+	}
+	if spec.Spec.CACert != "" {
+		// A relative certificate path is relative to the spec, not the working directory
+		if !filepath.IsAbs(spec.Spec.CACert) {
+			spec.Spec.CACert = filepath.Join(filepath.Dir(path), spec.Spec.CACert)
+		}
+		if err := build.ValidateCACert(spec.Spec.CACert); err != nil {
+			return fmt.Errorf("spec.ca_cert: %w", err)
+		}
 	}
 
-	// Create image manager
-	imageManager := images.NewImageManager(ctx)
+	if spec.Spec.ExpectedDigest != "" {
+		if err := validateExpectedDigest(domain.PackageProtocol(spec.Metadata.Protocol), spec.Spec.ExpectedDigest); err != nil {
+			return fmt.Errorf("spec.expected_digest: %w", err)
+		}
+	}
 
-	// Generate Dockerfile using toolhive's BuildFromProtocolSchemeWithName function with dryRun=true
-	dockerfile, err := runner.BuildFromProtocolSchemeWithName(
-		ctx,
-		imageManager,
-		protocolScheme,
-		"", // caCertPath - empty for now
-		imageTag,
-		spec.Spec.Args, // Pass args from spec if present
-		nil,            // runtimeOverride - use defaults
-		true,           // always dryRun to generate Dockerfile
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate Dockerfile for protocol scheme %s: %w", protocolScheme, err)
+	if spec.Provenance.GoSum != "" {
+		if spec.Metadata.Protocol != string(domain.ProtocolGo) {
+			return fmt.Errorf("provenance.go_sum is only valid for %s packages", domain.ProtocolGo)
+		}
+		if _, _, err := gomod.ParseGoSumLine(spec.Provenance.GoSum); err != nil {
+			return fmt.Errorf("provenance.go_sum: %w", err)
+		}
 	}
 
-	return dockerfile, nil
+	if fields := migrateLegacyProvenance(&spec.Provenance); len(fields) > 0 {
+		slog.Warn("spec uses deprecated provenance fields; use provenance.attestations instead",
+			"spec", path, "fields", strings.Join(fields, ", "))
+	}
+	return nil
 }
 
-// generateImageTag creates a container image tag based on the repository structure
-// Following the pattern: ghcr.io/stacklok/dockyard/{protocol}/{name}:{version}
-func generateImageTag(spec *MCPServerSpec) string {
-	// Base registry path
-	registry := "ghcr.io/stacklok/dockyard"
-
-	// Clean the package name to create a valid image name
-	name := cleanPackageName(spec.Metadata.Name)
-
-	// Use version from spec, fallback to "latest"
-	version := spec.Spec.Version
-	if version == "" {
-		version = "latest"
+// resolveImageTag returns customTag when set, otherwise the tag derived from the spec
+func resolveImageTag(spec *MCPServerSpec, customTag, imageRegistry string) string {
+	if customTag != "" {
+		return customTag
 	}
-
-	return fmt.Sprintf("%s/%s/%s:%s", registry, spec.Metadata.Protocol, name, version)
+	return build.ImageTag(spec, imageRegistry)
 }
 
-// cleanPackageName converts a package name to a valid container image name
-func cleanPackageName(packageName string) string {
-	// Remove common prefixes and clean up the name
-	name := packageName
-	name = strings.TrimPrefix(name, "@")
-	name = strings.ReplaceAll(name, "/", "-")
-	name = strings.ReplaceAll(name, "_", "-")
-	name = strings.ToLower(name)
-
-	// Ensure it doesn't start with a dash
-	name = strings.TrimPrefix(name, "-")
-
-	if name == "" {
-		name = "mcp-server"
+// resolveRegistry picks the image repository prefix from the --registry flag,
+// then $DOCKYARD_REGISTRY, then the built-in default, and validates it.
+func resolveRegistry(flagValue string) (string, error) {
+	value := flagValue
+	source := "--registry"
+	if value == "" {
+		value = os.Getenv(registryEnvVar)
+		source = "$" + registryEnvVar
+	}
+	if value == "" {
+		return defaultRegistry, nil
 	}
 
-	return name
+	value = strings.TrimSuffix(value, "/")
+	if !registryPrefixRe.MatchString(value) {
+		return "", fmt.Errorf("invalid registry %q from %s: must be an image repository prefix such as ghcr.io/org/project",
+			value, source)
+	}
+	return value, nil
 }
 
 // runVerifyProvenance verifies the provenance of a package
 func runVerifyProvenance(cmd *cobra.Command, _ []string) error {
-	// Load the spec
-	spec, err := loadMCPServerSpec(configFile)
+	if verifyOutputFormat != "text" && verifyOutputFormat != "json" {
+		return fmt.Errorf("invalid output format %q, must be one of: text, json", verifyOutputFormat)
+	}
+	var tmpl *template.Template
+	if resultTemplate != "" {
+		if verifyOutputFormat == "json" {
+			return fmt.Errorf("--template cannot be combined with --output-format json")
+		}
+		var err error
+		if tmpl, err = parseResultTemplate(resultTemplate); err != nil {
+			return err
+		}
+	}
+	if machineFooter && verifyOutputFormat == "json" {
+		return fmt.Errorf("--machine-footer cannot be combined with --output-format json")
+	}
+
+	if err := certificateIdentity().Validate(); err != nil {
+		return err
+	}
+	if err := service.ValidatePublisherPatterns(allowedPublishers); err != nil {
+		return err
+	}
+	if minAge < 0 {
+		return fmt.Errorf("--min-age must not be negative")
+	}
+
+	if bundlePath != "" || artifactDigest != "" || localArtifact != "" {
+		return runVerifyBundle(cmd)
+	}
+	if verifyDir != "" {
+		return runVerifyDir(cmd, verifyDir, tmpl)
+	}
+
+	// Resolve the package from the spec or the coordinate flags
+	spec, pkg, err := resolveVerifyTarget()
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
+	}
+	if onlyChangedProvenance && spec == nil {
+		return fmt.Errorf("--only-changed-provenance requires --config")
 	}
 
-	// Create provenance service
-	provenanceService, err := createProvenanceService()
+	// Create provenance service, reporting progress through a version history
+	var serviceOpts []service.Option
+	progress := newProgressReporter(cmd.ErrOrStderr())
+	if allVersions {
+		serviceOpts = append(serviceOpts, service.WithProgress(progress.report))
+	}
+	provenanceService, err := createProvenanceService(cmd.Context(), serviceOpts...)
 	if err != nil {
-		return fmt.Errorf("failed to create provenance service: %w", err)
+		cmd.SilenceUsage = true
+		return &exitError{code: exitVerificationError, err: fmt.Errorf("failed to create provenance service: %w", err)}
 	}
 
-	// Create package identifier
-	pkg := domain.PackageIdentifier{
-		Protocol: domain.PackageProtocol(spec.Metadata.Protocol),
-		Name:     spec.Spec.Package,
-		Version:  spec.Spec.Version,
+	if allVersions {
+		return runVerifyAllVersions(cmd, provenanceService, pkg, progress, tmpl)
 	}
 
 	// Verify provenance
-	ctx := context.Background()
-	result, err := provenanceService.VerifyProvenance(ctx, pkg)
+	result, err := provenanceService.VerifyProvenance(cmd.Context(), pkg)
 	if err != nil {
-		return fmt.Errorf("provenance verification failed: %w", err)
-	}
-
-	// Display results
-	printProvenanceResult(cmd, result)
-
-	// If spec has expected provenance info, validate against it
-	if spec.Provenance.Attestations != nil && spec.Provenance.Attestations.Available {
-		cmd.Println("\n--- Verification Against Spec ---")
-		if !result.HasAttestations {
-			cmd.Printf("⚠️  MISMATCH: Spec claims attestations are available, but none found in registry\n")
-		} else {
-			cmd.Printf("✓ Attestations found as expected\n")
-
-			// Validate publisher if specified
-			if spec.Provenance.Attestations.Publisher != nil && result.TrustedPublisher != nil {
-				expectedRepo := spec.Provenance.Attestations.Publisher.Repository
-				actualRepo := result.TrustedPublisher.Repository
-				if expectedRepo != "" && expectedRepo != actualRepo {
-					cmd.Printf("⚠️  MISMATCH: Expected publisher repository '%s', got '%s'\n", expectedRepo, actualRepo)
-				} else if expectedRepo != "" {
-					cmd.Printf("✓ Publisher repository matches: %s\n", expectedRepo)
-				}
-			}
+		cmd.SilenceUsage = true
+		return &exitError{code: exitVerificationError, err: fmt.Errorf("provenance verification failed: %w", err)}
+	}
+	if spec != nil {
+		if err := checkSpecPins(spec, result); err != nil {
+			cmd.SilenceUsage = true
+			return &exitError{code: exitVerificationError, err: err}
 		}
 	}
 
-	// Validate repository URI if specified
-	if spec.Provenance.RepositoryURI != "" && result.RepositoryURI != "" {
-		if !strings.Contains(result.RepositoryURI, spec.Provenance.RepositoryURI) {
-			cmd.Printf("\n⚠️  WARNING: Repository mismatch!\n")
-			cmd.Printf("   Expected: %s\n", spec.Provenance.RepositoryURI)
-			cmd.Printf("   Found: %s\n", result.RepositoryURI)
+	// Display results, with warnings last in text output
+	warnings := collectWarnings(spec, result)
+	switch {
+	case verifyOutputFormat == "json":
+		if err := writeProvenanceJSON(cmd, result, warnings); err != nil {
+			return err
+		}
+	case tmpl != nil:
+		if err := writeResultTemplate(cmd.OutOrStdout(), tmpl, result); err != nil {
+			return err
 		}
+	default:
+		printProvenanceResult(cmd, result)
+	}
+	if explain {
+		printExplanation(cmd, result)
 	}
 
-	return nil
+	if spec != nil {
+		printSpecComparison(cmd, spec, result)
+	}
+	if verifyOutputFormat != "json" {
+		printWarnings(cmd, warnings)
+	}
+	if machineFooter {
+		fmt.Fprintln(cmd.OutOrStdout(), machineFooterLine(result, warnings))
+	}
+
+	// The baseline is saved even when the result fails a check below
+	baselineErr := checkBaseline(cmd, result, baselinePath, saveBaselinePath)
+
+	cmd.SilenceUsage = true
+	if onlyChangedProvenance {
+		if spec.Provenance.Attestations == nil {
+			cmd.PrintErrf("%s records no provenance.attestations to compare the result with\n", configFile)
+		}
+		// A failed verification cannot be compared with anything
+		if err := provenanceExitError(result, false); err != nil {
+			return err
+		}
+		if err := specChangeExitError(spec, result); err != nil {
+			return err
+		}
+		return baselineErr
+	}
+	if err := provenanceExitError(result, strict); err != nil {
+		return err
+	}
+	if err := publisherExitError(result, allowedPublishers, strict); err != nil {
+		return err
+	}
+	if err := refExitError(spec, result, strict); err != nil {
+		return err
+	}
+	if err := minAgeExitError(result, minAge, strict, time.Now()); err != nil {
+		return err
+	}
+	return baselineErr
 }
 
-// createProvenanceService creates a provenance service with registered verifiers
-func createProvenanceService() (*service.Service, error) {
-	ctx := context.Background()
-	svc := service.New()
+// resolveVerifyTarget returns the package to verify, taken from the spec
+// named by --config, from the Package URL given with --purl, or from the
+// --package, --version, and --protocol flags. Exactly one of the forms must
+// be used. spec is nil unless the package comes from a spec.
+func resolveVerifyTarget() (*MCPServerSpec, domain.PackageIdentifier, error) {
+	if verifyPURL != "" {
+		pkg, err := vuln.ParsePackageURL(verifyPURL)
+		if err != nil {
+			return nil, domain.PackageIdentifier{}, fmt.Errorf("invalid --purl: %w", err)
+		}
+		return nil, pkg, nil
+	}
 
-	// Register npm verifier with sigstore support
-	npmVerifier, err := npm.NewVerifier(ctx)
+	coordinates := verifyPackage != "" || verifyVersion != "" || verifyProtocol != ""
+	switch {
+	case configFile != "" && coordinates:
+		return nil, domain.PackageIdentifier{}, fmt.Errorf(
+			"--config cannot be combined with --package, --version, or --protocol")
+	case configFile == "" && !coordinates:
+		return nil, domain.PackageIdentifier{}, fmt.Errorf(
+			"either --config, --dir, --purl, or all of --package, --version, and --protocol must be given")
+	case coordinates:
+		if allVersions && (verifyPackage == "" || verifyProtocol == "") {
+			return nil, domain.PackageIdentifier{}, fmt.Errorf("--all-versions requires --package and --protocol")
+		}
+		if !allVersions && (verifyPackage == "" || verifyVersion == "" || verifyProtocol == "") {
+			return nil, domain.PackageIdentifier{}, fmt.Errorf(
+				"--package, --version, and --protocol must all be given together")
+		}
+		if !slices.Contains(verifiableProtocols(), verifyProtocol) {
+			return nil, domain.PackageIdentifier{}, fmt.Errorf(
+				"invalid protocol %q, must be one of: %s", verifyProtocol, strings.Join(verifiableProtocols(), ", "))
+		}
+		return nil, domain.PackageIdentifier{
+			Protocol: domain.PackageProtocol(verifyProtocol),
+			Name:     verifyPackage,
+			Version:  verifyVersion,
+		}, nil
+	}
+
+	spec, err := loadMCPServerSpec(configFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create npm verifier: %w", err)
+		return nil, domain.PackageIdentifier{}, fmt.Errorf("failed to load configuration: %w", err)
 	}
-	if err := svc.RegisterVerifier(domain.ProtocolNPM, npmVerifier); err != nil {
-		return nil, fmt.Errorf("failed to register npm verifier: %w", err)
+	return spec, specPackage(spec), nil
+}
+
+// verifiableProtocols returns the protocols verify-provenance accepts: the
+// built-in ones and any added by verifier plugins.
+func verifiableProtocols() []string {
+	discoverPluginVerifiers()
+	var protocols []string
+	for _, protocol := range service.RegisteredProtocols() {
+		protocols = append(protocols, string(protocol))
 	}
+	return protocols
+}
+
+// expectedDigestAlgorithms is the digest algorithm spec.expected_digest must
+// use for each protocol: the one its artifacts are signed over.
+var expectedDigestAlgorithms = map[domain.PackageProtocol]string{
+	domain.ProtocolNPM:  "sha512",
+	domain.ProtocolPyPI: "sha256",
+	domain.ProtocolOCI:  "sha256",
+}
 
-	// Register PyPI verifier with sigstore support
-	pypiVerifier, err := pypi.NewVerifier(ctx)
+// validateExpectedDigest checks that digest is an algorithm:hex digest of the
+// kind verification computes for protocol.
+func validateExpectedDigest(protocol domain.PackageProtocol, digest string) error {
+	want, ok := expectedDigestAlgorithms[protocol]
+	if !ok {
+		if protocol == domain.ProtocolGo {
+			return fmt.Errorf("not supported for %s packages; pin the module with provenance.go_sum", protocol)
+		}
+		return fmt.Errorf("not supported for %s packages", protocol)
+	}
+	algorithm, _, err := parseArtifactDigest(digest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create pypi verifier: %w", err)
+		return err
 	}
-	if err := svc.RegisterVerifier(domain.ProtocolPyPI, pypiVerifier); err != nil {
-		return nil, fmt.Errorf("failed to register pypi verifier: %w", err)
+	if algorithm != want {
+		return fmt.Errorf("%s packages are pinned by their %s digest, not %s", protocol, want, algorithm)
 	}
+	return nil
+}
+
+// checkSpecPins checks a result against the pins in its spec,
+// spec.expected_digest and provenance.go_sum, failing it on a mismatch.
+func checkSpecPins(spec *MCPServerSpec, result *domain.ProvenanceResult) error {
+	if spec.Spec.ExpectedDigest != "" {
+		service.CheckExpectedDigest(result, spec.Spec.ExpectedDigest)
+	}
+	return checkSpecGoSum(spec, result)
+}
 
-	return svc, nil
+// checkSpecGoSum compares the module hash of a Go result with the spec's
+// provenance.go_sum, failing the result on a mismatch.
+func checkSpecGoSum(spec *MCPServerSpec, result *domain.ProvenanceResult) error {
+	if spec.Provenance.GoSum == "" {
+		return nil
+	}
+	if err := gomod.CheckGoSum(result, spec.Provenance.GoSum); err != nil {
+		return fmt.Errorf("provenance.go_sum: %w", err)
+	}
+	return nil
+}
+
+// addVerifierFlags registers the flags that configure the provenance
+// verifiers and the spec loader on cmd, for the commands that verify packages.
+func addVerifierFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&tufMirror, "tuf-mirror", "",
+		"Sigstore TUF repository to fetch the trusted root from (defaults to the public good instance)")
+	cmd.Flags().StringVar(&tufRoot, "tuf-root", "",
+		"Path to the root.json of the --tuf-mirror repository, for a private Sigstore deployment")
+	cmd.Flags().DurationVar(&tufTimeout, "tuf-timeout", sigstore.DefaultTUFTimeout,
+		"Maximum time to fetch the Sigstore trusted root before using the locally cached one (0 disables the limit)")
+	cmd.Flags().BoolVar(&expandEnv, "expand-env", false,
+		"Expand $VAR and ${VAR} references to environment variables in spec values")
+	cmd.Flags().BoolVar(&allowEmptyEnv, "allow-empty-env", false,
+		"With --expand-env, expand undefined variables to an empty string instead of failing")
+	cmd.Flags().StringVar(&npmRegistry, "npm-registry", "",
+		"npm registry to verify packages from, e.g. https://npm.pkg.github.com (defaults to the public registry)")
+	cmd.Flags().StringVar(&npmToken, "npm-token", "",
+		"Bearer token for npm registries matching --npm-token-host (defaults to $"+npmTokenEnvVar+")")
+	cmd.Flags().StringVar(&npmTokenHost, "npm-token-host", defaultNpmTokenHost,
+		"Host pattern of the npm registries --npm-token is sent to")
+	cmd.Flags().StringVar(&pypiArtifactKind, "artifact-kind", string(pypi.ArtifactKindAny),
+		"uvx only: distribution files to verify: wheel, sdist, or any, e.g. wheel when only the wheels carry provenance")
+	cmd.Flags().BoolVar(&allowVCS, "allow-vcs", false,
+		"go only: fetch modules matching GONOPROXY or GOPRIVATE from their git repositories and verify the tag's signature")
+}
+
+// createProvenanceService creates a provenance service with a verifier for
+// every protocol that has a registered factory, including the verifier
+// plugins on PATH. Under --strict a protocol
+// without a verifier is an error instead of an UNKNOWN result.
+func createProvenanceService(ctx context.Context, opts ...service.Option) (*service.Service, error) {
+	discoverPluginVerifiers()
+	return service.NewFromRegistry(ctx, append([]service.Option{service.WithStrictProtocols(strict)}, opts...)...)
 }
 
 // printProvenanceResult prints the provenance verification result
 func printProvenanceResult(cmd *cobra.Command, result *domain.ProvenanceResult) {
 	cmd.Printf("Package: %s@%s (protocol: %s)\n", result.PackageID.Name, result.PackageID.Version, result.PackageID.Protocol)
+	if tag, ok := result.Details["dist_tag"].(string); ok {
+		cmd.Printf("Resolved dist-tag %s to version %v\n", tag, result.Details["resolved_version"])
+	}
 	cmd.Printf("Status: %s\n", result.Status)
 
 	printStatusDetails(cmd, result)
+	printIntegrityInfo(cmd, result)
 	printRepositoryInfo(cmd, result)
+	printPublishedInfo(cmd, result)
 	printVerboseDetails(cmd, result)
 }
 
@@ -530,7 +1102,7 @@ func printStatusDetails(cmd *cobra.Command, result *domain.ProvenanceResult) {
 	case domain.ProvenanceStatusAttestations:
 		printAttestationsStatus(cmd, result)
 	case domain.ProvenanceStatusSignatures:
-		cmd.Printf("✓ Package has signatures (older provenance format)\n")
+		printSignaturesStatus(cmd, result)
 	case domain.ProvenanceStatusTrustedPublisher:
 		printTrustedPublisherStatus(cmd, result)
 	case domain.ProvenanceStatusNone:
@@ -546,16 +1118,32 @@ func printStatusDetails(cmd *cobra.Command, result *domain.ProvenanceResult) {
 func printVerifiedStatus(cmd *cobra.Command, result *domain.ProvenanceResult) {
 	cmd.Printf("✓✓ Package provenance VERIFIED cryptographically!\n")
 	if result.AttestationCount > 0 {
-		cmd.Printf("  Attestations: %d verified\n", result.AttestationCount)
+		cmd.Printf("  Attestations: %d of %d verified\n", result.VerifiedAttestationCount, result.AttestationCount)
 	}
 	printPublisherInfo(cmd, result.TrustedPublisher)
 }
 
+func printSignaturesStatus(cmd *cobra.Command, result *domain.ProvenanceResult) {
+	checksumDB, ok := result.Details["checksum_db"].(string)
+	if !ok {
+		cmd.Printf("✓ Package has signatures (older provenance format)\n")
+		return
+	}
+	cmd.Printf("✓ Module hash matches the Go checksum database (%s)\n", checksumDB)
+	cmd.Printf("  Module: %v@%v %v\n", result.Details["module"], result.Details["module_version"], result.Details["module_hash"])
+	if match, ok := result.Details["go_sum_match"].(bool); ok && match {
+		cmd.Printf("  Matches the spec's go.sum line\n")
+	}
+}
+
 func printAttestationsStatus(cmd *cobra.Command, result *domain.ProvenanceResult) {
 	cmd.Printf("✓ Package has %d attestation(s)\n", result.AttestationCount)
 	if result.TrustedPublisher != nil {
 		cmd.Printf("  Publisher: %s (%s)\n", result.TrustedPublisher.Kind, result.TrustedPublisher.Repository)
 	}
+	if note, ok := result.Details["verification"].(string); ok {
+		cmd.Printf("  Verification %s\n", note)
+	}
 }
 
 func printTrustedPublisherStatus(cmd *cobra.Command, result *domain.ProvenanceResult) {
@@ -575,17 +1163,35 @@ func printPublisherInfo(cmd *cobra.Command, publisher *domain.TrustedPublisher)
 	}
 }
 
+// printIntegrityInfo reports an npm tarball that was hashed and matched the
+// registry's dist.integrity, and an artifact that matched spec.expected_digest;
+// a mismatch of either is already reported as an error.
+func printIntegrityInfo(cmd *cobra.Command, result *domain.ProvenanceResult) {
+	if match, ok := result.Details["integrity_match"].(bool); ok && match {
+		cmd.Printf("✓ Tarball matches the registry integrity\n")
+	}
+	if match, ok := result.Details["digest_match"].(bool); ok && match {
+		cmd.Printf("✓ Artifact digest matches the spec's expected_digest\n")
+	}
+}
+
 func printRepositoryInfo(cmd *cobra.Command, result *domain.ProvenanceResult) {
 	if result.RepositoryURI != "" {
 		cmd.Printf("Repository: %s\n", result.RepositoryURI)
 	}
 }
 
+func printPublishedInfo(cmd *cobra.Command, result *domain.ProvenanceResult) {
+	if publishedAt, ok := domain.PublishedAt(result.Details); ok {
+		cmd.Printf("Published: %s (%v days ago)\n", publishedAt.Format(time.RFC3339), result.Details[domain.DetailAgeDays])
+	}
+}
+
 func printVerboseDetails(cmd *cobra.Command, result *domain.ProvenanceResult) {
 	if verbose && len(result.Details) > 0 {
 		cmd.Println("\nDetails:")
-		for key, value := range result.Details {
-			cmd.Printf("  %s: %v\n", key, value)
+		for _, key := range slices.Sorted(maps.Keys(result.Details)) {
+			cmd.Printf("  %s: %v\n", key, result.Details[key])
 		}
 	}
 }
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveRegistry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		flag    string
+		want    string
+		wantErr bool
+	}{
+		{"ghcr.io/myorg/mcp", "ghcr.io/myorg/mcp", false},
+		{"ghcr.io/myorg/mcp/", "ghcr.io/myorg/mcp", false},
+		{"localhost:5000", "localhost:5000", false},
+		{"registry.example.com:8443/team/images", "registry.example.com:8443/team/images", false},
+		{"ghcr.io/MyOrg", "", true},
+		{"ghcr.io//mcp", "", true},
+		{"https://ghcr.io/myorg", "", true},
+		{"ghcr.io/my org", "", true},
+		{"ghcr.io/myorg:latest", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := resolveRegistry(tt.flag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveRegistry(%q) err = %v, wantErr %v", tt.flag, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveRegistry(%q) = %q, want %q", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlongsideDockerfilePath(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "npx", "context7", "spec.yaml")
+	writeSpec(t, dir, "npx/context7/spec.yaml", "metadata: {}\n")
+	want := filepath.Join(dir, "npx", "context7", "Dockerfile")
+
+	got, err := alongsideDockerfilePath(configPath, false)
+	if err != nil || got != want {
+		t.Fatalf("alongsideDockerfilePath() = %q, %v, want %q", got, err, want)
+	}
+
+	if err := os.WriteFile(want, []byte("FROM scratch\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alongsideDockerfilePath(configPath, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("alongsideDockerfilePath() over an existing Dockerfile = %v, want an error suggesting --force", err)
+	}
+	if got, err := alongsideDockerfilePath(configPath, true); err != nil || got != want {
+		t.Errorf("alongsideDockerfilePath(force) = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestReadMCPServerSpecCACert(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeSpec(t, dir, "npx/context7/spec.yaml", `metadata:
+  name: context7
+  protocol: npx
+spec:
+  package: "@upstash/context7-mcp"
+  version: "1.0.14"
+  ca_cert: certs/proxy.pem
+`)
+
+	// The certificate is looked up next to the spec, not in the working directory
+	_, err := readMCPServerSpec(filepath.Join(dir, "npx", "context7", "spec.yaml"))
+	want := filepath.Join(dir, "npx", "context7", "certs", "proxy.pem") + " does not exist"
+	if err == nil || !strings.Contains(err.Error(), "spec.ca_cert") || !strings.Contains(err.Error(), want) {
+		t.Errorf("readMCPServerSpec() error = %v, want spec.ca_cert: ... %s", err, want)
+	}
+}
+
+func TestReadMCPServerSpecGoSum(t *testing.T) {
+	t.Parallel()
+
+	const goSum = "github.com/example/tool v1.2.3 h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	tests := []struct {
+		name     string
+		protocol string
+		goSum    string
+		wantErr  string
+	}{
+		{"go module line", "go", goSum, ""},
+		{"go.mod line", "go", "github.com/example/tool v1.2.3/go.mod h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+			"hashes only go.mod"},
+		{"not a Go package", "npx", goSum, "only valid for go packages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeSpec(t, dir, "spec.yaml", fmt.Sprintf(`metadata:
+  name: tool
+  protocol: %s
+spec:
+  package: github.com/example/tool
+  version: v1.2.3
+provenance:
+  go_sum: %q
+`, tt.protocol, tt.goSum))
+
+			_, err := readMCPServerSpec(filepath.Join(dir, "spec.yaml"))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("readMCPServerSpec() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "provenance.go_sum") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("readMCPServerSpec() error = %v, want provenance.go_sum: ... %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadMCPServerSpecExpectedDigest(t *testing.T) {
+	t.Parallel()
+
+	sha256Digest := "sha256:" + strings.Repeat("ab", 32)
+	sha512Digest := "sha512:" + strings.Repeat("cd", 64)
+	tests := []struct {
+		name     string
+		protocol string
+		digest   string
+		wantErr  string
+	}{
+		{"npm tarball", "npx", sha512Digest, ""},
+		{"pypi file", "uvx", sha256Digest, ""},
+		{"oci image", "oci", sha256Digest, ""},
+		{"wrong algorithm", "npx", sha256Digest, "npx packages are pinned by their sha512 digest"},
+		{"truncated", "uvx", "sha256:abcd", "want 32 hex-encoded bytes"},
+		{"go module", "go", sha256Digest, "pin the module with provenance.go_sum"},
+		{"not a digest", "uvx", "latest", "spec.expected_digest: Does not match pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeSpec(t, dir, "spec.yaml", fmt.Sprintf(`metadata:
+  name: server
+  protocol: %s
+spec:
+  package: server
+  version: "1.0.0"
+  expected_digest: %q
+`, tt.protocol, tt.digest))
+
+			_, err := readMCPServerSpec(filepath.Join(dir, "spec.yaml"))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("readMCPServerSpec() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("readMCPServerSpec() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
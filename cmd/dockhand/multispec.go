@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/dockyard/pkg/build"
+)
+
+// loadMCPServerSpecs reads every spec in a multi-document YAML file, with
+// documents separated by ---. Empty documents are skipped; an invalid one
+// fails the load, naming its 1-based position in the file.
+func loadMCPServerSpecs(configPath string) ([]*MCPServerSpec, error) {
+	cleanPath := filepath.Clean(configPath)
+	if ext := filepath.Ext(cleanPath); ext != ".yaml" && ext != ".yml" {
+		return nil, fmt.Errorf("invalid config path: %s is not a .yaml or .yml file", configPath)
+	}
+	data, err := readSpecFile(cleanPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var specs []*MCPServerSpec
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for index := 1; ; index++ {
+		var root yaml.Node
+		if err := decoder.Decode(&root); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("document %d: failed to parse YAML: %w", index, err)
+		}
+		if isEmptyDocument(&root) {
+			continue
+		}
+
+		var spec MCPServerSpec
+		if err := decodeSpecNode(&root, &spec); err != nil {
+			return nil, fmt.Errorf("document %d: %w", index, err)
+		}
+		if err := validateMCPServerSpec(&spec, cleanPath); err != nil {
+			return nil, fmt.Errorf("document %d: %w", index, err)
+		}
+		specs = append(specs, &spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("%s contains no specs", configPath)
+	}
+	return specs, nil
+}
+
+// isEmptyDocument reports whether a document holds nothing, as a stray ---
+// at the start or end of a file does.
+func isEmptyDocument(root *yaml.Node) bool {
+	return root.Kind == yaml.DocumentNode && len(root.Content) == 1 &&
+		root.Content[0].Kind == yaml.ScalarNode && root.Content[0].Tag == "!!null"
+}
+
+// multiDockerfileNames returns the Dockerfile name of each spec, after its
+// image name, rejecting specs that would overwrite each other's output.
+func multiDockerfileNames(specs []*MCPServerSpec) ([]string, error) {
+	names := make([]string, len(specs))
+	seen := make(map[string]int, len(specs))
+	for i, spec := range specs {
+		names[i] = build.ImageName(spec) + ".Dockerfile"
+		if first, ok := seen[names[i]]; ok {
+			return nil, fmt.Errorf("documents %d and %d would both be written to %s", first+1, i+1, names[i])
+		}
+		seen[names[i]] = i
+	}
+	return names, nil
+}
+
+// runBuildMulti generates a Dockerfile for every spec in --config, in order,
+// writing each to {name}.Dockerfile in the --output directory (by default the
+// current one). The first spec that fails stops the build.
+func runBuildMulti(cmd *cobra.Command, imageRegistry string) error {
+	specs, err := loadMCPServerSpecs(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	names, err := multiDockerfileNames(specs)
+	if err != nil {
+		return err
+	}
+
+	outputDir := output
+	if outputDir == "" {
+		outputDir = "."
+	}
+	if err := os.MkdirAll(outputDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	for i, spec := range specs {
+		cmd.Printf("Building %s (document %d of %d)\n", spec.Metadata.Name, i+1, len(specs))
+		if err := buildMultiSpec(cmd, spec, imageRegistry, filepath.Join(outputDir, names[i])); err != nil {
+			return fmt.Errorf("document %d (%s): %w", i+1, spec.Metadata.Name, err)
+		}
+	}
+	cmd.Printf("Generated %d Dockerfiles in %s\n", len(specs), outputDir)
+	return nil
+}
+
+// buildMultiSpec checks one spec's provenance, and with --block-vulnerable or
+// --warn-vulnerable its known vulnerabilities, as a single build would and
+// writes its Dockerfile to outputPath.
+func buildMultiSpec(cmd *cobra.Command, spec *MCPServerSpec, imageRegistry, outputPath string) error {
+	if checkProvenance || warnOnNoProvenance {
+		if _, err := checkBuildProvenance(cmd, spec); err != nil {
+			return err
+		}
+	}
+	if err := runVulnerabilityCheck(cmd, spec); err != nil {
+		return err
+	}
+
+	dockerfile, err := build.GenerateDockerfile(cmd.Context(), spec,
+		build.WithRegistry(imageRegistry), build.WithCACertPath(caCertPath), build.WithArgs(serverArgs...))
+	if err != nil {
+		return fmt.Errorf("failed to generate Dockerfile: %w", err)
+	}
+	dockerfile = applyPlatforms(dockerfile, platforms)
+
+	if err := writeFileAtomic(outputPath, []byte(dockerfile), 0600); err != nil {
+		return fmt.Errorf("failed to write Dockerfile to %s: %w", outputPath, err)
+	}
+	cmd.Printf("Dockerfile written to: %s\n", outputPath)
+	return nil
+}
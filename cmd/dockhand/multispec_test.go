@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMCPServerSpecs(t *testing.T) {
+	t.Parallel()
+
+	const first = `metadata:
+  name: context7
+  protocol: npx
+spec:
+  package: "@upstash/context7-mcp"
+  version: "1.0.14"
+`
+	const second = `metadata:
+  name: fetch
+  protocol: uvx
+spec:
+  package: mcp-server-fetch
+  version: "2025.4.7"
+`
+	const invalid = `metadata:
+  name: broken
+  protocol: uvx
+spec: {}
+`
+
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		wantNames []string
+		wantErr   string
+	}{
+		{"two documents", "servers.yaml", first + "---\n" + second, []string{"context7", "fetch"}, ""},
+		{"stray separators", "servers.yml", "---\n" + first + "---\n" + second + "---\n", []string{"context7", "fetch"}, ""},
+		{"single document", "servers.yaml", first, []string{"context7"}, ""},
+		{"invalid document", "servers.yaml", first + "---\n" + second + "---\n" + invalid, nil,
+			"document 3: spec.package is required"},
+		{"malformed document", "servers.yaml", first + "---\nmetadata: [\n", nil, "document 2: failed to parse YAML"},
+		{"no documents", "servers.yaml", "---\n", nil, "contains no specs"},
+		{"not yaml", "servers.json", first, nil, "not a .yaml or .yml file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			specs, err := loadMCPServerSpecs(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadMCPServerSpecs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadMCPServerSpecs() error: %v", err)
+			}
+			var names []string
+			for _, spec := range specs {
+				names = append(names, spec.Metadata.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("loadMCPServerSpecs() names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestMultiDockerfileNames(t *testing.T) {
+	t.Parallel()
+
+	spec := func(name, protocol string) *MCPServerSpec {
+		return &MCPServerSpec{Metadata: MCPServerMetadata{Name: name, Protocol: protocol}}
+	}
+
+	names, err := multiDockerfileNames([]*MCPServerSpec{spec("context7", "npx"), spec("fetch", "uvx")})
+	if err != nil {
+		t.Fatalf("multiDockerfileNames() error: %v", err)
+	}
+	if strings.Join(names, ",") != "context7.Dockerfile,fetch.Dockerfile" {
+		t.Errorf("multiDockerfileNames() = %v", names)
+	}
+
+	_, err = multiDockerfileNames([]*MCPServerSpec{spec("fetch", "npx"), spec("context7", "npx"), spec("fetch", "uvx")})
+	if err == nil || !strings.Contains(err.Error(), "documents 1 and 3") {
+		t.Errorf("multiDockerfileNames(duplicate) error = %v, want documents 1 and 3", err)
+	}
+}
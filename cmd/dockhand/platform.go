@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// supportedPlatforms lists the target platforms accepted by --platform. These
+// are the Linux platforms the upstream node, python, and golang base images publish.
+var supportedPlatforms = []string{
+	"linux/386",
+	"linux/amd64",
+	"linux/arm/v6",
+	"linux/arm/v7",
+	"linux/arm64",
+	"linux/ppc64le",
+	"linux/riscv64",
+	"linux/s390x",
+}
+
+// platformArgs are the automatic buildx arguments re-declared in every stage
+// so that RUN steps can branch on the target platform.
+var platformArgs = []string{"TARGETPLATFORM", "TARGETOS", "TARGETARCH", "TARGETVARIANT"}
+
+// validatePlatforms rejects platform strings buildx would not know how to build.
+func validatePlatforms(platforms []string) error {
+	for _, p := range platforms {
+		if !slices.Contains(supportedPlatforms, p) {
+			return fmt.Errorf("unsupported platform %q, must be one of: %s", p, strings.Join(supportedPlatforms, ", "))
+		}
+	}
+	return nil
+}
+
+// applyPlatforms rewrites a single-architecture Dockerfile for multi-platform
+// builds with `docker buildx build --platform ...`. Each FROM is pinned to
+// $TARGETPLATFORM and followed by the TARGET* build args, and a header records
+// the intended platforms and the matching buildx invocation.
+func applyPlatforms(dockerfile string, platforms []string) string {
+	if len(platforms) == 0 {
+		return dockerfile
+	}
+
+	var b strings.Builder
+	joined := strings.Join(platforms, ",")
+	fmt.Fprintf(&b, "# Multi-platform build for: %s\n", joined)
+	fmt.Fprintf(&b, "# Build with: docker buildx build --platform %s .\n", joined)
+	// ARG before the first FROM makes TARGETPLATFORM usable in FROM lines.
+	b.WriteString("ARG TARGETPLATFORM\n\n")
+
+	for _, line := range strings.SplitAfter(dockerfile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			b.WriteString(line)
+			continue
+		}
+
+		if !strings.HasPrefix(fields[1], "--platform") {
+			line = fields[0] + " --platform=$TARGETPLATFORM " + strings.TrimLeft(line[len(fields[0]):], " \t")
+		}
+		b.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			b.WriteString("\n")
+		}
+		for _, arg := range platformArgs {
+			fmt.Fprintf(&b, "ARG %s\n", arg)
+		}
+	}
+
+	return b.String()
+}
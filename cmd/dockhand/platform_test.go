@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePlatforms(t *testing.T) {
+	t.Parallel()
+
+	if err := validatePlatforms([]string{"linux/amd64", "linux/arm64"}); err != nil {
+		t.Errorf("validatePlatforms(amd64, arm64) = %v, want nil", err)
+	}
+	if err := validatePlatforms(nil); err != nil {
+		t.Errorf("validatePlatforms(nil) = %v, want nil", err)
+	}
+	for _, bad := range []string{"windows/amd64", "amd64", "linux/amd64 ", "linux/x86_64"} {
+		if err := validatePlatforms([]string{"linux/amd64", bad}); err == nil {
+			t.Errorf("validatePlatforms(%q) = nil, want error", bad)
+		}
+	}
+}
+
+func TestApplyPlatforms(t *testing.T) {
+	t.Parallel()
+
+	dockerfile := "FROM node:24-alpine AS builder\nRUN npm install\n\nFROM --platform=linux/amd64 node:24-alpine\nUSER app\n"
+
+	if got := applyPlatforms(dockerfile, nil); got != dockerfile {
+		t.Errorf("applyPlatforms with no platforms changed the Dockerfile:\n%s", got)
+	}
+
+	got := applyPlatforms(dockerfile, []string{"linux/amd64", "linux/arm64"})
+
+	for _, want := range []string{
+		"# Build with: docker buildx build --platform linux/amd64,linux/arm64 .\n",
+		"FROM --platform=$TARGETPLATFORM node:24-alpine AS builder\nARG TARGETPLATFORM\nARG TARGETOS\nARG TARGETARCH\n",
+		// An explicit --platform is left untouched.
+		"FROM --platform=linux/amd64 node:24-alpine\nARG TARGETPLATFORM\n",
+		"RUN npm install\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("applyPlatforms output missing %q:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "ARG TARGETARCH\n"); n != 2 {
+		t.Errorf("got %d ARG TARGETARCH lines, want one per stage (2)", n)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/service"
+)
+
+// provenancePolicy is the catalog's provenance policy, read by validate
+// --policy. Each spec is held to the requirements of the first rule matching
+// its {protocol}/{name} directory; specs no rule matches are not verified.
+type provenancePolicy struct {
+	Rules []policyRule `yaml:"rules"`
+}
+
+// policyRule sets the provenance requirements of the specs it matches. The
+// requirement fields mirror domain.ProvenanceRequirements.
+type policyRule struct {
+	// Match is a path.Match glob over a spec's {protocol}/{name} directory, e.g. "npx/*"
+	Match                   string   `yaml:"match"`
+	RequireAttestations     bool     `yaml:"require_attestations"`
+	RequireTrustedPublisher bool     `yaml:"require_trusted_publisher"`
+	RequireSignatures       bool     `yaml:"require_signatures"`
+	AllowNone               bool     `yaml:"allow_none"`
+	AllowedPublishers       []string `yaml:"allowed_publishers"`
+}
+
+// requirements returns the provenance requirements the rule sets.
+func (r policyRule) requirements() domain.ProvenanceRequirements {
+	return domain.ProvenanceRequirements{
+		RequireAttestations:     r.RequireAttestations,
+		RequireTrustedPublisher: r.RequireTrustedPublisher,
+		RequireSignatures:       r.RequireSignatures,
+		AllowNone:               r.AllowNone,
+		AllowedPublishers:       r.AllowedPublishers,
+	}
+}
+
+// loadProvenancePolicy reads the policy file at policyPath. Unknown keys,
+// invalid globs, and a policy without rules are rejected so typos do not go
+// unnoticed.
+func loadProvenancePolicy(policyPath string) (*provenancePolicy, error) {
+	data, err := os.ReadFile(policyPath) //#nosec G304 -- path is chosen by the user running the CLI
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy provenancePolicy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", policyPath, err)
+	}
+	if len(policy.Rules) == 0 {
+		return nil, fmt.Errorf("invalid policy file %s: no rules", policyPath)
+	}
+	for i, rule := range policy.Rules {
+		if _, err := path.Match(rule.Match, ""); err != nil || rule.Match == "" {
+			return nil, fmt.Errorf("invalid policy file %s: rule %d: invalid match glob %q", policyPath, i+1, rule.Match)
+		}
+		if err := service.ValidatePublisherPatterns(rule.AllowedPublishers); err != nil {
+			return nil, fmt.Errorf("invalid policy file %s: rule %d: %w", policyPath, i+1, err)
+		}
+	}
+	return &policy, nil
+}
+
+// ruleFor returns the first rule matching the spec at rel, a slash-separated
+// {protocol}/{name}/spec.yaml path.
+func (p *provenancePolicy) ruleFor(rel string) (policyRule, bool) {
+	for _, rule := range p.Rules {
+		if ok, _ := path.Match(rule.Match, path.Dir(rel)); ok {
+			return rule, true
+		}
+	}
+	return policyRule{}, false
+}
+
+// batchVerifier verifies packages together, as service.Service.BatchVerify does.
+type batchVerifier func(ctx context.Context, packages []domain.PackageIdentifier) ([]*domain.ProvenanceResult, error)
+
+// checkProvenancePolicy verifies the package of every spec in rels, relative
+// to dir, that a rule of policy matches, in one batch, and reports each spec
+// whose provenance does not meet its rule's requirements as an error. Specs
+// that fail to load are left to the other validate checks.
+func checkProvenancePolicy(
+	ctx context.Context,
+	verify batchVerifier,
+	policy *provenancePolicy,
+	dir string,
+	rels []string,
+) []specIssue {
+	var (
+		checked  []string
+		rules    []policyRule
+		packages []domain.PackageIdentifier
+	)
+	for _, rel := range rels {
+		rule, ok := policy.ruleFor(rel)
+		if !ok {
+			continue
+		}
+		spec, err := readMCPServerSpec(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		checked = append(checked, rel)
+		rules = append(rules, rule)
+		packages = append(packages, specPackage(spec))
+	}
+	if len(packages) == 0 {
+		return nil
+	}
+
+	// Per-package errors are reported in the results themselves
+	results, _ := verify(ctx, packages)
+
+	var issues []specIssue
+	for i, rel := range checked {
+		var err error
+		if i >= len(results) || results[i] == nil {
+			err = fmt.Errorf("%w: verification did not complete", service.ErrRequirementsNotMet)
+		} else {
+			err = service.ValidateRequirements(results[i], rules[i].requirements())
+		}
+		if err != nil {
+			issues = append(issues, specIssue{
+				Path:     rel,
+				Severity: severityError,
+				Message:  fmt.Sprintf("provenance policy %q: %v", rules[i].Match, err),
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestLoadProvenancePolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		policy    string
+		wantRules int
+		wantErr   string
+	}{
+		{
+			name: "rules",
+			policy: `rules:
+  - match: "npx/*"
+    require_attestations: true
+    allowed_publishers: ["upstash/*"]
+  - match: "go/*"
+    allow_none: true
+`,
+			wantRules: 2,
+		},
+		{name: "no rules", policy: "rules: []\n", wantErr: "no rules"},
+		{name: "unknown key", policy: "rules:\n  - match: \"npx/*\"\n    require_attestation: true\n", wantErr: "not found"},
+		{name: "missing glob", policy: "rules:\n  - allow_none: true\n", wantErr: "rule 1: invalid match glob"},
+		{name: "invalid glob", policy: "rules:\n  - match: \"npx/[\"\n", wantErr: "rule 1: invalid match glob"},
+		{
+			name:    "invalid publisher",
+			policy:  "rules:\n  - match: \"*/*\"\n  - match: \"npx/*\"\n    allowed_publishers: [\"[\"]\n",
+			wantErr: "rule 2: invalid publisher pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "provenance-policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.policy), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			policy, err := loadProvenancePolicy(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadProvenancePolicy() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadProvenancePolicy() error: %v", err)
+			}
+			if len(policy.Rules) != tt.wantRules {
+				t.Errorf("loadProvenancePolicy() = %d rules, want %d", len(policy.Rules), tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestCheckProvenancePolicy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, s := range []struct{ protocol, name, pkg string }{
+		{"npx", "attested", "attested-mcp"},
+		{"npx", "unattested", "unattested-mcp"},
+		{"go", "server", "example.com/server"},
+		{"uvx", "unmatched", "unmatched-mcp"},
+	} {
+		writeSpec(t, dir, s.protocol+"/"+s.name+"/spec.yaml", "metadata:\n  name: "+s.name+"\n  protocol: "+s.protocol+
+			"\nspec:\n  package: "+s.pkg+"\n  version: \"1.0.0\"\n")
+	}
+	writeSpec(t, dir, "npx/broken/spec.yaml", "metadata: [\n")
+
+	policy := &provenancePolicy{Rules: []policyRule{
+		{Match: "npx/*", RequireAttestations: true},
+		{Match: "go/*", AllowNone: true},
+	}}
+
+	var verified []string
+	verify := func(_ context.Context, packages []domain.PackageIdentifier) ([]*domain.ProvenanceResult, error) {
+		results := make([]*domain.ProvenanceResult, len(packages))
+		for i, pkg := range packages {
+			verified = append(verified, pkg.Name)
+			result := &domain.ProvenanceResult{PackageID: pkg, Status: domain.ProvenanceStatusSignatures, HasSignatures: true}
+			if pkg.Name == "attested-mcp" {
+				result.Status = domain.ProvenanceStatusVerified
+				result.HasAttestations = true
+			}
+			results[i] = result
+		}
+		return results, nil
+	}
+
+	paths, err := findSpecFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := checkProvenancePolicy(context.Background(), verify, policy, dir, paths)
+
+	// Specs that do not load and specs no rule matches are not verified
+	if got := strings.Join(verified, ","); got != "example.com/server,attested-mcp,unattested-mcp" {
+		t.Errorf("verified %s, want the go and matching npx packages in path order", got)
+	}
+	if len(issues) != 1 || issues[0].Path != "npx/unattested/spec.yaml" || issues[0].Severity != severityError ||
+		!strings.Contains(issues[0].Message, `provenance policy "npx/*"`) ||
+		!strings.Contains(issues[0].Message, "attestations required") {
+		t.Errorf("checkProvenancePolicy() = %+v, want one error for npx/unattested", issues)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/pkg/build"
+)
+
+// runBuildPreview prints what `build` would produce for spec without
+// generating or writing a Dockerfile.
+func runBuildPreview(cmd *cobra.Command, spec *MCPServerSpec, imageRegistry string) error {
+	version := spec.Spec.Version
+	if version == "" {
+		version = "(unpinned)"
+	}
+
+	protocolScheme, err := build.ProtocolScheme(spec)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Spec: %s\n", configFile)
+	cmd.Printf("Package: %s\n", spec.Spec.Package)
+	cmd.Printf("Version: %s\n", version)
+	cmd.Printf("Protocol scheme: %s\n", protocolScheme)
+	cmd.Printf("Registry: %s\n", imageRegistry)
+	cmd.Printf("Image tag: %s\n", resolveImageTag(spec, outputTag, imageRegistry))
+	if len(platforms) > 0 {
+		cmd.Printf("Platforms: %v\n", platforms)
+	}
+
+	printPreviewProvenance(cmd, spec)
+
+	cmd.Println("\nPreview only: no Dockerfile was generated.")
+	return nil
+}
+
+// printPreviewProvenance prints a one-line provenance summary for spec.
+// Verification failures are reported rather than returned, since a preview
+// should always complete.
+func printPreviewProvenance(cmd *cobra.Command, spec *MCPServerSpec) {
+	provenanceService, err := createProvenanceService(cmd.Context())
+	if err != nil {
+		cmd.Printf("Provenance: %s (%v)\n", domain.ProvenanceStatusError, err)
+		return
+	}
+
+	pkg := domain.PackageIdentifier{
+		Protocol: domain.PackageProtocol(spec.Metadata.Protocol),
+		Name:     spec.Spec.Package,
+		Version:  spec.Spec.Version,
+	}
+
+	result, err := provenanceService.VerifyProvenance(cmd.Context(), pkg)
+	if result != nil {
+		if pinErr := checkSpecPins(spec, result); pinErr != nil {
+			result.Status, result.ErrorMessage = domain.ProvenanceStatusError, pinErr.Error()
+		}
+	}
+	switch {
+	case result == nil:
+		cmd.Printf("Provenance: %s (%v)\n", domain.ProvenanceStatusError, err)
+		return
+	case result.ErrorMessage != "":
+		cmd.Printf("Provenance: %s (%s)\n", result.Status, result.ErrorMessage)
+	default:
+		cmd.Printf("Provenance: %s\n", result.Status)
+	}
+	if result.TrustedPublisher != nil {
+		cmd.Printf("Publisher: %s (%s)\n", result.TrustedPublisher.Kind, result.TrustedPublisher.Repository)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// progressReporter renders the progress of a batch verification: a counter
+// redrawn in place on an interactive terminal, and a line per finished
+// package otherwise, so that CI logs show what completed.
+type progressReporter struct {
+	w           io.Writer
+	interactive bool
+
+	mu      sync.Mutex
+	pending bool // a counter line awaits its newline
+}
+
+func newProgressReporter(w io.Writer) *progressReporter {
+	return &progressReporter{w: w, interactive: isTerminal(w)}
+}
+
+// report is a service.ProgressFunc.
+func (p *progressReporter) report(done, total int, last *domain.ProvenanceResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.interactive {
+		_, _ = fmt.Fprintf(p.w, "[%d/%d] %s@%s: %s\n", done, total, last.PackageID.Name, last.PackageID.Version, last.Status)
+		return
+	}
+	_, _ = fmt.Fprintf(p.w, "\rVerifying: %d/%d", done, total)
+	p.pending = true
+}
+
+// finish ends the counter line, including when the batch was cut short
+// before every package was reported.
+func (p *progressReporter) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending {
+		_, _ = fmt.Fprintln(p.w)
+		p.pending = false
+	}
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
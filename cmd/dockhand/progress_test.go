@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestProgressReporter(t *testing.T) {
+	t.Parallel()
+
+	result := &domain.ProvenanceResult{
+		PackageID: domain.PackageIdentifier{Name: "pkg", Version: "1.0.0"},
+		Status:    domain.ProvenanceStatusVerified,
+	}
+
+	t.Run("line per result", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		p := newProgressReporter(&buf)
+		p.report(1, 2, result)
+		p.finish()
+		if want := "[1/2] pkg@1.0.0: VERIFIED\n"; buf.String() != want {
+			t.Errorf("output = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("counter", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		p := &progressReporter{w: &buf, interactive: true}
+		p.report(1, 2, result)
+		p.report(2, 2, result)
+		p.finish()
+		p.finish()
+		if want := "\rVerifying: 1/2\rVerifying: 2/2\n"; buf.String() != want {
+			t.Errorf("output = %q, want %q", buf.String(), want)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/pkg/build"
+)
+
+func newRefreshCmd() *cobra.Command {
+	var dir string
+	var since string
+	var changedFiles []string
+	var imageRegistry string
+
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Regenerate Dockerfiles for the specs that changed",
+		Long: `Refresh regenerates the Dockerfile of every MCP server spec that changed and
+writes it next to the spec as {protocol}/{name}/Dockerfile. Unchanged specs
+are skipped, so only the affected part of the catalog is rebuilt.
+
+The changed specs are either taken from git, as the spec.yaml files that
+differ between --since and the working tree, or listed explicitly with
+--changed-file. Paths are relative to --dir; files that are not MCP server
+specs are ignored. Every spec is attempted, and the command exits non-zero if
+any of them failed.`,
+		Example: `  # Regenerate the specs changed since main
+  dockhand refresh --dir . --since origin/main
+
+  # Regenerate an explicit list of specs
+  dockhand refresh --changed-file npx/context7/spec.yaml --changed-file uvx/fetch/spec.yaml`,
+		RunE: withTimeout(func(cmd *cobra.Command, _ []string) error {
+			return runRefresh(cmd, dir, since, changedFiles, imageRegistry)
+		}),
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "Root directory of the spec repository")
+	cmd.Flags().StringVar(&since, "since", "", "Git ref to diff the working tree against")
+	cmd.Flags().StringArrayVar(&changedFiles, "changed-file", nil,
+		"Changed file relative to --dir (repeatable); used instead of --since")
+	cmd.Flags().StringVar(&imageRegistry, "registry", "",
+		"Image repository prefix for the image tags (defaults to $"+registryEnvVar+" or "+defaultRegistry+")")
+	cmd.MarkFlagsMutuallyExclusive("since", "changed-file")
+	cmd.MarkFlagsOneRequired("since", "changed-file")
+
+	return cmd
+}
+
+// runRefresh regenerates the Dockerfiles of the changed specs under dir.
+func runRefresh(cmd *cobra.Command, dir, since string, changedFiles []string, registryFlag string) error {
+	imageRegistry, err := resolveRegistry(registryFlag)
+	if err != nil {
+		return err
+	}
+
+	if since != "" {
+		changedFiles, err = gitChangedFiles(cmd.Context(), dir, since)
+		if err != nil {
+			return err
+		}
+	}
+
+	allSpecs, err := findSpecFiles(dir)
+	if err != nil {
+		return err
+	}
+	changed := changedSpecFiles(changedFiles, allSpecs)
+
+	failed := 0
+	for _, rel := range changed {
+		dockerfilePath, err := refreshSpec(cmd.Context(), dir, rel, imageRegistry)
+		if err != nil {
+			cmd.Printf("✗ %s: %v\n", rel, err)
+			failed++
+			continue
+		}
+		cmd.Printf("✓ %s -> %s\n", rel, dockerfilePath)
+	}
+
+	cmd.Printf("\n%d Dockerfile(s) regenerated, %d failed, %d unchanged spec(s) skipped\n",
+		len(changed)-failed, failed, len(allSpecs)-len(changed))
+
+	if failed > 0 {
+		return fmt.Errorf("failed to regenerate %d of %d changed spec(s)", failed, len(changed))
+	}
+	return nil
+}
+
+// gitChangedFiles returns the paths, relative to dir, of the files that differ
+// between since and the working tree of the git repository at dir. Deleted
+// files are left out since there is nothing to regenerate for them.
+func gitChangedFiles(ctx context.Context, dir, since string) ([]string, error) {
+	// A ref starting with "-" would be parsed by git as an option
+	if strings.HasPrefix(since, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", since)
+	}
+
+	//#nosec G204 -- arguments are passed to git directly, without a shell, and since cannot be an option
+	gitCmd := exec.CommandContext(ctx, "git", "diff", "--name-only", "--relative", "--diff-filter=d", since, "--")
+	gitCmd.Dir = dir
+	out, err := gitCmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("failed to diff against %s: %s", since, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("failed to diff against %s: %w", since, err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// changedSpecFiles returns, sorted and deduplicated, the entries of
+// changedFiles that are one of the MCP server specs in allSpecs.
+func changedSpecFiles(changedFiles, allSpecs []string) []string {
+	var changed []string
+	for _, file := range changedFiles {
+		rel := path.Clean(filepath.ToSlash(file))
+		if slices.Contains(allSpecs, rel) && validateConfigPath(rel) == nil && !strings.HasPrefix(rel, "skills/") {
+			changed = append(changed, rel)
+		}
+	}
+	slices.Sort(changed)
+	return slices.Compact(changed)
+}
+
+// refreshSpec regenerates the Dockerfile of the spec at rel, relative to dir,
+// and returns the path it was written to.
+func refreshSpec(ctx context.Context, dir, rel, imageRegistry string) (string, error) {
+	spec, err := readMCPServerSpec(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", err
+	}
+	if err := validateProtocolDirectory(rel, spec.Metadata.Protocol); err != nil {
+		return "", err
+	}
+
+	dockerfile, err := build.GenerateDockerfile(ctx, spec, build.WithRegistry(imageRegistry))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate Dockerfile: %w", err)
+	}
+
+	dockerfilePath := filepath.Join(dir, filepath.FromSlash(path.Dir(rel)), "Dockerfile")
+	if err := writeFileAtomic(dockerfilePath, []byte(dockerfile), 0600); err != nil {
+		return "", fmt.Errorf("failed to write Dockerfile to %s: %w", dockerfilePath, err)
+	}
+	return dockerfilePath, nil
+}
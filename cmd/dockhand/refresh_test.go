@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChangedSpecFiles(t *testing.T) {
+	t.Parallel()
+
+	allSpecs := []string{"npx/a/spec.yaml", "npx/b/spec.yaml", "uvx/c/spec.yaml"}
+	changed := []string{
+		"uvx/c/spec.yaml",
+		"README.md",
+		"npx/a/spec.yaml",
+		"./npx/a/spec.yaml",
+		"npx/a/Dockerfile",
+		"skills/s/spec.yaml",
+		"npx/removed/spec.yaml",
+	}
+
+	got := changedSpecFiles(changed, allSpecs)
+	want := []string{"npx/a/spec.yaml", "uvx/c/spec.yaml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changedSpecFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestRefreshSpec(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeSpec(t, dir, "npx/context7/spec.yaml", `
+metadata:
+  name: context7
+  protocol: npx
+spec:
+  package: "@upstash/context7-mcp"
+  version: "2.2.4"
+`)
+
+	got, err := refreshSpec(context.Background(), dir, "npx/context7/spec.yaml", defaultRegistry)
+	if err != nil {
+		t.Fatalf("refreshSpec() error: %v", err)
+	}
+	if want := filepath.Join(dir, "npx", "context7", "Dockerfile"); got != want {
+		t.Errorf("refreshSpec() path = %q, want %q", got, want)
+	}
+	data, err := os.ReadFile(got) //#nosec G304 -- test file in a temp dir
+	if err != nil {
+		t.Fatalf("read Dockerfile: %v", err)
+	}
+	if !strings.Contains(string(data), "@upstash/context7-mcp@2.2.4") {
+		t.Errorf("Dockerfile does not install the spec's package:\n%s", data)
+	}
+}
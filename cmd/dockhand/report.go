@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/attest"
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// Destinations of a generated Dockerfile recorded in a build report.
+const (
+	reportOutputStdout = "stdout"
+	reportOutputFile   = "file"
+)
+
+// buildReport is the machine-readable summary written by `build --report`.
+type buildReport struct {
+	Spec             string   `json:"spec"`
+	ImageTag         string   `json:"image_tag"`
+	ProtocolScheme   string   `json:"protocol_scheme"`
+	Platforms        []string `json:"platforms,omitempty"`
+	ProvenanceStatus string   `json:"provenance_status,omitempty"`
+	Output           string   `json:"output"`
+	DockerfilePath   string   `json:"dockerfile_path,omitempty"`
+}
+
+// writeBuildReport writes report as indented JSON to path.
+func writeBuildReport(path string, report *buildReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode build report: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write build report to %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeBuildAttestation writes the in-toto statement of `build --attest` to
+// path. result is the package's provenance verification, if there was one.
+func writeBuildAttestation(
+	cmd *cobra.Command,
+	path string,
+	spec *MCPServerSpec,
+	report *buildReport,
+	dockerfile string,
+	result *domain.ProvenanceResult,
+) error {
+	stmt, err := attest.NewStatement(attest.Input{
+		SpecPath: report.Spec,
+		Package: domain.PackageIdentifier{
+			Protocol: domain.PackageProtocol(spec.Metadata.Protocol),
+			Name:     spec.Spec.Package,
+			Version:  spec.Spec.Version,
+		},
+		ProtocolScheme: report.ProtocolScheme,
+		ImageTag:       report.ImageTag,
+		Dockerfile:     []byte(dockerfile),
+		Provenance:     result,
+		Time:           time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create attestation: %w", err)
+	}
+	data, err := stmt.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write attestation to %s: %w", path, err)
+	}
+	cmd.Printf("Attestation written to: %s\n", path)
+	return nil
+}
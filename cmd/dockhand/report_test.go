@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteBuildReport(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	report := &buildReport{
+		Spec:           "npx/context7/spec.yaml",
+		ImageTag:       "ghcr.io/stacklok/dockyard/npx/context7:2.2.4",
+		ProtocolScheme: "npx://@upstash/context7-mcp@2.2.4",
+		Output:         reportOutputStdout,
+	}
+	if err := writeBuildReport(path, report); err != nil {
+		t.Fatalf("writeBuildReport() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path) //#nosec G304 -- test file in a temp dir
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if got["output"] != reportOutputStdout {
+		t.Errorf("output = %v, want %s", got["output"], reportOutputStdout)
+	}
+	if got["protocol_scheme"] != report.ProtocolScheme {
+		t.Errorf("protocol_scheme = %v, want %s", got["protocol_scheme"], report.ProtocolScheme)
+	}
+	for _, key := range []string{"provenance_status", "dockerfile_path", "platforms"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("report contains %s, want it omitted when unset", key)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// selftestPackage is a public npm package published with Sigstore provenance
+// from GitHub Actions, verified end-to-end to prove the whole chain works.
+var selftestPackage = domain.PackageIdentifier{Name: "sigstore", Version: "3.0.0", Protocol: domain.ProtocolNPM}
+
+// selftestCheck is one readiness check run by `dockhand selftest`.
+type selftestCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// selftestResult is the outcome of one check in `dockhand selftest` output.
+type selftestResult struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+func newSelftestCmd() *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Check that provenance verification works in this environment",
+		Long: `Selftest checks that the Sigstore trusted root can be fetched from TUF, that
+the npm and PyPI registries are reachable, and that a known-good npm package
+verifies end-to-end. Every check is run and reported as passed or failed, and
+the command exits non-zero if any of them failed, so proxy, trust root, or
+network problems surface before a real build.`,
+		Example: `  # Check readiness before a CI run
+  dockhand selftest
+
+  # Machine-readable results
+  dockhand selftest --output-format json`,
+		RunE: withTimeout(func(cmd *cobra.Command, _ []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return fmt.Errorf("invalid output format %q, must be one of: text, json", outputFormat)
+			}
+			return runSelftest(cmd, outputFormat, defaultSelftestChecks())
+		}),
+	}
+
+	cmd.Flags().StringVar(&outputFormat, "output-format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// defaultSelftestChecks returns the checks run by `dockhand selftest`.
+func defaultSelftestChecks() []selftestCheck {
+	client := httpclient.New(httpclient.WithTimeout(httpTimeout))
+	return []selftestCheck{
+		{name: "trusted-root", run: func(ctx context.Context) error {
+			bundleVerifier, err := sharedBundleVerifier(ctx)
+			if err != nil {
+				return err
+			}
+			return bundleVerifier.Load()
+		}},
+		{name: "npm-registry", run: func(ctx context.Context) error {
+			return checkReachable(ctx, client, "https://registry.npmjs.org/")
+		}},
+		{name: "pypi-registry", run: func(ctx context.Context) error {
+			return checkReachable(ctx, client, "https://pypi.org/simple/")
+		}},
+		{name: "npm-verify", run: verifyKnownGoodPackage},
+	}
+}
+
+// runSelftest runs every check, prints its outcome, and fails if any check failed.
+func runSelftest(cmd *cobra.Command, outputFormat string, checks []selftestCheck) error {
+	results := make([]selftestResult, 0, len(checks))
+	failed := 0
+	for _, check := range checks {
+		start := time.Now()
+		err := check.run(cmd.Context())
+		result := selftestResult{Name: check.name, Passed: err == nil, DurationMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+		results = append(results, result)
+
+		if outputFormat == "text" {
+			if err != nil {
+				cmd.Printf("✗ %s: %v\n", check.name, err)
+			} else {
+				cmd.Printf("✓ %s (%dms)\n", check.name, result.DurationMS)
+			}
+		}
+	}
+
+	if outputFormat == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return fmt.Errorf("failed to encode self-test results: %w", err)
+		}
+	}
+
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d self-test check(s) failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checkReachable requires a successful response to a HEAD request for rawURL.
+// Unlike a warm-up, an error status counts as failure, since that is how a
+// blocking proxy usually answers.
+func checkReachable(ctx context.Context, client *httpclient.Client, rawURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s answered with status %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+// verifyKnownGoodPackage verifies selftestPackage and requires it to be VERIFIED.
+func verifyKnownGoodPackage(ctx context.Context) error {
+	provenanceService, err := createProvenanceService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create provenance service: %w", err)
+	}
+	result, err := provenanceService.VerifyProvenance(ctx, selftestPackage)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s@%s: %w", selftestPackage.Name, selftestPackage.Version, err)
+	}
+	if result.Status != domain.ProvenanceStatusVerified {
+		return fmt.Errorf("%s@%s is %s, want %s: %s", selftestPackage.Name, selftestPackage.Version,
+			result.Status, domain.ProvenanceStatusVerified, result.ErrorMessage)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+func TestRunSelftest(t *testing.T) {
+	t.Parallel()
+
+	checks := []selftestCheck{
+		{name: "passing", run: func(context.Context) error { return nil }},
+		{name: "failing", run: func(context.Context) error { return errors.New("registry blocked") }},
+	}
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+
+	err := runSelftest(cmd, "json", checks)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Fatalf("runSelftest() error = %v, want 1 of 2 checks failed", err)
+	}
+
+	var results []selftestResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if len(results) != 2 || !results[0].Passed || results[1].Passed || results[1].Error != "registry blocked" {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestCheckReachable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/blocked" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	client := httpclient.New()
+	if err := checkReachable(context.Background(), client, server.URL+"/"); err != nil {
+		t.Errorf("checkReachable() error: %v", err)
+	}
+	if err := checkReachable(context.Background(), client, server.URL+"/blocked"); err == nil {
+		t.Error("checkReachable() succeeded for a 403 response")
+	}
+}
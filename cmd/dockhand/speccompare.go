@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// specCheck is the outcome of comparing one provenance claim of a spec with
+// the verification result.
+type specCheck struct {
+	ok      bool
+	message string
+}
+
+// compareWithSpec checks the result against the provenance.attestations
+// block of the spec: whether attestations exist as recorded, whether they
+// verified if the spec says so, and whether the publisher is the recorded
+// one. A spec without the block records nothing to compare.
+func compareWithSpec(spec *MCPServerSpec, result *domain.ProvenanceResult) []specCheck {
+	attestations := spec.Provenance.Attestations
+	switch {
+	case attestations == nil:
+		return nil
+	case !attestations.Available && result.HasAttestations:
+		return []specCheck{{message: "Attestations found, but the spec records none"}}
+	case !attestations.Available:
+		return nil
+	case !result.HasAttestations:
+		return []specCheck{{message: "Attestations not found"}}
+	}
+
+	checks := []specCheck{{ok: true, message: "Attestations found as expected"}}
+	if attestations.Verified {
+		if result.Status == domain.ProvenanceStatusVerified {
+			checks = append(checks, specCheck{ok: true, message: "Attestations verified as expected"})
+		} else {
+			checks = append(checks, specCheck{
+				message: fmt.Sprintf("Spec records verified attestations, but the status is %s", result.Status),
+			})
+		}
+	}
+
+	expected, actual := attestations.Publisher, result.TrustedPublisher
+	switch {
+	case expected == nil || expected.Kind == "" && expected.Repository == "":
+	case actual == nil:
+		checks = append(checks, specCheck{message: "Spec records a publisher, but the result names none"})
+	default:
+		if expected.Kind != "" && !strings.EqualFold(expected.Kind, actual.Kind) {
+			checks = append(checks, specCheck{
+				message: fmt.Sprintf("Publisher kind is %s, spec records %s", actual.Kind, expected.Kind),
+			})
+		}
+		if expected.Repository != "" {
+			if strings.EqualFold(expected.Repository, actual.Repository) {
+				checks = append(checks, specCheck{ok: true, message: "Publisher repository matches: " + expected.Repository})
+			} else {
+				checks = append(checks, specCheck{
+					message: fmt.Sprintf("Publisher repository is %s, spec records %s", actual.Repository, expected.Repository),
+				})
+			}
+		}
+		// Workflows are recorded by file name or by path, depending on the registry
+		if expected.Workflow != "" && actual.Workflow != "" && path.Base(expected.Workflow) != path.Base(actual.Workflow) {
+			checks = append(checks, specCheck{
+				message: fmt.Sprintf("Publisher workflow is %s, spec records %s", actual.Workflow, expected.Workflow),
+			})
+		}
+	}
+	return checks
+}
+
+// printSpecComparison prints which of the provenance claims the spec
+// documents the result confirms, and which it contradicts.
+func printSpecComparison(cmd *cobra.Command, spec *MCPServerSpec, result *domain.ProvenanceResult) {
+	checks := compareWithSpec(spec, result)
+	if len(checks) == 0 {
+		return
+	}
+	cmd.Println("\n--- Verification Against Spec ---")
+	for _, check := range checks {
+		mark := "✓"
+		if !check.ok {
+			mark = "✗"
+		}
+		cmd.Printf("%s %s\n", mark, check.message)
+	}
+}
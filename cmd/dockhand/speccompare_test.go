@@ -0,0 +1,111 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestCompareWithSpec(t *testing.T) {
+	t.Parallel()
+
+	publisher := &PublisherInfo{Kind: "GitHub", Repository: "upstash/context7", Workflow: "release.yml"}
+	verified := &domain.ProvenanceResult{
+		Status:          domain.ProvenanceStatusVerified,
+		HasAttestations: true,
+		TrustedPublisher: &domain.TrustedPublisher{
+			Kind: "GitHub", Repository: "Upstash/context7", Workflow: ".github/workflows/release.yml",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		attestations *AttestationInfo
+		result       *domain.ProvenanceResult
+		wantOK       []string
+		wantFailed   []string
+	}{
+		{
+			name:   "nothing recorded",
+			result: verified,
+		},
+		{
+			name:         "recorded and confirmed",
+			attestations: &AttestationInfo{Available: true, Verified: true, Publisher: publisher},
+			result:       verified,
+			wantOK: []string{
+				"Attestations found as expected", "Attestations verified as expected",
+				"Publisher repository matches: upstash/context7",
+			},
+		},
+		{
+			name:         "attestations gone",
+			attestations: &AttestationInfo{Available: true, Verified: true, Publisher: publisher},
+			result:       &domain.ProvenanceResult{Status: domain.ProvenanceStatusNone},
+			wantFailed:   []string{"Attestations not found"},
+		},
+		{
+			name:         "recorded as absent",
+			attestations: &AttestationInfo{},
+			result:       &domain.ProvenanceResult{Status: domain.ProvenanceStatusNone},
+		},
+		{
+			name:         "new attestations",
+			attestations: &AttestationInfo{},
+			result:       verified,
+			wantFailed:   []string{"Attestations found, but the spec records none"},
+		},
+		{
+			name:         "no longer verified",
+			attestations: &AttestationInfo{Available: true, Verified: true},
+			result:       &domain.ProvenanceResult{Status: domain.ProvenanceStatusAttestations, HasAttestations: true},
+			wantOK:       []string{"Attestations found as expected"},
+			wantFailed:   []string{"Spec records verified attestations, but the status is ATTESTATIONS"},
+		},
+		{
+			name:         "publisher changed",
+			attestations: &AttestationInfo{Available: true, Publisher: publisher},
+			result: &domain.ProvenanceResult{
+				Status:           domain.ProvenanceStatusVerified,
+				HasAttestations:  true,
+				TrustedPublisher: &domain.TrustedPublisher{Kind: "GitLab", Repository: "fork/context7", Workflow: "publish.yml"},
+			},
+			wantOK: []string{"Attestations found as expected"},
+			wantFailed: []string{
+				"Publisher kind is GitLab, spec records GitHub",
+				"Publisher repository is fork/context7, spec records upstash/context7",
+				"Publisher workflow is publish.yml, spec records release.yml",
+			},
+		},
+		{
+			name:         "publisher missing",
+			attestations: &AttestationInfo{Available: true, Publisher: publisher},
+			result:       &domain.ProvenanceResult{Status: domain.ProvenanceStatusAttestations, HasAttestations: true},
+			wantOK:       []string{"Attestations found as expected"},
+			wantFailed:   []string{"Spec records a publisher, but the result names none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec := &MCPServerSpec{Provenance: MCPServerProvenance{Attestations: tt.attestations}}
+			var gotOK, gotFailed []string
+			for _, check := range compareWithSpec(spec, tt.result) {
+				if check.ok {
+					gotOK = append(gotOK, check.message)
+				} else {
+					gotFailed = append(gotFailed, check.message)
+				}
+			}
+			if !slices.Equal(gotOK, tt.wantOK) {
+				t.Errorf("confirmed = %q, want %q", gotOK, tt.wantOK)
+			}
+			if !slices.Equal(gotFailed, tt.wantFailed) {
+				t.Errorf("contradicted = %q, want %q", gotFailed, tt.wantFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxSpecSize caps the bytes read from a spec file
+	maxSpecSize = 1 << 20
+	// maxSpecNodes caps the YAML nodes of a spec document, counting an alias
+	// as the nodes it expands to
+	maxSpecNodes = 10000
+	// maxSpecDepth caps how deeply a spec document may nest
+	maxSpecDepth = 64
+)
+
+// errSpecTooComplex rejects a spec whose size or structure is beyond anything
+// a real spec needs, before decoding it can exhaust memory.
+var errSpecTooComplex = errors.New("spec too large or complex")
+
+// readSpecFile reads the spec file at path, refusing files over maxSpecSize.
+func readSpecFile(path string) ([]byte, error) {
+	f, err := os.Open(path) //#nosec G304 -- callers validate the path
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxSpecSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSpecSize {
+		return nil, fmt.Errorf("%w: the file is over %d bytes", errSpecTooComplex, maxSpecSize)
+	}
+	return data, nil
+}
+
+// checkSpecComplexity rejects a parsed spec document that nests deeper than
+// maxSpecDepth or expands to more than maxSpecNodes nodes. Parsing leaves
+// aliases unexpanded, so this bounds what decoding the document costs; an
+// alias to an anchor containing it, which would never finish expanding, is
+// rejected too.
+func checkSpecComplexity(root *yaml.Node) error {
+	sizes := make(map[*yaml.Node]int)
+	var size func(node *yaml.Node, depth int) (int, error)
+	size = func(node *yaml.Node, depth int) (int, error) {
+		if depth > maxSpecDepth {
+			return 0, fmt.Errorf("%w: line %d nests more than %d levels deep", errSpecTooComplex, node.Line, maxSpecDepth)
+		}
+		if node.Kind == yaml.AliasNode {
+			n, ok := sizes[node.Alias]
+			if !ok {
+				return 0, fmt.Errorf("%w: line %d: alias *%s refers to an anchor containing it",
+					errSpecTooComplex, node.Line, node.Value)
+			}
+			return n, nil
+		}
+
+		total := 1
+		for _, child := range node.Content {
+			n, err := size(child, depth+1)
+			if err != nil {
+				return 0, err
+			}
+			if total += n; total > maxSpecNodes {
+				return 0, fmt.Errorf("%w: the document expands to more than %d YAML nodes", errSpecTooComplex, maxSpecNodes)
+			}
+		}
+		// Aliases come after their anchor, so it is complete by the time one is seen
+		if node.Anchor != "" {
+			sizes[node] = total
+		}
+		return total, nil
+	}
+
+	_, err := size(root, 0)
+	return err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCheckSpecComplexity(t *testing.T) {
+	t.Parallel()
+
+	// Each level repeats the one before it ten times, as in a billion laughs attack
+	laughs := "a0: &a0 [lol]\n"
+	for i := 1; i <= 9; i++ {
+		laughs += fmt.Sprintf("a%d: &a%d [%s]\n", i, i, strings.Repeat(fmt.Sprintf("*a%d, ", i-1), 10))
+	}
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "spec",
+			yaml: `metadata:
+  name: context7
+  protocol: npx
+spec:
+  package: "@upstash/context7-mcp"
+  version: 1.0.14
+  args: &args ["--port", "8080"]
+  env_args: *args
+`,
+		},
+		{name: "alias expansion", yaml: laughs, wantErr: "more than 10000 YAML nodes"},
+		{name: "alias inside its anchor", yaml: "a: &a [*a]\n", wantErr: "refers to an anchor containing it"},
+		{name: "deep nesting", yaml: strings.Repeat("[", 100) + strings.Repeat("]", 100), wantErr: "more than 64 levels deep"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var root yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.yaml), &root); err != nil {
+				t.Fatalf("yaml.Unmarshal() error: %v", err)
+			}
+			err := checkSpecComplexity(&root)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkSpecComplexity() error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, errSpecTooComplex) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkSpecComplexity() error = %v, want %v containing %q", err, errSpecTooComplex, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadSpecFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	atLimit := filepath.Join(dir, "limit.yaml")
+	overLimit := filepath.Join(dir, "over.yaml")
+	if err := os.WriteFile(atLimit, []byte(strings.Repeat("#", maxSpecSize)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overLimit, []byte(strings.Repeat("#", maxSpecSize+1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := readSpecFile(atLimit); err != nil || len(data) != maxSpecSize {
+		t.Errorf("readSpecFile() = %d bytes, %v, want %d bytes", len(data), err, maxSpecSize)
+	}
+	if _, err := readSpecFile(overLimit); !errors.Is(err, errSpecTooComplex) {
+		t.Errorf("readSpecFile() error = %v, want %v", err, errSpecTooComplex)
+	}
+}
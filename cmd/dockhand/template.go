@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// builtinTemplates are the named templates --template accepts in place of
+// template text.
+var builtinTemplates = map[string]string{
+	"short": `{{.PackageID.Name}}@{{.PackageID.Version}} {{.Status}}`,
+	"full": `{{.PackageID.Name}}@{{.PackageID.Version}} ({{.PackageID.Protocol}})
+  status: {{.Status}}
+{{- with .TrustedPublisher}}
+  publisher: {{.Kind}} {{.Repository}}{{with .Workflow}} ({{.}}){{end}}
+{{- end}}
+{{- if .AttestationCount}}
+  attestations: {{.VerifiedAttestationCount}} of {{.AttestationCount}} verified
+{{- end}}
+{{- with .RepositoryURI}}
+  repository: {{.}}
+{{- end}}
+{{- with .ErrorMessage}}
+  error: {{.}}
+{{- end}}`,
+}
+
+// builtinTemplateNames returns the names of the built-in templates, sorted.
+func builtinTemplateNames() []string {
+	names := make([]string, 0, len(builtinTemplates))
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// parseResultTemplate parses the --template value, either the name of a
+// built-in template or text/template text executed against a
+// domain.ProvenanceResult. Each result is written on its own line, so a
+// trailing newline is added when the text lacks one.
+func parseResultTemplate(text string) (*template.Template, error) {
+	if builtin, ok := builtinTemplates[text]; ok {
+		text = builtin
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	tmpl, err := template.New("result").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeResultTemplate executes tmpl against result, writing to w.
+func writeResultTemplate(w io.Writer, tmpl *template.Template, result *domain.ProvenanceResult) error {
+	if err := tmpl.Execute(w, result); err != nil {
+		return fmt.Errorf("failed to execute --template: %w", err)
+	}
+	return nil
+}
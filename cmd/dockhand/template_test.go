@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestResultTemplate(t *testing.T) {
+	t.Parallel()
+
+	verified := &domain.ProvenanceResult{
+		PackageID:                domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "server-mcp", Version: "1.2.3"},
+		Status:                   domain.ProvenanceStatusVerified,
+		AttestationCount:         2,
+		VerifiedAttestationCount: 2,
+		TrustedPublisher:         &domain.TrustedPublisher{Kind: "GitHub", Repository: "org/server", Workflow: "release.yml"},
+		RepositoryURI:            "https://github.com/org/server",
+		Details:                  map[string]interface{}{"digest_source": "registry"},
+	}
+	failed := &domain.ProvenanceResult{
+		PackageID:    domain.PackageIdentifier{Protocol: domain.ProtocolPyPI, Name: "server", Version: "0.1.0"},
+		Status:       domain.ProvenanceStatusError,
+		ErrorMessage: "version not found",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		result   *domain.ProvenanceResult
+		want     string
+	}{
+		{"short", "short", verified, "server-mcp@1.2.3 VERIFIED\n"},
+		{
+			"full",
+			"full",
+			verified,
+			"server-mcp@1.2.3 (npx)\n  status: VERIFIED\n  publisher: GitHub org/server (release.yml)\n" +
+				"  attestations: 2 of 2 verified\n  repository: https://github.com/org/server\n",
+		},
+		{"full error", "full", failed, "server@0.1.0 (uvx)\n  status: ERROR\n  error: version not found\n"},
+		{"custom", "{{.PackageID.Name}} {{.Status}}", verified, "server-mcp VERIFIED\n"},
+		{"custom with newline", "{{.Status}}\n", failed, "ERROR\n"},
+		{"details", "{{.Details.digest_source}}", verified, "registry\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl, err := parseResultTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("parseResultTemplate() error = %v", err)
+			}
+			var out strings.Builder
+			if err := writeResultTemplate(&out, tmpl, tt.result); err != nil {
+				t.Fatalf("writeResultTemplate() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		if _, err := parseResultTemplate("{{.Status"); err == nil || !strings.Contains(err.Error(), "invalid --template") {
+			t.Errorf("parseResultTemplate() error = %v, want an invalid --template error", err)
+		}
+	})
+}
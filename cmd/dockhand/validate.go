@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/dockyard/pkg/build"
+)
+
+// specIssueSeverity distinguishes problems that fail validation from advisories.
+type specIssueSeverity string
+
+const (
+	severityError   specIssueSeverity = "error"
+	severityWarning specIssueSeverity = "warning"
+)
+
+// specIssue is a single problem found while validating a spec file.
+type specIssue struct {
+	Path     string
+	Severity specIssueSeverity
+	Message  string
+}
+
+func newValidateCmd() *cobra.Command {
+	var (
+		dir        string
+		schemaOnly bool
+		policyPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate every MCP server specification in a directory tree",
+		Long: `Validate recursively finds every spec.yaml under --dir and checks that it
+is in the {protocol}/{name}/spec.yaml layout, parses, matches the spec
+schema, and has all required fields. All problems are reported at once.
+
+The schema rejects unknown keys, so a misspelled field such as protcol: is
+reported with its path rather than silently ignored. With --schema-only, only
+the structure of each spec is checked against the schema; the layout and
+field value checks below are skipped.
+
+A spec whose {protocol} directory does not match metadata.protocol is an
+error. In addition, validate warns when:
+  - spec.version is missing
+  - the {name} directory does not match metadata.name
+
+With --policy, the package of every spec matched by a rule of the policy
+file is also verified, and a spec whose provenance does not meet its rule's
+requirements is an error. Each rule matches {protocol}/{name} directories
+with a glob; the first matching rule applies:
+
+  rules:
+    - match: "npx/*"
+      require_attestations: true
+    - match: "go/*"
+      allow_none: true
+
+Skill specs under skills/ are not MCP server specs and are skipped; use
+validate-skill for those. Test fixtures under testdata/ directories are
+skipped too. The command exits non-zero if any spec has errors.`,
+		Example: `  # Validate the whole catalog from the repository root
+  dockhand validate --dir .
+
+  # Check only the structure of every spec
+  dockhand validate --dir . --schema-only
+
+  # Also hold every spec to the catalog's provenance policy
+  dockhand validate --dir . --policy provenance-policy.yaml`,
+		RunE: withTimeout(func(cmd *cobra.Command, _ []string) error {
+			return runValidate(cmd, dir, schemaOnly, policyPath)
+		}),
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "Root directory of the spec repository")
+	cmd.Flags().BoolVar(&schemaOnly, "schema-only", false,
+		"Only check specs against the spec schema, skipping layout and field value checks")
+	cmd.Flags().StringVar(&policyPath, "policy", "",
+		"Provenance policy file whose rules every matching spec's package must meet, e.g. provenance-policy.yaml")
+	cmd.MarkFlagsMutuallyExclusive("schema-only", "policy")
+
+	return cmd
+}
+
+// runValidate validates all specs under dir and prints every issue found.
+// With a policyPath, each spec's provenance is also checked against the policy.
+func runValidate(cmd *cobra.Command, dir string, schemaOnly bool, policyPath string) error {
+	var policy *provenancePolicy
+	if policyPath != "" {
+		var err error
+		if policy, err = loadProvenancePolicy(policyPath); err != nil {
+			return err
+		}
+	}
+	paths, err := findSpecFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	var issues []specIssue
+	for _, rel := range paths {
+		if schemaOnly {
+			issues = append(issues, validateSpecSchema(dir, rel)...)
+			continue
+		}
+		issues = append(issues, validateSpecFile(dir, rel)...)
+	}
+	if policy != nil {
+		provenanceService, err := createProvenanceService(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to create provenance service: %w", err)
+		}
+		issues = append(issues, checkProvenancePolicy(cmd.Context(), provenanceService.BatchVerify, policy, dir, paths)...)
+	}
+
+	errorCount := 0
+	for _, issue := range issues {
+		switch issue.Severity {
+		case severityError:
+			errorCount++
+			cmd.Printf("✗ %s: %s\n", issue.Path, issue.Message)
+		case severityWarning:
+			cmd.Printf("⚠  %s: %s\n", issue.Path, issue.Message)
+		}
+	}
+
+	cmd.Printf("\n%d spec(s) checked, %d error(s), %d warning(s)\n",
+		len(paths), errorCount, len(issues)-errorCount)
+
+	if errorCount > 0 {
+		return fmt.Errorf("validation failed with %d error(s)", errorCount)
+	}
+	return nil
+}
+
+// findSpecFiles walks dir and returns the slash-separated paths, relative to
+// dir, of every spec.yaml outside hidden directories, skills/, and testdata/
+// directories of test fixtures.
+func findSpecFiles(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || rel == "skills" || d.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "spec.yaml" {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	return paths, nil
+}
+
+// validateSpecFile checks the spec at rel (relative to dir) and returns every
+// issue found.
+func validateSpecFile(dir, rel string) []specIssue {
+	errorf := func(format string, args ...any) []specIssue {
+		return []specIssue{{Path: rel, Severity: severityError, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if err := validateConfigPath(rel); err != nil {
+		return errorf("invalid config path: %v", err)
+	}
+
+	spec, err := readMCPServerSpec(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	if err := validateProtocolDirectory(rel, spec.Metadata.Protocol); err != nil {
+		return errorf("%v", err)
+	}
+
+	var issues []specIssue
+	warnf := func(format string, args ...any) {
+		issues = append(issues, specIssue{Path: rel, Severity: severityWarning, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if spec.Spec.Version == "" {
+		warnf("spec.version is missing; the image will be tagged 'latest'")
+	}
+
+	if dirName := path.Base(path.Dir(rel)); dirName != spec.Metadata.Name {
+		warnf("directory name %q does not match metadata.name %q", dirName, spec.Metadata.Name)
+	}
+
+	return issues
+}
+
+// validateSpecSchema checks only that the spec at rel (relative to dir) parses
+// and matches the spec schema.
+func validateSpecSchema(dir, rel string) []specIssue {
+	errorf := func(format string, args ...any) []specIssue {
+		return []specIssue{{Path: rel, Severity: severityError, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	data, err := readSpecFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return errorf("failed to read config file: %v", err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return errorf("failed to parse YAML: %v", err)
+	}
+	if err := checkSpecComplexity(&root); err != nil {
+		return errorf("%v", err)
+	}
+	if expandEnv {
+		if err := expandEnvNode(&root, os.LookupEnv, allowEmptyEnv); err != nil {
+			return errorf("%v", err)
+		}
+	}
+	if err := build.ValidateSchema(&root); err != nil {
+		return errorf("%v", err)
+	}
+	return nil
+}
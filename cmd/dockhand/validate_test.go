@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSpec writes content to dir/rel, creating parent directories.
+func writeSpec(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	full := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(full), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
+func TestValidateSpecFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeSpec(t, dir, "npx/good/spec.yaml", `
+metadata:
+  name: good
+  protocol: npx
+spec:
+  package: good-mcp
+  version: "1.0.0"
+`)
+	writeSpec(t, dir, "npx/renamed/spec.yaml", `
+metadata:
+  name: other
+  protocol: npx
+spec:
+  package: other-mcp
+`)
+	writeSpec(t, dir, "uvx/typo/spec.yaml", `
+metadata:
+  name: typo
+  protocol: uvxx
+spec:
+  package: typo-mcp
+  version: "1.0.0"
+`)
+	writeSpec(t, dir, "npx/nested/extra/spec.yaml", `
+metadata:
+  name: nested
+  protocol: npx
+spec:
+  package: nested
+`)
+	writeSpec(t, dir, "npx/mismatch/spec.yaml", `
+metadata:
+  name: mismatch
+  protocol: uvx
+spec:
+  package: mismatch
+  version: "1.0.0"
+`)
+	writeSpec(t, dir, "skills/ignored/spec.yaml", "not: an mcp spec\n")
+	writeSpec(t, dir, "npx/fixtures/testdata/npx/ignored/spec.yaml", "not: a catalog spec\n")
+
+	paths, err := findSpecFiles(dir)
+	if err != nil {
+		t.Fatalf("findSpecFiles: %v", err)
+	}
+	if len(paths) != 5 {
+		t.Fatalf("findSpecFiles found %v, want 5 specs outside skills/ and testdata/", paths)
+	}
+
+	tests := []struct {
+		rel          string
+		wantErrors   int
+		wantWarnings int
+	}{
+		{"npx/good/spec.yaml", 0, 0},
+		{"npx/renamed/spec.yaml", 0, 2}, // missing version, directory/name mismatch
+		{"uvx/typo/spec.yaml", 1, 0},
+		{"npx/nested/extra/spec.yaml", 1, 0},
+		{"npx/mismatch/spec.yaml", 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			t.Parallel()
+
+			var gotErrors, gotWarnings int
+			for _, issue := range validateSpecFile(dir, tt.rel) {
+				switch issue.Severity {
+				case severityError:
+					gotErrors++
+				case severityWarning:
+					gotWarnings++
+				}
+			}
+			if gotErrors != tt.wantErrors || gotWarnings != tt.wantWarnings {
+				t.Errorf("validateSpecFile(%q) = %d error(s), %d warning(s), want %d, %d",
+					tt.rel, gotErrors, gotWarnings, tt.wantErrors, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestValidateProtocolDirectory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path     string
+		protocol string
+		wantErr  bool
+	}{
+		{"npx/foo/spec.yaml", "npx", false},
+		{"uvx/foo/spec.yaml", "uvx", false},
+		{"npx/foo/spec.yaml", "uvx", true},
+		{"go/foo/spec.yaml", "npx", true},
+		{"skills/foo/spec.yaml", "npx", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+"/"+tt.protocol, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateProtocolDirectory(tt.path, tt.protocol)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateProtocolDirectory(%q, %q) err = %v, wantErr %v", tt.path, tt.protocol, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecSchema(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	// Structurally valid, but in the wrong protocol directory
+	writeSpec(t, dir, "uvx/misplaced/spec.yaml", `
+metadata:
+  name: misplaced
+  protocol: npx
+spec:
+  package: misplaced-mcp
+`)
+	writeSpec(t, dir, "npx/typo/spec.yaml", `
+metadata:
+  name: typo
+  protocol: npx
+spec:
+  pakage: typo-mcp
+`)
+
+	if issues := validateSpecSchema(dir, "uvx/misplaced/spec.yaml"); len(issues) != 0 {
+		t.Errorf("validateSpecSchema(misplaced) = %v, want no issues", issues)
+	}
+	if issues := validateSpecFile(dir, "uvx/misplaced/spec.yaml"); len(issues) != 1 {
+		t.Errorf("validateSpecFile(misplaced) = %v, want the protocol directory error", issues)
+	}
+
+	issues := validateSpecSchema(dir, "npx/typo/spec.yaml")
+	want := "spec.package is required; spec.pakage is not a known field"
+	if len(issues) != 1 || issues[0].Severity != severityError || issues[0].Message != want {
+		t.Errorf("validateSpecSchema(typo) = %v, want one error %q", issues, want)
+	}
+}
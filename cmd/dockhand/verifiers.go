@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/gomod"
+	"github.com/stacklok/dockyard/internal/provenance/npm"
+	"github.com/stacklok/dockyard/internal/provenance/oci"
+	"github.com/stacklok/dockyard/internal/provenance/plugin"
+	"github.com/stacklok/dockyard/internal/provenance/pypi"
+	"github.com/stacklok/dockyard/internal/provenance/service"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// Register a factory for every built-in protocol dockhand can verify.
+// Supporting a new ecosystem only takes another registration here, or a
+// verifier plugin on PATH, which discoverPluginVerifiers registers.
+func init() {
+	mustRegisterFactory(domain.ProtocolNPM, func(ctx context.Context) (domain.ProvenanceVerifier, error) {
+		bundleVerifier, err := sharedBundleVerifier(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts := []npm.Option{
+			npm.WithBundleVerifier(bundleVerifier), npm.WithLogger(slog.Default()), npm.WithHTTPTimeout(httpTimeout),
+			npm.WithCertificateIdentity(certificateIdentity()), npm.WithAttestationType(attestationType),
+		}
+		if verifyIntegrity {
+			opts = append(opts, npm.WithTarballDownload())
+		}
+		return npm.NewVerifier(ctx, append(opts, npmRegistryOptions()...)...)
+	})
+
+	mustRegisterFactory(domain.ProtocolPyPI, func(ctx context.Context) (domain.ProvenanceVerifier, error) {
+		bundleVerifier, err := sharedBundleVerifier(ctx)
+		if err != nil {
+			return nil, err
+		}
+		kind, err := pypi.ParseArtifactKind(pypiArtifactKind)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --artifact-kind: %w", err)
+		}
+		opts := []pypi.Option{
+			pypi.WithBundleVerifier(bundleVerifier), pypi.WithLogger(slog.Default()), pypi.WithHTTPTimeout(httpTimeout),
+			pypi.WithCertificateIdentity(certificateIdentity()), pypi.WithArtifactKind(kind),
+		}
+		if quickVerify {
+			opts = append(opts, pypi.WithQuick())
+		}
+		return pypi.NewVerifier(ctx, opts...)
+	})
+
+	mustRegisterFactory(domain.ProtocolGo, func(ctx context.Context) (domain.ProvenanceVerifier, error) {
+		opts := []gomod.Option{gomod.WithLogger(slog.Default()), gomod.WithHTTPTimeout(httpTimeout)}
+		if allowVCS {
+			patterns := goPrivatePatterns()
+			if patterns == "" {
+				slog.Warn("--allow-vcs has no effect: neither GONOPROXY nor GOPRIVATE is set")
+			}
+			opts = append(opts, gomod.WithVCS(patterns))
+		}
+		return gomod.NewVerifier(ctx, opts...)
+	})
+
+	mustRegisterFactory(domain.ProtocolOCI, func(ctx context.Context) (domain.ProvenanceVerifier, error) {
+		bundleVerifier, err := sharedBundleVerifier(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return oci.NewVerifier(ctx, oci.WithBundleVerifier(bundleVerifier), oci.WithLogger(slog.Default()),
+			oci.WithCertificateIdentity(certificateIdentity()))
+	})
+}
+
+// discoverPluginVerifiers registers the verifier plugins on PATH, once, when
+// they are first needed, so that commands that verify nothing never search
+// PATH for them.
+var discoverPluginVerifiers = sync.OnceFunc(func() {
+	registerPluginVerifiers(os.Getenv("PATH"))
+})
+
+// registerPluginVerifiers registers a factory for each verifier plugin found
+// in pathList. Plugins only add protocols: one named after a built-in protocol
+// is ignored, so nothing on PATH can replace a built-in verifier.
+func registerPluginVerifiers(pathList string) {
+	builtin := service.RegisteredProtocols()
+	for protocol, path := range plugin.Discover(pathList) {
+		if slices.Contains(builtin, protocol) {
+			slog.Debug("ignoring verifier plugin for built-in protocol", "protocol", protocol, "plugin", path)
+			continue
+		}
+		mustRegisterFactory(protocol, func(context.Context) (domain.ProvenanceVerifier, error) {
+			return plugin.NewVerifier(protocol, path, plugin.WithLogger(slog.Default())), nil
+		})
+	}
+}
+
+// mustRegisterFactory registers a verifier factory, panicking on a duplicate
+// registration since that is a programming error.
+func mustRegisterFactory(protocol domain.PackageProtocol, factory service.VerifierFactory) {
+	if err := service.RegisterFactory(protocol, factory); err != nil {
+		panic(fmt.Sprintf("failed to register %s verifier factory: %v", protocol, err))
+	}
+}
+
+var (
+	bundleVerifierOnce sync.Once
+	bundleVerifier     *sigstore.BundleVerifier
+	bundleVerifierErr  error
+)
+
+// sharedBundleVerifier returns the Sigstore bundle verifier shared by every
+// protocol verifier, so the trusted root is fetched once. The fetch is
+// deferred to the first verification: creating the service works offline.
+func sharedBundleVerifier(_ context.Context) (*sigstore.BundleVerifier, error) {
+	bundleVerifierOnce.Do(func() {
+		var opts []sigstore.Option
+		opts, bundleVerifierErr = sigstoreOptions()
+		if bundleVerifierErr != nil {
+			return
+		}
+		bundleVerifier = sigstore.NewLazyBundleVerifier(opts...)
+	})
+	return bundleVerifier, bundleVerifierErr
+}
+
+// sigstoreOptions returns the bundle verifier options for --tuf-mirror,
+// --tuf-root, and --tuf-timeout.
+func sigstoreOptions() ([]sigstore.Option, error) {
+	opts := []sigstore.Option{sigstore.WithTUFTimeout(tufTimeout)}
+	if tufMirror != "" {
+		opts = append(opts, sigstore.WithTUFMirror(tufMirror))
+	}
+	if tufRoot != "" {
+		rootJSON, err := os.ReadFile(tufRoot) //#nosec G304 -- path is chosen by the user running the CLI
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TUF root: %w", err)
+		}
+		opts = append(opts, sigstore.WithTUFRoot(rootJSON))
+	}
+	return opts, nil
+}
+
+// npmTokenEnvVar names the environment variable --npm-token defaults to.
+const npmTokenEnvVar = "GITHUB_TOKEN"
+
+// defaultNpmTokenHost is the GitHub Packages npm registry, which requires a
+// token even for package metadata.
+const defaultNpmTokenHost = "npm.pkg.github.com"
+
+// npmRegistryOptions returns the npm verifier options for --npm-registry and
+// --npm-token. The token is read from the environment when the flag is unset,
+// and is only sent to hosts matching --npm-token-host.
+func npmRegistryOptions() []npm.Option {
+	var opts []npm.Option
+	if npmRegistry != "" {
+		opts = append(opts, npm.WithRegistryURL(npmRegistry))
+	}
+	token := npmToken
+	if token == "" {
+		token = os.Getenv(npmTokenEnvVar)
+	}
+	if token != "" {
+		opts = append(opts, npm.WithRegistryToken(npmTokenHost, token))
+	}
+	return opts
+}
+
+// goPrivatePatterns returns the module path patterns --allow-vcs fetches
+// from version control: GONOPROXY, which like for the go command defaults to
+// GOPRIVATE.
+func goPrivatePatterns() string {
+	if patterns := os.Getenv("GONOPROXY"); patterns != "" {
+		return patterns
+	}
+	return os.Getenv("GOPRIVATE")
+}
+
+// certificateIdentity returns the signer identity given by --cert-identity-regexp
+// and --cert-oidc-issuer; its empty fields keep each verifier's default.
+func certificateIdentity() sigstore.CertificateIdentity {
+	return sigstore.CertificateIdentity{Issuer: certOIDCIssuer, SANRegexp: certIdentityRegexp}
+}
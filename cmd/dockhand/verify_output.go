@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// resultSchemaVersion is the version of the JSON form of a result. Bump it,
+// and add a migration to resultMigrations, whenever a change to
+// provenanceResultOutput would misread files written before it.
+const resultSchemaVersion = 1
+
+// resultMigration upgrades the decoded JSON object of a result by one schema
+// version, in place.
+type resultMigration func(doc map[string]any) error
+
+// resultMigrations upgrade a result from the schema version they are keyed by
+// to the next one. Version 1 is the first, so there are none yet.
+var resultMigrations = map[int]resultMigration{}
+
+// provenanceResultOutput is the JSON form of a provenance verification result.
+type provenanceResultOutput struct {
+	// SchemaVersion is the resultSchemaVersion the result was written with
+	SchemaVersion    int                    `json:"schema_version"`
+	Protocol         string                 `json:"protocol"`
+	Package          string                 `json:"package"`
+	Version          string                 `json:"version,omitempty"`
+	Status           string                 `json:"status"`
+	HasAttestations  bool                   `json:"has_attestations"`
+	AttestationCount int                    `json:"attestation_count"`
+	VerifiedCount    int                    `json:"verified_attestation_count"`
+	HasSignatures    bool                   `json:"has_signatures"`
+	TrustedPublisher *publisherOutput       `json:"trusted_publisher,omitempty"`
+	RepositoryURI    string                 `json:"repository_uri,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+	Details          map[string]interface{} `json:"details,omitempty"`
+	Warnings         []verifyWarning        `json:"warnings,omitempty"`
+}
+
+// publisherOutput is the JSON form of a trusted publisher.
+type publisherOutput struct {
+	Kind       string `json:"kind,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Workflow   string `json:"workflow,omitempty"`
+}
+
+// newProvenanceResultOutput converts a verification result and its warnings to their JSON form.
+func newProvenanceResultOutput(result *domain.ProvenanceResult, warnings []verifyWarning) provenanceResultOutput {
+	out := provenanceResultOutput{
+		SchemaVersion:    resultSchemaVersion,
+		Protocol:         string(result.PackageID.Protocol),
+		Package:          result.PackageID.Name,
+		Version:          result.PackageID.Version,
+		Status:           string(result.Status),
+		HasAttestations:  result.HasAttestations,
+		AttestationCount: result.AttestationCount,
+		VerifiedCount:    result.VerifiedAttestationCount,
+		HasSignatures:    result.HasSignatures,
+		RepositoryURI:    result.RepositoryURI,
+		Error:            result.ErrorMessage,
+		Details:          result.Details,
+		Warnings:         warnings,
+	}
+	if p := result.TrustedPublisher; p != nil {
+		out.TrustedPublisher = &publisherOutput{Kind: p.Kind, Repository: p.Repository, Workflow: p.Workflow}
+	}
+	return out
+}
+
+// provenanceResult converts the JSON form of a result back to the result.
+func (o provenanceResultOutput) provenanceResult() *domain.ProvenanceResult {
+	result := &domain.ProvenanceResult{
+		PackageID: domain.PackageIdentifier{
+			Protocol: domain.PackageProtocol(o.Protocol),
+			Name:     o.Package,
+			Version:  o.Version,
+		},
+		Status:                   domain.ProvenanceStatus(o.Status),
+		HasAttestations:          o.HasAttestations,
+		AttestationCount:         o.AttestationCount,
+		VerifiedAttestationCount: o.VerifiedCount,
+		HasSignatures:            o.HasSignatures,
+		RepositoryURI:            o.RepositoryURI,
+		ErrorMessage:             o.Error,
+		Details:                  o.Details,
+	}
+	if p := o.TrustedPublisher; p != nil {
+		result.TrustedPublisher = &domain.TrustedPublisher{Kind: p.Kind, Repository: p.Repository, Workflow: p.Workflow}
+	}
+	return result
+}
+
+// decodeProvenanceResultJSON reads a result saved in the --output-format json
+// shape, migrating it from the schema version it was written with. Results
+// written before the version was recorded are version 1. A result from a newer
+// dockhand is rejected rather than misread.
+func decodeProvenanceResultJSON(data []byte) (provenanceResultOutput, error) {
+	return decodeResultJSON(data, resultSchemaVersion, resultMigrations)
+}
+
+// decodeResultJSON decodes a result of up to schema version current, applying
+// migrations in order to upgrade an older one.
+func decodeResultJSON(data []byte, current int, migrations map[int]resultMigration) (provenanceResultOutput, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return provenanceResultOutput{}, fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	version := 1
+	if raw, ok := doc["schema_version"]; ok {
+		number, isNumber := raw.(float64)
+		if !isNumber || number != float64(int(number)) {
+			return provenanceResultOutput{}, fmt.Errorf("invalid result schema_version %v", raw)
+		}
+		version = int(number)
+	}
+	switch {
+	case version > current:
+		return provenanceResultOutput{}, fmt.Errorf(
+			"result schema version %d is newer than the supported version %d; upgrade dockhand to read it", version, current)
+	case version < 1:
+		return provenanceResultOutput{}, fmt.Errorf("unsupported result schema version %d", version)
+	}
+
+	for ; version < current; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return provenanceResultOutput{}, fmt.Errorf("no migration from result schema version %d", version)
+		}
+		if err := migrate(doc); err != nil {
+			return provenanceResultOutput{}, fmt.Errorf("failed to migrate result from schema version %d: %w", version, err)
+		}
+	}
+	doc["schema_version"] = current
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return provenanceResultOutput{}, fmt.Errorf("failed to encode migrated result: %w", err)
+	}
+	var out provenanceResultOutput
+	if err := json.Unmarshal(migrated, &out); err != nil {
+		return provenanceResultOutput{}, fmt.Errorf("failed to parse result: %w", err)
+	}
+	return out, nil
+}
+
+// writeProvenanceJSON writes result and its warnings as indented JSON to stdout.
+func writeProvenanceJSON(cmd *cobra.Command, result *domain.ProvenanceResult, warnings []verifyWarning) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(newProvenanceResultOutput(result, warnings)); err != nil {
+		return fmt.Errorf("failed to encode provenance result: %w", err)
+	}
+	return nil
+}
+
+// machineFooterLine renders the --machine-footer line of a result: RESULT
+// followed by key=value fields, for shell pipelines to parse. Values that are
+// empty or contain spaces, quotes, backslashes, or equals signs are quoted as
+// Go strings. The publisher and error fields are left out when there is none.
+func machineFooterLine(result *domain.ProvenanceResult, warnings []verifyWarning) string {
+	fields := [][2]string{
+		{"status", string(result.Status)},
+		{"protocol", string(result.PackageID.Protocol)},
+		{"package", result.PackageID.Name},
+		{"version", result.PackageID.Version},
+		{"attestations", strconv.Itoa(result.AttestationCount)},
+		{"verified", strconv.Itoa(result.VerifiedAttestationCount)},
+	}
+	if p := result.TrustedPublisher; p != nil && p.Repository != "" {
+		fields = append(fields, [2]string{"publisher", p.Repository})
+	}
+	fields = append(fields, [2]string{"warnings", strconv.Itoa(len(warnings))})
+	if result.ErrorMessage != "" {
+		fields = append(fields, [2]string{"error", result.ErrorMessage})
+	}
+
+	var b strings.Builder
+	b.WriteString("RESULT")
+	for _, f := range fields {
+		value := f[1]
+		if value == "" || strings.ContainsFunc(value, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '"' || r == '\\' || r == '=' || !unicode.IsPrint(r)
+		}) {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", f[0], value)
+	}
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestMachineFooterLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		result   *domain.ProvenanceResult
+		warnings []verifyWarning
+		want     string
+	}{
+		{
+			name: "verified",
+			result: &domain.ProvenanceResult{
+				PackageID: domain.PackageIdentifier{
+					Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14",
+				},
+				Status:                   domain.ProvenanceStatusVerified,
+				AttestationCount:         2,
+				VerifiedAttestationCount: 1,
+				TrustedPublisher:         &domain.TrustedPublisher{Kind: "GitHub", Repository: "upstash/context7"},
+			},
+			want: "RESULT status=VERIFIED protocol=npx package=@upstash/context7-mcp version=1.0.14 " +
+				"attestations=2 verified=1 publisher=upstash/context7 warnings=0",
+		},
+		{
+			name: "error with warnings",
+			result: &domain.ProvenanceResult{
+				PackageID:    domain.PackageIdentifier{Protocol: domain.ProtocolPyPI, Name: "mcp-server-fetch"},
+				Status:       domain.ProvenanceStatusError,
+				ErrorMessage: `version "9.9" not found`,
+			},
+			warnings: []verifyWarning{{Code: warningRefMismatch}},
+			want: `RESULT status=ERROR protocol=uvx package=mcp-server-fetch version="" attestations=0 verified=0 ` +
+				`warnings=1 error="version \"9.9\" not found"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := machineFooterLine(tt.result, tt.warnings); got != tt.want {
+				t.Errorf("machineFooterLine() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeProvenanceResultJSON(t *testing.T) {
+	t.Parallel()
+
+	// A result saved with --output-format json before the schema was versioned
+	const v1 = `{
+  "protocol": "npx",
+  "package": "@upstash/context7-mcp",
+  "version": "1.0.14",
+  "status": "VERIFIED",
+  "has_attestations": true,
+  "attestation_count": 2,
+  "verified_attestation_count": 2,
+  "has_signatures": false,
+  "trusted_publisher": {"kind": "GitHub", "repository": "upstash/context7", "workflow": "release.yml"},
+  "repository_uri": "https://github.com/upstash/context7",
+  "details": {"predicate_type": "https://slsa.dev/provenance/v1"}
+}`
+	want := &domain.ProvenanceResult{
+		PackageID: domain.PackageIdentifier{
+			Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14",
+		},
+		Status:                   domain.ProvenanceStatusVerified,
+		HasAttestations:          true,
+		AttestationCount:         2,
+		VerifiedAttestationCount: 2,
+		TrustedPublisher:         &domain.TrustedPublisher{Kind: "GitHub", Repository: "upstash/context7", Workflow: "release.yml"},
+		RepositoryURI:            "https://github.com/upstash/context7",
+		Details:                  map[string]interface{}{"predicate_type": "https://slsa.dev/provenance/v1"},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "unversioned v1 file", data: v1},
+		{name: "versioned v1 file", data: strings.Replace(v1, "{", `{"schema_version": 1,`, 1)},
+		{name: "newer version", data: `{"schema_version": 2, "status": "VERIFIED"}`, wantErr: "upgrade dockhand"},
+		{name: "version 0", data: `{"schema_version": 0}`, wantErr: "unsupported result schema version 0"},
+		{name: "invalid version", data: `{"schema_version": "1"}`, wantErr: "invalid result schema_version"},
+		{name: "not JSON", data: `VERIFIED`, wantErr: "failed to parse result"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := decodeProvenanceResultJSON([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeProvenanceResultJSON() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeProvenanceResultJSON() error: %v", err)
+			}
+			if out.SchemaVersion != resultSchemaVersion {
+				t.Errorf("SchemaVersion = %d, want %d", out.SchemaVersion, resultSchemaVersion)
+			}
+			if got := out.provenanceResult(); !reflect.DeepEqual(got, want) {
+				t.Errorf("provenanceResult() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := json.Marshal(newProvenanceResultOutput(want, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := decodeProvenanceResultJSON(data)
+		if err != nil {
+			t.Fatalf("decodeProvenanceResultJSON() error: %v", err)
+		}
+		if got := out.provenanceResult(); !reflect.DeepEqual(got, want) {
+			t.Errorf("provenanceResult() = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestDecodeResultJSONMigrations(t *testing.T) {
+	t.Parallel()
+
+	// A hypothetical version 2 that renamed error to error_message
+	migrations := map[int]resultMigration{
+		1: func(doc map[string]any) error {
+			doc["error_message"] = doc["error"]
+			delete(doc, "error")
+			return nil
+		},
+	}
+	var migrated map[string]any
+	migrations[2] = func(doc map[string]any) error {
+		migrated = doc
+		return nil
+	}
+
+	out, err := decodeResultJSON([]byte(`{"status": "ERROR", "error": "registry unavailable"}`), 3, migrations)
+	if err != nil {
+		t.Fatalf("decodeResultJSON() error: %v", err)
+	}
+	if migrated["error_message"] != "registry unavailable" || migrated["error"] != nil {
+		t.Errorf("migrations ran out of order: version 2 saw %v", migrated)
+	}
+	if out.SchemaVersion != 3 || out.Status != "ERROR" {
+		t.Errorf("decodeResultJSON() = %+v, want schema version 3", out)
+	}
+
+	if _, err := decodeResultJSON([]byte(`{"status": "ERROR"}`), 4, migrations); err == nil ||
+		!strings.Contains(err.Error(), "no migration from result schema version 3") {
+		t.Errorf("decodeResultJSON() error = %v, want a missing migration", err)
+	}
+}
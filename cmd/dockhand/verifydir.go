@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/service"
+)
+
+// dirSpec is a spec found by verify-provenance --dir.
+type dirSpec struct {
+	// rel is the slash-separated path of the spec relative to the directory
+	rel  string
+	spec *MCPServerSpec
+}
+
+// dirResultOutput is the JSON form of the result for one spec of
+// verify-provenance --dir.
+type dirResultOutput struct {
+	Spec string `json:"spec"`
+	provenanceResultOutput
+}
+
+// specPackage returns the package spec names, with the protocol of its metadata.
+func specPackage(spec *MCPServerSpec) domain.PackageIdentifier {
+	return domain.PackageIdentifier{
+		Protocol: domain.PackageProtocol(spec.Metadata.Protocol),
+		Name:     spec.Spec.Package,
+		Version:  spec.Spec.Version,
+	}
+}
+
+// loadDirSpecs loads every spec under dir, in path order. Specs of any
+// protocol are loaded, whether or not it has a verifier; those that fail to
+// load are returned as issues instead.
+func loadDirSpecs(dir string) ([]dirSpec, []specIssue, error) {
+	rels, err := findSpecFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		specs  []dirSpec
+		issues []specIssue
+	)
+	for _, rel := range rels {
+		spec, err := readMCPServerSpec(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			issues = append(issues, specIssue{Path: rel, Severity: severityError, Message: err.Error()})
+			continue
+		}
+		specs = append(specs, dirSpec{rel: rel, spec: spec})
+	}
+	return specs, issues, nil
+}
+
+// verifyDirSpecs verifies the packages of specs in one batch and returns
+// their results in the same order. A protocol without a verifier, or a
+// verification that fails, is reported in its result rather than stopping
+// the others.
+func verifyDirSpecs(ctx context.Context, verify batchVerifier, specs []dirSpec) []*domain.ProvenanceResult {
+	packages := make([]domain.PackageIdentifier, len(specs))
+	for i, s := range specs {
+		packages[i] = specPackage(s.spec)
+	}
+	// Per-package errors, such as ErrUnsupportedProtocol under --strict, are
+	// reported in the results themselves
+	batch, _ := verify(ctx, packages)
+
+	results := make([]*domain.ProvenanceResult, len(specs))
+	copy(results, batch)
+	for i, s := range specs {
+		if results[i] == nil {
+			results[i] = &domain.ProvenanceResult{
+				PackageID:    packages[i],
+				Status:       domain.ProvenanceStatusUnknown,
+				ErrorMessage: "verification did not complete",
+			}
+			continue
+		}
+		if err := checkSpecPins(s.spec, results[i]); err != nil {
+			results[i].Status = domain.ProvenanceStatusError
+			results[i].ErrorMessage = err.Error()
+		}
+	}
+	return results
+}
+
+// runVerifyDir verifies the package of every spec under dir, whatever its
+// protocol, and prints each result. Specs that fail to load are reported
+// without stopping the others. It fails like a single verification would
+// for the first spec that does not pass, and with exit code 1 if a spec
+// failed to load. tmpl, when not nil, formats each result in place of the
+// text output.
+func runVerifyDir(cmd *cobra.Command, dir string, tmpl *template.Template) error {
+	specs, issues, err := loadDirSpecs(dir)
+	if err != nil {
+		return err
+	}
+	if len(specs) == 0 && len(issues) == 0 {
+		return fmt.Errorf("no spec.yaml files found under %s", dir)
+	}
+	for _, issue := range issues {
+		cmd.PrintErrf("%s: %s\n", issue.Path, issue.Message)
+	}
+
+	progress := newProgressReporter(cmd.ErrOrStderr())
+	provenanceService, err := createProvenanceService(cmd.Context(), service.WithProgress(progress.report))
+	if err != nil {
+		cmd.SilenceUsage = true
+		return &exitError{code: exitVerificationError, err: fmt.Errorf("failed to create provenance service: %w", err)}
+	}
+	results := verifyDirSpecs(cmd.Context(), provenanceService.BatchVerify, specs)
+	progress.finish()
+	cmd.SilenceUsage = true
+
+	if err := printDirResults(cmd, specs, results, tmpl); err != nil {
+		return err
+	}
+
+	for i, s := range specs {
+		if err := dirResultExitError(s.spec, results[i]); err != nil {
+			return specExitError(s.rel, err)
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d of %d specs under %s failed to load", len(issues), len(specs)+len(issues), dir)
+	}
+	return nil
+}
+
+// printDirResults prints the result of every spec of verify-provenance --dir.
+func printDirResults(cmd *cobra.Command, specs []dirSpec, results []*domain.ProvenanceResult, tmpl *template.Template) error {
+	switch {
+	case verifyOutputFormat == "json":
+		outputs := make([]dirResultOutput, len(specs))
+		for i, s := range specs {
+			outputs[i] = dirResultOutput{
+				Spec:                   s.rel,
+				provenanceResultOutput: newProvenanceResultOutput(results[i], collectWarnings(s.spec, results[i])),
+			}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(outputs); err != nil {
+			return fmt.Errorf("failed to encode provenance results: %w", err)
+		}
+	case tmpl != nil:
+		for i, s := range specs {
+			if err := writeResultTemplate(cmd.OutOrStdout(), tmpl, results[i]); err != nil {
+				return err
+			}
+			if explain {
+				printExplanation(cmd, results[i])
+			}
+			printWarnings(cmd, collectWarnings(s.spec, results[i]))
+		}
+	default:
+		cmd.Printf("Verified %d specs\n", len(specs))
+		for i, s := range specs {
+			cmd.Println()
+			cmd.Printf("Spec: %s\n", s.rel)
+			printProvenanceResult(cmd, results[i])
+			if explain {
+				printExplanation(cmd, results[i])
+			}
+			printSpecComparison(cmd, s.spec, results[i])
+			printWarnings(cmd, collectWarnings(s.spec, results[i]))
+		}
+	}
+	return nil
+}
+
+// dirResultExitError returns the error verify-provenance exits with for the
+// result of spec, as for a single verification, or nil for a zero exit.
+func dirResultExitError(spec *MCPServerSpec, result *domain.ProvenanceResult) error {
+	if err := provenanceExitError(result, strict); err != nil {
+		return err
+	}
+	if err := publisherExitError(result, allowedPublishers, strict); err != nil {
+		return err
+	}
+	if err := refExitError(spec, result, strict); err != nil {
+		return err
+	}
+	return minAgeExitError(result, minAge, strict, time.Now())
+}
+
+// specExitError prefixes an exit error with the spec it is about.
+func specExitError(rel string, err error) error {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return &exitError{code: exitErr.code, err: fmt.Errorf("%s: %w", rel, exitErr.err)}
+	}
+	return fmt.Errorf("%s: %w", rel, err)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/service"
+)
+
+// protocolVerifier is a verifier for one protocol that reports status for
+// every package, failing any package of another protocol dispatched to it.
+type protocolVerifier struct {
+	protocol domain.PackageProtocol
+	status   domain.ProvenanceStatus
+}
+
+func (v protocolVerifier) Verify(_ context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	if pkg.Protocol != v.protocol {
+		return &domain.ProvenanceResult{PackageID: pkg, Status: domain.ProvenanceStatusError, ErrorMessage: "wrong verifier"}, nil
+	}
+	return &domain.ProvenanceResult{PackageID: pkg, Status: v.status}, nil
+}
+
+func (v protocolVerifier) SupportsProtocol(protocol domain.PackageProtocol) bool {
+	return protocol == v.protocol
+}
+
+func TestLoadDirSpecs(t *testing.T) {
+	t.Parallel()
+
+	specs, issues, err := loadDirSpecs("testdata/mixed")
+	if err != nil {
+		t.Fatalf("loadDirSpecs() error: %v", err)
+	}
+
+	want := []struct {
+		rel string
+		pkg domain.PackageIdentifier
+	}{
+		{
+			"go/example-server/spec.yaml",
+			domain.PackageIdentifier{Protocol: "go", Name: "github.com/example/mcp-server", Version: "v1.2.0"},
+		},
+		{"npx/context7/spec.yaml", domain.PackageIdentifier{Protocol: "npx", Name: "@upstash/context7-mcp", Version: "1.0.14"}},
+		{"uvx/mcp-clickhouse/spec.yaml", domain.PackageIdentifier{Protocol: "uvx", Name: "mcp-clickhouse", Version: "0.1.10"}},
+	}
+	if len(specs) != len(want) {
+		t.Fatalf("loadDirSpecs() = %d specs, want %d", len(specs), len(want))
+	}
+	for i, w := range want {
+		if specs[i].rel != w.rel || specPackage(specs[i].spec) != w.pkg {
+			t.Errorf("spec %d = %s %+v, want %s %+v", i, specs[i].rel, specPackage(specs[i].spec), w.rel, w.pkg)
+		}
+	}
+	if len(issues) != 1 || issues[0].Path != "npx/broken/spec.yaml" {
+		t.Errorf("loadDirSpecs() issues = %+v, want one for npx/broken", issues)
+	}
+}
+
+func TestVerifyDirSpecs_MixedProtocols(t *testing.T) {
+	t.Parallel()
+
+	specs, _, err := loadDirSpecs("testdata/mixed")
+	if err != nil {
+		t.Fatalf("loadDirSpecs() error: %v", err)
+	}
+
+	for _, strictProtocols := range []bool{false, true} {
+		// go has no verifier, so its spec must come back UNKNOWN without
+		// stopping the others, even when that is an error
+		svc := service.New(service.WithStrictProtocols(strictProtocols))
+		for _, v := range []protocolVerifier{
+			{protocol: domain.ProtocolNPM, status: domain.ProvenanceStatusVerified},
+			{protocol: domain.ProtocolPyPI, status: domain.ProvenanceStatusSignatures},
+		} {
+			if err := svc.RegisterVerifier(v.protocol, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		results := verifyDirSpecs(context.Background(), svc.BatchVerify, specs)
+		want := []domain.ProvenanceStatus{
+			domain.ProvenanceStatusUnknown, domain.ProvenanceStatusVerified, domain.ProvenanceStatusSignatures,
+		}
+		if len(results) != len(want) {
+			t.Fatalf("verifyDirSpecs() = %d results, want %d", len(results), len(want))
+		}
+		for i, status := range want {
+			if results[i].PackageID != specPackage(specs[i].spec) || results[i].Status != status {
+				t.Errorf("strict protocols %v: %s = %+v %s, want %s",
+					strictProtocols, specs[i].rel, results[i].PackageID, results[i].Status, status)
+			}
+		}
+		if !strings.Contains(results[0].ErrorMessage, "no verifier registered for protocol go") {
+			t.Errorf("go result error = %q, want no verifier registered", results[0].ErrorMessage)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/vuln"
+)
+
+// vulnerabilityQuerier looks up the known vulnerabilities of a package version
+type vulnerabilityQuerier interface {
+	Query(ctx context.Context, pkg domain.PackageIdentifier) ([]vuln.Vulnerability, error)
+}
+
+// newVulnerabilityQuerier returns the OSV client --block-vulnerable and
+// --warn-vulnerable query
+func newVulnerabilityQuerier() vulnerabilityQuerier {
+	return vuln.NewClient(vuln.WithLogger(slog.Default()), vuln.WithHTTPTimeout(httpTimeout))
+}
+
+// runVulnerabilityCheck checks the package version of spec for known
+// vulnerabilities under --block-vulnerable or --warn-vulnerable.
+func runVulnerabilityCheck(cmd *cobra.Command, spec *MCPServerSpec) error {
+	if !blockVulnerable && !warnVulnerable {
+		return nil
+	}
+	svc, err := createProvenanceService(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to create provenance service: %w", err)
+	}
+	return checkVulnerabilities(cmd, newVulnerabilityQuerier(), svc, spec, blockVulnerable)
+}
+
+// checkVulnerabilities lists the known vulnerabilities of the package version
+// of spec and, with block, fails the build when there are any. An unpinned or
+// latest version is first resolved in the registry to the version the build
+// installs. With block, a version that cannot be resolved or looked up fails
+// the build too, since it cannot be shown to be clean; otherwise it is only a
+// warning.
+func checkVulnerabilities(
+	cmd *cobra.Command,
+	querier vulnerabilityQuerier,
+	resolver versionResolver,
+	spec *MCPServerSpec,
+	block bool,
+) error {
+	pkg := domain.PackageIdentifier{
+		Protocol: domain.PackageProtocol(spec.Metadata.Protocol),
+		Name:     spec.Spec.Package,
+		Version:  spec.Spec.Version,
+	}
+	// warnOr fails the build with err under block, and only warns otherwise
+	warnOr := func(err error) error {
+		if block {
+			return err
+		}
+		cmd.Printf("⚠  Warning: %v\n", err)
+		return nil
+	}
+
+	if pkg.Version == "" || pkg.Version == "latest" {
+		version, err := resolver.ResolveVersion(cmd.Context(), pkg)
+		if err != nil {
+			return warnOr(fmt.Errorf("failed to resolve the version to check for known vulnerabilities: %w", err))
+		}
+		pkg.Version = version
+	}
+
+	vulns, err := querier.Query(cmd.Context(), pkg)
+	if err != nil {
+		return warnOr(fmt.Errorf("failed to check for known vulnerabilities: %w", err))
+	}
+	if len(vulns) == 0 {
+		cmd.Printf("Vulnerability check: no known vulnerabilities in %s\n", pkg.Version)
+		return nil
+	}
+
+	cmd.Printf("Vulnerability check: %d known vulnerabilities\n", len(vulns))
+	ids := make([]string, 0, len(vulns))
+	for _, v := range vulns {
+		ids = append(ids, v.ID)
+		line := "  " + v.ID
+		if len(v.Aliases) > 0 {
+			line += " (" + strings.Join(v.Aliases, ", ") + ")"
+		}
+		if v.Summary != "" {
+			line += ": " + v.Summary
+		}
+		cmd.Println(line)
+	}
+	return warnOr(fmt.Errorf("%s@%s has known vulnerabilities: %s", pkg.Name, pkg.Version, strings.Join(ids, ", ")))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/vuln"
+)
+
+// fakeQuerier returns fixed vulnerabilities, or err, for every package.
+type fakeQuerier struct {
+	vulns []vuln.Vulnerability
+	err   error
+}
+
+func (q fakeQuerier) Query(context.Context, domain.PackageIdentifier) ([]vuln.Vulnerability, error) {
+	return q.vulns, q.err
+}
+
+// fakeResolver resolves every package to version, or fails with err.
+type fakeResolver struct {
+	version string
+	err     error
+}
+
+func (r fakeResolver) ResolveVersion(context.Context, domain.PackageIdentifier) (string, error) {
+	return r.version, r.err
+}
+
+func TestCheckVulnerabilities(t *testing.T) {
+	t.Parallel()
+
+	newSpec := func(version string) *MCPServerSpec {
+		return &MCPServerSpec{
+			Metadata: MCPServerMetadata{Name: "context7", Protocol: "npx"},
+			Spec:     MCPServerPackageSpec{Package: "@upstash/context7-mcp", Version: version},
+		}
+	}
+	vulnerable := fakeQuerier{vulns: []vuln.Vulnerability{
+		{ID: "GHSA-aaaa-bbbb-cccc", Summary: "Prototype pollution", Aliases: []string{"CVE-2025-0001"}},
+		{ID: "GHSA-dddd-eeee-ffff"},
+	}}
+	tests := []struct {
+		name       string
+		version    string
+		querier    fakeQuerier
+		resolver   fakeResolver
+		warnOnly   bool
+		wantErr    string
+		wantOutput string
+	}{
+		{
+			name:       "clean",
+			version:    "1.0.14",
+			wantOutput: "no known vulnerabilities in 1.0.14",
+		},
+		{
+			name:       "vulnerable",
+			version:    "1.0.14",
+			querier:    vulnerable,
+			wantErr:    "@upstash/context7-mcp@1.0.14 has known vulnerabilities: GHSA-aaaa-bbbb-cccc, GHSA-dddd-eeee-ffff",
+			wantOutput: "GHSA-aaaa-bbbb-cccc (CVE-2025-0001): Prototype pollution",
+		},
+		{
+			name:    "query failed",
+			version: "1.0.14",
+			querier: fakeQuerier{err: errors.New("unexpected status code: 503")},
+			wantErr: "failed to check for known vulnerabilities: unexpected status code: 503",
+		},
+		{
+			name:       "unpinned version resolved before the lookup",
+			resolver:   fakeResolver{version: "1.0.20"},
+			querier:    vulnerable,
+			wantErr:    "@upstash/context7-mcp@1.0.20 has known vulnerabilities: GHSA-aaaa-bbbb-cccc, GHSA-dddd-eeee-ffff",
+			wantOutput: "GHSA-dddd-eeee-ffff",
+		},
+		{
+			name:       "latest version resolved before the lookup",
+			version:    "latest",
+			resolver:   fakeResolver{version: "1.0.20"},
+			wantOutput: "no known vulnerabilities in 1.0.20",
+		},
+		{
+			name:     "unresolvable version",
+			resolver: fakeResolver{err: errors.New("dist-tag not found")},
+			wantErr:  "failed to resolve the version to check for known vulnerabilities: dist-tag not found",
+		},
+		{
+			name:       "vulnerable, warn only",
+			version:    "1.0.14",
+			querier:    vulnerable,
+			warnOnly:   true,
+			wantOutput: "Warning: @upstash/context7-mcp@1.0.14 has known vulnerabilities",
+		},
+		{
+			name:       "query failed, warn only",
+			version:    "1.0.14",
+			querier:    fakeQuerier{err: errors.New("unexpected status code: 503")},
+			warnOnly:   true,
+			wantOutput: "Warning: failed to check for known vulnerabilities: unexpected status code: 503",
+		},
+		{
+			name:       "unresolvable version, warn only",
+			resolver:   fakeResolver{err: errors.New("dist-tag not found")},
+			warnOnly:   true,
+			wantOutput: "Warning: failed to resolve the version to check for known vulnerabilities",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out strings.Builder
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+
+			err := checkVulnerabilities(cmd, tt.querier, tt.resolver, newSpec(tt.version), !tt.warnOnly)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("checkVulnerabilities() error = %v, want %q", err, tt.wantErr)
+			}
+			if !strings.Contains(out.String(), tt.wantOutput) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// Codes of verify-provenance warnings, stable for wrappers to act on.
+const (
+	// warningAttestationsMissing means the spec expects attestations the registry does not have
+	warningAttestationsMissing = "attestations_missing"
+	// warningPublisherMismatch means the publisher repository differs from the spec's
+	warningPublisherMismatch = "publisher_mismatch"
+	// warningRepositoryMismatch means the package repository differs from the spec's
+	warningRepositoryMismatch = "repository_mismatch"
+	// warningRefMismatch means the attested source ref differs from the spec's repository_ref
+	warningRefMismatch = "ref_mismatch"
+	// warningIdentityUnscoped means attestations were accepted from any GitHub repository
+	warningIdentityUnscoped = "identity_unscoped"
+)
+
+// verifyWarning is a problem found by verify-provenance that does not by
+// itself fail the command.
+type verifyWarning struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// collectWarnings returns the warnings about result, comparing it against the
+// provenance spec documents when spec is not nil.
+func collectWarnings(spec *MCPServerSpec, result *domain.ProvenanceResult) []verifyWarning {
+	var warnings []verifyWarning
+	if spec != nil {
+		warnings = append(warnings, specWarnings(spec, result)...)
+	}
+	if msg, ok := result.Details["identity_warning"].(string); ok {
+		warnings = append(warnings, verifyWarning{Code: warningIdentityUnscoped, Message: msg})
+	}
+	return warnings
+}
+
+// specWarnings returns where result contradicts the provenance the spec documents.
+func specWarnings(spec *MCPServerSpec, result *domain.ProvenanceResult) []verifyWarning {
+	var warnings []verifyWarning
+	if attestations := spec.Provenance.Attestations; attestations != nil && attestations.Available {
+		switch {
+		case !result.HasAttestations:
+			warnings = append(warnings, verifyWarning{
+				Code:    warningAttestationsMissing,
+				Message: "Spec claims attestations are available, but none found in registry",
+			})
+		case attestations.Publisher != nil && result.TrustedPublisher != nil:
+			expectedRepo := attestations.Publisher.Repository
+			actualRepo := result.TrustedPublisher.Repository
+			if expectedRepo != "" && expectedRepo != actualRepo {
+				warnings = append(warnings, verifyWarning{
+					Code:     warningPublisherMismatch,
+					Message:  "Publisher repository does not match the spec",
+					Expected: expectedRepo,
+					Actual:   actualRepo,
+				})
+			}
+		}
+	}
+
+	if spec.Provenance.RepositoryURI != "" && result.RepositoryURI != "" &&
+		!strings.Contains(result.RepositoryURI, spec.Provenance.RepositoryURI) {
+		warnings = append(warnings, verifyWarning{
+			Code:     warningRepositoryMismatch,
+			Message:  "Repository does not match the spec",
+			Expected: spec.Provenance.RepositoryURI,
+			Actual:   result.RepositoryURI,
+		})
+	}
+	if w := refWarning(spec, result); w != nil {
+		warnings = append(warnings, *w)
+	}
+	return warnings
+}
+
+// refWarning compares the spec's repository_ref with the source ref and
+// commit the attestations claim, returning nil when they agree or either is
+// unknown.
+func refWarning(spec *MCPServerSpec, result *domain.ProvenanceResult) *verifyWarning {
+	expected := spec.Provenance.RepositoryRef
+	ref, commit := result.SourceRef()
+	if expected == "" || ref == "" && commit == "" || refMatches(expected, ref, commit) {
+		return nil
+	}
+	actual := ref
+	switch {
+	case ref == "":
+		actual = commit
+	case commit != "":
+		actual = fmt.Sprintf("%s (%s)", ref, commit)
+	}
+	return &verifyWarning{
+		Code:     warningRefMismatch,
+		Message:  "Attested source ref does not match the spec's repository_ref",
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+// refMatches reports whether expected, a repository_ref, names the attested
+// ref or commit. A short branch or tag name matches its full ref, and an
+// abbreviated commit of at least seven characters matches the full commit.
+func refMatches(expected, ref, commit string) bool {
+	if ref != "" && (expected == ref || "refs/heads/"+expected == ref || "refs/tags/"+expected == ref) {
+		return true
+	}
+	return commit != "" && len(expected) >= 7 && strings.HasPrefix(strings.ToLower(commit), strings.ToLower(expected))
+}
+
+// printWarnings renders warnings as text, after the rest of the output.
+func printWarnings(cmd *cobra.Command, warnings []verifyWarning) {
+	if len(warnings) == 0 {
+		return
+	}
+	cmd.Println()
+	for _, w := range warnings {
+		cmd.Printf("⚠️  WARNING: %s\n", w.Message)
+		if w.Expected != "" || w.Actual != "" {
+			cmd.Printf("   Expected: %s\n", w.Expected)
+			cmd.Printf("   Found: %s\n", w.Actual)
+		}
+	}
+}
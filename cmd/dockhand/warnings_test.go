@@ -0,0 +1,128 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestCollectWarnings(t *testing.T) {
+	t.Parallel()
+
+	spec := &MCPServerSpec{Provenance: MCPServerProvenance{
+		RepositoryURI: "https://github.com/upstash/context7",
+		Attestations: &AttestationInfo{
+			Available: true,
+			Publisher: &PublisherInfo{Kind: "GitHub", Repository: "upstash/context7"},
+		},
+	}}
+
+	tests := []struct {
+		name   string
+		spec   *MCPServerSpec
+		result *domain.ProvenanceResult
+		want   []string
+	}{
+		{
+			name: "matches",
+			spec: spec,
+			result: &domain.ProvenanceResult{
+				HasAttestations:  true,
+				TrustedPublisher: &domain.TrustedPublisher{Repository: "upstash/context7"},
+				RepositoryURI:    "git+https://github.com/upstash/context7.git",
+			},
+		},
+		{
+			name:   "attestations missing",
+			spec:   spec,
+			result: &domain.ProvenanceResult{},
+			want:   []string{warningAttestationsMissing},
+		},
+		{
+			name: "publisher and repository mismatch",
+			spec: spec,
+			result: &domain.ProvenanceResult{
+				HasAttestations:  true,
+				TrustedPublisher: &domain.TrustedPublisher{Repository: "evil/context7"},
+				RepositoryURI:    "https://github.com/evil/context7",
+			},
+			want: []string{warningPublisherMismatch, warningRepositoryMismatch},
+		},
+		{
+			name: "unscoped identity without spec",
+			result: &domain.ProvenanceResult{
+				Details: map[string]interface{}{"identity_warning": "accepting attestations signed by any GitHub repository"},
+			},
+			want: []string{warningIdentityUnscoped},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			warnings := collectWarnings(tt.spec, tt.result)
+			codes := make([]string, 0, len(warnings))
+			for _, w := range warnings {
+				codes = append(codes, w.Code)
+				if w.Message == "" {
+					t.Errorf("warning %s has no message", w.Code)
+				}
+			}
+			if !slices.Equal(codes, tt.want) && (len(codes) > 0 || len(tt.want) > 0) {
+				t.Errorf("collectWarnings() codes = %v, want %v", codes, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefWarning(t *testing.T) {
+	t.Parallel()
+
+	const commit = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	attested := func(ref, digest string) *domain.ProvenanceResult {
+		claims := map[string]interface{}{}
+		if ref != "" {
+			claims[domain.ClaimSourceRef] = ref
+		}
+		if digest != "" {
+			claims[domain.ClaimSourceDigest] = digest
+		}
+		return &domain.ProvenanceResult{TrustedPublisher: &domain.TrustedPublisher{Claims: claims}}
+	}
+
+	tests := []struct {
+		name       string
+		expected   string
+		result     *domain.ProvenanceResult
+		wantActual string
+	}{
+		{"full ref", "refs/tags/v1.0.0", attested("refs/tags/v1.0.0", commit), ""},
+		{"short tag", "v1.0.0", attested("refs/tags/v1.0.0", ""), ""},
+		{"short branch", "main", attested("refs/heads/main", ""), ""},
+		{"abbreviated commit", "4B825DC", attested("refs/tags/v1.0.0", commit), ""},
+		{"no spec ref", "", attested("refs/tags/v1.0.0", commit), ""},
+		{"nothing attested", "refs/heads/main", &domain.ProvenanceResult{}, ""},
+		{"too short a commit", "4b825d", attested("", commit), commit},
+		{"other branch", "refs/heads/main", attested("refs/heads/dev", commit), "refs/heads/dev (" + commit + ")"},
+		{"tag named like the branch", "refs/heads/v1.0.0", attested("refs/tags/v1.0.0", ""), "refs/tags/v1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec := &MCPServerSpec{Provenance: MCPServerProvenance{RepositoryRef: tt.expected}}
+			w := refWarning(spec, tt.result)
+			switch {
+			case tt.wantActual == "" && w != nil:
+				t.Errorf("refWarning() = %+v, want none", w)
+			case tt.wantActual != "" && w == nil:
+				t.Errorf("refWarning() = nil, want a mismatch")
+			case w != nil && (w.Code != warningRefMismatch || w.Actual != tt.wantActual || w.Expected != tt.expected):
+				t.Errorf("refWarning() = %+v, want %s, expected %q, actual %q", w, warningRefMismatch, tt.expected, tt.wantActual)
+			}
+		})
+	}
+}
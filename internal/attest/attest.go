@@ -0,0 +1,152 @@
+// Package attest produces in-toto statements describing the images dockhand
+// builds: the package they were built from, its provenance verification, and
+// the resulting image tag. Statements are left unsigned for a later signing
+// step, e.g. cosign attest.
+package attest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// PredicateType identifies the predicate of dockhand build statements
+const PredicateType = "https://github.com/stacklok/dockyard/attestation/build/v1"
+
+// builderID identifies dockhand as the producer of the statement
+const builderID = "https://github.com/stacklok/dockyard/cmd/dockhand"
+
+// Input describes a build to attest
+type Input struct {
+	// SpecPath is the spec the image was built from
+	SpecPath string
+	// Package is the package installed into the image
+	Package domain.PackageIdentifier
+	// ProtocolScheme is the scheme the Dockerfile was generated from, e.g. npx://pkg@1.0.0
+	ProtocolScheme string
+	// ImageTag is the tag the image is built under
+	ImageTag string
+	// Dockerfile is the generated Dockerfile, which becomes the statement subject
+	Dockerfile []byte
+	// Provenance is the verification result of Package; nil if it was not verified
+	Provenance *domain.ProvenanceResult
+	// Time is when the build happened
+	Time time.Time
+}
+
+// Statement is an in-toto v1 statement with a build predicate
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject is an artifact the statement is about
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate describes how an image was built and from what
+type Predicate struct {
+	Builder        Builder     `json:"builder"`
+	BuiltAt        string      `json:"builtAt"`
+	Spec           string      `json:"spec,omitempty"`
+	ImageTag       string      `json:"imageTag"`
+	ProtocolScheme string      `json:"protocolScheme"`
+	Package        Package     `json:"package"`
+	Provenance     *Provenance `json:"provenance,omitempty"`
+}
+
+// Builder identifies what produced the build
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// Package is the package installed into the image
+type Package struct {
+	Protocol string `json:"protocol"`
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"`
+}
+
+// Provenance summarizes the provenance verification of the package
+type Provenance struct {
+	Status                   string     `json:"status"`
+	AttestationCount         int        `json:"attestationCount"`
+	VerifiedAttestationCount int        `json:"verifiedAttestationCount"`
+	TrustedPublisher         *Publisher `json:"trustedPublisher,omitempty"`
+	RepositoryURI            string     `json:"repositoryUri,omitempty"`
+	Error                    string     `json:"error,omitempty"`
+}
+
+// Publisher is the trusted publisher the package provenance names
+type Publisher struct {
+	Kind       string `json:"kind,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Workflow   string `json:"workflow,omitempty"`
+}
+
+// NewStatement returns the statement for a build. Its subject is the
+// generated Dockerfile, as the image digest is not known until the image is
+// built; the predicate names the image tag instead. Signing tools that attach
+// a predicate to the pushed image, such as cosign attest --predicate, take
+// the Predicate field alone.
+func NewStatement(in Input) (*Statement, error) {
+	if in.ImageTag == "" {
+		return nil, fmt.Errorf("image tag is required")
+	}
+	if len(in.Dockerfile) == 0 {
+		return nil, fmt.Errorf("dockerfile is required")
+	}
+
+	sum := sha256.Sum256(in.Dockerfile)
+	stmt := &Statement{
+		Type:          in_toto.StatementTypeUri,
+		Subject:       []Subject{{Name: "Dockerfile", Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])}}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			Builder:        Builder{ID: builderID},
+			BuiltAt:        in.Time.UTC().Format(time.RFC3339),
+			Spec:           in.SpecPath,
+			ImageTag:       in.ImageTag,
+			ProtocolScheme: in.ProtocolScheme,
+			Package: Package{
+				Protocol: string(in.Package.Protocol),
+				Name:     in.Package.Name,
+				Version:  in.Package.Version,
+			},
+		},
+	}
+
+	if result := in.Provenance; result != nil {
+		stmt.Predicate.Provenance = &Provenance{
+			Status:                   string(result.Status),
+			AttestationCount:         result.AttestationCount,
+			VerifiedAttestationCount: result.VerifiedAttestationCount,
+			RepositoryURI:            result.RepositoryURI,
+			Error:                    result.ErrorMessage,
+		}
+		if p := result.TrustedPublisher; p != nil {
+			stmt.Predicate.Provenance.TrustedPublisher = &Publisher{Kind: p.Kind, Repository: p.Repository, Workflow: p.Workflow}
+		}
+	}
+	return stmt, nil
+}
+
+// Marshal encodes the statement as indented JSON, ready to be wrapped in a
+// DSSE envelope with payload type application/vnd.in-toto+json.
+func (s *Statement) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode statement: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,83 @@
+package attest
+
+import (
+	"testing"
+	"time"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestNewStatement(t *testing.T) {
+	t.Parallel()
+
+	pkg := domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14"}
+	in := Input{
+		SpecPath:       "npx/context7/spec.yaml",
+		Package:        pkg,
+		ProtocolScheme: "npx://@upstash/context7-mcp@1.0.14",
+		ImageTag:       "ghcr.io/stacklok/dockyard/npx/context7:1.0.14",
+		Dockerfile:     []byte("FROM node:22-alpine\n"),
+		Provenance: &domain.ProvenanceResult{
+			PackageID:                pkg,
+			Status:                   domain.ProvenanceStatusVerified,
+			AttestationCount:         2,
+			VerifiedAttestationCount: 2,
+			TrustedPublisher:         &domain.TrustedPublisher{Kind: "Verified", Repository: "upstash/context7"},
+		},
+		Time: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	stmt, err := NewStatement(in)
+	if err != nil {
+		t.Fatalf("NewStatement() error: %v", err)
+	}
+	data, err := stmt.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+
+	// The output must be a statement in-toto's own types accept
+	var parsed in_toto.Statement
+	if err := protojson.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("statement does not parse as in-toto: %v\n%s", err, data)
+	}
+	if err := parsed.Validate(); err != nil {
+		t.Errorf("statement is not a valid in-toto statement: %v", err)
+	}
+	if parsed.GetPredicateType() != PredicateType {
+		t.Errorf("predicateType = %q, want %q", parsed.GetPredicateType(), PredicateType)
+	}
+
+	predicate := parsed.GetPredicate().AsMap()
+	if predicate["imageTag"] != in.ImageTag {
+		t.Errorf("imageTag = %v, want %s", predicate["imageTag"], in.ImageTag)
+	}
+	if predicate["builtAt"] != "2025-06-01T12:00:00Z" {
+		t.Errorf("builtAt = %v", predicate["builtAt"])
+	}
+	provenance, _ := predicate["provenance"].(map[string]interface{})
+	if provenance["status"] != "VERIFIED" {
+		t.Errorf("provenance = %v, want status VERIFIED", provenance)
+	}
+	if publisher, _ := provenance["trustedPublisher"].(map[string]interface{}); publisher["repository"] != "upstash/context7" {
+		t.Errorf("trustedPublisher = %v", provenance["trustedPublisher"])
+	}
+
+	// Without a verification the predicate says nothing about provenance
+	in.Provenance = nil
+	stmt, err = NewStatement(in)
+	if err != nil {
+		t.Fatalf("NewStatement() error: %v", err)
+	}
+	if stmt.Predicate.Provenance != nil {
+		t.Errorf("Provenance = %+v, want nil", stmt.Predicate.Provenance)
+	}
+
+	in.ImageTag = ""
+	if _, err := NewStatement(in); err == nil {
+		t.Error("NewStatement() without an image tag = nil error, want error")
+	}
+}
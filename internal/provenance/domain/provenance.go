@@ -1,7 +1,19 @@
 // Package domain defines the core provenance domain models and interfaces
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrPackageNotFound is wrapped by verification errors for a package the
+	// registry does not know, or that has been unpublished
+	ErrPackageNotFound = errors.New("package not found")
+	// ErrVersionNotFound is wrapped by verification errors for a version that
+	// does not exist in an otherwise existing package
+	ErrVersionNotFound = errors.New("version not found")
+)
 
 // ProvenanceStatus represents the provenance verification status
 type ProvenanceStatus string
@@ -33,6 +45,8 @@ const (
 	ProtocolPyPI PackageProtocol = "uvx"
 	// ProtocolGo represents Go packages
 	ProtocolGo PackageProtocol = "go"
+	// ProtocolOCI represents prebuilt OCI images
+	ProtocolOCI PackageProtocol = "oci"
 )
 
 // PackageIdentifier uniquely identifies a package in its ecosystem
@@ -48,11 +62,18 @@ type ProvenanceResult struct {
 	Status           ProvenanceStatus
 	HasAttestations  bool
 	AttestationCount int
-	HasSignatures    bool
-	TrustedPublisher *TrustedPublisher
-	RepositoryURI    string
-	ErrorMessage     string
-	Details          map[string]interface{}
+	// VerifiedAttestationCount is how many of the AttestationCount
+	// attestations passed cryptographic verification
+	VerifiedAttestationCount int
+	HasSignatures            bool
+	TrustedPublisher         *TrustedPublisher
+	RepositoryURI            string
+	ErrorMessage             string
+	Details                  map[string]interface{}
+	// RawResults are the full Sigstore verification results of the bundles
+	// that verified, each a *verify.VerificationResult. They are only kept
+	// when the verifier is asked to; see sigstore.VerificationResults.
+	RawResults []interface{}
 }
 
 // TrustedPublisher contains information about the trusted publisher
@@ -72,6 +93,29 @@ type ProvenanceVerifier interface {
 	SupportsProtocol(protocol PackageProtocol) bool
 }
 
+// Warmer is implemented by verifiers that can prepare for verification ahead
+// of time, e.g. by opening connections to their registry
+type Warmer interface {
+	// Warmup primes the verifier's connections so later verifications reuse them
+	Warmup(ctx context.Context) error
+}
+
+// VersionLister is implemented by verifiers that can enumerate the published
+// versions of a package
+type VersionLister interface {
+	// ListVersions returns every published version of a package, oldest first
+	ListVersions(ctx context.Context, name string) ([]string, error)
+}
+
+// VersionResolver is implemented by verifiers that can look up a version of a
+// package in their registry
+type VersionResolver interface {
+	// ResolveVersion returns the published version that version names: itself
+	// if it exists, or the newest release for "latest". It wraps
+	// ErrVersionNotFound or ErrPackageNotFound when there is none.
+	ResolveVersion(ctx context.Context, name, version string) (string, error)
+}
+
 // ProvenanceService coordinates provenance verification across different protocols
 type ProvenanceService interface {
 	// VerifyProvenance verifies the provenance of a package
@@ -93,6 +137,9 @@ type ProvenanceRequirements struct {
 	RequireTrustedPublisher bool
 	RequireSignatures       bool
 	AllowNone               bool
+	// AllowedPublishers lists globs, e.g. "myorg/*", one of which the trusted
+	// publisher's repository must match; empty allows any publisher
+	AllowedPublishers []string
 }
 
 // DefaultRequirements returns the default provenance requirements
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Detail keys under which the publication time of a release is recorded in
+// ProvenanceResult.Details
+const (
+	DetailPublishedAt = "published_at"
+	DetailAgeDays     = "age_days"
+)
+
+// RecordPublished stores when a release was published in details, as an
+// RFC 3339 UTC timestamp, with its age at now in whole days
+func RecordPublished(details map[string]interface{}, publishedAt, now time.Time) {
+	details[DetailPublishedAt] = publishedAt.UTC().Format(time.RFC3339)
+	details[DetailAgeDays] = int(now.Sub(publishedAt).Hours() / 24)
+}
+
+// PublishedAt returns the publication time recorded in details, and false
+// when the verifier did not record one
+func PublishedAt(details map[string]interface{}) (time.Time, bool) {
+	value, ok := details[DetailPublishedAt].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	publishedAt, err := time.Parse(time.RFC3339, value)
+	return publishedAt, err == nil
+}
@@ -0,0 +1,20 @@
+package domain
+
+// Claim keys under which verifiers record, in TrustedPublisher.Claims, the
+// source a release was built from as its signing certificate attests it
+const (
+	ClaimSourceRef    = "source_repository_ref"
+	ClaimSourceDigest = "source_repository_digest"
+)
+
+// SourceRef returns the git ref, e.g. "refs/tags/v1.0.0", and commit the
+// verified attestations of r say the release was built from. Either is empty
+// when no attestation claims it.
+func (r *ProvenanceResult) SourceRef() (ref, commit string) {
+	if r.TrustedPublisher == nil {
+		return "", ""
+	}
+	ref, _ = r.TrustedPublisher.Claims[ClaimSourceRef].(string)
+	commit, _ = r.TrustedPublisher.Claims[ClaimSourceDigest].(string)
+	return ref, commit
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Detail keys under which PhaseTimings are recorded in ProvenanceResult.Details
+const (
+	DetailMetadataMs = "metadata_ms"
+	DetailTarballMs  = "tarball_ms"
+	DetailSigstoreMs = "sigstore_ms"
+)
+
+// PhaseTimings accumulates the time a verification spends in each phase:
+// fetching registry metadata, downloading artifacts, and verifying Sigstore bundles
+type PhaseTimings struct {
+	Metadata time.Duration
+	Tarball  time.Duration
+	Sigstore time.Duration
+}
+
+// Add adds the durations of other to t, e.g. to total the timings of work
+// done concurrently.
+func (t *PhaseTimings) Add(other PhaseTimings) {
+	t.Metadata += other.Metadata
+	t.Tarball += other.Tarball
+	t.Sigstore += other.Sigstore
+}
+
+// Record stores the timings in details as whole milliseconds
+func (t *PhaseTimings) Record(details map[string]interface{}) {
+	details[DetailMetadataMs] = t.Metadata.Milliseconds()
+	details[DetailTarballMs] = t.Tarball.Milliseconds()
+	details[DetailSigstoreMs] = t.Sigstore.Milliseconds()
+}
@@ -0,0 +1,73 @@
+package gomod
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/module"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// ParseGoSumLine parses the go.sum line of a module zip, e.g.
+// "github.com/org/tool v1.2.3 h1:...=", returning the module version and its
+// h1: hash. A /go.mod line is rejected since it does not cover the module's code.
+func ParseGoSumLine(line string) (module.Version, string, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return module.Version{}, "", fmt.Errorf("go.sum line %q must be a module path, version, and hash", line)
+	}
+	mod, hash := module.Version{Path: fields[0], Version: fields[1]}, fields[2]
+	if strings.HasSuffix(mod.Version, "/go.mod") {
+		return module.Version{}, "", fmt.Errorf("go.sum line %q hashes only go.mod; use the line of the module itself", line)
+	}
+	if err := module.Check(mod.Path, mod.Version); err != nil {
+		return module.Version{}, "", fmt.Errorf("invalid go.sum line: %w", err)
+	}
+
+	encoded, found := strings.CutPrefix(hash, "h1:")
+	if !found {
+		return module.Version{}, "", fmt.Errorf("go.sum hash %q is not an h1: hash", hash)
+	}
+	if sum, err := base64.StdEncoding.DecodeString(encoded); err != nil || len(sum) != sha256.Size {
+		return module.Version{}, "", fmt.Errorf("go.sum hash %q is not a base64 SHA-256 digest", hash)
+	}
+	return mod, hash, nil
+}
+
+// CheckGoSum compares a go.sum line with the module a verification checked,
+// turning the result into an error when the line pins another version or a
+// different hash. Results without a module hash, which already failed, are
+// left alone.
+func CheckGoSum(result *domain.ProvenanceResult, line string) error {
+	want, wantHash, err := ParseGoSumLine(line)
+	if err != nil {
+		return err
+	}
+	gotHash, ok := result.Details["module_hash"].(string)
+	if !ok {
+		return nil
+	}
+	got := module.Version{
+		Path:    fmt.Sprint(result.Details["module"]),
+		Version: fmt.Sprint(result.Details["module_version"]),
+	}
+
+	result.Details["go_sum_hash"] = wantHash
+	switch {
+	case want != got:
+		result.ErrorMessage = fmt.Sprintf("go.sum pins %s@%s, but %s@%s was verified",
+			want.Path, want.Version, got.Path, got.Version)
+	case wantHash != gotHash:
+		result.ErrorMessage = fmt.Sprintf("go.sum hash mismatch for %s@%s: go.sum has %s, module hashes to %s",
+			got.Path, got.Version, wantHash, gotHash)
+	default:
+		result.Details["go_sum_match"] = true
+		return nil
+	}
+	result.Details["go_sum_match"] = false
+	result.Status = domain.ProvenanceStatusError
+	return nil
+}
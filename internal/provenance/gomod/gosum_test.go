@@ -0,0 +1,99 @@
+package gomod
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+const testHash = "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+func TestParseGoSumLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string
+	}{
+		{"module line", "github.com/org/tool v1.2.3 " + testHash, ""},
+		{"incompatible", "github.com/org/legacy v2.0.1+incompatible " + testHash, ""},
+		{"pseudo-version", "github.com/org/tool v0.0.0-20250102030405-abcdef012345 " + testHash, ""},
+		{"go.mod line", "github.com/org/tool v1.2.3/go.mod " + testHash, "hashes only go.mod"},
+		{"missing hash", "github.com/org/tool v1.2.3", "must be a module path, version, and hash"},
+		{"major version mismatch", "github.com/org/tool v2.0.0 " + testHash, "invalid go.sum line"},
+		{"other hash", "github.com/org/tool v1.2.3 h2:abc", "not an h1: hash"},
+		{"short digest", "github.com/org/tool v1.2.3 h1:YWJj", "not a base64 SHA-256 digest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := ParseGoSumLine(tt.line)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseGoSumLine() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseGoSumLine() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckGoSum(t *testing.T) {
+	t.Parallel()
+
+	const otherHash = "h1:frcCV1k9oG9oKj3dpUqdJg1PxRT2RSN/XKdLCPjaYaY="
+	tests := []struct {
+		name       string
+		line       string
+		wantStatus domain.ProvenanceStatus
+		wantMatch  bool
+	}{
+		{"match", "github.com/org/tool v1.2.3 " + testHash, domain.ProvenanceStatusSignatures, true},
+		{"hash mismatch", "github.com/org/tool v1.2.3 " + otherHash, domain.ProvenanceStatusError, false},
+		{"version mismatch", "github.com/org/tool v1.2.4 " + testHash, domain.ProvenanceStatusError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := &domain.ProvenanceResult{
+				Status:        domain.ProvenanceStatusSignatures,
+				HasSignatures: true,
+				Details: map[string]interface{}{
+					"module":         "github.com/org/tool",
+					"module_version": "v1.2.3",
+					"module_hash":    testHash,
+				},
+			}
+			if err := CheckGoSum(result, tt.line); err != nil {
+				t.Fatalf("CheckGoSum() error = %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.Details["go_sum_match"] != tt.wantMatch {
+				t.Errorf("go_sum_match = %v, want %v", result.Details["go_sum_match"], tt.wantMatch)
+			}
+			if !tt.wantMatch && !strings.Contains(result.ErrorMessage, "v1.2.") {
+				t.Errorf("ErrorMessage = %q", result.ErrorMessage)
+			}
+		})
+	}
+
+	t.Run("failed verification", func(t *testing.T) {
+		t.Parallel()
+		result := &domain.ProvenanceResult{Status: domain.ProvenanceStatusError, Details: map[string]interface{}{}}
+		if err := CheckGoSum(result, "github.com/org/tool v1.2.3 "+testHash); err != nil {
+			t.Fatalf("CheckGoSum() error = %v", err)
+		}
+		if _, ok := result.Details["go_sum_match"]; ok {
+			t.Error("go_sum_match set for a result without a module hash")
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package gomod
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// options holds the settings applied by NewVerifier.
+type options struct {
+	httpOptions []httpclient.Option
+	logger      *slog.Logger
+	maxDownload int64
+	vcsPatterns string
+}
+
+// Option configures a Verifier.
+type Option func(*options)
+
+// WithHTTPClient sets the *http.Client proxy and checksum database requests
+// are sent with, e.g. to route them through a corporate proxy.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithHTTPClient(httpClient))
+	}
+}
+
+// WithHTTPTimeout sets the timeout applied to each request.
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithTimeout(d))
+	}
+}
+
+// WithLogger sets the logger used for debug output about module resolution
+// and checksum database lookups. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMaxDownloadSize caps how many bytes of a module zip are downloaded for
+// hashing; larger modules fail verification. The default is
+// httpclient.DefaultMaxDownloadSize.
+func WithMaxDownloadSize(n int64) Option {
+	return func(o *options) {
+		o.maxDownload = n
+	}
+}
+
+// WithVCS fetches the modules matching patterns, a comma-separated list of
+// globs matched against module path prefixes as GOPRIVATE is, from their git
+// repositories instead of the module proxy, which cannot serve private
+// modules. Their versions must be tagged. With no checksum database to check
+// them against, they are verified by the signature of their tag or commit.
+func WithVCS(patterns string) Option {
+	return func(o *options) {
+		o.vcsPatterns = patterns
+	}
+}
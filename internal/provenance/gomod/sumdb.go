@@ -0,0 +1,104 @@
+package gomod
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+
+	"golang.org/x/mod/sumdb"
+
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// maxSumdbResponseSize caps lookup records and tiles, which are a few KiB.
+const maxSumdbResponseSize = 1 << 20
+
+// sumdbOps implements sumdb.ClientOps for the lookups of one verification. The
+// signed tree head and tiles are kept in memory only, so each verification
+// checks its record against a freshly fetched tree.
+type sumdbOps struct {
+	ctx context.Context
+	v   *Verifier
+
+	mu     sync.Mutex
+	config map[string][]byte
+	cache  map[string][]byte
+}
+
+func newSumdbOps(ctx context.Context, v *Verifier) *sumdbOps {
+	return &sumdbOps{
+		ctx:    ctx,
+		v:      v,
+		config: make(map[string][]byte),
+		cache:  make(map[string][]byte),
+	}
+}
+
+// ReadRemote fetches a lookup record or tile from the checksum database.
+func (o *sumdbOps) ReadRemote(path string) ([]byte, error) {
+	resp, err := o.v.get(o.ctx, o.v.sumdbURL+path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := httpclient.LimitBody(resp, maxSumdbResponseSize)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(body)
+}
+
+// ReadConfig returns the verifier key, or the latest tree head seen so far,
+// which starts out empty.
+func (o *sumdbOps) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(o.v.sumdbKey), nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.config[file], nil
+}
+
+// WriteConfig records a newer tree head.
+func (o *sumdbOps) WriteConfig(file string, old, new []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !bytes.Equal(o.config[file], old) {
+		return sumdb.ErrWriteConflict
+	}
+	o.config[file] = new
+	return nil
+}
+
+// ReadCache returns a record or tile fetched earlier in this verification.
+func (o *sumdbOps) ReadCache(file string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	data, ok := o.cache[file]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return data, nil
+}
+
+// WriteCache keeps a verified record or tile for the rest of this verification.
+func (o *sumdbOps) WriteCache(file string, data []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.cache[file] = data
+}
+
+// Log writes the client's progress messages as debug output.
+func (o *sumdbOps) Log(msg string) {
+	o.v.logger.DebugContext(o.ctx, "checksum database", "message", msg)
+}
+
+// SecurityError logs proof that the checksum database misbehaved; the lookup
+// then fails with sumdb.ErrSecurity.
+func (o *sumdbOps) SecurityError(msg string) {
+	o.v.logger.ErrorContext(o.ctx, "checksum database security error", "message", msg)
+}
@@ -0,0 +1,365 @@
+package gomod
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	modzip "golang.org/x/mod/zip"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// maxGoImportPageSize caps the ?go-get=1 page read to discover a repository.
+const maxGoImportPageSize = 1 << 20
+
+// knownGitHosts serve the git repository of a module at host/owner/repo,
+// without a go-import page to discover.
+var knownGitHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+var (
+	// goImportMetaRe matches the go-import meta tags of a ?go-get=1 page
+	goImportMetaRe = regexp.MustCompile(`(?is)<meta\s[^>]*name\s*=\s*["']go-import["'][^>]*>`)
+	// metaContentRe extracts the content attribute of a meta tag
+	metaContentRe = regexp.MustCompile(`(?is)content\s*=\s*["']([^"']*)["']`)
+)
+
+// vcsRepo is the git repository a module is fetched from.
+type vcsRepo struct {
+	// root is the import path prefix the repository serves, e.g. github.com/org/tool
+	root string
+	url  string
+}
+
+// vcsModule is a module version found in a repository.
+type vcsModule struct {
+	mod module.Version
+	// tag is the git tag of the version, prefixed with the module's directory
+	tag string
+	// dir is the module's directory in the repository, "" for its root
+	dir string
+}
+
+// usesVCS reports whether the module providing packagePath is fetched from
+// its repository rather than the module proxy.
+func (v *Verifier) usesVCS(packagePath string) bool {
+	return v.vcsPatterns != "" && module.MatchPrefixPatterns(v.vcsPatterns, packagePath)
+}
+
+// verifyVCS checks a Go package whose module is private: the tagged version
+// is fetched from its git repository and hashed as the go command would, and
+// the signature of the tag, or else of its commit, is verified with git.
+// There is no checksum database to compare the hash with, so the result is
+// SIGNATURES only when git verifies the signature, and NONE otherwise.
+func (v *Verifier) verifyVCS(ctx context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	var timings domain.PhaseTimings
+	result := &domain.ProvenanceResult{PackageID: pkg, Details: make(map[string]interface{})}
+	fail := func(format string, err error) (*domain.ProvenanceResult, error) {
+		result.Status = domain.ProvenanceStatusError
+		result.ErrorMessage = fmt.Sprintf(format, err)
+		if errors.Is(err, domain.ErrPackageNotFound) {
+			result.ErrorMessage = err.Error()
+			result.Details["not_found"] = "module"
+		}
+		timings.Record(result.Details)
+		return result, err
+	}
+
+	dir, err := os.MkdirTemp("", "dockhand-gomod-")
+	if err != nil {
+		return fail("failed to create repository directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	start := time.Now()
+	repo, m, err := v.fetchVCSModule(ctx, dir, pkg)
+	timings.Metadata += time.Since(start)
+	if err != nil {
+		return fail("failed to resolve module: %v", err)
+	}
+
+	v.logger.DebugContext(ctx, "resolved Go module from version control",
+		"package", pkg.Name, "module", m.mod.Path, "version", m.mod.Version, "repository", repo.url, "tag", m.tag)
+
+	result.Details["module"] = m.mod.Path
+	result.Details["module_version"] = m.mod.Version
+	result.Details["vcs_repository"] = repo.url
+	result.Details["vcs_tag"] = m.tag
+	if _, version := packageRef(pkg); version != m.mod.Version {
+		result.Details["resolved_version"] = m.mod.Version
+	}
+
+	start = time.Now()
+	var buf bytes.Buffer
+	err = modzip.CreateFromVCS(&buf, m.mod, dir, "refs/tags/"+m.tag, m.dir)
+	if err == nil {
+		result.Details["module_hash"], err = hashZip(buf.Bytes())
+	}
+	timings.Tarball += time.Since(start)
+	if err != nil {
+		return fail("failed to hash module: %v", err)
+	}
+
+	if commit, err := runGit(ctx, dir, "rev-parse", "refs/tags/"+m.tag+"^{commit}"); err == nil {
+		result.Details["vcs_commit"] = strings.TrimSpace(string(commit))
+	}
+	signature, verified := tagSignature(ctx, dir, m.tag)
+	result.Details["vcs_signature"] = signature
+	if verified {
+		result.Status = domain.ProvenanceStatusSignatures
+		result.HasSignatures = true
+	} else {
+		result.Status = domain.ProvenanceStatusNone
+	}
+
+	timings.Record(result.Details)
+	return result, nil
+}
+
+// resolveVCSVersion returns the version of the private module providing a
+// package that version names, resolving latest to its newest tagged release.
+func (v *Verifier) resolveVCSVersion(ctx context.Context, pkg domain.PackageIdentifier) (string, error) {
+	dir, err := os.MkdirTemp("", "dockhand-gomod-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create repository directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, m, err := v.fetchVCSModule(ctx, dir, pkg)
+	if err != nil {
+		return "", err
+	}
+	return m.mod.Version, nil
+}
+
+// fetchVCSModule finds the module providing the package in its repository
+// and fetches the tag of its version into the empty git repository it
+// creates in dir. Like resolveModule, it tries the package path and then each
+// of its parents within the repository, longest first. Only tagged versions
+// can be fetched: a pseudo-version names a commit by an abbreviated hash,
+// which git cannot fetch.
+func (v *Verifier) fetchVCSModule(ctx context.Context, dir string, pkg domain.PackageIdentifier) (vcsRepo, vcsModule, error) {
+	packagePath, version := packageRef(pkg)
+	if err := module.CheckImportPath(packagePath); err != nil {
+		return vcsRepo{}, vcsModule{}, fmt.Errorf("invalid Go package path: %w", err)
+	}
+	if version == "" {
+		version = latestVersion
+	}
+	if module.IsPseudoVersion(version) {
+		return vcsRepo{}, vcsModule{}, fmt.Errorf("pseudo-version %s cannot be fetched from version control; pin a tagged release",
+			version)
+	}
+
+	repo, err := v.lookupRepo(ctx, packagePath)
+	if err != nil {
+		return vcsRepo{}, vcsModule{}, err
+	}
+	if _, err := runGit(ctx, dir, "init", "--quiet"); err != nil {
+		return vcsRepo{}, vcsModule{}, err
+	}
+	tags, err := remoteTags(ctx, dir, repo.url)
+	if err != nil {
+		return vcsRepo{}, vcsModule{}, err
+	}
+
+	fetched := make(map[string]bool)
+	inRepo := func(p string) bool { return p == repo.root || strings.HasPrefix(p, repo.root+"/") }
+	for candidate := packagePath; inRepo(candidate); candidate = path.Dir(candidate) {
+		prefix, pathMajor, ok := module.SplitPathVersion(candidate)
+		if !ok {
+			continue
+		}
+		// A module in a subdirectory tags its versions with the directory, less any major version suffix
+		tagPrefix := strings.TrimPrefix(strings.TrimPrefix(prefix, repo.root), "/")
+		if tagPrefix != "" {
+			tagPrefix += "/"
+		}
+		resolved, ok := pickTag(tags, tagPrefix, candidate, version)
+		if !ok {
+			continue
+		}
+
+		tag := tagPrefix + resolved
+		if !fetched[tag] {
+			if _, err := runGit(ctx, dir, "fetch", "--quiet", "--depth=1", "--no-tags", repo.url,
+				"refs/tags/"+tag+":refs/tags/"+tag); err != nil {
+				return vcsRepo{}, vcsModule{}, fmt.Errorf("failed to fetch tag %s: %w", tag, err)
+			}
+			fetched[tag] = true
+		}
+
+		// A major version lives in its own subdirectory, or at the tag's directory on a major branch
+		dirs := []string{strings.TrimPrefix(strings.TrimPrefix(candidate, repo.root), "/")}
+		if pathMajor != "" {
+			dirs = append(dirs, strings.TrimSuffix(tagPrefix, "/"))
+		}
+		for _, d := range dirs {
+			if goModPath(ctx, dir, tag, d) == candidate {
+				return repo, vcsModule{mod: module.Version{Path: candidate, Version: resolved}, tag: tag, dir: d}, nil
+			}
+		}
+	}
+	return vcsRepo{}, vcsModule{}, fmt.Errorf("%w: no module in %s provides %s@%s",
+		domain.ErrPackageNotFound, repo.url, packagePath, version)
+}
+
+// pickTag returns the version of modulePath that version names among the
+// repository's tags, which carry tagPrefix: version itself if it is tagged,
+// or for latest the newest release, or the newest pre-release if there are
+// no releases.
+func pickTag(tags []string, tagPrefix, modulePath, version string) (string, bool) {
+	if version != latestVersion {
+		if slices.Contains(tags, tagPrefix+version) && module.Check(modulePath, version) == nil {
+			return version, true
+		}
+		return "", false
+	}
+
+	var best string
+	for _, tag := range tags {
+		candidate, ok := strings.CutPrefix(tag, tagPrefix)
+		if !ok || semver.Canonical(candidate) != candidate || module.Check(modulePath, candidate) != nil {
+			continue
+		}
+		release, bestRelease := semver.Prerelease(candidate) == "", semver.Prerelease(best) == ""
+		if best == "" || release && !bestRelease || release == bestRelease && semver.Compare(candidate, best) > 0 {
+			best = candidate
+		}
+	}
+	return best, best != ""
+}
+
+// goModPath returns the module path declared by the go.mod in dir at tag, or
+// "" if there is none.
+func goModPath(ctx context.Context, repoDir, tag, dir string) string {
+	data, err := runGit(ctx, repoDir, "cat-file", "blob", "refs/tags/"+tag+":"+path.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	return modfile.ModulePath(data)
+}
+
+// remoteTags lists the tags of the repository at repoURL.
+func remoteTags(ctx context.Context, dir, repoURL string) ([]string, error) {
+	out, err := runGit(ctx, dir, "ls-remote", "--tags", "--refs", repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", repoURL, err)
+	}
+	var tags []string
+	for line := range strings.Lines(string(out)) {
+		_, ref, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if tag, found := strings.CutPrefix(ref, "refs/tags/"); ok && found {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// tagSignature verifies the signature of the tag or, for an unsigned tag, of
+// its commit, returning a description of the outcome and whether git verified
+// it. git checks signatures against the keys it is configured with, such as
+// the GPG keyring or gpg.ssh.allowedSignersFile.
+func tagSignature(ctx context.Context, dir, tag string) (string, bool) {
+	ref := "refs/tags/" + tag
+	if object, err := runGit(ctx, dir, "cat-file", "tag", ref); err == nil && bytes.Contains(object, []byte("-----BEGIN ")) {
+		if _, err := runGit(ctx, dir, "verify-tag", ref); err != nil {
+			return fmt.Sprintf("tag signed, not verified: %v", err), false
+		}
+		return "tag signature verified", true
+	}
+
+	commit := ref + "^{commit}"
+	if object, err := runGit(ctx, dir, "cat-file", "commit", commit); err == nil && bytes.Contains(object, []byte("\ngpgsig")) {
+		if _, err := runGit(ctx, dir, "verify-commit", commit); err != nil {
+			return fmt.Sprintf("commit signed, not verified: %v", err), false
+		}
+		return "commit signature verified", true
+	}
+	return "unsigned", false
+}
+
+// discoverRepo finds the git repository serving importPath: by the path on
+// well-known hosts, and otherwise from the go-import meta tag of the
+// ?go-get=1 page, as the go command does.
+func (v *Verifier) discoverRepo(ctx context.Context, importPath string) (vcsRepo, error) {
+	elems := strings.Split(importPath, "/")
+	if slices.Contains(knownGitHosts, elems[0]) {
+		if len(elems) < 3 {
+			return vcsRepo{}, fmt.Errorf("%s is not a repository path on %s", importPath, elems[0])
+		}
+		root := strings.Join(elems[:3], "/")
+		return vcsRepo{root: root, url: "https://" + root}, nil
+	}
+
+	resp, err := v.get(ctx, "https://"+importPath+"?go-get=1")
+	if err != nil {
+		return vcsRepo{}, fmt.Errorf("failed to discover the repository of %s: %w", importPath, err)
+	}
+	defer resp.Body.Close()
+	body, err := httpclient.LimitBody(resp, maxGoImportPageSize)
+	if err != nil {
+		return vcsRepo{}, err
+	}
+	page, err := io.ReadAll(body)
+	if err != nil {
+		return vcsRepo{}, fmt.Errorf("failed to read go-import page: %w", err)
+	}
+	return parseGoImport(page, importPath)
+}
+
+// parseGoImport returns the repository the go-import meta tags of page
+// declare for importPath. Only git repositories over https or ssh are
+// supported.
+func parseGoImport(page []byte, importPath string) (vcsRepo, error) {
+	for _, tag := range goImportMetaRe.FindAll(page, -1) {
+		content := metaContentRe.FindSubmatch(tag)
+		if content == nil {
+			continue
+		}
+		fields := strings.Fields(string(content[1]))
+		if len(fields) != 3 || importPath != fields[0] && !strings.HasPrefix(importPath, fields[0]+"/") {
+			continue
+		}
+		if fields[1] != "git" {
+			return vcsRepo{}, fmt.Errorf("%s is served from %s, only git is supported", fields[0], fields[1])
+		}
+		if u, err := url.Parse(fields[2]); err != nil || u.Scheme != "https" && u.Scheme != "ssh" {
+			return vcsRepo{}, fmt.Errorf("repository URL %q of %s must use https or ssh", fields[2], fields[0])
+		}
+		return vcsRepo{root: fields[0], url: fields[2]}, nil
+	}
+	return vcsRepo{}, fmt.Errorf("no go-import meta tag for %s", importPath)
+}
+
+// runGit runs git in dir, without prompting for credentials, and returns its
+// standard output.
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...) //#nosec G204 -- arguments are validated module paths, tags, and URLs
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,306 @@
+package gomod
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// gitRepo is a local repository standing in for a private module's remote.
+type gitRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newGitRepo(t *testing.T) *gitRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := &gitRepo{t: t, dir: t.TempDir()}
+	r.git("init", "--quiet")
+	return r
+}
+
+// git runs git in the repository, independent of the user's configuration.
+func (r *gitRepo) git(args ...string) {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{
+		"-c", "user.name=test", "-c", "user.email=test@example.com",
+		"-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false",
+	}, args...)...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		r.t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+// commit writes files into the repository and commits them.
+func (r *gitRepo) commit(files map[string]string) {
+	r.t.Helper()
+	writeFiles(r.t, r.dir, files)
+	r.git("add", "-A")
+	r.git("commit", "--quiet", "-m", "update")
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// moduleHash returns the h1: hash of a module version made of files.
+func moduleHash(t *testing.T, modulePath, version string, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+	hash, err := dirhash.HashDir(dir, modulePath+"@"+version, dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestVerifyVCS(t *testing.T) {
+	t.Parallel()
+
+	const root = "example.com/private/tool"
+	v1 := map[string]string{"go.mod": "module " + root + "\n", "cmd/server/main.go": "package main\n"}
+	v11 := map[string]string{"go.mod": "module " + root + "\n", "cmd/server/main.go": "package main\n\nfunc main() {}\n"}
+	sub := map[string]string{"go.mod": "module " + root + "/sub\n", "sub.go": "package sub\n"}
+
+	repo := newGitRepo(t)
+	repo.commit(v1)
+	repo.git("tag", "v1.0.0")
+	repo.commit(v11)
+	repo.git("tag", "-a", "-m", "release", "v1.1.0")
+	repo.commit(map[string]string{"sub/go.mod": sub["go.mod"], "sub/sub.go": sub["sub.go"]})
+	repo.git("tag", "sub/v0.1.0")
+	repo.git("tag", "v1.2.0-rc.1")
+
+	verifier, err := NewVerifier(context.Background(), WithVCS("example.com/private"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.lookupRepo = func(_ context.Context, importPath string) (vcsRepo, error) {
+		if importPath != root && !strings.HasPrefix(importPath, root+"/") {
+			return vcsRepo{}, errors.New("unknown repository")
+		}
+		return vcsRepo{root: root, url: "file://" + repo.dir}, nil
+	}
+
+	tests := []struct {
+		name        string
+		pkg         string
+		version     string
+		wantModule  string
+		wantVersion string
+		wantHash    string
+		wantErr     string
+		notFound    bool
+	}{
+		{
+			name:        "latest release of a package",
+			pkg:         root + "/cmd/server",
+			wantModule:  root,
+			wantVersion: "v1.1.0",
+			wantHash:    moduleHash(t, root, "v1.1.0", v11),
+		},
+		{
+			name:        "inline version",
+			pkg:         root + "/cmd/server@v1.0.0",
+			wantModule:  root,
+			wantVersion: "v1.0.0",
+			wantHash:    moduleHash(t, root, "v1.0.0", v1),
+		},
+		{
+			name:        "nested module",
+			pkg:         root + "/sub",
+			version:     "latest",
+			wantModule:  root + "/sub",
+			wantVersion: "v0.1.0",
+			wantHash:    moduleHash(t, root+"/sub", "v0.1.0", sub),
+		},
+		{
+			name:     "untagged version",
+			pkg:      root,
+			version:  "v1.5.0",
+			wantErr:  "no module in file://",
+			notFound: true,
+		},
+		{
+			name:    "pseudo-version",
+			pkg:     root,
+			version: "v0.0.0-20240101000000-abcdefabcdef",
+			wantErr: "pin a tagged release",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := verifier.Verify(context.Background(),
+				domain.PackageIdentifier{Protocol: domain.ProtocolGo, Name: tt.pkg, Version: tt.version})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if got := errors.Is(err, domain.ErrPackageNotFound); got != tt.notFound {
+					t.Errorf("errors.Is(err, ErrPackageNotFound) = %v, want %v", got, tt.notFound)
+				}
+				if result.Status != domain.ProvenanceStatusError {
+					t.Errorf("Status = %s, want %s", result.Status, domain.ProvenanceStatusError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error: %v", err)
+			}
+
+			if result.Status != domain.ProvenanceStatusNone {
+				t.Errorf("Status = %s, want %s for an unsigned tag", result.Status, domain.ProvenanceStatusNone)
+			}
+			if got := result.Details["module"]; got != tt.wantModule {
+				t.Errorf("module = %v, want %s", got, tt.wantModule)
+			}
+			if got := result.Details["module_version"]; got != tt.wantVersion {
+				t.Errorf("module_version = %v, want %s", got, tt.wantVersion)
+			}
+			if got := result.Details["module_hash"]; got != tt.wantHash {
+				t.Errorf("module_hash = %v, want %s", got, tt.wantHash)
+			}
+			if got := result.Details["vcs_signature"]; got != "unsigned" {
+				t.Errorf("vcs_signature = %v, want unsigned", got)
+			}
+			if commit, _ := result.Details["vcs_commit"].(string); len(commit) != 40 {
+				t.Errorf("vcs_commit = %q, want a commit hash", commit)
+			}
+		})
+	}
+}
+
+func TestPickTag(t *testing.T) {
+	t.Parallel()
+
+	tags := []string{"v1.0.0", "v1.1.0", "v1.2.0-rc.1", "v2.0.0", "v1.3", "sub/v0.1.0", "sub/v0.2.0-beta", "other"}
+
+	tests := []struct {
+		name       string
+		tags       []string
+		tagPrefix  string
+		modulePath string
+		version    string
+		want       string
+	}{
+		{name: "latest release", tags: tags, modulePath: "example.com/tool", version: "latest", want: "v1.1.0"},
+		{name: "latest of a major version", tags: tags, modulePath: "example.com/tool/v2", version: "latest", want: "v2.0.0"},
+		{name: "exact version", tags: tags, modulePath: "example.com/tool", version: "v1.2.0-rc.1", want: "v1.2.0-rc.1"},
+		{name: "major version outside its path", tags: tags, modulePath: "example.com/tool", version: "v2.0.0"},
+		{name: "untagged version", tags: tags, modulePath: "example.com/tool", version: "v1.4.0"},
+		{name: "nested module", tags: tags, tagPrefix: "sub/", modulePath: "example.com/tool/sub", version: "latest", want: "v0.1.0"},
+		{
+			name:       "only pre-releases",
+			tags:       []string{"v0.1.0-alpha", "v0.1.0-beta"},
+			modulePath: "example.com/tool",
+			version:    "latest",
+			want:       "v0.1.0-beta",
+		},
+		{name: "no tags", modulePath: "example.com/tool", version: "latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := pickTag(tt.tags, tt.tagPrefix, tt.modulePath, tt.version)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("pickTag() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGoImport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		page     string
+		path     string
+		wantRoot string
+		wantURL  string
+		wantErr  string
+	}{
+		{
+			name: "git repository",
+			page: `<html><head>
+<meta name="go-import" content="go.corp.example/tools/mcp git https://git.corp.example/tools/mcp.git">
+</head></html>`,
+			path:     "go.corp.example/tools/mcp/cmd/server",
+			wantRoot: "go.corp.example/tools/mcp",
+			wantURL:  "https://git.corp.example/tools/mcp.git",
+		},
+		{
+			name:     "attributes in either order",
+			page:     `<META content='go.corp.example/mcp git ssh://git@git.corp.example/mcp' name='go-import'/>`,
+			path:     "go.corp.example/mcp",
+			wantRoot: "go.corp.example/mcp",
+			wantURL:  "ssh://git@git.corp.example/mcp",
+		},
+		{
+			name:    "other prefix",
+			page:    `<meta name="go-import" content="go.corp.example/other git https://git.corp.example/other">`,
+			path:    "go.corp.example/mcp",
+			wantErr: "no go-import meta tag",
+		},
+		{
+			name:    "not git",
+			page:    `<meta name="go-import" content="go.corp.example/mcp hg https://hg.corp.example/mcp">`,
+			path:    "go.corp.example/mcp",
+			wantErr: "only git is supported",
+		},
+		{
+			name:    "unsafe URL",
+			page:    `<meta name="go-import" content="go.corp.example/mcp git file:///etc">`,
+			path:    "go.corp.example/mcp",
+			wantErr: "must use https or ssh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := parseGoImport([]byte(tt.page), tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseGoImport() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGoImport() error: %v", err)
+			}
+			if repo.root != tt.wantRoot || repo.url != tt.wantURL {
+				t.Errorf("parseGoImport() = %+v, want root %s and url %s", repo, tt.wantRoot, tt.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+// Package gomod implements integrity verification for Go modules against the
+// Go checksum database, sum.golang.org
+package gomod
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// sumGolangOrgKey is the verifier key of sum.golang.org, as built into the Go toolchain.
+const sumGolangOrgKey = "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ni1g4/ctNk2F1Wx8z"
+
+// latestVersion is the version query that resolves to a module's newest release.
+const latestVersion = "latest"
+
+// errNotFound is returned when the module proxy or checksum database has no
+// such module or version.
+var errNotFound = errors.New("not found")
+
+// Verifier checks that the zip of a Go module version, as served by the module
+// proxy, hashes to what the Go checksum database records for it. Go modules
+// publish no build provenance; the checksum database's transparency log
+// guarantees instead that every user of a version gets the same code.
+type Verifier struct {
+	httpClient *httpclient.Client
+	proxyURL   string
+	sumdbURL   string
+	// sumdbKey is the note verifier key the checksum database's tree heads are signed with
+	sumdbKey    string
+	logger      *slog.Logger
+	maxDownload int64
+	// vcsPatterns are the GOPRIVATE-style globs of modules fetched from their repositories
+	vcsPatterns string
+	// lookupRepo finds the git repository of an import path; tests replace it
+	lookupRepo func(ctx context.Context, importPath string) (vcsRepo, error)
+}
+
+// NewVerifier creates a new Go module verifier using proxy.golang.org and sum.golang.org
+func NewVerifier(_ context.Context, opts ...Option) (*Verifier, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	v := &Verifier{
+		httpClient:  httpclient.New(append(o.httpOptions, httpclient.WithLogger(logger))...),
+		proxyURL:    "https://proxy.golang.org",
+		sumdbURL:    "https://sum.golang.org",
+		sumdbKey:    sumGolangOrgKey,
+		logger:      logger,
+		maxDownload: o.maxDownload,
+		vcsPatterns: o.vcsPatterns,
+	}
+	v.lookupRepo = v.discoverRepo
+	return v, nil
+}
+
+// SupportsProtocol returns true if this verifier supports the given protocol
+func (*Verifier) SupportsProtocol(protocol domain.PackageProtocol) bool {
+	return protocol == domain.ProtocolGo
+}
+
+// Warmup opens a connection to the module proxy, so the first verification
+// skips the TLS handshake.
+func (v *Verifier) Warmup(ctx context.Context) error {
+	return v.httpClient.Warmup(ctx, v.proxyURL)
+}
+
+// Verify checks a Go package against the checksum database. The package may
+// be any package path within a module, e.g. github.com/org/tool/cmd/server,
+// and may carry its version inline after an @. Pseudo-versions and
+// +incompatible versions are looked up as they are. Modules matching the
+// patterns given to WithVCS are fetched from their repositories instead.
+func (v *Verifier) Verify(ctx context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	if pkg.Protocol != domain.ProtocolGo {
+		return nil, fmt.Errorf("go verifier does not support protocol %s", pkg.Protocol)
+	}
+	if packagePath, _ := packageRef(pkg); v.usesVCS(packagePath) {
+		return v.verifyVCS(ctx, pkg)
+	}
+
+	var timings domain.PhaseTimings
+
+	// Find the module providing the package in the module proxy
+	start := time.Now()
+	mod, err := v.resolveModule(ctx, pkg)
+	timings.Metadata += time.Since(start)
+	if err != nil {
+		result := &domain.ProvenanceResult{
+			PackageID:    pkg,
+			Status:       domain.ProvenanceStatusError,
+			ErrorMessage: fmt.Sprintf("failed to resolve module: %v", err),
+			Details:      make(map[string]interface{}),
+		}
+		if errors.Is(err, domain.ErrPackageNotFound) {
+			result.ErrorMessage = err.Error()
+			result.Details["not_found"] = "module"
+		}
+		timings.Record(result.Details)
+		return result, err
+	}
+
+	v.logger.DebugContext(ctx, "resolved Go module", "package", pkg.Name, "module", mod.Path, "version", mod.Version)
+
+	result := &domain.ProvenanceResult{
+		PackageID: pkg,
+		Details: map[string]interface{}{
+			"module":         mod.Path,
+			"module_version": mod.Version,
+		},
+	}
+	if _, version := packageRef(pkg); version != mod.Version {
+		result.Details["resolved_version"] = mod.Version
+	}
+
+	start = time.Now()
+	zipHash, err := v.moduleZipHash(ctx, mod)
+	timings.Tarball += time.Since(start)
+	if err != nil {
+		result.Status = domain.ProvenanceStatusError
+		result.ErrorMessage = fmt.Sprintf("failed to hash module zip: %v", err)
+		timings.Record(result.Details)
+		return result, err
+	}
+	result.Details["module_hash"] = zipHash
+
+	start = time.Now()
+	dbHash, err := v.lookupChecksum(ctx, mod)
+	timings.Metadata += time.Since(start)
+	if err != nil {
+		result.Status = domain.ProvenanceStatusError
+		result.ErrorMessage = err.Error()
+		timings.Record(result.Details)
+		return result, err
+	}
+	result.Details["checksum_db"] = checksumDBName(v.sumdbKey)
+	result.Details["checksum_db_hash"] = dbHash
+
+	// A zip that differs from the logged hash was tampered with somewhere
+	// between the origin and us, whatever the proxy claims
+	if zipHash != dbHash {
+		result.Status = domain.ProvenanceStatusError
+		result.ErrorMessage = fmt.Sprintf("module hash mismatch for %s@%s: zip hashes to %s, checksum database has %s",
+			mod.Path, mod.Version, zipHash, dbHash)
+	} else {
+		result.Status = domain.ProvenanceStatusSignatures
+		result.HasSignatures = true
+	}
+
+	timings.Record(result.Details)
+	return result, nil
+}
+
+// ResolveVersion returns the version of the module providing a package that
+// version names, resolving latest to the newest release.
+func (v *Verifier) ResolveVersion(ctx context.Context, name, version string) (string, error) {
+	pkg := domain.PackageIdentifier{Protocol: domain.ProtocolGo, Name: name, Version: version}
+	if packagePath, _ := packageRef(pkg); v.usesVCS(packagePath) {
+		return v.resolveVCSVersion(ctx, pkg)
+	}
+	mod, err := v.resolveModule(ctx, pkg)
+	if err != nil {
+		return "", err
+	}
+	return mod.Version, nil
+}
+
+// packageRef returns the package path and version of pkg, taking an inline
+// @version when pkg.Version is empty.
+func packageRef(pkg domain.PackageIdentifier) (packagePath, version string) {
+	packagePath, inline, found := strings.Cut(pkg.Name, "@")
+	version = pkg.Version
+	if found && version == "" {
+		version = inline
+	}
+	return packagePath, version
+}
+
+// moduleInfo is the JSON the module proxy serves for a version query.
+type moduleInfo struct {
+	Version string    `json:"Version"`
+	Time    time.Time `json:"Time"`
+}
+
+// resolveModule finds the module that provides the package, trying the
+// package path and then each of its parents, longest first, as the go command
+// does. An empty version or latest resolves to the newest release.
+func (v *Verifier) resolveModule(ctx context.Context, pkg domain.PackageIdentifier) (module.Version, error) {
+	packagePath, version := packageRef(pkg)
+	if err := module.CheckImportPath(packagePath); err != nil {
+		return module.Version{}, fmt.Errorf("invalid Go package path: %w", err)
+	}
+	if version == "" {
+		version = latestVersion
+	}
+
+	for candidate := packagePath; candidate != "."; candidate = path.Dir(candidate) {
+		info, err := v.fetchModuleInfo(ctx, candidate, version)
+		if errors.Is(err, errNotFound) {
+			continue
+		}
+		if err != nil {
+			return module.Version{}, err
+		}
+
+		mod := module.Version{Path: candidate, Version: info.Version}
+		// Rejects e.g. a v2 version of a module path without /v2 that is not +incompatible
+		if err := module.Check(mod.Path, mod.Version); err != nil {
+			return module.Version{}, fmt.Errorf("module proxy returned an invalid version: %w", err)
+		}
+		return mod, nil
+	}
+	return module.Version{}, fmt.Errorf("%w: no module in the Go module proxy provides %s@%s",
+		domain.ErrPackageNotFound, packagePath, version)
+}
+
+// fetchModuleInfo queries the module proxy for a version of modulePath.
+func (v *Verifier) fetchModuleInfo(ctx context.Context, modulePath, version string) (*moduleInfo, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module path %q: %w", modulePath, err)
+	}
+	infoURL := fmt.Sprintf("%s/%s/@latest", v.proxyURL, escapedPath)
+	if version != latestVersion {
+		escapedVersion, err := module.EscapeVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid module version %q: %w", version, err)
+		}
+		infoURL = fmt.Sprintf("%s/%s/@v/%s.info", v.proxyURL, escapedPath, escapedVersion)
+	}
+
+	resp, err := v.get(ctx, infoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query module proxy: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var info moduleInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode module info: %w", err)
+	}
+	if info.Version == "" {
+		return nil, fmt.Errorf("module info for %s@%s has no version", modulePath, version)
+	}
+	return &info, nil
+}
+
+// moduleZipHash downloads the module zip from the proxy and returns its h1:
+// hash, the hash recorded in go.sum and the checksum database.
+func (v *Verifier) moduleZipHash(ctx context.Context, mod module.Version) (string, error) {
+	escapedPath, err := module.EscapePath(mod.Path)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := module.EscapeVersion(mod.Version)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := v.get(ctx, fmt.Sprintf("%s/%s/@v/%s.zip", v.proxyURL, escapedPath, escapedVersion))
+	if err != nil {
+		return "", fmt.Errorf("failed to download module zip: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := httpclient.LimitBody(resp, v.maxDownload)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read module zip: %w", err)
+	}
+	return hashZip(data)
+}
+
+// hashZip returns the h1: hash of a module zip held in memory, computed the
+// same way as dirhash.HashZip.
+func hashZip(data []byte) (string, error) {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("invalid module zip: %w", err)
+	}
+	files := make([]string, 0, len(z.File))
+	zfiles := make(map[string]*zip.File, len(z.File))
+	for _, file := range z.File {
+		files = append(files, file.Name)
+		zfiles[file.Name] = file
+	}
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return zfiles[name].Open()
+	})
+}
+
+// lookupChecksum returns the h1: hash the checksum database records for the
+// module zip, after verifying the record's inclusion in the signed log.
+func (v *Verifier) lookupChecksum(ctx context.Context, mod module.Version) (string, error) {
+	client := sumdb.NewClient(newSumdbOps(ctx, v))
+	lines, err := client.Lookup(mod.Path, mod.Version)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up module in the checksum database: %w", err)
+	}
+	// Lookup returns only the lines of this version, not its /go.mod line
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) == 3 {
+			return fields[2], nil
+		}
+	}
+	return "", fmt.Errorf("checksum database has no hash for %s@%s", mod.Path, mod.Version)
+}
+
+// get fetches rawURL, reporting 404 and 410, the module proxy's answers for
+// unknown modules and versions, as errNotFound.
+func (v *Verifier) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := v.httpClient.Do(req) //nolint:gosec // G704 — URL built from the configured proxy or checksum database
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound, http.StatusGone:
+		resp.Body.Close()
+		return nil, errNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+}
+
+// checksumDBName returns the server name of a checksum database verifier key.
+func checksumDBName(key string) string {
+	name, _, _ := strings.Cut(key, "+")
+	return name
+}
@@ -0,0 +1,296 @@
+package gomod
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// handlerTransport serves every request from a handler, standing in for the
+// module proxy and checksum database.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+// moduleZip builds the zip of a module version, with its files under path@version/.
+func moduleZip(t *testing.T, mod module.Version, source string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := map[string]string{
+		"go.mod":  "module " + mod.Path + "\n",
+		"main.go": source,
+	}
+	for name, content := range files {
+		f, err := w.Create(mod.Path + "@" + mod.Version + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipHash hashes a module zip from disk with dirhash, independently of hashZip.
+func zipHash(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "module.zip")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := dirhash.HashZip(path, dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// testModule is a module version served by the fake proxy. logged is the zip
+// the checksum database hashed, which differs from zip for a tampered module.
+type testModule struct {
+	mod    module.Version
+	zip    []byte
+	logged []byte
+	latest bool
+}
+
+// newTestVerifier returns a verifier backed by a fake module proxy serving
+// modules and a checksum database logging their hashes.
+func newTestVerifier(t *testing.T, modules []testModule) *Verifier {
+	t.Helper()
+	signer, verifierKey, err := note.GenerateKey(rand.Reader, "sum.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byVersion := make(map[module.Version]testModule)
+	latest := make(map[string]string)
+	for _, m := range modules {
+		byVersion[m.mod] = m
+		if m.latest {
+			latest[m.mod.Path] = m.mod.Version
+		}
+	}
+
+	gosum := func(path, vers string) ([]byte, error) {
+		m, ok := byVersion[module.Version{Path: path, Version: vers}]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return fmt.Appendf(nil, "%s %s %s\n%s %s/go.mod h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=\n",
+			path, vers, zipHash(t, m.logged), path, vers), nil
+	}
+	sumdbHandler := sumdb.NewServer(sumdb.NewTestServer(signer, gosum))
+
+	proxyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		escapedPath, query, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/@")
+		modulePath, err := module.UnescapePath(escapedPath)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if query == "latest" {
+			version, ok := latest[modulePath]
+			if !ok {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"Version":%q}`, version)
+			return
+		}
+
+		file := strings.TrimPrefix(query, "v/")
+		ext := filepath.Ext(file)
+		version, err := module.UnescapeVersion(strings.TrimSuffix(file, ext))
+		m, ok := byVersion[module.Version{Path: modulePath, Version: version}]
+		if err != nil || !ok {
+			http.Error(w, "not found", http.StatusGone)
+			return
+		}
+		switch ext {
+		case ".info":
+			_, _ = fmt.Fprintf(w, `{"Version":%q}`, version)
+		case ".zip":
+			_, _ = w.Write(m.zip)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host == "sum.example" {
+			sumdbHandler.ServeHTTP(w, r)
+			return
+		}
+		proxyHandler.ServeHTTP(w, r)
+	})
+
+	return &Verifier{
+		httpClient: httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		proxyURL:   "https://proxy.example",
+		sumdbURL:   "https://sum.example",
+		sumdbKey:   verifierKey,
+		logger:     slog.New(slog.DiscardHandler),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	tool := module.Version{Path: "github.com/Example/tool", Version: "v1.2.3"}
+	toolNext := module.Version{Path: "github.com/Example/tool", Version: "v1.3.0"}
+	incompatible := module.Version{Path: "github.com/example/legacy", Version: "v2.0.1+incompatible"}
+	pseudo := module.Version{Path: "github.com/example/unreleased", Version: "v0.0.0-20250102030405-abcdef012345"}
+	tampered := module.Version{Path: "github.com/example/tampered", Version: "v1.0.0"}
+
+	modules := []testModule{
+		{mod: tool, zip: moduleZip(t, tool, "package main\n")},
+		{mod: toolNext, zip: moduleZip(t, toolNext, "package main // next\n"), latest: true},
+		{mod: incompatible, zip: moduleZip(t, incompatible, "package legacy\n")},
+		{mod: pseudo, zip: moduleZip(t, pseudo, "package unreleased\n")},
+		{
+			mod:    tampered,
+			zip:    moduleZip(t, tampered, "package main // backdoor\n"),
+			logged: moduleZip(t, tampered, "package main\n"),
+		},
+	}
+	for i := range modules {
+		if modules[i].logged == nil {
+			modules[i].logged = modules[i].zip
+		}
+	}
+	v := newTestVerifier(t, modules)
+
+	tests := []struct {
+		name        string
+		pkg         string
+		version     string
+		wantModule  module.Version
+		wantStatus  domain.ProvenanceStatus
+		wantHash    []byte
+		wantErr     error
+		wantMessage string
+	}{
+		{
+			name:       "package within module",
+			pkg:        "github.com/Example/tool/cmd/server",
+			version:    "v1.2.3",
+			wantModule: tool,
+			wantStatus: domain.ProvenanceStatusSignatures,
+			wantHash:   modules[0].zip,
+		},
+		{
+			name:       "inline version",
+			pkg:        "github.com/Example/tool@v1.2.3",
+			wantModule: tool,
+			wantStatus: domain.ProvenanceStatusSignatures,
+			wantHash:   modules[0].zip,
+		},
+		{
+			name:       "latest",
+			pkg:        "github.com/Example/tool/cmd/server",
+			version:    "latest",
+			wantModule: toolNext,
+			wantStatus: domain.ProvenanceStatusSignatures,
+			wantHash:   modules[1].zip,
+		},
+		{
+			name:       "incompatible version",
+			pkg:        "github.com/example/legacy",
+			version:    "v2.0.1+incompatible",
+			wantModule: incompatible,
+			wantStatus: domain.ProvenanceStatusSignatures,
+			wantHash:   modules[2].zip,
+		},
+		{
+			name:       "pseudo-version",
+			pkg:        "github.com/example/unreleased",
+			version:    "v0.0.0-20250102030405-abcdef012345",
+			wantModule: pseudo,
+			wantStatus: domain.ProvenanceStatusSignatures,
+			wantHash:   modules[3].zip,
+		},
+		{
+			name:        "tampered zip",
+			pkg:         "github.com/example/tampered",
+			version:     "v1.0.0",
+			wantModule:  tampered,
+			wantStatus:  domain.ProvenanceStatusError,
+			wantHash:    modules[4].zip,
+			wantMessage: zipHash(t, modules[4].logged),
+		},
+		{
+			name:       "missing version",
+			pkg:        "github.com/Example/tool",
+			version:    "v9.9.9",
+			wantStatus: domain.ProvenanceStatusError,
+			wantErr:    domain.ErrPackageNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := v.Verify(context.Background(), domain.PackageIdentifier{
+				Protocol: domain.ProtocolGo,
+				Name:     tt.pkg,
+				Version:  tt.version,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+				if result.Details["not_found"] != "module" {
+					t.Errorf("not_found = %v, want module", result.Details["not_found"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s (%s)", result.Status, tt.wantStatus, result.ErrorMessage)
+			}
+			if result.Details["module"] != tt.wantModule.Path || result.Details["module_version"] != tt.wantModule.Version {
+				t.Errorf("module = %v@%v, want %s", result.Details["module"], result.Details["module_version"], tt.wantModule)
+			}
+			wantHash := zipHash(t, tt.wantHash)
+			if result.Details["module_hash"] != wantHash {
+				t.Errorf("module_hash = %v, want %s", result.Details["module_hash"], wantHash)
+			}
+			if tt.wantMessage != "" &&
+				(!strings.Contains(result.ErrorMessage, tt.wantMessage) || !strings.Contains(result.ErrorMessage, wantHash)) {
+				t.Errorf("ErrorMessage = %q, want both %s and %s", result.ErrorMessage, wantHash, tt.wantMessage)
+			}
+		})
+	}
+}
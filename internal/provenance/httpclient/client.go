@@ -0,0 +1,369 @@
+// Package httpclient provides the HTTP client shared by the provenance verifiers,
+// adding registry rate-limit handling on top of net/http.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultTimeout is the per-request timeout used when no client is supplied.
+	defaultTimeout = 30 * time.Second
+	// defaultMaxRetries is how many times a 429 or 503 response is retried.
+	defaultMaxRetries = 3
+	// defaultBackoff is the first retry delay when the registry sends no Retry-After.
+	defaultBackoff = time.Second
+	// maxRetryDelay caps any single wait so a hostile Retry-After cannot stall us indefinitely.
+	maxRetryDelay = time.Minute
+)
+
+// ErrRateLimited is returned when a registry keeps answering 429 after all retries.
+var ErrRateLimited = errors.New("registry rate limit exceeded")
+
+// ErrUnavailable is returned when a registry keeps answering 503 after all retries.
+var ErrUnavailable = errors.New("registry unavailable")
+
+// Client performs HTTP requests against package registries, backing off on
+// 429 and 503 responses and optionally throttling outgoing requests.
+type Client struct {
+	httpClient *http.Client
+	limiter    *rate.Limiter
+	maxRetries int
+	backoff    time.Duration
+	sleep      func(*http.Request, time.Duration) error
+	// jitter returns a random duration in [0, d]
+	jitter func(d time.Duration) time.Duration
+	logger *slog.Logger
+	// headers are set on the requests to their host, which they are keyed by
+	headers map[string]http.Header
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying *http.Client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithTimeout sets the per-request timeout of the underlying *http.Client. A
+// non-positive value leaves the default in place.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.httpClient.Timeout = d
+		}
+	}
+}
+
+// WithMaxRetries sets how many times a rate-limited request is retried.
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		if n >= 0 {
+			c.maxRetries = n
+		}
+	}
+}
+
+// WithRateLimit throttles outgoing requests with a token bucket that refills at
+// requestsPerSecond and holds up to burst tokens. A non-positive rate disables it.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) {
+		if requestsPerSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+}
+
+// WithLogger sets the logger used for per-request debug output. Only the
+// method, host, and path of a request are logged, never headers or query
+// strings, so credentials cannot leak into logs.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithHeader sets a header on every request to host, e.g. the API key of a
+// gateway in front of the registry at host, such as registry.npmjs.org.
+// Requests to any other host, including downloads and redirect targets, are
+// sent without it, so credentials never reach third parties. A header given
+// several times is sent with every value, and replaces any value the caller
+// set on the request. Like all headers, the values are never logged.
+func WithHeader(host, key, value string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(map[string]http.Header)
+		}
+		if c.headers[host] == nil {
+			c.headers[host] = make(http.Header)
+		}
+		c.headers[host].Add(key, value)
+	}
+}
+
+// WithJitterSeed seeds the random source that spreads out backoff delays, so
+// a test sees the same delays on every run. By default the source is seeded
+// randomly, so clients retrying at the same moment wait different times.
+func WithJitterSeed(seed uint64) Option {
+	return func(c *Client) {
+		var mu sync.Mutex
+		r := rand.New(rand.NewPCG(seed, seed))
+		c.jitter = func(d time.Duration) time.Duration {
+			mu.Lock()
+			defer mu.Unlock()
+			return time.Duration(r.Int64N(int64(d) + 1))
+		}
+	}
+}
+
+// fullJitter returns a random duration in [0, d] from the global source.
+func fullJitter(d time.Duration) time.Duration {
+	return rand.N(d + 1)
+}
+
+// New creates a Client with the given options applied.
+func New(opts ...Option) *Client {
+	c := &Client{
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+		sleep:      sleepContext,
+		jitter:     fullJitter,
+		logger:     slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if len(c.headers) > 0 {
+		// Headers are set per hop, so a redirect to another host does not
+		// carry them; the caller's client is copied rather than changed
+		httpClient := *c.httpClient
+		httpClient.Transport = &headerTransport{base: httpClient.Transport, headers: c.headers}
+		c.httpClient = &httpClient
+	}
+	return c
+}
+
+// headerTransport sets the headers configured for a host on every request to it.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]http.Header
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	headers, ok := t.headers[req.URL.Host]
+	if !ok {
+		return base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for key, values := range headers {
+		req.Header[key] = slices.Clone(values)
+	}
+	return base.RoundTrip(req)
+}
+
+// Do sends req, waiting for the rate limiter first. When the registry answers
+// 429 or 503 the request is retried after the delay given by Retry-After (or a
+// random delay of up to an exponential backoff when absent) up to the
+// configured retry limit. Only requests without a body, or with GetBody set,
+// can be retried.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(req.Context()); err != nil {
+				return nil, fmt.Errorf("waiting for rate limiter: %w", err)
+			}
+		}
+
+		c.logger.DebugContext(req.Context(), "sending registry request",
+			"method", req.Method, "url", redactURL(req), "attempt", attempt+1)
+		start := time.Now()
+		resp, err := c.httpClient.Do(req) //nolint:gosec // G704 — callers validate URLs against their allowlists
+		if err != nil {
+			c.logger.DebugContext(req.Context(), "registry request failed", "url", redactURL(req), "error", err)
+			return nil, err
+		}
+		c.logger.DebugContext(req.Context(), "registry response",
+			"url", redactURL(req), "status", resp.StatusCode, "duration", time.Since(start))
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+			return resp, nil
+		}
+
+		// Without Retry-After, back off exponentially with full jitter, so
+		// clients rate limited together do not all retry together
+		delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			delay = c.jitter(backoffDelay(c.backoff, attempt))
+		}
+		delay = min(delay, maxRetryDelay)
+		discard(resp)
+
+		if attempt >= c.maxRetries {
+			sentinel := ErrRateLimited
+			if resp.StatusCode == http.StatusServiceUnavailable {
+				sentinel = ErrUnavailable
+			}
+			return nil, fmt.Errorf("%w: %s %s still returned %d after %d retries",
+				sentinel, req.Method, req.URL.Host+req.URL.Path, resp.StatusCode, c.maxRetries)
+		}
+
+		c.logger.DebugContext(req.Context(), "registry busy, retrying",
+			"url", redactURL(req), "status", resp.StatusCode, "delay", delay)
+		if err := c.sleep(req, delay); err != nil {
+			return nil, fmt.Errorf("waiting to retry request: %w", err)
+		}
+
+		if req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rewinding request body: %w", err)
+			}
+			req.Body = body
+		}
+	}
+}
+
+// backoffDelay returns backoff doubled attempt times, capped at
+// maxRetryDelay. The cap is checked before shifting, so that no retry limit
+// can overflow the delay into a negative duration.
+func backoffDelay(backoff time.Duration, attempt int) time.Duration {
+	if backoff > maxRetryDelay>>attempt {
+		return maxRetryDelay
+	}
+	return backoff << attempt
+}
+
+// Warmup sends a HEAD request to rawURL and discards the response, leaving an
+// idle keep-alive connection (including its TLS session) in the pool for the
+// requests that follow. Any HTTP status counts as success.
+func (c *Client) Warmup(ctx context.Context, rawURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create warm-up request: %w", err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to warm up connection to %s: %w", req.URL.Host, err)
+	}
+	discard(resp)
+	return nil
+}
+
+// redactURL renders a request URL for logging, dropping user info and the query
+// string, where registry tokens would live.
+func redactURL(req *http.Request) string {
+	return req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+}
+
+// parseRetryAfter interprets a Retry-After header value, which may be either a
+// number of seconds or an HTTP-date. ok is false when the value is absent or
+// malformed. Dates in the past yield a zero delay.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	when, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(when.Sub(now), 0), true
+}
+
+// discard drains and closes a response body so the connection can be reused.
+func discard(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+}
+
+// sleepContext waits for d or until the request context is done.
+func sleepContext(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// DefaultMaxDownloadSize is the largest artifact the verifiers download when
+// no other limit is configured.
+const DefaultMaxDownloadSize int64 = 500 << 20
+
+// ErrArtifactTooLarge is returned when a download exceeds its size limit.
+var ErrArtifactTooLarge = errors.New("artifact exceeds max size")
+
+// LimitBody returns a reader over resp.Body that fails with ErrArtifactTooLarge
+// once more than maxSize bytes have been read. A response whose Content-Length
+// already exceeds maxSize is rejected before anything is read. A non-positive
+// maxSize means DefaultMaxDownloadSize.
+func LimitBody(resp *http.Response, maxSize int64) (io.Reader, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDownloadSize
+	}
+	if resp.ContentLength > maxSize {
+		return nil, fmt.Errorf("%w: Content-Length %d is over the %d byte limit",
+			ErrArtifactTooLarge, resp.ContentLength, maxSize)
+	}
+	return &limitedReader{r: resp.Body, remaining: maxSize, max: maxSize}, nil
+}
+
+// limitedReader is an io.LimitReader that reports overflowing the limit as an
+// error instead of a silent EOF, so a truncated artifact is never hashed.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrArtifactTooLarge, l.max)
+	}
+	// Read one byte past the limit to tell "exactly max" from "too large"
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), fmt.Errorf("%w: more than %d bytes", ErrArtifactTooLarge, l.max)
+	}
+	return n, err
+}
@@ -0,0 +1,75 @@
+package httpclient
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// AcceptEncoding lists the content codings DecodeBody understands.
+const AcceptEncoding = "gzip, deflate"
+
+// AcceptCompressed asks for a compressed response. Setting the header turns
+// off the transparent decompression of http.Transport, so the response body
+// must be read through DecodeBody, which then works the same whether or not
+// the underlying client decompresses on its own.
+func AcceptCompressed(req *http.Request) {
+	req.Header.Set("Accept-Encoding", AcceptEncoding)
+}
+
+// DecodeBody returns a reader over resp.Body with its Content-Encoding
+// removed. Closing the reader closes resp.Body.
+func DecodeBody(resp *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip response: %w", err)
+		}
+		return &decodedBody{Reader: zr, decoder: zr, body: resp.Body}, nil
+	case "deflate":
+		return decodeDeflate(resp.Body)
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", encoding)
+	}
+}
+
+// decodeDeflate decodes a deflate coded body. HTTP defines it as zlib-wrapped,
+// but some servers send a bare deflate stream, so the zlib header is sniffed.
+func decodeDeflate(body io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(body)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode deflate response: %w", err)
+		}
+		return &decodedBody{Reader: zr, decoder: zr, body: body}, nil
+	}
+	fr := flate.NewReader(br)
+	return &decodedBody{Reader: fr, decoder: fr, body: body}, nil
+}
+
+// decodedBody reads a decompressed response and closes both the decompressor
+// and the underlying body.
+type decodedBody struct {
+	io.Reader
+	decoder io.Closer
+	body    io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	decErr := d.decoder.Close()
+	if err := d.body.Close(); err != nil {
+		return err
+	}
+	return decErr
+}
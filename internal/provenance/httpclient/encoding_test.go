@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestDecodeBody(t *testing.T) {
+	t.Parallel()
+
+	const payload = `{"name":"example"}`
+	compress := func(newWriter func(io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		_, _ = w.Write([]byte(payload))
+		_ = w.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		wantErr  bool
+	}{
+		{name: "identity", body: []byte(payload)},
+		{name: "gzip", encoding: "gzip",
+			body: compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })},
+		{name: "zlib deflate", encoding: "deflate",
+			body: compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })},
+		{name: "raw deflate", encoding: "Deflate",
+			body: compress(func(w io.Writer) io.WriteCloser { fw, _ := flate.NewWriter(w, flate.DefaultCompression); return fw })},
+		{name: "corrupt gzip", encoding: "gzip", body: []byte(payload), wantErr: true},
+		{name: "unsupported", encoding: "br", body: []byte(payload), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(tt.body))}
+			if tt.encoding != "" {
+				resp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			body, err := DecodeBody(resp)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("DecodeBody() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeBody() error: %v", err)
+			}
+			defer body.Close()
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("reading decoded body: %v", err)
+			}
+			if string(got) != payload {
+				t.Errorf("decoded body = %q, want %q", got, payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+const (
+	// attestationKindProvenance is a SLSA provenance attestation produced by the publishing CI workflow
+	attestationKindProvenance = "provenance"
+	// attestationKindPublish is the publish attestation npm itself signs when a version is uploaded
+	attestationKindPublish = "publish"
+)
+
+// attestationsResponse is the body returned by npm's attestations endpoint
+type attestationsResponse struct {
+	Attestations []npmAttestation `json:"attestations"`
+}
+
+// npmAttestation is a single entry of the attestations endpoint
+type npmAttestation struct {
+	PredicateType string          `json:"predicateType"`
+	Bundle        json.RawMessage `json:"bundle"`
+}
+
+// attestationOutcome records the verification result of a single attestation
+type attestationOutcome struct {
+	kind          string
+	predicateType string
+	publisher     *domain.TrustedPublisher
+	subject       *sigstore.Subject
+	// repository is the source repository the verified provenance names
+	repository string
+	// raw is the full Sigstore verification result, once the attestation verified
+	raw *verify.VerificationResult
+	err error
+}
+
+// parseAttestations decodes attestation data into individual attestations. The
+// registry serves an {"attestations": [...]} array; a bare Sigstore bundle is
+// accepted as a single attestation whose type is taken from its signed statement.
+func parseAttestations(data []byte) ([]npmAttestation, error) {
+	var resp attestationsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode attestations: %w", err)
+	}
+	if len(resp.Attestations) > 0 {
+		return resp.Attestations, nil
+	}
+	return []npmAttestation{{Bundle: data}}, nil
+}
+
+// attestationKind classifies an attestation by its in-toto predicate type.
+func attestationKind(predicateType string) string {
+	switch {
+	case strings.HasPrefix(predicateType, "https://slsa.dev/provenance/"):
+		return attestationKindProvenance
+	case strings.HasPrefix(predicateType, "https://github.com/npm/attestation/tree/main/specs/publish/"):
+		return attestationKindPublish
+	case predicateType == "":
+		return "unknown"
+	default:
+		return predicateType
+	}
+}
+
+// applyAttestationOutcomes aggregates per-attestation outcomes into result.
+// The status is Verified only when a provenance attestation verifies; a
+// verified publish attestation alone proves the upload came through npm, not
+// which source and workflow built it. With a requiredType, only attestations
+// of that type count, and the result is an error when there is none.
+func applyAttestationOutcomes(result *domain.ProvenanceResult, outcomes []attestationOutcome, requiredType string) {
+	result.HasAttestations = true
+	result.AttestationCount = len(outcomes)
+	result.Status = domain.ProvenanceStatusAttestations
+	result.Details["attestation_types"] = attestationTypes(outcomes)
+
+	// counts reports whether an outcome decides the status
+	counts := func(outcome attestationOutcome) bool {
+		if requiredType != "" {
+			return outcome.matchesType(requiredType)
+		}
+		return outcome.kind == attestationKindProvenance
+	}
+
+	statuses := make(map[string]string, len(outcomes))
+	var provenanceErr error
+	found := false
+	for _, outcome := range outcomes {
+		key := outcome.kind
+		for i := 2; statuses[key] != ""; i++ {
+			key = fmt.Sprintf("%s#%d", outcome.kind, i)
+		}
+
+		if requiredType != "" && !outcome.matchesType(requiredType) {
+			statuses[key] = "ignored"
+			continue
+		}
+		found = found || counts(outcome)
+
+		if outcome.err != nil {
+			statuses[key] = "failed: " + outcome.err.Error()
+			if counts(outcome) && provenanceErr == nil {
+				provenanceErr = outcome.err
+			}
+			continue
+		}
+
+		statuses[key] = "verified"
+		result.VerifiedAttestationCount++
+		if counts(outcome) && result.Status != domain.ProvenanceStatusVerified {
+			result.Status = domain.ProvenanceStatusVerified
+			result.TrustedPublisher = outcome.publisher
+			if outcome.subject != nil {
+				result.Details["subject_name"] = outcome.subject.Name
+				result.Details["subject_digest"] = outcome.subject.Digest
+			}
+			if outcome.repository != "" {
+				result.Details["provenance_repository"] = outcome.repository
+			}
+		}
+	}
+	result.Details["attestations"] = statuses
+
+	switch {
+	case result.Status == domain.ProvenanceStatusVerified:
+	case provenanceErr != nil:
+		result.ErrorMessage = fmt.Sprintf("provenance attestation verification failed: %v", provenanceErr)
+		result.Details["verification_error"] = provenanceErr.Error()
+	case requiredType != "" && !found:
+		// Other attestations verifying does not make up for the required one
+		result.Status = domain.ProvenanceStatusError
+		result.ErrorMessage = fmt.Sprintf("no attestation of type %s found", requiredType)
+		result.Details["required_attestation_type"] = "missing"
+	default:
+		result.Details["provenance_attestation"] = "missing"
+	}
+}
+
+// matchesType reports whether the attestation is of the given type, either an
+// exact predicate type or one of the "provenance" and "publish" kinds.
+func (o attestationOutcome) matchesType(attestationType string) bool {
+	return o.predicateType == attestationType || o.kind == attestationType
+}
+
+// attestationTypes returns the sorted, distinct predicate types of outcomes.
+func attestationTypes(outcomes []attestationOutcome) []string {
+	types := make([]string, 0, len(outcomes))
+	for _, outcome := range outcomes {
+		if outcome.predicateType != "" {
+			types = append(types, outcome.predicateType)
+		}
+	}
+	slices.Sort(types)
+	return slices.Compact(types)
+}
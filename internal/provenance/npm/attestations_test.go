@@ -0,0 +1,138 @@
+package npm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestParseAttestations(t *testing.T) {
+	t.Parallel()
+
+	array := `{"attestations":[
+		{"predicateType":"https://github.com/npm/attestation/tree/main/specs/publish/v0.1","bundle":{"mediaType":"a"}},
+		{"predicateType":"https://slsa.dev/provenance/v1","bundle":{"mediaType":"b"}}
+	]}`
+	got, err := parseAttestations([]byte(array))
+	if err != nil {
+		t.Fatalf("parseAttestations(array) error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("parseAttestations(array) returned %d attestations, want 2", len(got))
+	}
+	if kind := attestationKind(got[0].PredicateType); kind != attestationKindPublish {
+		t.Errorf("attestationKind(%q) = %q, want %q", got[0].PredicateType, kind, attestationKindPublish)
+	}
+	if kind := attestationKind(got[1].PredicateType); kind != attestationKindProvenance {
+		t.Errorf("attestationKind(%q) = %q, want %q", got[1].PredicateType, kind, attestationKindProvenance)
+	}
+
+	bare := `{"mediaType":"application/vnd.dev.sigstore.bundle.v0.3+json"}`
+	got, err = parseAttestations([]byte(bare))
+	if err != nil {
+		t.Fatalf("parseAttestations(bare) error: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Bundle) != bare {
+		t.Errorf("parseAttestations(bare) = %+v, want the bundle as a single attestation", got)
+	}
+
+	if _, err := parseAttestations([]byte("not json")); err == nil {
+		t.Error("parseAttestations(invalid) = nil error, want error")
+	}
+}
+
+func TestApplyAttestationOutcomes(t *testing.T) {
+	t.Parallel()
+
+	publisher := &domain.TrustedPublisher{Kind: "Verified"}
+	failure := errors.New("bad signature")
+
+	const slsaV1 = "https://slsa.dev/provenance/v1"
+	tests := []struct {
+		name         string
+		outcomes     []attestationOutcome
+		requiredType string
+		wantStatus   domain.ProvenanceStatus
+		wantError    bool
+		// wantRepository is the provenance_repository detail, if any
+		wantRepository string
+	}{
+		{
+			name: "provenance verified",
+			outcomes: []attestationOutcome{
+				{kind: attestationKindPublish, err: failure},
+				{kind: attestationKindProvenance, publisher: publisher, repository: "https://github.com/upstash/context7"},
+			},
+			wantStatus:     domain.ProvenanceStatusVerified,
+			wantRepository: "https://github.com/upstash/context7",
+		},
+		{
+			name: "only publish verified",
+			outcomes: []attestationOutcome{
+				{kind: attestationKindPublish, publisher: publisher, repository: "https://github.com/upstash/context7"},
+			},
+			wantStatus: domain.ProvenanceStatusAttestations,
+		},
+		{
+			name: "provenance failed",
+			outcomes: []attestationOutcome{
+				{kind: attestationKindPublish, publisher: publisher},
+				{kind: attestationKindProvenance, err: failure},
+			},
+			wantStatus: domain.ProvenanceStatusAttestations,
+			wantError:  true,
+		},
+		{
+			name: "required type verified",
+			outcomes: []attestationOutcome{
+				{kind: attestationKindPublish, err: failure},
+				{kind: attestationKindProvenance, predicateType: slsaV1, publisher: publisher},
+			},
+			requiredType: slsaV1,
+			wantStatus:   domain.ProvenanceStatusVerified,
+		},
+		{
+			name:         "required kind verified",
+			outcomes:     []attestationOutcome{{kind: attestationKindPublish, publisher: publisher}},
+			requiredType: attestationKindPublish,
+			wantStatus:   domain.ProvenanceStatusVerified,
+		},
+		{
+			name: "required type absent",
+			outcomes: []attestationOutcome{
+				{kind: attestationKindPublish, publisher: publisher},
+				{kind: attestationKindProvenance, predicateType: "https://slsa.dev/provenance/v0.2", publisher: publisher},
+			},
+			requiredType: slsaV1,
+			wantStatus:   domain.ProvenanceStatusError,
+			wantError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := &domain.ProvenanceResult{Details: make(map[string]interface{})}
+			applyAttestationOutcomes(result, tt.outcomes, tt.requiredType)
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.AttestationCount != len(tt.outcomes) {
+				t.Errorf("AttestationCount = %d, want %d", result.AttestationCount, len(tt.outcomes))
+			}
+			if got, _ := result.Details["provenance_repository"].(string); got != tt.wantRepository {
+				t.Errorf("Details[provenance_repository] = %q, want %q", got, tt.wantRepository)
+			}
+			if (result.ErrorMessage != "") != tt.wantError {
+				t.Errorf("ErrorMessage = %q, wantError %v", result.ErrorMessage, tt.wantError)
+			}
+			statuses, ok := result.Details["attestations"].(map[string]string)
+			if !ok || len(statuses) != len(tt.outcomes) {
+				t.Errorf("Details[attestations] = %v, want one entry per attestation", result.Details["attestations"])
+			}
+		})
+	}
+}
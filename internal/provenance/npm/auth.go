@@ -0,0 +1,66 @@
+package npm
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// registryToken is a bearer token for the registries whose host matches hostPattern.
+type registryToken struct {
+	hostPattern string
+	token       string
+}
+
+// do sends req with the bearer token of the first registry token matching its
+// host. Go's HTTP client drops the Authorization header on redirects to
+// another host, so the token does not follow a tarball to a CDN.
+func (v *Verifier) do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	for _, t := range v.tokens {
+		if matched, _ := path.Match(t.hostPattern, host); matched && t.token != "" {
+			req.Header.Set("Authorization", "Bearer "+t.token)
+			break
+		}
+	}
+
+	resp, err := v.httpClient.Do(req) //nolint:gosec // G704 — URL validated against allowlist by validateURL
+	if err != nil {
+		return nil, v.redact(err)
+	}
+	return resp, nil
+}
+
+// redact replaces every registry token in err's message, e.g. one echoed in a
+// request URL, with a placeholder. The original error stays reachable through
+// errors.Is and errors.As.
+func (v *Verifier) redact(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	redacted := msg
+	for _, t := range v.tokens {
+		if t.token != "" {
+			redacted = strings.ReplaceAll(redacted, t.token, "[REDACTED]")
+		}
+	}
+	if redacted == msg {
+		return err
+	}
+	return &redactedError{msg: redacted, err: err}
+}
+
+// redactedError is an error whose message has had registry tokens removed.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
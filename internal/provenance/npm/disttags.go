@@ -0,0 +1,58 @@
+package npm
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// resolveVersion returns the metadata of the requested version. A request
+// that is not a version of the package but one of its dist-tags, such as
+// latest or next, resolves to the version the tag points at; tag is then the
+// tag name. An empty request means latest, as it does for npx. The error
+// wraps domain.ErrVersionNotFound.
+func resolveVersion(metadata *PackageMetadata, requested string) (versionData VersionMetadata, tag string, err error) {
+	if requested == "" {
+		requested = latestTag
+	}
+	if versionData, ok := metadata.Versions[requested]; ok {
+		return versionData, "", nil
+	}
+
+	if version, ok := metadata.DistTags[requested]; ok {
+		versionData, ok := metadata.Versions[version]
+		if !ok {
+			return VersionMetadata{}, requested, fmt.Errorf("%w: dist-tag %s of %s points at missing version %s",
+				domain.ErrVersionNotFound, requested, metadata.Name, version)
+		}
+		return versionData, requested, nil
+	}
+
+	// Tag names cannot be valid semver, so anything else was meant as a tag
+	if !semver.IsValid("v" + requested) {
+		return VersionMetadata{}, requested, fmt.Errorf("%w: dist-tag %q not found in %s (tags: %s)",
+			domain.ErrVersionNotFound, requested, metadata.Name,
+			strings.Join(slices.Sorted(maps.Keys(metadata.DistTags)), ", "))
+	}
+	return VersionMetadata{}, "", fmt.Errorf("%w: version %s not found in package %s",
+		domain.ErrVersionNotFound, requested, metadata.Name)
+}
+
+// latestTag is the dist-tag npm installs when no version is given.
+const latestTag = "latest"
+
+// sortedVersions returns the published versions of a package in semver order,
+// oldest first; versions that are not valid semver sort first, by name.
+func sortedVersions(metadata *PackageMetadata) []string {
+	return slices.SortedFunc(maps.Keys(metadata.Versions), func(a, b string) int {
+		if c := semver.Compare("v"+a, "v"+b); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+}
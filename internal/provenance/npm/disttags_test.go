@@ -0,0 +1,71 @@
+package npm
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestResolveVersion(t *testing.T) {
+	t.Parallel()
+
+	metadata := &PackageMetadata{
+		Name: "example",
+		Versions: map[string]VersionMetadata{
+			"1.0.0":        {Version: "1.0.0"},
+			"2.0.0-beta.1": {Version: "2.0.0-beta.1"},
+		},
+		DistTags: map[string]string{"latest": "1.0.0", "next": "2.0.0-beta.1", "stale": "0.9.0"},
+	}
+
+	tests := []struct {
+		requested   string
+		wantVersion string
+		wantTag     string
+		wantErr     bool
+	}{
+		{requested: "1.0.0", wantVersion: "1.0.0"},
+		{requested: "latest", wantVersion: "1.0.0", wantTag: "latest"},
+		{requested: "", wantVersion: "1.0.0", wantTag: "latest"},
+		{requested: "next", wantVersion: "2.0.0-beta.1", wantTag: "next"},
+		{requested: "beta", wantTag: "beta", wantErr: true},
+		{requested: "stale", wantTag: "stale", wantErr: true},
+		{requested: "3.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			t.Parallel()
+
+			got, tag, err := resolveVersion(metadata, tt.requested)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrVersionNotFound) {
+					t.Fatalf("resolveVersion(%q) error = %v, want ErrVersionNotFound", tt.requested, err)
+				}
+			} else if err != nil {
+				t.Fatalf("resolveVersion(%q) error: %v", tt.requested, err)
+			}
+			if got.Version != tt.wantVersion || tag != tt.wantTag {
+				t.Errorf("resolveVersion(%q) = %q, tag %q, want %q, tag %q", tt.requested, got.Version, tag, tt.wantVersion, tt.wantTag)
+			}
+		})
+	}
+}
+
+func TestSortedVersions(t *testing.T) {
+	t.Parallel()
+
+	metadata := &PackageMetadata{Versions: map[string]VersionMetadata{
+		"1.10.0":       {},
+		"1.2.0":        {},
+		"1.2.0-beta.1": {},
+		"0.9.1":        {},
+		"2.0.0":        {},
+	}}
+	want := []string{"0.9.1", "1.2.0-beta.1", "1.2.0", "1.10.0", "2.0.0"}
+	if got := sortedVersions(metadata); !slices.Equal(got, want) {
+		t.Errorf("sortedVersions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package npm
+
+import (
+	"bytes"
+	"crypto/sha1" //#nosec G505 -- npm's legacy dist.shasum is sha1; compared, not relied on alone
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// tarballDigests holds the digests computed while downloading a tarball.
+type tarballDigests struct {
+	sha1   []byte
+	sha512 []byte
+}
+
+// hashTarball computes every digest npm publishes for a tarball in one pass.
+func hashTarball(r io.Reader) (*tarballDigests, error) {
+	sha1Hasher := sha1.New() //#nosec G401 -- see import comment
+	sha512Hasher := sha512.New()
+	if _, err := io.Copy(io.MultiWriter(sha1Hasher, sha512Hasher), r); err != nil {
+		return nil, fmt.Errorf("failed to hash tarball: %w", err)
+	}
+	return &tarballDigests{
+		sha1:   sha1Hasher.Sum(nil),
+		sha512: sha512Hasher.Sum(nil),
+	}, nil
+}
+
+// integrityDigests decodes the sha512 digest from a registry dist.integrity
+// Subresource Integrity string so the tarball need not be downloaded. The
+// string must carry a sha512 entry; other algorithms are not accepted as the
+// artifact digest.
+func integrityDigests(integrity string) (*tarballDigests, error) {
+	for _, entry := range strings.Fields(integrity) {
+		alg, value, ok := strings.Cut(entry, "-")
+		if !ok || alg != "sha512" {
+			continue
+		}
+		value, _, _ = strings.Cut(value, "?")
+		digest, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sha512 integrity %q: %w", entry, err)
+		}
+		if len(digest) != sha512.Size {
+			return nil, fmt.Errorf("invalid sha512 integrity %q: got %d bytes, want %d", entry, len(digest), sha512.Size)
+		}
+		return &tarballDigests{sha512: digest}, nil
+	}
+	return nil, fmt.Errorf("unsupported integrity algorithm in %q: only sha512 is supported", integrity)
+}
+
+// ArtifactDigest returns the sha512 digest npm attestations sign for the
+// package tarball read from r.
+func ArtifactDigest(r io.Reader) ([]byte, error) {
+	digests, err := hashTarball(r)
+	if err != nil {
+		return nil, err
+	}
+	return digests.sha512, nil
+}
+
+// checkDistIntegrity compares the downloaded tarball's digests against the
+// registry's dist.shasum and dist.integrity, recording the outcome of each
+// comparison in details. It returns an error describing the first mismatch.
+func checkDistIntegrity(dist Dist, digests *tarballDigests, details map[string]interface{}) error {
+	var mismatches []string
+
+	if dist.Shasum != "" {
+		actual := hex.EncodeToString(digests.sha1)
+		match := strings.EqualFold(actual, dist.Shasum)
+		details["shasum_match"] = match
+		if !match {
+			mismatches = append(mismatches, fmt.Sprintf("shasum: expected %s, got %s", dist.Shasum, actual))
+		}
+	}
+
+	if dist.Integrity != "" {
+		match, checked := matchIntegrity(dist.Integrity, digests)
+		if checked {
+			details["integrity_match"] = match
+			if !match {
+				mismatches = append(mismatches, fmt.Sprintf("integrity: expected %s, got sha512-%s",
+					dist.Integrity, base64.StdEncoding.EncodeToString(digests.sha512)))
+			}
+		} else {
+			details["integrity_unsupported"] = dist.Integrity
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("tarball does not match registry digests (%s)", strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// matchIntegrity checks digests against a Subresource Integrity string, which
+// may list several space-separated "<alg>-<base64>[?opts]" entries. checked is
+// false when no entry uses an algorithm we computed; otherwise match reports
+// whether any supported entry agrees with the tarball.
+func matchIntegrity(integrity string, digests *tarballDigests) (match, checked bool) {
+	for _, entry := range strings.Fields(integrity) {
+		alg, value, ok := strings.Cut(entry, "-")
+		if !ok {
+			continue
+		}
+		value, _, _ = strings.Cut(value, "?")
+
+		var actual []byte
+		switch alg {
+		case "sha512":
+			actual = digests.sha512
+		case "sha1":
+			actual = digests.sha1
+		default:
+			continue
+		}
+
+		checked = true
+		expected, err := base64.StdEncoding.DecodeString(value)
+		if err == nil && bytes.Equal(expected, actual) {
+			return true, true
+		}
+	}
+	return false, checked
+}
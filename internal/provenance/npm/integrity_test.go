@@ -0,0 +1,121 @@
+package npm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //#nosec G505 -- test mirrors npm's legacy shasum
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+func TestCheckDistIntegrity(t *testing.T) {
+	t.Parallel()
+
+	tarball := "fake tarball contents"
+	digests, err := hashTarball(strings.NewReader(tarball))
+	if err != nil {
+		t.Fatalf("hashTarball: %v", err)
+	}
+
+	sha1Sum := sha1.Sum([]byte(tarball)) //#nosec G401 -- see import comment
+	sha512Sum := sha512.Sum512([]byte(tarball))
+	shasum := hex.EncodeToString(sha1Sum[:])
+	integrity := "sha512-" + base64.StdEncoding.EncodeToString(sha512Sum[:])
+	wrongIntegrity := "sha512-" + base64.StdEncoding.EncodeToString(make([]byte, sha512.Size))
+
+	tests := []struct {
+		name          string
+		dist          Dist
+		wantErr       bool
+		wantShasum    interface{}
+		wantIntegrity interface{}
+	}{
+		{"both match", Dist{Shasum: shasum, Integrity: integrity}, false, true, true},
+		{"multiple integrity entries", Dist{Integrity: "sha384-AAAA " + wrongIntegrity + " " + integrity + "?opt"}, false, nil, true},
+		{"shasum mismatch", Dist{Shasum: strings.Repeat("0", 40), Integrity: integrity}, true, false, true},
+		{"integrity mismatch", Dist{Shasum: shasum, Integrity: wrongIntegrity}, true, true, false},
+		{"unsupported algorithm only", Dist{Integrity: "sha384-AAAA"}, false, nil, nil},
+		{"nothing advertised", Dist{}, false, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			details := make(map[string]interface{})
+			err := checkDistIntegrity(tt.dist, digests, details)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkDistIntegrity() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := details["shasum_match"]; got != tt.wantShasum {
+				t.Errorf("shasum_match = %v, want %v", got, tt.wantShasum)
+			}
+			if got := details["integrity_match"]; got != tt.wantIntegrity {
+				t.Errorf("integrity_match = %v, want %v", got, tt.wantIntegrity)
+			}
+		})
+	}
+}
+
+func TestArtifactDigests(t *testing.T) {
+	t.Parallel()
+
+	tarball := "fake tarball contents"
+	sum := sha512.Sum512([]byte(tarball))
+	integrity := "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+	tarballURL := "https://registry.npmjs.org/pkg/-/pkg-1.0.0.tgz"
+
+	tests := []struct {
+		name           string
+		dist           Dist
+		download       bool
+		wantDownloaded bool
+		wantErr        bool
+	}{
+		{"registry integrity", Dist{Tarball: tarballURL, Integrity: "sha1-AAAA " + integrity}, false, false, false},
+		{"forced download", Dist{Tarball: tarballURL, Integrity: integrity}, true, true, false},
+		{"no integrity", Dist{Tarball: tarballURL}, false, true, false},
+		{"unsupported algorithm", Dist{Tarball: tarballURL, Integrity: "sha384-AAAA"}, false, false, true},
+		{"malformed digest", Dist{Tarball: tarballURL, Integrity: "sha512-not base64!"}, false, false, true},
+		{"truncated digest", Dist{Tarball: tarballURL, Integrity: "sha512-AAAA"}, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var downloads atomic.Int32
+			handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				downloads.Add(1)
+				_, _ = w.Write([]byte(tarball))
+			})
+			v := &Verifier{
+				httpClient:      httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+				logger:          slog.New(slog.DiscardHandler),
+				downloadTarball: tt.download,
+			}
+
+			digests, downloaded, err := v.artifactDigests(context.Background(), tt.dist)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("artifactDigests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if downloaded != tt.wantDownloaded || (downloads.Load() > 0) != tt.wantDownloaded {
+				t.Errorf("downloaded = %v after %d requests, want %v", downloaded, downloads.Load(), tt.wantDownloaded)
+			}
+			if !bytes.Equal(digests.sha512, sum[:]) {
+				t.Errorf("sha512 = %x, want %x", digests.sha512, sum)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package npm
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// options holds the settings applied by NewVerifier.
+type options struct {
+	httpOptions     []httpclient.Option
+	logger          *slog.Logger
+	bundleVerifier  *sigstore.BundleVerifier
+	sigstoreOpts    []sigstore.Option
+	certIdentity    sigstore.CertificateIdentity
+	attestationType string
+	maxDownload     int64
+	downloadTarball bool
+	registryURL     string
+	tokens          []registryToken
+	// headers are key and value pairs sent to the registry host
+	headers    [][2]string
+	rawResults bool
+}
+
+// Option configures a Verifier.
+type Option func(*options)
+
+// WithRateLimit throttles registry requests with a token bucket refilling at
+// requestsPerSecond and holding up to burst tokens.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithRateLimit(requestsPerSecond, burst))
+	}
+}
+
+// WithHTTPClient sets the *http.Client registry requests are sent with, e.g.
+// to route them through a proxy. Compressed responses are decoded either way.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithHTTPClient(httpClient))
+	}
+}
+
+// WithHTTPTimeout sets the timeout applied to each registry request.
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithTimeout(d))
+	}
+}
+
+// WithMaxRetries sets how many times a request answered with 429 or 503 is retried
+// before giving up.
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithMaxRetries(n))
+	}
+}
+
+// WithHeader sets a header on every request to the registry's host, e.g. the
+// API key of a gateway in front of a private registry, a tracing header, or a
+// tenant selector. Tarballs and attestations downloaded from other hosts are
+// requested without it. It can be given several times, including for the
+// same header. Header values are never logged.
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		o.headers = append(o.headers, [2]string{key, value})
+	}
+}
+
+// WithBundleVerifier makes the verifier use an existing Sigstore bundle
+// verifier instead of creating its own, so that several verifiers can share a
+// single trusted root fetch.
+func WithBundleVerifier(bundleVerifier *sigstore.BundleVerifier) Option {
+	return func(o *options) {
+		o.bundleVerifier = bundleVerifier
+	}
+}
+
+// WithLogger sets the logger used for debug output about registry requests,
+// version resolution, and bundle verification. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSigstoreOptions configures the Sigstore bundle verifier the verifier
+// creates, e.g. to use a private TUF mirror and root. It has no effect
+// together with WithBundleVerifier.
+func WithSigstoreOptions(opts ...sigstore.Option) Option {
+	return func(o *options) {
+		o.sigstoreOpts = append(o.sigstoreOpts, opts...)
+	}
+}
+
+// WithMaxDownloadSize caps how many bytes of a tarball are downloaded for
+// hashing; larger artifacts fail verification. The default is
+// httpclient.DefaultMaxDownloadSize.
+func WithMaxDownloadSize(n int64) Option {
+	return func(o *options) {
+		o.maxDownload = n
+	}
+}
+
+// WithCertificateIdentity overrides the signer identity that bundle
+// certificates must match, for callers that want to pin a specific workflow.
+// Empty fields keep the default.
+func WithCertificateIdentity(identity sigstore.CertificateIdentity) Option {
+	return func(o *options) {
+		o.certIdentity = identity
+	}
+}
+
+// WithAttestationType requires an attestation of the given type to verify,
+// either an in-toto predicate type such as https://slsa.dev/provenance/v1 or
+// the "provenance" or "publish" kind. Attestations of other types are ignored,
+// and a version without one of this type fails verification.
+func WithAttestationType(attestationType string) Option {
+	return func(o *options) {
+		o.attestationType = attestationType
+	}
+}
+
+// WithTarballDownload downloads and hashes every tarball and checks it against
+// the registry's dist.shasum and dist.integrity. By default the sha512 digest
+// in dist.integrity is used as is, and tarballs are only downloaded for
+// versions the registry publishes no integrity for.
+func WithTarballDownload() Option {
+	return func(o *options) {
+		o.downloadTarball = true
+	}
+}
+
+// WithRawResults keeps the full Sigstore verification result of every
+// attestation that verifies on the result's RawResults, for callers that need
+// more than the summary, such as the verified timestamps or certificate.
+func WithRawResults() Option {
+	return func(o *options) {
+		o.rawResults = true
+	}
+}
+
+// WithRegistryURL fetches package metadata from the registry at registryURL,
+// e.g. https://npm.pkg.github.com, instead of the public npm registry. Tarballs
+// and attestations may be downloaded from its host as well.
+func WithRegistryURL(registryURL string) Option {
+	return func(o *options) {
+		o.registryURL = strings.TrimSuffix(registryURL, "/")
+	}
+}
+
+// WithRegistryToken sends token as a bearer token with every request to a
+// host matching hostPattern, a path.Match pattern such as npm.pkg.github.com.
+// The token is never logged and is redacted from returned errors.
+func WithRegistryToken(hostPattern, token string) Option {
+	return func(o *options) {
+		o.tokens = append(o.tokens, registryToken{hostPattern: hostPattern, token: token})
+	}
+}
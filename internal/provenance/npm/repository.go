@@ -0,0 +1,52 @@
+package npm
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// githubShorthandRe matches package.json repository shorthands naming a GitHub
+// repository: "owner/repo" or "github:owner/repo".
+var githubShorthandRe = regexp.MustCompile(`^(?:github:)?([A-Za-z0-9-]+/[A-Za-z0-9_.-]+)$`)
+
+// githubRepository returns the "owner/repo" of the GitHub repository a
+// package.json repository URL points at, in any of the forms npm accepts
+// (https, git+https, git, git+ssh, scp-like, or shorthand). ok is false when
+// the URL names no GitHub repository.
+func githubRepository(repoURL string) (repo string, ok bool) {
+	repoURL = strings.TrimSpace(repoURL)
+	if m := githubShorthandRe.FindStringSubmatch(repoURL); m != nil {
+		return strings.TrimSuffix(m[1], ".git"), true
+	}
+
+	// scp-like syntax: git@github.com:owner/repo.git
+	if rest, found := strings.CutPrefix(repoURL, "git@github.com:"); found {
+		repoURL = "ssh://git@github.com/" + rest
+	}
+
+	u, err := url.Parse(strings.TrimPrefix(repoURL, "git+"))
+	if err != nil || !strings.EqualFold(u.Hostname(), "github.com") {
+		return "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0] + "/" + strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// identitySANRegexp returns the certificate SAN regexp attestations must match:
+// the workflows of the GitHub repository in the package metadata, or any GitHub
+// workflow when the metadata names none. scoped reports which one it is.
+func identitySANRegexp(metadata *PackageMetadata) (sanRegexp string, scoped bool) {
+	if repoURL, ok := metadata.Repository["url"].(string); ok {
+		if repo, ok := githubRepository(repoURL); ok {
+			// GitHub names are case-insensitive, and package.json often differs in case
+			return "^https://github.com/(?i:" + regexp.QuoteMeta(repo) + ")/", true
+		}
+	}
+	return sigstore.AnyGitHubSANRegexp, false
+}
@@ -0,0 +1,71 @@
+package npm
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+func TestGitHubRepository(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		url    string
+		want   string
+		wantOK bool
+	}{
+		{"https://github.com/upstash/context7", "upstash/context7", true},
+		{"git+https://github.com/upstash/context7.git", "upstash/context7", true},
+		{"git://github.com/upstash/context7.git", "upstash/context7", true},
+		{"git+ssh://git@github.com/upstash/context7.git", "upstash/context7", true},
+		{"git@github.com:upstash/context7.git", "upstash/context7", true},
+		{"github:upstash/context7", "upstash/context7", true},
+		{"upstash/context7", "upstash/context7", true},
+		{"https://github.com/upstash/context7/tree/main/packages/mcp", "upstash/context7", true},
+		{"https://gitlab.com/group/project", "", false},
+		{"https://github.com/upstash", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := githubRepository(tt.url)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("githubRepository(%q) = %q, %v, want %q, %v", tt.url, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIdentitySANRegexp(t *testing.T) {
+	t.Parallel()
+
+	const san = "https://github.com/Upstash/Context7/.github/workflows/release.yml@refs/tags/v1.0.14"
+
+	scopedRegexp, scoped := identitySANRegexp(&PackageMetadata{
+		Repository: map[string]interface{}{"url": "git+https://github.com/upstash/context7.git"},
+	})
+	if !scoped {
+		t.Fatal("expected a repository-scoped regexp")
+	}
+	re := regexp.MustCompile(scopedRegexp)
+	if !re.MatchString(san) {
+		t.Errorf("%q does not match the package's own workflow %q", scopedRegexp, san)
+	}
+	for _, other := range []string{
+		"https://github.com/attacker/context7/.github/workflows/release.yml@refs/heads/main",
+		"https://github.com/upstash/context7-fork/.github/workflows/release.yml@refs/heads/main",
+	} {
+		if re.MatchString(other) {
+			t.Errorf("%q matches another repository's workflow %q", scopedRegexp, other)
+		}
+	}
+
+	broadRegexp, scoped := identitySANRegexp(&PackageMetadata{})
+	if scoped || broadRegexp != sigstore.AnyGitHubSANRegexp {
+		t.Errorf("identitySANRegexp() without a repository = %q, %v, want the broad default", broadRegexp, scoped)
+	}
+}
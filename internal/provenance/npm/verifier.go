@@ -3,40 +3,94 @@ package npm
 
 import (
 	"context"
-	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"path"
+	"strings"
 	"time"
 
-	"github.com/sigstore/sigstore-go/pkg/verify"
-
 	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
 	"github.com/stacklok/dockyard/internal/provenance/sigstore"
 )
 
+// defaultRegistryURL is the public npm registry.
+const defaultRegistryURL = "https://registry.npmjs.org"
+
 // Verifier implements provenance verification for npm packages using sigstore-go
 type Verifier struct {
-	httpClient     *http.Client
+	httpClient     *httpclient.Client
 	registryURL    string
 	bundleVerifier *sigstore.BundleVerifier
+	certIdentity   sigstore.CertificateIdentity
+	// attestationType, when set, is the attestation type that must verify
+	attestationType string
+	logger          *slog.Logger
+	maxDownload     int64
+	// downloadTarball hashes the tarball even when the registry publishes its digest
+	downloadTarball bool
+	// tokens authenticate requests to private registries
+	tokens []registryToken
+	// rawResults keeps the Sigstore verification results on the result
+	rawResults bool
 }
 
 // NewVerifier creates a new npm provenance verifier with sigstore support
-func NewVerifier(ctx context.Context) (*Verifier, error) {
-	bundleVerifier, err := sigstore.NewBundleVerifier(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create bundle verifier: %w", err)
+func NewVerifier(ctx context.Context, opts ...Option) (*Verifier, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// The trusted root is fetched on the first verification, so creating a
+	// verifier works offline
+	bundleVerifier := o.bundleVerifier
+	if bundleVerifier == nil {
+		bundleVerifier = sigstore.NewLazyBundleVerifier(o.sigstoreOpts...)
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	registryURL := defaultRegistryURL
+	if o.registryURL != "" {
+		if err := validateRegistryURL(o.registryURL); err != nil {
+			return nil, err
+		}
+		registryURL = o.registryURL
+	}
+	for _, t := range o.tokens {
+		if _, err := path.Match(t.hostPattern, ""); err != nil || t.hostPattern == "" {
+			return nil, fmt.Errorf("invalid registry token host pattern %q", t.hostPattern)
+		}
+	}
+
+	// Headers go only to the registry; registryURL was validated above
+	httpOptions := append(o.httpOptions, httpclient.WithLogger(logger))
+	registry, _ := url.Parse(registryURL)
+	for _, h := range o.headers {
+		httpOptions = append(httpOptions, httpclient.WithHeader(registry.Host, h[0], h[1]))
 	}
 
 	return &Verifier{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		registryURL:    "https://registry.npmjs.org",
-		bundleVerifier: bundleVerifier,
+		httpClient:      httpclient.New(httpOptions...),
+		registryURL:     registryURL,
+		bundleVerifier:  bundleVerifier,
+		certIdentity:    o.certIdentity,
+		attestationType: o.attestationType,
+		logger:          logger,
+		maxDownload:     o.maxDownload,
+		downloadTarball: o.downloadTarball,
+		rawResults:      o.rawResults,
+		tokens:          o.tokens,
 	}, nil
 }
 
@@ -45,55 +99,159 @@ func (*Verifier) SupportsProtocol(protocol domain.PackageProtocol) bool {
 	return protocol == domain.ProtocolNPM
 }
 
+// Warmup fetches the Sigstore trusted root and opens a connection to the npm
+// registry, which serves both package metadata and tarballs, so the first
+// verification skips the TLS handshake.
+func (v *Verifier) Warmup(ctx context.Context) error {
+	if err := v.bundleVerifier.Load(); err != nil {
+		return fmt.Errorf("failed to load Sigstore trusted root: %w", err)
+	}
+	return v.httpClient.Warmup(ctx, v.registryURL)
+}
+
+// ListVersions returns every published version of an npm package, oldest first.
+func (v *Verifier) ListVersions(ctx context.Context, name string) ([]string, error) {
+	metadata, err := v.fetchPackageMetadata(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata.Versions) == 0 {
+		return nil, fmt.Errorf("%w: %s has no published versions", domain.ErrPackageNotFound, name)
+	}
+	return sortedVersions(metadata), nil
+}
+
+// ResolveVersion returns the published version a version or dist-tag, such
+// as latest, names.
+func (v *Verifier) ResolveVersion(ctx context.Context, name, version string) (string, error) {
+	metadata, err := v.fetchPackageMetadata(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	versionData, _, err := resolveVersion(metadata, version)
+	if err != nil {
+		return "", err
+	}
+	return versionData.Version, nil
+}
+
 // Verify checks the provenance of an npm package
 func (v *Verifier) Verify(ctx context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
 	if pkg.Protocol != domain.ProtocolNPM {
 		return nil, fmt.Errorf("npm verifier does not support protocol %s", pkg.Protocol)
 	}
 
+	var timings domain.PhaseTimings
+
 	// Fetch package metadata from npm registry
+	start := time.Now()
 	metadata, err := v.fetchPackageMetadata(ctx, pkg.Name)
+	timings.Metadata += time.Since(start)
+	if err == nil && len(metadata.Versions) == 0 {
+		// Unpublished packages keep a document, but without any versions
+		err = fmt.Errorf("%w: %s has no published versions", domain.ErrPackageNotFound, pkg.Name)
+	}
 	if err != nil {
-		return &domain.ProvenanceResult{
+		result := &domain.ProvenanceResult{
 			PackageID:    pkg,
 			Status:       domain.ProvenanceStatusError,
 			ErrorMessage: fmt.Sprintf("failed to fetch package metadata: %v", err),
-		}, err
+			Details:      make(map[string]interface{}),
+		}
+		if errors.Is(err, domain.ErrPackageNotFound) {
+			result.ErrorMessage = fmt.Sprintf("package %s not found in the npm registry", pkg.Name)
+			result.Details["not_found"] = "package"
+		}
+		timings.Record(result.Details)
+		return result, err
 	}
 
-	// Extract version-specific information
-	versionData, ok := metadata.Versions[pkg.Version]
-	if !ok {
-		return &domain.ProvenanceResult{
+	// Extract version-specific information, resolving a dist-tag such as latest
+	versionData, tag, err := resolveVersion(metadata, pkg.Version)
+	if err != nil {
+		notFound := "version"
+		if tag != "" {
+			notFound = "dist-tag"
+		}
+		result := &domain.ProvenanceResult{
 			PackageID:    pkg,
 			Status:       domain.ProvenanceStatusError,
-			ErrorMessage: fmt.Sprintf("version %s not found", pkg.Version),
-		}, fmt.Errorf("version %s not found in registry", pkg.Version)
+			ErrorMessage: err.Error(),
+			Details:      map[string]interface{}{"not_found": notFound},
+		}
+		timings.Record(result.Details)
+		return result, err
 	}
 
+	v.logger.DebugContext(ctx, "resolved npm version",
+		"package", pkg.Name, "version", versionData.Version, "dist_tag", tag, "tarball", versionData.Dist.Tarball,
+		"has_attestations", versionData.Dist.Attestations != nil)
+
 	result := &domain.ProvenanceResult{
 		PackageID: pkg,
 		Details:   make(map[string]interface{}),
 	}
+	if tag != "" {
+		result.Details["dist_tag"] = tag
+		result.Details["resolved_version"] = versionData.Version
+	}
+	if published, ok := metadata.Time[versionData.Version].(string); ok {
+		if publishedAt, err := time.Parse(time.RFC3339, published); err == nil {
+			domain.RecordPublished(result.Details, publishedAt, time.Now())
+		}
+	}
+
+	// The digests feed the sigstore artifact policy and, when the tarball was
+	// downloaded, the registry integrity check
+	start = time.Now()
+	digests, downloaded, digestErr := v.artifactDigests(ctx, versionData.Dist)
+	timings.Tarball += time.Since(start)
+	var integrityErr error
+	switch {
+	case digestErr != nil:
+		digestErr = fmt.Errorf("failed to calculate artifact digest: %w", digestErr)
+		result.Details["tarball_error"] = digestErr.Error()
+	case downloaded:
+		result.Details["digest_source"] = "tarball"
+		integrityErr = checkDistIntegrity(versionData.Dist, digests, result.Details)
+	default:
+		result.Details["digest_source"] = "registry"
+	}
+	if digestErr == nil {
+		result.Details["artifact_digest"] = "sha512:" + hex.EncodeToString(digests.sha512)
+	}
 
 	// Check for attestations (newer provenance format with Sigstore bundles)
 	if versionData.Dist.Attestations != nil {
-		// Try to verify attestations using sigstore
-		verified, publisher, err := v.verifyAttestations(ctx, versionData, pkg)
+		// Attestations must come from the package's own repository when it is known
+		sanRegexp, scoped := identitySANRegexp(metadata)
+		result.Details["certificate_identity"] = v.certIdentity.Describe(sigstore.GitHubActionsIssuer, sanRegexp)
+		if !scoped && v.certIdentity.SANRegexp == "" {
+			result.Details["identity_warning"] =
+				"package metadata names no GitHub repository; accepting attestations signed by any GitHub repository"
+		}
+
+		// Try to verify each attestation using sigstore
+		var outcomes []attestationOutcome
+		err := digestErr
+		if err == nil {
+			outcomes, err = v.verifyAttestations(ctx, versionData, digests.sha512, sanRegexp, &timings)
+		}
 		if err != nil {
-			// Has attestations but verification failed
+			// Has attestations but they could not be retrieved or verified
 			result.Status = domain.ProvenanceStatusAttestations
 			result.HasAttestations = true
 			result.ErrorMessage = fmt.Sprintf("attestation verification failed: %v", err)
 			result.Details["verification_error"] = err.Error()
-		} else if verified {
-			result.Status = domain.ProvenanceStatusVerified
-			result.HasAttestations = true
-			result.TrustedPublisher = publisher
-			result.AttestationCount = 1
 		} else {
-			result.Status = domain.ProvenanceStatusAttestations
-			result.HasAttestations = true
+			applyAttestationOutcomes(result, outcomes, v.attestationType)
+			if v.rawResults {
+				for _, outcome := range outcomes {
+					if outcome.raw != nil {
+						result.RawResults = append(result.RawResults, outcome.raw)
+					}
+				}
+			}
 		}
 	} else if versionData.Dist.Signatures != nil {
 		// Check for signatures (older format, can't verify with sigstore)
@@ -104,111 +262,199 @@ func (v *Verifier) Verify(ctx context.Context, pkg domain.PackageIdentifier) (*d
 		result.Status = domain.ProvenanceStatusNone
 	}
 
-	// Extract repository information from package metadata
+	// A tarball that differs from what the registry advertises is an error
+	// regardless of what the provenance checks concluded
+	if integrityErr != nil {
+		result.Status = domain.ProvenanceStatusError
+		result.ErrorMessage = integrityErr.Error()
+	}
+
+	timings.Record(result.Details)
+
+	// Extract repository information from package metadata, falling back to
+	// the repository the verified provenance was built from
 	if metadata.Repository != nil {
 		if repoURL, ok := metadata.Repository["url"].(string); ok {
 			result.RepositoryURI = repoURL
 		}
 	}
+	if repoURL, ok := result.Details["provenance_repository"].(string); ok && result.RepositoryURI == "" {
+		result.RepositoryURI = repoURL
+		result.Details["repository_source"] = "provenance"
+	}
 
 	return result, nil
 }
 
-// verifyAttestations verifies npm attestations using sigstore
+// verifyAttestations fetches npm attestations and verifies each one using sigstore
 func (v *Verifier) verifyAttestations(
 	ctx context.Context,
 	versionData VersionMetadata,
-	pkg domain.PackageIdentifier,
-) (bool, *domain.TrustedPublisher, error) {
+	artifactDigest []byte,
+	sanRegexp string,
+	timings *domain.PhaseTimings,
+) ([]attestationOutcome, error) {
 	// npm attestations can be in different formats
 	// Try to extract the attestation URL or bundle data
 	attestationData, ok := versionData.Dist.Attestations.(map[string]interface{})
 	if !ok {
-		return false, nil, fmt.Errorf("attestations in unexpected format")
+		return nil, fmt.Errorf("attestations in unexpected format")
 	}
 
-	// Check if there's a URL to fetch the attestation bundle
-	bundleURL, hasURL := attestationData["url"].(string)
-	if !hasURL {
+	var data []byte
+	if bundleURL, hasURL := attestationData["url"].(string); hasURL {
+		start := time.Now()
+		fetched, err := v.fetchAttestations(ctx, bundleURL)
+		timings.Metadata += time.Since(start)
+		if err != nil {
+			return nil, err
+		}
+		data = fetched
+	} else {
 		// Attestation data might be embedded
-		bundleBytes, err := json.Marshal(attestationData)
+		embedded, err := json.Marshal(attestationData)
 		if err != nil {
-			return false, nil, fmt.Errorf("failed to marshal attestation data: %w", err)
+			return nil, fmt.Errorf("failed to marshal attestation data: %w", err)
 		}
-		return v.verifyBundleData(ctx, bundleBytes, versionData, pkg)
+		data = embedded
+	}
+
+	attestations, err := parseAttestations(data)
+	if err != nil {
+		return nil, err
+	}
+	v.logger.DebugContext(ctx, "parsed npm attestations", "count", len(attestations))
+
+	outcomes := make([]attestationOutcome, 0, len(attestations))
+	for _, attestation := range attestations {
+		start := time.Now()
+		outcome := v.verifyAttestation(ctx, attestation, artifactDigest, sanRegexp)
+		timings.Sigstore += time.Since(start)
+		v.logger.DebugContext(ctx, "verified npm attestation",
+			"kind", outcome.kind, "predicate_type", attestation.PredicateType, "error", outcome.err)
+		outcomes = append(outcomes, outcome)
 	}
+	return outcomes, nil
+}
 
-	// Fetch the attestation bundle from URL
-	if err := validateNpmURL(bundleURL); err != nil {
-		return false, nil, fmt.Errorf("SSRF protection: %w", err)
+// fetchAttestations downloads the attestations document from the registry
+func (v *Verifier) fetchAttestations(ctx context.Context, attestationsURL string) ([]byte, error) {
+	if err := v.validateURL(attestationsURL); err != nil {
+		return nil, fmt.Errorf("SSRF protection: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bundleURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, attestationsURL, nil)
 	if err != nil {
-		return false, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	httpclient.AcceptCompressed(req)
 
-	resp, err := v.httpClient.Do(req) //nolint:gosec // G704 — URL validated against allowlist by validateNpmURL
+	resp, err := v.do(req)
 	if err != nil {
-		return false, nil, fmt.Errorf("failed to fetch attestation: %w", err)
+		return nil, fmt.Errorf("failed to fetch attestation: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return false, nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
-	bundleData, err := io.ReadAll(resp.Body)
+	body, err := httpclient.DecodeBody(resp)
 	if err != nil {
-		return false, nil, fmt.Errorf("failed to read attestation: %w", err)
+		return nil, err
 	}
+	defer body.Close()
 
-	return v.verifyBundleData(ctx, bundleData, versionData, pkg)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attestation: %w", err)
+	}
+	return data, nil
 }
 
-// verifyBundleData verifies a Sigstore bundle
-func (v *Verifier) verifyBundleData(
+// verifyAttestation verifies a single attestation bundle against the sha512
+// digest of the tarball, requiring a certificate SAN matching sanRegexp
+func (v *Verifier) verifyAttestation(
 	ctx context.Context,
-	bundleData []byte,
-	versionData VersionMetadata,
-	_ domain.PackageIdentifier,
-) (bool, *domain.TrustedPublisher, error) {
-	// Calculate the artifact digest (sha512 of the tarball)
-	// For npm, we need to hash the tarball
-	artifactDigest, err := v.calculateTarballDigest(ctx, versionData.Dist.Tarball)
-	if err != nil {
-		return false, nil, fmt.Errorf("failed to calculate artifact digest: %w", err)
+	attestation npmAttestation,
+	artifactDigest []byte,
+	sanRegexp string,
+) attestationOutcome {
+	outcome := attestationOutcome{
+		kind:          attestationKind(attestation.PredicateType),
+		predicateType: attestation.PredicateType,
 	}
 
 	// Create verification policy
-	// For npm packages, we expect GitHub Actions as the issuer
-	certID, err := verify.NewShortCertificateIdentity(
-		"https://token.actions.githubusercontent.com",
-		"",
-		"",
-		"^https://github.com/.*",
-	)
+	// For npm packages, we expect GitHub Actions as the issuer unless overridden
+	v.logger.DebugContext(ctx, "constructing npm verification policy",
+		"issuer", sigstore.GitHubActionsIssuer, "san_regex", sanRegexp,
+		"override", v.certIdentity, "digest_algorithm", "sha512")
+	identityPolicy, err := v.certIdentity.PolicyOption(sigstore.GitHubActionsIssuer, sanRegexp)
 	if err != nil {
-		return false, nil, fmt.Errorf("failed to create certificate identity: %w", err)
+		outcome.err = err
+		return outcome
 	}
 
 	// Verify the bundle with artifact digest and certificate identity
-	verifyResult, err := v.bundleVerifier.VerifyBundle(bundleData, "sha512", artifactDigest, verify.WithCertificateIdentity(certID))
+	verifyResult, err := v.bundleVerifier.VerifyBundle(attestation.Bundle, "sha512", artifactDigest, identityPolicy)
 	if err != nil {
-		return false, nil, err
+		outcome.err = err
+		return outcome
 	}
 
-	// Extract publisher information
-	publisher := sigstore.ExtractPublisherInfo(verifyResult)
+	// A bare bundle carries no declared type; classify it from the signed statement
+	if attestation.PredicateType == "" && verifyResult.Statement != nil {
+		outcome.predicateType = verifyResult.Statement.GetPredicateType()
+		outcome.kind = attestationKind(outcome.predicateType)
+	}
+
+	// The statement must describe this tarball, not merely be signed over its digest
+	outcome.subject, outcome.err = sigstore.MatchSubject(verifyResult, "sha512", artifactDigest)
+	if outcome.err != nil {
+		return outcome
+	}
 
-	return true, publisher, nil
+	// Extract publisher information
+	outcome.publisher = sigstore.ExtractPublisherInfo(verifyResult)
+	outcome.repository = sigstore.PredicateRepository(verifyResult)
+	outcome.raw = verifyResult
+	return outcome
 }
 
-// allowedHosts is the set of hostnames that the verifier is permitted to contact.
+// allowedHosts is the set of hostnames that the verifier is permitted to contact,
+// besides the host of its registry.
 var allowedHosts = map[string]bool{
 	"registry.npmjs.org": true,
 }
 
+// validateURL checks that a URL is HTTPS and targets an allowed npm host or
+// the verifier's registry.
+func (v *Verifier) validateURL(rawURL string) error {
+	if registry, err := url.Parse(v.registryURL); err == nil {
+		if u, err := url.Parse(rawURL); err == nil && u.Scheme == "https" && u.Host == registry.Host {
+			return nil
+		}
+	}
+	return validateNpmURL(rawURL)
+}
+
+// validateRegistryURL checks that a registry URL set with WithRegistryURL is
+// an HTTPS URL without credentials, which belong in WithRegistryToken.
+func validateRegistryURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid registry URL: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("registry URL %q must be an https:// URL", u.Redacted())
+	}
+	if u.User != nil {
+		return fmt.Errorf("registry URL %q must not contain credentials", u.Redacted())
+	}
+	return nil
+}
+
 // validateNpmURL checks that a URL is HTTPS and targets an allowed npm host.
 func validateNpmURL(rawURL string) error {
 	u, err := url.Parse(rawURL)
@@ -224,9 +470,22 @@ func validateNpmURL(rawURL string) error {
 	return nil
 }
 
-// calculateTarballDigest downloads and hashes the tarball
-func (v *Verifier) calculateTarballDigest(ctx context.Context, tarballURL string) ([]byte, error) {
-	if err := validateNpmURL(tarballURL); err != nil {
+// artifactDigests returns the digests of a version's tarball, decoded from the
+// registry's dist.integrity unless the verifier always downloads tarballs or
+// the registry publishes none. downloaded reports whether the tarball was
+// fetched and hashed.
+func (v *Verifier) artifactDigests(ctx context.Context, dist Dist) (digests *tarballDigests, downloaded bool, err error) {
+	if dist.Integrity != "" && !v.downloadTarball {
+		digests, err = integrityDigests(dist.Integrity)
+		return digests, false, err
+	}
+	digests, err = v.calculateTarballDigests(ctx, dist.Tarball)
+	return digests, true, err
+}
+
+// calculateTarballDigests downloads and hashes the tarball
+func (v *Verifier) calculateTarballDigests(ctx context.Context, tarballURL string) (*tarballDigests, error) {
+	if err := v.validateURL(tarballURL); err != nil {
 		return nil, fmt.Errorf("SSRF protection: %w", err)
 	}
 
@@ -235,7 +494,7 @@ func (v *Verifier) calculateTarballDigest(ctx context.Context, tarballURL string
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := v.httpClient.Do(req) //nolint:gosec // G704 — URL validated against allowlist by validateNpmURL
+	resp, err := v.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch tarball: %w", err)
 	}
@@ -245,20 +504,18 @@ func (v *Verifier) calculateTarballDigest(ctx context.Context, tarballURL string
 		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
-	hasher := sha512.New()
-	if _, err := io.Copy(hasher, resp.Body); err != nil {
-		return nil, fmt.Errorf("failed to hash tarball: %w", err)
+	body, err := httpclient.LimitBody(resp, v.maxDownload)
+	if err != nil {
+		return nil, err
 	}
-
-	digest := hasher.Sum(nil)
-	return digest, nil
+	return hashTarball(body)
 }
 
 // fetchPackageMetadata fetches the package metadata from the npm registry
 func (v *Verifier) fetchPackageMetadata(ctx context.Context, packageName string) (*PackageMetadata, error) {
-	targetURL := fmt.Sprintf("%s/%s", v.registryURL, packageName)
+	targetURL := packageMetadataURL(v.registryURL, packageName)
 
-	if err := validateNpmURL(targetURL); err != nil {
+	if err := v.validateURL(targetURL); err != nil {
 		return nil, fmt.Errorf("SSRF protection: %w", err)
 	}
 
@@ -266,31 +523,53 @@ func (v *Verifier) fetchPackageMetadata(ctx context.Context, packageName string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	httpclient.AcceptCompressed(req)
 
-	resp, err := v.httpClient.Do(req) //nolint:gosec // G704 — URL validated against allowlist by validateNpmURL
+	resp, err := v.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch package metadata: %w", err)
 	}
 	defer resp.Body.Close()
 
+	body, err := httpclient.DecodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPackageNotFound, packageName)
+	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		msg, _ := io.ReadAll(body)
+		return nil, v.redact(fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(msg)))
 	}
 
 	var metadata PackageMetadata
-	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+	if err := json.NewDecoder(body).Decode(&metadata); err != nil {
 		return nil, fmt.Errorf("failed to decode package metadata: %w", err)
 	}
 
 	return &metadata, nil
 }
 
+// packageMetadataURL returns the registry URL of a package's metadata
+// document. The slash of a scoped name is escaped the way the npm CLI does it,
+// as @scope%2fname, since registries that route on path segments answer 404
+// for @scope/name.
+func packageMetadataURL(registryURL, packageName string) string {
+	return registryURL + "/" + strings.Replace(url.PathEscape(packageName), "%2F", "%2f", 1)
+}
+
 // PackageMetadata represents the npm package metadata structure
 type PackageMetadata struct {
 	Name       string                     `json:"name"`
 	Versions   map[string]VersionMetadata `json:"versions"`
+	DistTags   map[string]string          `json:"dist-tags"`
 	Repository map[string]interface{}     `json:"repository"`
+	// Time maps each version to when it was published. Unpublished packages
+	// keep an object under "unpublished", so values are not all strings.
+	Time map[string]interface{} `json:"time"`
 }
 
 // VersionMetadata represents metadata for a specific package version
@@ -0,0 +1,231 @@
+package npm
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// handlerTransport serves every request from a handler, standing in for the registry.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+func TestVerifyNotFound(t *testing.T) {
+	t.Parallel()
+
+	documents := map[string]string{
+		"/existing":    `{"name":"existing","versions":{"1.0.0":{"name":"existing","version":"1.0.0"}}}`,
+		"/unpublished": `{"name":"unpublished","time":{"unpublished":{"time":"2025-01-01T00:00:00Z"}}}`,
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := documents[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"error":"Not found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(doc))
+	})
+
+	v := &Verifier{
+		httpClient:  httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		registryURL: "https://registry.npmjs.org",
+		logger:      slog.New(slog.DiscardHandler),
+	}
+
+	tests := []struct {
+		name         string
+		pkg          string
+		version      string
+		wantErr      error
+		wantNotFound string
+	}{
+		{"missing package", "missing", "1.0.0", domain.ErrPackageNotFound, "package"},
+		{"unpublished package", "unpublished", "1.0.0", domain.ErrPackageNotFound, "package"},
+		{"missing version", "existing", "2.0.0", domain.ErrVersionNotFound, "version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pkg := domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: tt.pkg, Version: tt.version}
+			result, err := v.Verify(context.Background(), pkg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if result.Status != domain.ProvenanceStatusError {
+				t.Errorf("Status = %s, want %s", result.Status, domain.ProvenanceStatusError)
+			}
+			if result.Details["not_found"] != tt.wantNotFound {
+				t.Errorf("Details[not_found] = %v, want %q", result.Details["not_found"], tt.wantNotFound)
+			}
+		})
+	}
+}
+
+func TestVerifyTarballIntegrity(t *testing.T) {
+	t.Parallel()
+
+	published := []byte("published tarball")
+	sum := sha512.Sum512(published)
+	integrity := "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name       string
+		tarball    []byte
+		wantMatch  bool
+		wantStatus domain.ProvenanceStatus
+	}{
+		{"match", published, true, domain.ProvenanceStatusNone},
+		{"compromised mirror", []byte("tampered tarball"), false, domain.ProvenanceStatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/pkg/-/pkg-1.0.0.tgz" {
+					_, _ = w.Write(tt.tarball)
+					return
+				}
+				_, _ = fmt.Fprintf(w, `{"name":"pkg","versions":{"1.0.0":{"name":"pkg","version":"1.0.0",`+
+					`"dist":{"tarball":"https://registry.npmjs.org/pkg/-/pkg-1.0.0.tgz","integrity":%q}}}}`, integrity)
+			})
+			v := &Verifier{
+				httpClient:      httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+				registryURL:     "https://registry.npmjs.org",
+				logger:          slog.New(slog.DiscardHandler),
+				downloadTarball: true,
+			}
+
+			pkg := domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "pkg", Version: "1.0.0"}
+			result, err := v.Verify(context.Background(), pkg)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if result.Details["integrity_match"] != tt.wantMatch {
+				t.Errorf("integrity_match = %v, want %v", result.Details["integrity_match"], tt.wantMatch)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s (%s)", result.Status, tt.wantStatus, result.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestPackageMetadataURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"@upstash/context7-mcp": "https://registry.npmjs.org/@upstash%2fcontext7-mcp",
+		"left-pad":              "https://registry.npmjs.org/left-pad",
+	}
+	for name, want := range tests {
+		if got := packageMetadataURL("https://registry.npmjs.org", name); got != want {
+			t.Errorf("packageMetadataURL(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFetchPackageMetadataScoped(t *testing.T) {
+	t.Parallel()
+
+	var requested string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.EscapedPath()
+		_, _ = w.Write([]byte(`{"name":"@upstash/context7-mcp","versions":{"1.0.14":{"version":"1.0.14"}}}`))
+	})
+	v := &Verifier{
+		httpClient:  httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		registryURL: "https://registry.npmjs.org",
+		logger:      slog.New(slog.DiscardHandler),
+	}
+
+	metadata, err := v.fetchPackageMetadata(context.Background(), "@upstash/context7-mcp")
+	if err != nil {
+		t.Fatalf("fetchPackageMetadata() error: %v", err)
+	}
+	if want := "/@upstash%2fcontext7-mcp"; requested != want {
+		t.Errorf("requested path %q, want %q", requested, want)
+	}
+	if _, ok := metadata.Versions["1.0.14"]; !ok {
+		t.Errorf("fetchPackageMetadata() = %+v, want version 1.0.14", metadata)
+	}
+}
+
+func TestVerifyPublishedAt(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"pkg","versions":{"1.0.0":{"name":"pkg","version":"1.0.0"}},` +
+			`"time":{"created":"2020-01-01T00:00:00.000Z","1.0.0":"2024-05-01T12:34:56.789Z"}}`))
+	})
+	v := &Verifier{
+		httpClient:  httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		registryURL: "https://registry.npmjs.org",
+		logger:      slog.New(slog.DiscardHandler),
+	}
+
+	pkg := domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "pkg", Version: "1.0.0"}
+	result, err := v.Verify(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got := result.Details[domain.DetailPublishedAt]; got != "2024-05-01T12:34:56Z" {
+		t.Errorf("published_at = %v, want 2024-05-01T12:34:56Z", got)
+	}
+	if age, ok := result.Details[domain.DetailAgeDays].(int); !ok || age < 365 {
+		t.Errorf("age_days = %v, want at least 365", result.Details[domain.DetailAgeDays])
+	}
+}
+
+func TestVerifyWithoutVersion(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"pkg","dist-tags":{"latest":"1.1.0","next":"2.0.0-rc.1"},"versions":{` +
+			`"1.0.0":{"name":"pkg","version":"1.0.0"},"1.1.0":{"name":"pkg","version":"1.1.0"},` +
+			`"2.0.0-rc.1":{"name":"pkg","version":"2.0.0-rc.1"}}}`))
+	})
+	v := &Verifier{
+		httpClient:  httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		registryURL: "https://registry.npmjs.org",
+		logger:      slog.New(slog.DiscardHandler),
+	}
+
+	// An unpinned npx spec verifies the version npx would install
+	pkg := domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "pkg"}
+	result, err := v.Verify(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Status != domain.ProvenanceStatusNone {
+		t.Errorf("Status = %s, want %s", result.Status, domain.ProvenanceStatusNone)
+	}
+	if result.Details["dist_tag"] != "latest" || result.Details["resolved_version"] != "1.1.0" {
+		t.Errorf("dist_tag = %v, resolved_version = %v, want latest and 1.1.0",
+			result.Details["dist_tag"], result.Details["resolved_version"])
+	}
+
+	version, err := v.ResolveVersion(context.Background(), "pkg", "")
+	if err != nil || version != "1.1.0" {
+		t.Errorf("ResolveVersion(\"\") = %q, %v, want 1.1.0", version, err)
+	}
+}
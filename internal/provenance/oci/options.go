@@ -0,0 +1,63 @@
+package oci
+
+import (
+	"log/slog"
+
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// options holds the settings applied by NewVerifier.
+type options struct {
+	logger         *slog.Logger
+	bundleVerifier *sigstore.BundleVerifier
+	sigstoreOpts   []sigstore.Option
+	certIdentity   sigstore.CertificateIdentity
+	rawResults     bool
+}
+
+// Option configures a Verifier.
+type Option func(*options)
+
+// WithBundleVerifier makes the verifier use an existing Sigstore bundle
+// verifier instead of creating its own, so that several verifiers can share a
+// single trusted root fetch.
+func WithBundleVerifier(bundleVerifier *sigstore.BundleVerifier) Option {
+	return func(o *options) {
+		o.bundleVerifier = bundleVerifier
+	}
+}
+
+// WithLogger sets the logger used for debug output about image resolution and
+// bundle verification. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSigstoreOptions configures the Sigstore bundle verifier the verifier
+// creates, e.g. to use a private TUF mirror and root. It has no effect
+// together with WithBundleVerifier.
+func WithSigstoreOptions(opts ...sigstore.Option) Option {
+	return func(o *options) {
+		o.sigstoreOpts = append(o.sigstoreOpts, opts...)
+	}
+}
+
+// WithCertificateIdentity overrides the signer identity that bundle
+// certificates must match, for callers that want to pin a specific workflow.
+// Empty fields keep the default.
+func WithCertificateIdentity(identity sigstore.CertificateIdentity) Option {
+	return func(o *options) {
+		o.certIdentity = identity
+	}
+}
+
+// WithRawResults keeps the full Sigstore verification result of every bundle
+// that verifies on the result's RawResults, for callers that need more than
+// the summary.
+func WithRawResults() Option {
+	return func(o *options) {
+		o.rawResults = true
+	}
+}
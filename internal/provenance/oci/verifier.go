@@ -0,0 +1,354 @@
+// Package oci implements provenance verification for prebuilt OCI images,
+// reading Sigstore bundles attached to an image through the registry's
+// referrers API
+package oci
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+const (
+	// bundleArtifactTypePrefix matches the artifact types cosign and other
+	// Sigstore clients use for bundles attached as referrers, for every bundle version
+	bundleArtifactTypePrefix = "application/vnd.dev.sigstore.bundle"
+	// maxBundleSize bounds how much of a referrer layer is read as a bundle
+	maxBundleSize = 4 << 20
+	// githubRegistry is the registry whose image paths start with the owner
+	// and name of the GitHub repository the images are published from
+	githubRegistry = "ghcr.io"
+)
+
+// ErrSignerIdentityRequired is returned for an image outside ghcr.io without a
+// certificate SAN regexp: its reference does not name the repository whose
+// workflows may sign it, and any GitHub workflow is too broad.
+var ErrSignerIdentityRequired = errors.New("certificate identity regexp required")
+
+// Verifier implements provenance verification for OCI images using sigstore-go
+type Verifier struct {
+	bundleVerifier *sigstore.BundleVerifier
+	certIdentity   sigstore.CertificateIdentity
+	logger         *slog.Logger
+	// nameOptions and remoteOptions let tests target a plain-HTTP registry
+	nameOptions   []name.Option
+	remoteOptions []remote.Option
+	// rawResults keeps the Sigstore verification results on the result
+	rawResults bool
+}
+
+// NewVerifier creates a new OCI image provenance verifier with sigstore support
+func NewVerifier(ctx context.Context, opts ...Option) (*Verifier, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// The trusted root is fetched on the first verification, so creating a
+	// verifier works offline
+	bundleVerifier := o.bundleVerifier
+	if bundleVerifier == nil {
+		bundleVerifier = sigstore.NewLazyBundleVerifier(o.sigstoreOpts...)
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	return &Verifier{
+		bundleVerifier: bundleVerifier,
+		certIdentity:   o.certIdentity,
+		logger:         logger,
+		rawResults:     o.rawResults,
+	}, nil
+}
+
+// SupportsProtocol returns true if this verifier supports the given protocol
+func (*Verifier) SupportsProtocol(protocol domain.PackageProtocol) bool {
+	return protocol == domain.ProtocolOCI
+}
+
+// Verify checks the provenance of an OCI image. The package name is the image
+// repository and the version its tag or sha256 digest.
+func (v *Verifier) Verify(ctx context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	if pkg.Protocol != domain.ProtocolOCI {
+		return nil, fmt.Errorf("oci verifier does not support protocol %s", pkg.Protocol)
+	}
+
+	var timings domain.PhaseTimings
+	errorResult := func(format string, err error) (*domain.ProvenanceResult, error) {
+		result := &domain.ProvenanceResult{
+			PackageID:    pkg,
+			Status:       domain.ProvenanceStatusError,
+			ErrorMessage: fmt.Sprintf(format, err),
+			Details:      make(map[string]interface{}),
+		}
+		timings.Record(result.Details)
+		return result, err
+	}
+
+	ref, err := ImageReference(pkg.Name, pkg.Version, v.nameOptions...)
+	if err != nil {
+		return errorResult("invalid image reference: %v", err)
+	}
+
+	// Resolve the tag to the manifest digest that bundles are attached to
+	start := time.Now()
+	desc, err := remote.Head(ref, v.remote(ctx)...)
+	timings.Metadata += time.Since(start)
+	if err != nil {
+		return errorResult("failed to resolve image: %v", err)
+	}
+	digest := ref.Context().Digest(desc.Digest.String())
+	v.logger.DebugContext(ctx, "resolved oci image", "reference", ref.String(), "digest", desc.Digest.String())
+
+	result := &domain.ProvenanceResult{
+		PackageID: pkg,
+		Details:   map[string]interface{}{"image_digest": desc.Digest.String()},
+	}
+
+	start = time.Now()
+	bundles, err := v.fetchBundles(ctx, digest)
+	timings.Metadata += time.Since(start)
+	if err != nil {
+		return errorResult("failed to fetch referrers: %v", err)
+	}
+
+	if len(bundles) == 0 {
+		// Images signed before bundles were attached as referrers carry a
+		// cosign signature tag instead; like npm signatures it is only detected
+		if v.hasSignatureTag(ctx, digest) {
+			result.Status = domain.ProvenanceStatusSignatures
+			result.HasSignatures = true
+		} else {
+			result.Status = domain.ProvenanceStatusNone
+		}
+		timings.Record(result.Details)
+		return result, nil
+	}
+
+	sanRegexp, ok := identitySANRegexp(ref.Context())
+	if !ok && v.certIdentity.SANRegexp == "" {
+		return errorResult("cannot verify attached bundles: %v", fmt.Errorf(
+			"%w: %s is not on %s, so pass the workflows that may sign it with --cert-identity-regexp",
+			ErrSignerIdentityRequired, ref.Context().Name(), githubRegistry))
+	}
+	result.Details["certificate_identity"] = v.certIdentity.Describe(sigstore.GitHubActionsIssuer, sanRegexp)
+
+	digestBytes, err := hex.DecodeString(desc.Digest.Hex)
+	if err != nil {
+		return errorResult("invalid image digest: %v", err)
+	}
+
+	start = time.Now()
+	outcomes := make([]bundleOutcome, 0, len(bundles))
+	for _, b := range bundles {
+		outcome := v.verifyBundle(b, digestBytes, sanRegexp)
+		v.logger.DebugContext(ctx, "verified oci bundle", "referrer", b.referrer, "error", outcome.err)
+		outcomes = append(outcomes, outcome)
+	}
+	timings.Sigstore += time.Since(start)
+
+	applyBundleOutcomes(result, outcomes)
+	if v.rawResults {
+		for _, outcome := range outcomes {
+			if outcome.raw != nil {
+				result.RawResults = append(result.RawResults, outcome.raw)
+			}
+		}
+	}
+	timings.Record(result.Details)
+	return result, nil
+}
+
+// ImageReference builds the reference for an image repository and a version
+// that is either a tag or a sha256 digest. An empty version means latest.
+func ImageReference(repository, version string, opts ...name.Option) (name.Reference, error) {
+	switch {
+	case strings.HasPrefix(version, "sha256:"):
+		return name.NewDigest(repository+"@"+version, opts...)
+	case version != "":
+		return name.NewTag(repository+":"+version, opts...)
+	default:
+		return name.NewTag(repository, opts...)
+	}
+}
+
+// remote returns the registry options for a request bound to ctx
+func (v *Verifier) remote(ctx context.Context) []remote.Option {
+	return append([]remote.Option{remote.WithContext(ctx)}, v.remoteOptions...)
+}
+
+// referrerBundle is a Sigstore bundle attached to an image
+type referrerBundle struct {
+	referrer string
+	data     []byte
+}
+
+// fetchBundles returns every Sigstore bundle attached to digest as a referrer
+func (v *Verifier) fetchBundles(ctx context.Context, digest name.Digest) ([]referrerBundle, error) {
+	index, err := remote.Referrers(digest, v.remote(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referrers index: %w", err)
+	}
+
+	var bundles []referrerBundle
+	for _, desc := range manifest.Manifests {
+		if !strings.HasPrefix(desc.ArtifactType, bundleArtifactTypePrefix) {
+			continue
+		}
+		data, err := v.fetchBundle(ctx, digest.Context().Digest(desc.Digest.String()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch bundle %s: %w", desc.Digest, err)
+		}
+		bundles = append(bundles, referrerBundle{referrer: desc.Digest.String(), data: data})
+	}
+	return bundles, nil
+}
+
+// fetchBundle reads the bundle stored as the single layer of a referrer manifest
+func (v *Verifier) fetchBundle(ctx context.Context, ref name.Digest) ([]byte, error) {
+	img, err := remote.Image(ref, v.remote(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		return nil, errors.New("referrer has no layers")
+	}
+	rc, err := layers[0].Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxBundleSize))
+}
+
+// hasSignatureTag reports whether digest has a legacy cosign signature tag
+func (v *Verifier) hasSignatureTag(ctx context.Context, digest name.Digest) bool {
+	tag := digest.Context().Tag(strings.Replace(digest.DigestStr(), ":", "-", 1) + ".sig")
+	_, err := remote.Head(tag, v.remote(ctx)...)
+	if err != nil {
+		var terr *transport.Error
+		if !errors.As(err, &terr) || terr.StatusCode != http.StatusNotFound {
+			v.logger.DebugContext(ctx, "failed to check cosign signature tag", "tag", tag.String(), "error", err)
+		}
+		return false
+	}
+	return true
+}
+
+// identitySANRegexp returns the certificate SAN regexp bundles must match by
+// default: the workflows of the GitHub repository a ghcr.io image is published
+// from, named by the first two components of its path. ok is false for images
+// on other registries, whose path says nothing about their source.
+func identitySANRegexp(repository name.Repository) (sanRegexp string, ok bool) {
+	if repository.RegistryStr() != githubRegistry {
+		return "", false
+	}
+	parts := strings.Split(repository.RepositoryStr(), "/")
+	if len(parts) < 2 {
+		return "", false
+	}
+	// GitHub names are case-insensitive, while image paths are lowercase
+	return "^https://github.com/(?i:" + regexp.QuoteMeta(parts[0]+"/"+parts[1]) + ")/", true
+}
+
+// verifyBundle verifies one bundle against the image manifest digest,
+// requiring a certificate SAN matching sanRegexp
+func (v *Verifier) verifyBundle(b referrerBundle, digest []byte, sanRegexp string) bundleOutcome {
+	outcome := bundleOutcome{referrer: b.referrer}
+
+	// Images are expected to be signed from GitHub Actions, as for npm, unless overridden
+	identityPolicy, err := v.certIdentity.PolicyOption(sigstore.GitHubActionsIssuer, sanRegexp)
+	if err != nil {
+		outcome.err = err
+		return outcome
+	}
+
+	verifyResult, err := v.bundleVerifier.VerifyBundle(b.data, "sha256", digest, identityPolicy)
+	if err != nil {
+		outcome.err = err
+		return outcome
+	}
+
+	// The statement must describe this image, not merely be signed over its digest
+	outcome.subject, outcome.err = sigstore.MatchSubject(verifyResult, "sha256", digest)
+	if outcome.err != nil {
+		return outcome
+	}
+	outcome.publisher = sigstore.ExtractPublisherInfo(verifyResult)
+	outcome.raw = verifyResult
+	return outcome
+}
+
+// bundleOutcome records the verification result of a single attached bundle
+type bundleOutcome struct {
+	referrer  string
+	publisher *domain.TrustedPublisher
+	subject   *sigstore.Subject
+	// raw is the full Sigstore verification result, once the bundle verified
+	raw *verify.VerificationResult
+	err error
+}
+
+// applyBundleOutcomes sets the status and details of result from the outcome
+// of every attached bundle. The image is VERIFIED if any bundle verifies.
+func applyBundleOutcomes(result *domain.ProvenanceResult, outcomes []bundleOutcome) {
+	result.HasAttestations = true
+	result.AttestationCount = len(outcomes)
+	result.Status = domain.ProvenanceStatusAttestations
+
+	statuses := make(map[string]string, len(outcomes))
+	var firstErr error
+	for _, outcome := range outcomes {
+		if outcome.err != nil {
+			statuses[outcome.referrer] = "failed: " + outcome.err.Error()
+			if firstErr == nil {
+				firstErr = outcome.err
+			}
+			continue
+		}
+
+		statuses[outcome.referrer] = "verified"
+		result.VerifiedAttestationCount++
+		if result.Status != domain.ProvenanceStatusVerified {
+			result.Status = domain.ProvenanceStatusVerified
+			result.TrustedPublisher = outcome.publisher
+			if outcome.subject != nil {
+				result.Details["subject_name"] = outcome.subject.Name
+				result.Details["subject_digest"] = outcome.subject.Digest
+			}
+		}
+	}
+	result.Details["bundles"] = statuses
+
+	if result.Status != domain.ProvenanceStatusVerified && firstErr != nil {
+		result.ErrorMessage = fmt.Sprintf("bundle verification failed: %v", firstErr)
+		result.Details["verification_error"] = firstErr.Error()
+	}
+}
@@ -0,0 +1,237 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+const bundleArtifactType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+// pushImage pushes a random image to repo:tag on the registry at host and
+// returns its descriptor.
+func pushImage(t *testing.T, host, repo, tag string) *v1.Descriptor {
+	t.Helper()
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatalf("random.Image: %v", err)
+	}
+	ref, err := name.NewTag(host+"/"+repo+":"+tag, name.Insecure)
+	if err != nil {
+		t.Fatalf("NewTag: %v", err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatalf("push image: %v", err)
+	}
+	desc, err := remote.Head(ref)
+	if err != nil {
+		t.Fatalf("head image: %v", err)
+	}
+	return desc
+}
+
+// attachBundle pushes bundle as a referrer of subject in repo.
+func attachBundle(t *testing.T, host, repo string, subject *v1.Descriptor, bundle string) {
+	t.Helper()
+
+	layer := static.NewLayer([]byte(bundle), types.MediaType(bundleArtifactType))
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatalf("AppendLayers: %v", err)
+	}
+	img = mutate.ConfigMediaType(mutate.MediaType(img, types.OCIManifestSchema1), bundleArtifactType)
+	img = mutate.Subject(img, *subject).(v1.Image)
+
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("referrer digest: %v", err)
+	}
+	ref, err := name.NewDigest(host+"/"+repo+"@"+digest.String(), name.Insecure)
+	if err != nil {
+		t.Fatalf("NewDigest: %v", err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatalf("push referrer: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(registry.New(registry.WithReferrersSupport(true), registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	pushImage(t, host, "org/unsigned", "1.0.0")
+	signed := pushImage(t, host, "org/signed", "1.0.0")
+	attachBundle(t, host, "org/signed", signed, `not a bundle`)
+
+	v := &Verifier{
+		certIdentity: sigstore.CertificateIdentity{SANRegexp: "^https://github.com/org/"},
+		logger:       slog.New(slog.DiscardHandler),
+		nameOptions:  []name.Option{name.Insecure},
+	}
+	ctx := context.Background()
+
+	t.Run("no bundles", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := v.Verify(ctx, domain.PackageIdentifier{
+			Protocol: domain.ProtocolOCI, Name: host + "/org/unsigned", Version: "1.0.0"})
+		if err != nil {
+			t.Fatalf("Verify() error: %v", err)
+		}
+		if result.Status != domain.ProvenanceStatusNone {
+			t.Errorf("Status = %s, want %s", result.Status, domain.ProvenanceStatusNone)
+		}
+	})
+
+	t.Run("bundle that does not verify", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := v.Verify(ctx, domain.PackageIdentifier{
+			Protocol: domain.ProtocolOCI, Name: host + "/org/signed", Version: signed.Digest.String()})
+		if err != nil {
+			t.Fatalf("Verify() error: %v", err)
+		}
+		if result.Status != domain.ProvenanceStatusAttestations || result.AttestationCount != 1 {
+			t.Errorf("Status = %s with %d attestations, want %s with 1",
+				result.Status, result.AttestationCount, domain.ProvenanceStatusAttestations)
+		}
+		if !strings.Contains(result.ErrorMessage, "not a valid Sigstore bundle") {
+			t.Errorf("ErrorMessage = %q, want an invalid bundle error", result.ErrorMessage)
+		}
+		if result.Details["image_digest"] != signed.Digest.String() {
+			t.Errorf("image_digest = %v, want %s", result.Details["image_digest"], signed.Digest)
+		}
+	})
+
+	t.Run("signer identity required outside ghcr.io", func(t *testing.T) {
+		t.Parallel()
+
+		anySigner := &Verifier{logger: slog.New(slog.DiscardHandler), nameOptions: []name.Option{name.Insecure}}
+		result, err := anySigner.Verify(ctx, domain.PackageIdentifier{
+			Protocol: domain.ProtocolOCI, Name: host + "/org/signed", Version: signed.Digest.String()})
+		if !errors.Is(err, ErrSignerIdentityRequired) || result.Status != domain.ProvenanceStatusError {
+			t.Errorf("Verify() = %s, %v, want ERROR with ErrSignerIdentityRequired", result.Status, err)
+		}
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := v.Verify(ctx, domain.PackageIdentifier{
+			Protocol: domain.ProtocolOCI, Name: host + "/org/missing", Version: "1.0.0"})
+		if err == nil || result.Status != domain.ProvenanceStatusError {
+			t.Errorf("Verify() = %s, %v, want ERROR with an error", result.Status, err)
+		}
+	})
+}
+
+func TestIdentitySANRegexp(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		image  string
+		want   string
+		wantOK bool
+	}{
+		{"ghcr.io/stacklok/dockyard/npx/context7", `^https://github.com/(?i:stacklok/dockyard)/`, true},
+		{"ghcr.io/org/server", `^https://github.com/(?i:org/server)/`, true},
+		{"ghcr.io/server", "", false},
+		{"docker.io/org/server", "", false},
+		{"registry.example.com/org/server", "", false},
+	}
+	for _, tt := range tests {
+		repository, err := name.NewRepository(tt.image)
+		if err != nil {
+			t.Fatalf("NewRepository(%q): %v", tt.image, err)
+		}
+		got, ok := identitySANRegexp(repository)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("identitySANRegexp(%q) = %q, %v, want %q, %v", tt.image, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// The scoped regexp accepts the repository's workflows and nothing else
+	sanRegexp, _ := identitySANRegexp(name.MustParseReference("ghcr.io/stacklok/dockyard").Context())
+	re := regexp.MustCompile(sanRegexp)
+	if !re.MatchString("https://github.com/Stacklok/dockyard/.github/workflows/release.yml@refs/tags/v1") {
+		t.Errorf("%s does not match a workflow of the image's repository", sanRegexp)
+	}
+	if re.MatchString("https://github.com/attacker/dockyard/.github/workflows/release.yml@refs/heads/main") ||
+		re.MatchString("https://github.com/stacklok/dockyard-fork/.github/workflows/release.yml@refs/heads/main") {
+		t.Errorf("%s matches a workflow of another repository", sanRegexp)
+	}
+}
+
+func TestApplyBundleOutcomes_Publisher(t *testing.T) {
+	t.Parallel()
+
+	// The publisher is the source repository the verified certificate names
+	publisher := sigstore.ExtractPublisherInfo(&verify.VerificationResult{
+		Signature: &verify.SignatureVerificationResult{Certificate: &certificate.Summary{
+			Extensions: certificate.Extensions{SourceRepositoryURI: "https://github.com/stacklok/dockyard"},
+		}},
+	})
+	result := &domain.ProvenanceResult{Details: make(map[string]interface{})}
+	applyBundleOutcomes(result, []bundleOutcome{
+		{referrer: "sha256:aa", err: errors.New("bad signature")},
+		{referrer: "sha256:bb", publisher: publisher},
+	})
+
+	if result.Status != domain.ProvenanceStatusVerified {
+		t.Errorf("Status = %s, want %s", result.Status, domain.ProvenanceStatusVerified)
+	}
+	if result.TrustedPublisher == nil || result.TrustedPublisher.Repository != "stacklok/dockyard" {
+		t.Errorf("TrustedPublisher = %+v, want repository stacklok/dockyard", result.TrustedPublisher)
+	}
+}
+
+func TestImageReference(t *testing.T) {
+	t.Parallel()
+
+	const digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	tests := []struct {
+		repository, version, want string
+	}{
+		{"ghcr.io/org/server", "1.2.3", "ghcr.io/org/server:1.2.3"},
+		{"ghcr.io/org/server", digest, "ghcr.io/org/server@" + digest},
+		{"ghcr.io/org/server", "", "ghcr.io/org/server:latest"},
+	}
+	for _, tt := range tests {
+		ref, err := ImageReference(tt.repository, tt.version)
+		if err != nil {
+			t.Errorf("ImageReference(%q, %q) error: %v", tt.repository, tt.version, err)
+			continue
+		}
+		if ref.Name() != tt.want {
+			t.Errorf("ImageReference(%q, %q) = %q, want %q", tt.repository, tt.version, ref.Name(), tt.want)
+		}
+	}
+	if _, err := ImageReference("ghcr.io/Org/Server", "1.0"); err == nil {
+		t.Error("ImageReference(uppercase repository) = nil error, want error")
+	}
+}
@@ -0,0 +1,79 @@
+package plugin
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// Request is the JSON document a plugin reads from stdin: the package to
+// verify. Version may be empty, meaning the latest version.
+type Request struct {
+	Protocol string `json:"protocol"`
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"`
+}
+
+// Response is the JSON document a plugin writes to stdout, the same shape as
+// the result `dockhand verify-provenance --output-format json` prints. Only
+// status is required.
+type Response struct {
+	// Status is one of VERIFIED, TRUSTED_PUBLISHER, ATTESTATIONS, SIGNATURES,
+	// NONE, UNKNOWN, or ERROR
+	Status                   string                 `json:"status"`
+	HasAttestations          bool                   `json:"has_attestations,omitempty"`
+	AttestationCount         int                    `json:"attestation_count,omitempty"`
+	VerifiedAttestationCount int                    `json:"verified_attestation_count,omitempty"`
+	HasSignatures            bool                   `json:"has_signatures,omitempty"`
+	TrustedPublisher         *Publisher             `json:"trusted_publisher,omitempty"`
+	RepositoryURI            string                 `json:"repository_uri,omitempty"`
+	Error                    string                 `json:"error,omitempty"`
+	Details                  map[string]interface{} `json:"details,omitempty"`
+}
+
+// Publisher is the trusted publisher of a Response.
+type Publisher struct {
+	Kind       string `json:"kind,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Workflow   string `json:"workflow,omitempty"`
+}
+
+// statuses are the statuses a Response may report
+var statuses = []domain.ProvenanceStatus{
+	domain.ProvenanceStatusVerified,
+	domain.ProvenanceStatusTrustedPublisher,
+	domain.ProvenanceStatusAttestations,
+	domain.ProvenanceStatusSignatures,
+	domain.ProvenanceStatusNone,
+	domain.ProvenanceStatusUnknown,
+	domain.ProvenanceStatusError,
+}
+
+// result converts the response to the result for pkg. The package is the one
+// requested, whatever the plugin may claim.
+func (r *Response) result(pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	status := domain.ProvenanceStatus(r.Status)
+	if !slices.Contains(statuses, status) {
+		return nil, fmt.Errorf("invalid status %q", r.Status)
+	}
+
+	result := &domain.ProvenanceResult{
+		PackageID:                pkg,
+		Status:                   status,
+		HasAttestations:          r.HasAttestations,
+		AttestationCount:         r.AttestationCount,
+		VerifiedAttestationCount: r.VerifiedAttestationCount,
+		HasSignatures:            r.HasSignatures,
+		RepositoryURI:            r.RepositoryURI,
+		ErrorMessage:             r.Error,
+		Details:                  r.Details,
+	}
+	if p := r.TrustedPublisher; p != nil {
+		result.TrustedPublisher = &domain.TrustedPublisher{Kind: p.Kind, Repository: p.Repository, Workflow: p.Workflow}
+	}
+	if result.Details == nil {
+		result.Details = make(map[string]interface{})
+	}
+	return result, nil
+}
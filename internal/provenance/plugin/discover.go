@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// ExecutablePrefix is the name prefix of plugin executables, followed by the
+// protocol they verify, e.g. dockhand-verifier-cargo.
+const ExecutablePrefix = "dockhand-verifier-"
+
+// protocolRe matches the protocol names a plugin may verify
+var protocolRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Discover finds the plugin executables in the directories of pathList, a
+// PATH-style list, and returns the path of each by protocol. As with command
+// lookup, the first directory providing a protocol wins.
+func Discover(pathList string) map[domain.PackageProtocol]string {
+	plugins := make(map[domain.PackageProtocol]string)
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			protocol, ok := pluginProtocol(entry.Name())
+			if !ok {
+				continue
+			}
+			if _, found := plugins[protocol]; found {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if isExecutable(path) {
+				plugins[protocol] = path
+			}
+		}
+	}
+	return plugins
+}
+
+// pluginProtocol returns the protocol a plugin executable named name verifies
+func pluginProtocol(name string) (domain.PackageProtocol, bool) {
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	}
+	protocol, ok := strings.CutPrefix(name, ExecutablePrefix)
+	if !ok || !protocolRe.MatchString(protocol) {
+		return "", false
+	}
+	return domain.PackageProtocol(protocol), true
+}
+
+// isExecutable reports whether path is a regular file that can be executed,
+// following symlinks
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0o111 != 0
+}
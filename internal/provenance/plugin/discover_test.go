@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bits are not used on Windows")
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	cargoPath := writePlugin(t, first, "cargo", "exit 0")
+	writePlugin(t, second, "cargo", "exit 0")
+	gemPath := writePlugin(t, second, "gem", "exit 0")
+	writePlugin(t, first, "Bad.Name", "exit 0")
+	// Not executable
+	if err := os.WriteFile(filepath.Join(first, ExecutablePrefix+"nuget"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A directory is never a plugin
+	if err := os.Mkdir(filepath.Join(first, ExecutablePrefix+"hex"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	pathList := strings.Join([]string{first, "", filepath.Join(first, "missing"), second}, string(os.PathListSeparator))
+	got := Discover(pathList)
+
+	want := map[domain.PackageProtocol]string{"cargo": cargoPath, "gem": gemPath}
+	if len(got) != len(want) {
+		t.Fatalf("Discover() = %v, want %v", got, want)
+	}
+	for protocol, path := range want {
+		if got[protocol] != path {
+			t.Errorf("Discover()[%s] = %q, want %q", protocol, got[protocol], path)
+		}
+	}
+}
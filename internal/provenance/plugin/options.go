@@ -0,0 +1,40 @@
+package plugin
+
+import (
+	"log/slog"
+	"time"
+)
+
+// options holds the settings applied by NewVerifier.
+type options struct {
+	timeout       time.Duration
+	logger        *slog.Logger
+	maxOutputSize int64
+}
+
+// Option configures a Verifier.
+type Option func(*options)
+
+// WithTimeout sets how long one plugin run may take before it is killed and
+// the verification fails. The default is DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithLogger sets the logger used for debug output about plugin runs. By
+// default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMaxOutputSize caps how many bytes a plugin may write to stdout; a
+// plugin writing more fails verification. The default is DefaultMaxOutputSize.
+func WithMaxOutputSize(n int64) Option {
+	return func(o *options) {
+		o.maxOutputSize = n
+	}
+}
@@ -0,0 +1,173 @@
+// Package plugin implements provenance verification by external programs, for
+// ecosystems dockyard has no built-in verifier for.
+//
+// A plugin is an executable named dockhand-verifier-<protocol> on PATH. For
+// each package it is run with a Request as JSON on stdin and must write a
+// Response as JSON on stdout and exit 0. Anything it writes to stderr is
+// included in the error when it fails. A plugin that exits non-zero, runs
+// longer than its timeout, or writes an invalid response fails verification
+// with an ERROR result.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+const (
+	// DefaultTimeout is how long a plugin run may take unless WithTimeout says otherwise
+	DefaultTimeout = 2 * time.Minute
+	// DefaultMaxOutputSize is how much a plugin may write to stdout unless
+	// WithMaxOutputSize says otherwise
+	DefaultMaxOutputSize = 10 << 20
+	// maxStderr is how much of a failing plugin's stderr is kept for its error
+	maxStderr = 4 << 10
+)
+
+// Verifier verifies packages of one protocol by running a plugin executable.
+type Verifier struct {
+	protocol      domain.PackageProtocol
+	path          string
+	timeout       time.Duration
+	logger        *slog.Logger
+	maxOutputSize int64
+}
+
+// NewVerifier creates a verifier for protocol that runs the plugin at path.
+func NewVerifier(protocol domain.PackageProtocol, path string, opts ...Option) *Verifier {
+	o := options{timeout: DefaultTimeout, maxOutputSize: DefaultMaxOutputSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	logger := o.logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	return &Verifier{
+		protocol:      protocol,
+		path:          path,
+		timeout:       o.timeout,
+		logger:        logger,
+		maxOutputSize: o.maxOutputSize,
+	}
+}
+
+// SupportsProtocol returns true for the protocol the plugin was found for
+func (v *Verifier) SupportsProtocol(protocol domain.PackageProtocol) bool {
+	return protocol == v.protocol
+}
+
+// Verify runs the plugin for pkg and returns the result it reports
+func (v *Verifier) Verify(ctx context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	if pkg.Protocol != v.protocol {
+		return nil, fmt.Errorf("%s plugin does not support protocol %s", v.protocol, pkg.Protocol)
+	}
+
+	response, err := v.run(ctx, pkg)
+	if err != nil {
+		err = fmt.Errorf("%s verifier plugin failed: %w", v.protocol, err)
+		return &domain.ProvenanceResult{
+			PackageID:    pkg,
+			Status:       domain.ProvenanceStatusError,
+			ErrorMessage: err.Error(),
+			Details:      map[string]interface{}{"plugin": v.path},
+		}, err
+	}
+
+	result, err := response.result(pkg)
+	if err != nil {
+		err = fmt.Errorf("%s verifier plugin returned an invalid response: %w", v.protocol, err)
+		return &domain.ProvenanceResult{
+			PackageID:    pkg,
+			Status:       domain.ProvenanceStatusError,
+			ErrorMessage: err.Error(),
+			Details:      map[string]interface{}{"plugin": v.path},
+		}, err
+	}
+	result.Details["plugin"] = v.path
+	return result, nil
+}
+
+// run executes the plugin with pkg on stdin and decodes its response
+func (v *Verifier) run(ctx context.Context, pkg domain.PackageIdentifier) (*Response, error) {
+	request, err := json.Marshal(Request{Protocol: string(pkg.Protocol), Name: pkg.Name, Version: pkg.Version})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: v.maxOutputSize}
+	stderr := &limitedBuffer{limit: maxStderr, truncate: true}
+	// #nosec G204 -- the plugin is an executable the user installed on PATH
+	cmd := exec.CommandContext(ctx, v.path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	v.logger.DebugContext(ctx, "ran verifier plugin",
+		"plugin", v.path, "package", pkg.Name, "version", pkg.Version, "duration", time.Since(start), "error", err)
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("timed out after %s", v.timeout)
+	case stdout.exceeded:
+		return nil, fmt.Errorf("wrote more than %d bytes to stdout", v.maxOutputSize)
+	case err != nil:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			err = fmt.Errorf("exited with status %d", exitErr.ExitCode())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var response Response
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &response, nil
+}
+
+// limitedBuffer collects up to limit bytes. Past the limit it either drops
+// the rest, when truncate is set, or fails the write, stopping the plugin.
+// The buffer is not embedded so io.Copy cannot bypass Write with ReadFrom.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	truncate bool
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	room := b.limit - int64(b.buf.Len())
+	if int64(len(p)) <= room {
+		return b.buf.Write(p)
+	}
+	b.exceeded = true
+	if !b.truncate {
+		return 0, errors.New("output limit exceeded")
+	}
+	if room > 0 {
+		b.buf.Write(p[:room])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) Bytes() []byte  { return b.buf.Bytes() }
+func (b *limitedBuffer) String() string { return b.buf.String() }
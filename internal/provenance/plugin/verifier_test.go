@@ -0,0 +1,159 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+const cargo domain.PackageProtocol = "cargo"
+
+// writePlugin writes a shell script plugin with body to a temporary directory
+// and returns its path.
+func writePlugin(t *testing.T, dir, protocol, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, ExecutablePrefix+protocol)
+	//#nosec G306 -- the test plugin must be executable
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return path
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins in this test are shell scripts")
+	}
+
+	pkg := domain.PackageIdentifier{Protocol: cargo, Name: "ripgrep", Version: "14.1.0"}
+	tests := []struct {
+		name       string
+		body       string
+		opts       []Option
+		wantStatus domain.ProvenanceStatus
+		wantErr    string
+	}{
+		{
+			name: "verified",
+			body: `read request
+case "$request" in
+*'"name":"ripgrep"'*) ;;
+*) exit 3 ;;
+esac
+echo '{"status":"VERIFIED","has_attestations":true,"attestation_count":1,"verified_attestation_count":1,` +
+				`"repository_uri":"https://github.com/BurntSushi/ripgrep","details":{"crate_digest":"sha256:aa"}}'`,
+			wantStatus: domain.ProvenanceStatusVerified,
+		},
+		{
+			name:       "reported error",
+			body:       `echo '{"status":"ERROR","error":"crate not found"}'`,
+			wantStatus: domain.ProvenanceStatusError,
+		},
+		{
+			name:       "non-zero exit",
+			body:       `echo "registry unreachable" >&2; exit 2`,
+			wantStatus: domain.ProvenanceStatusError,
+			wantErr:    "cargo verifier plugin failed: exited with status 2: registry unreachable",
+		},
+		{
+			name:       "invalid JSON",
+			body:       `echo 'not json'`,
+			wantStatus: domain.ProvenanceStatusError,
+			wantErr:    "failed to decode response",
+		},
+		{
+			name:       "invalid status",
+			body:       `echo '{"status":"MAYBE"}'`,
+			wantStatus: domain.ProvenanceStatusError,
+			wantErr:    `invalid response: invalid status "MAYBE"`,
+		},
+		{
+			name:       "timeout",
+			body:       `exec sleep 10`,
+			opts:       []Option{WithTimeout(100 * time.Millisecond)},
+			wantStatus: domain.ProvenanceStatusError,
+			wantErr:    "timed out after 100ms",
+		},
+		{
+			name:       "output too large",
+			body:       `echo '{"status":"NONE","details":{"padding":"xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"}}'`,
+			opts:       []Option{WithMaxOutputSize(32)},
+			wantStatus: domain.ProvenanceStatusError,
+			wantErr:    "wrote more than 32 bytes to stdout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := writePlugin(t, t.TempDir(), string(cargo), tt.body)
+			v := NewVerifier(cargo, path, tt.opts...)
+
+			result, err := v.Verify(context.Background(), pkg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if result == nil || result.ErrorMessage != err.Error() {
+					t.Errorf("result error message = %v, want %q", result, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Verify() unexpected error: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.PackageID != pkg {
+				t.Errorf("PackageID = %+v, want %+v", result.PackageID, pkg)
+			}
+			if result.Details["plugin"] != path {
+				t.Errorf("Details[plugin] = %v, want %s", result.Details["plugin"], path)
+			}
+		})
+	}
+}
+
+func TestVerifier_VerifyResponseFields(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins in this test are shell scripts")
+	}
+
+	path := writePlugin(t, t.TempDir(), string(cargo), `cat >/dev/null
+echo '{"status":"TRUSTED_PUBLISHER","trusted_publisher":{"kind":"GitHub","repository":"o/r","workflow":"release.yml"},`+
+		`"package":{"name":"other"}}'`)
+	pkg := domain.PackageIdentifier{Protocol: cargo, Name: "ripgrep", Version: "14.1.0"}
+
+	result, err := NewVerifier(cargo, path).Verify(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Verify() unexpected error: %v", err)
+	}
+	if result.TrustedPublisher == nil || result.TrustedPublisher.Workflow != "release.yml" {
+		t.Errorf("TrustedPublisher = %+v, want workflow release.yml", result.TrustedPublisher)
+	}
+	if result.PackageID != pkg {
+		t.Errorf("PackageID = %+v, want the requested package %+v", result.PackageID, pkg)
+	}
+}
+
+func TestVerifier_SupportsProtocol(t *testing.T) {
+	t.Parallel()
+
+	v := NewVerifier(cargo, "/nonexistent")
+	if !v.SupportsProtocol(cargo) {
+		t.Error("SupportsProtocol(cargo) = false, want true")
+	}
+	if v.SupportsProtocol(domain.ProtocolNPM) {
+		t.Error("SupportsProtocol(npx) = true, want false")
+	}
+	if _, err := v.Verify(context.Background(), domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "x"}); err == nil {
+		t.Error("Verify() for another protocol succeeded, want error")
+	}
+}
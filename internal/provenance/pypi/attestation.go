@@ -0,0 +1,133 @@
+package pypi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// bundleMediaType is the Sigstore bundle version attestations are converted to
+	bundleMediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+	// inTotoPayloadType is the DSSE payload type of in-toto statements
+	inTotoPayloadType = "application/vnd.in-toto+json"
+)
+
+// attestationObject is a single entry of a provenance object's attestations.
+// PEP 740 defines it as a DSSE envelope, whose statement and signature are
+// base64, plus the verification material needed to check it. Some producers
+// instead publish a ready-made Sigstore bundle, recognized by its mediaType.
+type attestationObject struct {
+	MediaType            string                `json:"mediaType"`
+	VerificationMaterial *verificationMaterial `json:"verification_material"`
+	Envelope             *attestationEnvelope  `json:"envelope"`
+}
+
+// verificationMaterial is the signing certificate and transparency log
+// entries of a PEP 740 attestation
+type verificationMaterial struct {
+	Certificate         string            `json:"certificate"`
+	TransparencyEntries []json.RawMessage `json:"transparency_entries"`
+}
+
+// attestationEnvelope is the envelope of a PEP 740 attestation. It is accepted
+// both in PEP 740's compact form (statement, signature) and as a standard
+// DSSE envelope (payload, payloadType, signatures).
+type attestationEnvelope struct {
+	Statement   string          `json:"statement"`
+	Signature   string          `json:"signature"`
+	Payload     string          `json:"payload"`
+	PayloadType string          `json:"payloadType"`
+	Signatures  []dsseSignature `json:"signatures"`
+}
+
+// sigstoreBundle is the subset of the Sigstore bundle format needed to carry
+// a DSSE-signed attestation with its verification material
+type sigstoreBundle struct {
+	MediaType            string                     `json:"mediaType"`
+	VerificationMaterial bundleVerificationMaterial `json:"verificationMaterial"`
+	DSSEEnvelope         dsseEnvelope               `json:"dsseEnvelope"`
+}
+
+// bundleVerificationMaterial is a bundle's signing certificate and log entries
+type bundleVerificationMaterial struct {
+	Certificate bundleCertificate `json:"certificate"`
+	TlogEntries []json.RawMessage `json:"tlogEntries"`
+}
+
+// bundleCertificate is a DER certificate, base64 encoded
+type bundleCertificate struct {
+	RawBytes string `json:"rawBytes"`
+}
+
+// dsseEnvelope is a DSSE envelope in the Sigstore bundle's JSON form
+type dsseEnvelope struct {
+	Payload     string          `json:"payload"`
+	PayloadType string          `json:"payloadType"`
+	Signatures  []dsseSignature `json:"signatures"`
+}
+
+// dsseSignature is one signature of a DSSE envelope
+type dsseSignature struct {
+	Sig   string `json:"sig"`
+	KeyID string `json:"keyid,omitempty"`
+}
+
+// attestationBundle returns an attestation as Sigstore bundle JSON. A bundle
+// is returned unchanged; a PEP 740 DSSE envelope is wrapped into a bundle
+// together with its verification material.
+func attestationBundle(attestation json.RawMessage) ([]byte, error) {
+	var obj attestationObject
+	if err := json.Unmarshal(attestation, &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode attestation: %w", err)
+	}
+
+	switch {
+	case obj.MediaType != "":
+		return attestation, nil
+	case obj.Envelope == nil:
+		return nil, fmt.Errorf("attestation is neither a Sigstore bundle nor a DSSE envelope")
+	case obj.VerificationMaterial == nil || obj.VerificationMaterial.Certificate == "":
+		return nil, fmt.Errorf("DSSE attestation has no verification material")
+	}
+
+	envelope, err := obj.Envelope.dsse()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(sigstoreBundle{
+		MediaType: bundleMediaType,
+		VerificationMaterial: bundleVerificationMaterial{
+			Certificate: bundleCertificate{RawBytes: obj.VerificationMaterial.Certificate},
+			TlogEntries: obj.VerificationMaterial.TransparencyEntries,
+		},
+		DSSEEnvelope: envelope,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attestation bundle: %w", err)
+	}
+	return data, nil
+}
+
+// dsse returns the envelope in DSSE form. PEP 740 envelopes always carry an
+// in-toto statement with a single signature.
+func (e *attestationEnvelope) dsse() (dsseEnvelope, error) {
+	if e.Payload != "" {
+		if len(e.Signatures) == 0 {
+			return dsseEnvelope{}, fmt.Errorf("DSSE envelope has no signatures")
+		}
+		payloadType := e.PayloadType
+		if payloadType == "" {
+			payloadType = inTotoPayloadType
+		}
+		return dsseEnvelope{Payload: e.Payload, PayloadType: payloadType, Signatures: e.Signatures}, nil
+	}
+
+	if e.Statement == "" || e.Signature == "" {
+		return dsseEnvelope{}, fmt.Errorf("DSSE envelope lacks a statement or signature")
+	}
+	return dsseEnvelope{
+		Payload:     e.Statement,
+		PayloadType: inTotoPayloadType,
+		Signatures:  []dsseSignature{{Sig: e.Signature}},
+	}, nil
+}
@@ -0,0 +1,92 @@
+package pypi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+var (
+	// testStatement and testSignature stand in for a signed in-toto statement
+	testStatement = base64.StdEncoding.EncodeToString([]byte(`{"_type":"https://in-toto.io/Statement/v1"}`))
+	testSignature = base64.StdEncoding.EncodeToString([]byte("signature"))
+	testCert      = base64.StdEncoding.EncodeToString([]byte("certificate"))
+)
+
+// pep740Attestation is an attestation in PEP 740's own shape: a compact DSSE
+// envelope next to its verification material
+var pep740Attestation = `{
+  "version": 1,
+  "verification_material": {"certificate": "` + testCert + `", "transparency_entries": []},
+  "envelope": {"statement": "` + testStatement + `", "signature": "` + testSignature + `"}
+}`
+
+// dsseAttestation carries a standard DSSE envelope instead
+var dsseAttestation = `{
+  "version": 1,
+  "verification_material": {"certificate": "` + testCert + `", "transparency_entries": []},
+  "envelope": {"payload": "` + testStatement + `", "payloadType": "application/vnd.in-toto+json",
+    "signatures": [{"sig": "` + testSignature + `"}]}
+}`
+
+// bundleAttestation is an attestation published as a Sigstore bundle
+var bundleAttestation = `{
+  "mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json",
+  "verificationMaterial": {"certificate": {"rawBytes": "` + testCert + `"}, "tlogEntries": []},
+  "dsseEnvelope": {"payload": "` + testStatement + `", "payloadType": "application/vnd.in-toto+json",
+    "signatures": [{"sig": "` + testSignature + `"}]}
+}`
+
+func TestAttestationBundle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		attestation string
+		wantErr     bool
+	}{
+		{"PEP 740 envelope", pep740Attestation, false},
+		{"DSSE envelope", dsseAttestation, false},
+		{"Sigstore bundle", bundleAttestation, false},
+		{"no envelope", `{"version": 1, "verification_material": {"certificate": "` + testCert + `"}}`, true},
+		{"no verification material", `{"version": 1, "envelope": {"statement": "` + testStatement +
+			`", "signature": "` + testSignature + `"}}`, true},
+		{"unsigned envelope", `{"verification_material": {"certificate": "` + testCert + `"}, "envelope": {"statement": "` +
+			testStatement + `"}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := attestationBundle(json.RawMessage(tt.attestation))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("attestationBundle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// The result must be a bundle sigstore-go accepts, carrying the envelope
+			var b bundle.Bundle
+			if err := json.Unmarshal(data, &b); err != nil {
+				t.Fatalf("attestationBundle() = %s, not a valid Sigstore bundle: %v", data, err)
+			}
+			envelope := b.GetDsseEnvelope()
+			if envelope == nil || len(envelope.GetSignatures()) != 1 {
+				t.Fatalf("bundle envelope = %v, want one signature", envelope)
+			}
+			if got := base64.StdEncoding.EncodeToString(envelope.GetPayload()); got != testStatement {
+				t.Errorf("payload = %s, want the attestation statement", got)
+			}
+			if envelope.GetPayloadType() != inTotoPayloadType {
+				t.Errorf("payloadType = %q, want %q", envelope.GetPayloadType(), inTotoPayloadType)
+			}
+			if got := string(b.GetVerificationMaterial().GetCertificate().GetRawBytes()); got != "certificate" {
+				t.Errorf("certificate = %q, want the attestation certificate", got)
+			}
+		})
+	}
+}
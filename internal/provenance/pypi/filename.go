@@ -0,0 +1,184 @@
+package pypi
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sdistExtensions lists the source distribution archive suffixes PyPI accepts.
+// PEP 625 standardises on .tar.gz, the others are kept for older releases.
+var sdistExtensions = []string{".tar.gz", ".zip", ".tar.bz2", ".tgz"}
+
+// nameSeparatorRe matches runs of the characters PEP 503 treats as equivalent.
+var nameSeparatorRe = regexp.MustCompile(`[-_.]+`)
+
+// parseFilename extracts the project name and version from a wheel or sdist
+// filename. Wheels follow PEP 427 ({name}-{version}(-{build})?-{python}-{abi}-{platform}.whl)
+// and sdists follow {name}-{version}.tar.gz. ok is false for any other shape.
+func parseFilename(filename string) (name, version string, ok bool) {
+	if base, isWheel := strings.CutSuffix(filename, ".whl"); isWheel {
+		parts := strings.Split(base, "-")
+		if len(parts) != 5 && len(parts) != 6 {
+			return "", "", false
+		}
+		return parts[0], parts[1], parts[0] != "" && parts[1] != ""
+	}
+
+	for _, ext := range sdistExtensions {
+		base, isSdist := strings.CutSuffix(filename, ext)
+		if !isSdist {
+			continue
+		}
+		// Legacy sdist names may contain dashes, but the version never does,
+		// so the last dash separates the two components.
+		idx := strings.LastIndex(base, "-")
+		if idx <= 0 || idx == len(base)-1 {
+			return "", "", false
+		}
+		return base[:idx], base[idx+1:], true
+	}
+
+	return "", "", false
+}
+
+// ArtifactKind selects which distribution files of a release are verified.
+type ArtifactKind string
+
+const (
+	// ArtifactKindAny verifies every file of a release
+	ArtifactKindAny ArtifactKind = "any"
+	// ArtifactKindWheel verifies only wheels, which installers such as uv prefer
+	ArtifactKindWheel ArtifactKind = "wheel"
+	// ArtifactKindSdist verifies only source distributions
+	ArtifactKindSdist ArtifactKind = "sdist"
+)
+
+// ParseArtifactKind returns the artifact kind s names: wheel, sdist, or any.
+func ParseArtifactKind(s string) (ArtifactKind, error) {
+	switch kind := ArtifactKind(strings.ToLower(s)); kind {
+	case ArtifactKindAny, ArtifactKindWheel, ArtifactKindSdist:
+		return kind, nil
+	}
+	return "", fmt.Errorf("invalid artifact kind %q, must be one of: wheel, sdist, any", s)
+}
+
+// fileKind classifies a distribution file by its filename, returning an empty
+// kind for a file that is neither a wheel nor an sdist.
+func fileKind(filename string) ArtifactKind {
+	if strings.HasSuffix(filename, ".whl") {
+		return ArtifactKindWheel
+	}
+	for _, ext := range sdistExtensions {
+		if strings.HasSuffix(filename, ext) {
+			return ArtifactKindSdist
+		}
+	}
+	return ""
+}
+
+// includes reports whether filename is a file of kind k. The empty kind, like
+// ArtifactKindAny, includes every file.
+func (k ArtifactKind) includes(filename string) bool {
+	return k == "" || k == ArtifactKindAny || fileKind(filename) == k
+}
+
+// normalizeName normalizes a project name per PEP 503.
+func normalizeName(name string) string {
+	return strings.ToLower(nameSeparatorRe.ReplaceAllString(name, "-"))
+}
+
+// normalizeVersion applies the subset of PEP 440 normalization needed to
+// compare versions taken from filenames against versions from a spec: case
+// folding, dropping a leading "v", and canonicalising local segment separators.
+func normalizeVersion(version string) string {
+	v := strings.ToLower(strings.TrimSpace(version))
+	v = strings.TrimPrefix(v, "v")
+
+	public, local, hasLocal := strings.Cut(v, "+")
+	if !hasLocal {
+		return public
+	}
+	local = strings.NewReplacer("-", ".", "_", ".").Replace(local)
+	return public + "+" + local
+}
+
+// matchesRelease reports whether filename is a distribution of the given
+// project name and exact version.
+func matchesRelease(filename, name, version string) bool {
+	fileName, fileVersion, ok := parseFilename(filename)
+	if !ok {
+		return false
+	}
+	return normalizeName(fileName) == normalizeName(name) &&
+		normalizeVersion(fileVersion) == normalizeVersion(version)
+}
+
+// releaseVersions returns the distinct versions of project name among files,
+// in order of first appearance. Spellings that normalize to the same version
+// are listed once.
+func releaseVersions(files []File, name string) []string {
+	seen := make(map[string]bool)
+	var versions []string
+	for _, file := range files {
+		fileName, version, ok := parseFilename(file.Filename)
+		if !ok || normalizeName(fileName) != normalizeName(name) || seen[normalizeVersion(version)] {
+			continue
+		}
+		seen[normalizeVersion(version)] = true
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// finalReleaseRe matches the PEP 440 versions that are final releases, with an
+// optional post-release: no pre-release, development, or local segment.
+var finalReleaseRe = regexp.MustCompile(`^v?([0-9]+(?:\.[0-9]+)*)(?:[._-]?post[._-]?([0-9]*))?$`)
+
+// latestFinalRelease returns the highest final release among versions, in
+// PEP 440 order.
+func latestFinalRelease(versions []string) (string, bool) {
+	var latest string
+	var latestKey []int
+	for _, version := range versions {
+		key, ok := finalReleaseKey(version)
+		if ok && (latestKey == nil || slices.Compare(key, latestKey) > 0) {
+			latest, latestKey = version, key
+		}
+	}
+	return latest, latestKey != nil
+}
+
+// finalReleaseKey returns a sort key for a final release: its release
+// segments, padded so that 1.0 and 1.0.0 compare equal, and its post-release
+// number, or -1 for none.
+func finalReleaseKey(version string) ([]int, bool) {
+	version = strings.ToLower(strings.TrimSpace(version))
+	m := finalReleaseRe.FindStringSubmatch(version)
+	if m == nil {
+		return nil, false
+	}
+	const segments = 8
+	parts := strings.Split(m[1], ".")
+	if len(parts) > segments {
+		return nil, false
+	}
+	key := make([]int, segments+1)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		key[i] = n
+	}
+	key[segments] = -1
+	if strings.Contains(version, "post") {
+		key[segments] = 0
+		if m[2] != "" {
+			key[segments], _ = strconv.Atoi(m[2])
+		}
+	}
+	return key, true
+}
@@ -0,0 +1,192 @@
+package pypi
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFilename(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filename    string
+		wantName    string
+		wantVersion string
+		wantOK      bool
+	}{
+		{"pkg-1.2.3-py3-none-any.whl", "pkg", "1.2.3", true},
+		{"mcp_clickhouse-0.2.0-py3-none-any.whl", "mcp_clickhouse", "0.2.0", true},
+		{"pkg-1.2.3-1-cp312-cp312-manylinux_2_17_x86_64.whl", "pkg", "1.2.3", true},
+		{"pkg-1.2.3+local.1-py3-none-any.whl", "pkg", "1.2.3+local.1", true},
+		{"pkg-1.2.3.tar.gz", "pkg", "1.2.3", true},
+		{"mcp-clickhouse-0.2.0.tar.gz", "mcp-clickhouse", "0.2.0", true},
+		{"pkg-1.2.3.zip", "pkg", "1.2.3", true},
+		{"pkg-1.2.3-py3.whl", "", "", false},
+		{"pkg.tar.gz", "", "", false},
+		{"pkg-.tar.gz", "", "", false},
+		{"pkg-1.2.3-py3.11.egg", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			t.Parallel()
+
+			name, version, ok := parseFilename(tt.filename)
+			if ok != tt.wantOK {
+				t.Fatalf("parseFilename(%q) ok = %v, want %v", tt.filename, ok, tt.wantOK)
+			}
+			if name != tt.wantName || version != tt.wantVersion {
+				t.Errorf("parseFilename(%q) = (%q, %q), want (%q, %q)",
+					tt.filename, name, version, tt.wantName, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestMatchesRelease(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filename string
+		pkgName  string
+		version  string
+		want     bool
+	}{
+		{"exact wheel", "pkg-1.2-py3-none-any.whl", "pkg", "1.2", true},
+		{"exact sdist", "pkg-1.2.tar.gz", "pkg", "1.2", true},
+		{"prefix version is not a match", "pkg-1.20.0-py3-none-any.whl", "pkg", "1.2", false},
+		{"suffix version is not a match", "pkg-11.2.tar.gz", "pkg", "1.2", false},
+		{"version inside name is not a match", "pkg1.2-0.1.0.tar.gz", "pkg", "1.2", false},
+		{"normalized wheel name", "awslabs_aws_diagram_mcp_server-1.0.23-py3-none-any.whl",
+			"awslabs.aws-diagram-mcp-server", "1.0.23", true},
+		{"case-insensitive name", "Flask_Cors-4.0.0.tar.gz", "flask-cors", "4.0.0", true},
+		{"different project", "other-1.2.tar.gz", "pkg", "1.2", false},
+		{"local segment", "pkg-1.2.3+ubuntu.1-py3-none-any.whl", "pkg", "1.2.3+ubuntu-1", true},
+		{"local segment differs", "pkg-1.2.3+ubuntu.1-py3-none-any.whl", "pkg", "1.2.3", false},
+		{"leading v in spec", "pkg-1.2.3.tar.gz", "pkg", "v1.2.3", true},
+		{"unparseable filename", "pkg-1.2.3.exe", "pkg", "1.2.3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := matchesRelease(tt.filename, tt.pkgName, tt.version); got != tt.want {
+				t.Errorf("matchesRelease(%q, %q, %q) = %v, want %v",
+					tt.filename, tt.pkgName, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimpleProjectURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Flask_Cors", "https://pypi.org/simple/flask-cors/"},
+		{"My.Package", "https://pypi.org/simple/my-package/"},
+		{"mcp__server.-time", "https://pypi.org/simple/mcp-server-time/"},
+		{"MixedCase", "https://pypi.org/simple/mixedcase/"},
+		{"already-normal", "https://pypi.org/simple/already-normal/"},
+	}
+	for _, tt := range tests {
+		if got := simpleProjectURL("https://pypi.org/simple", tt.name); got != tt.want {
+			t.Errorf("simpleProjectURL(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReleaseVersions(t *testing.T) {
+	t.Parallel()
+
+	files := []File{
+		{Filename: "mcp_server_time-0.5.0.tar.gz"},
+		{Filename: "mcp_server_time-0.5.0-py3-none-any.whl"},
+		{Filename: "mcp-server-time-0.6.0.tar.gz"},
+		{Filename: "mcp_server_time-0.6.0-py3-none-any.whl"},
+		{Filename: "mcp_server_time-2025.1.0.post1-py3-none-any.whl"},
+		{Filename: "other_project-1.0.0-py3-none-any.whl"},
+		{Filename: "README.txt"},
+	}
+	want := []string{"0.5.0", "0.6.0", "2025.1.0.post1"}
+	if got := releaseVersions(files, "mcp-server-time"); !slices.Equal(got, want) {
+		t.Errorf("releaseVersions() = %v, want %v", got, want)
+	}
+}
+
+func TestLatestFinalRelease(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		versions []string
+		want     string
+		wantOK   bool
+	}{
+		{"numeric not lexical order", []string{"0.9.0", "0.10.0", "0.2.0"}, "0.10.0", true},
+		{"backport uploaded last", []string{"1.0.0", "2.0.0", "1.0.1"}, "2.0.0", true},
+		{"pre-releases skipped", []string{"1.0.0", "2.0.0rc1", "2.0.0.dev3", "2.0.0b2"}, "1.0.0", true},
+		{"post-release wins", []string{"2025.1.0", "2025.1.0.post1", "2025.1.0.post2"}, "2025.1.0.post2", true},
+		{"local version skipped", []string{"1.0.0", "1.1.0+cpu"}, "1.0.0", true},
+		{"padded segments", []string{"1.1", "1.0.5"}, "1.1", true},
+		{"only pre-releases", []string{"1.0.0a1", "1.0.0rc1"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := latestFinalRelease(tt.versions)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("latestFinalRelease(%v) = %q, %t, want %q, %t", tt.versions, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestArtifactKindIncludes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		kind     ArtifactKind
+		filename string
+		want     bool
+	}{
+		{ArtifactKindAny, "pkg-1.2-py3-none-any.whl", true},
+		{ArtifactKindAny, "pkg-1.2.tar.gz", true},
+		{"", "pkg-1.2.tar.gz", true},
+		{ArtifactKindWheel, "pkg-1.2-py3-none-any.whl", true},
+		{ArtifactKindWheel, "pkg-1.2.tar.gz", false},
+		{ArtifactKindSdist, "pkg-1.2.tar.gz", true},
+		{ArtifactKindSdist, "pkg-1.2.zip", true},
+		{ArtifactKindSdist, "pkg-1.2-py3-none-any.whl", false},
+		{ArtifactKindSdist, "pkg-1.2.exe", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind)+" "+tt.filename, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.kind.includes(tt.filename); got != tt.want {
+				t.Errorf("ArtifactKind(%q).includes(%q) = %v, want %v", tt.kind, tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArtifactKind(t *testing.T) {
+	t.Parallel()
+
+	for input, want := range map[string]ArtifactKind{
+		"wheel": ArtifactKindWheel, "Sdist": ArtifactKindSdist, "any": ArtifactKindAny, "egg": "", "": "",
+	} {
+		got, err := ParseArtifactKind(input)
+		if got != want || (err != nil) != (want == "") {
+			t.Errorf("ParseArtifactKind(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package pypi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// fileOutcome is the verification result of one distribution file of a release.
+type fileOutcome struct {
+	filename      string
+	hasProvenance bool
+	publisher     *domain.TrustedPublisher
+	subject       *sigstore.Subject
+	err           error
+	// digest and identity are what the provenance was verified against
+	digest   string
+	identity string
+	// claimed marks a publisher read from the provenance without verifying it
+	claimed bool
+	// raw is the full Sigstore verification result, once the provenance verified
+	raw *verify.VerificationResult
+}
+
+// fileStatus renders an outcome for the per-file "files" detail.
+func (o fileOutcome) fileStatus() string {
+	switch {
+	case !o.hasProvenance:
+		return "no provenance"
+	case o.err != nil:
+		return "unverified: " + o.err.Error()
+	case o.claimed:
+		return strings.TrimSpace(fmt.Sprintf("claimed (%s %s), not verified", o.publisher.Kind, o.publisher.Repository))
+	case o.publisher != nil && o.publisher.Repository != "":
+		return strings.TrimSpace(fmt.Sprintf("verified (%s %s)", o.publisher.Kind, o.publisher.Repository))
+	default:
+		return "verified"
+	}
+}
+
+// applyFileOutcomes records the outcome of every release file on result and
+// derives the aggregate status. The release is VERIFIED only when every file
+// that publishes provenance verified; if any of them failed it is reported as
+// ATTESTATIONS. Files without provenance are counted but do not affect the status.
+func applyFileOutcomes(result *domain.ProvenanceResult, outcomes []fileOutcome) {
+	files := make(map[string]string, len(outcomes))
+	subjects := make(map[string]string)
+	identities := make(map[string]string)
+	var verifiedFiles []string
+	var failed []fileOutcome
+	withoutProvenance := 0
+
+	for _, outcome := range outcomes {
+		files[outcome.filename] = outcome.fileStatus()
+		if outcome.identity != "" {
+			identities[outcome.filename] = outcome.identity
+		}
+		switch {
+		case !outcome.hasProvenance:
+			withoutProvenance++
+		case outcome.err != nil:
+			failed = append(failed, outcome)
+		default:
+			verifiedFiles = append(verifiedFiles, outcome.filename)
+			if outcome.subject != nil {
+				subjects[outcome.filename] = outcome.subject.Name + " " + outcome.subject.Digest
+			}
+			if result.TrustedPublisher == nil {
+				result.TrustedPublisher = outcome.publisher
+			}
+		}
+	}
+
+	result.AttestationCount = len(verifiedFiles) + len(failed)
+	result.VerifiedAttestationCount = len(verifiedFiles)
+	result.HasAttestations = result.AttestationCount > 0
+	result.Details["files"] = files
+	result.Details["files_without_provenance"] = withoutProvenance
+	if len(verifiedFiles) > 0 {
+		result.Details["verified_files"] = verifiedFiles
+	}
+	if len(subjects) > 0 {
+		result.Details["subjects"] = subjects
+	}
+	if len(identities) > 0 {
+		result.Details["file_identities"] = identities
+	}
+
+	switch {
+	case result.AttestationCount == 0:
+		result.Status = domain.ProvenanceStatusNone
+	case len(failed) == 0:
+		result.Status = domain.ProvenanceStatusVerified
+	case len(verifiedFiles) > 0:
+		result.Status = domain.ProvenanceStatusAttestations
+		result.ErrorMessage = fmt.Sprintf("%d of %d files with provenance failed verification (first: %s: %v)",
+			len(failed), result.AttestationCount, failed[0].filename, failed[0].err)
+	default:
+		result.Status = domain.ProvenanceStatusAttestations
+		result.ErrorMessage = "attestations found but verification failed"
+	}
+}
+
+// recordFileDigests records the sha256 digest of every release file whose
+// digest is known, from the index or from hashing the file, in file_digests.
+func recordFileDigests(result *domain.ProvenanceResult, outcomes []fileOutcome) {
+	digests := make(map[string]string)
+	for _, outcome := range outcomes {
+		if outcome.digest != "" {
+			digests[outcome.filename] = outcome.digest
+		}
+	}
+	if len(digests) > 0 {
+		result.Details["file_digests"] = digests
+	}
+}
+
+// releasePublished returns when a release was published: the upload time of
+// its first file. Files without an upload time are ignored.
+func releasePublished(files []File) (time.Time, bool) {
+	var first time.Time
+	for _, file := range files {
+		uploaded, err := time.Parse(time.RFC3339, file.UploadTime)
+		if err == nil && (first.IsZero() || uploaded.Before(first)) {
+			first = uploaded
+		}
+	}
+	return first, !first.IsZero()
+}
+
+// applyClaimedOutcomes records outcomes whose provenance was only inspected,
+// not verified. Publisher claims are reported, but the status never goes
+// beyond ATTESTATIONS since nothing was checked cryptographically.
+func applyClaimedOutcomes(result *domain.ProvenanceResult, outcomes []fileOutcome) {
+	files := make(map[string]string, len(outcomes))
+	var failed []fileOutcome
+	withoutProvenance := 0
+
+	for _, outcome := range outcomes {
+		switch {
+		case !outcome.hasProvenance:
+			withoutProvenance++
+		case outcome.err != nil:
+			failed = append(failed, outcome)
+		default:
+			outcome.claimed = true
+			if result.TrustedPublisher == nil {
+				result.TrustedPublisher = outcome.publisher
+			}
+		}
+		files[outcome.filename] = outcome.fileStatus()
+	}
+
+	result.AttestationCount = len(outcomes) - withoutProvenance
+	result.HasAttestations = result.AttestationCount > 0
+	result.Details["files"] = files
+	result.Details["files_without_provenance"] = withoutProvenance
+	result.Details["verification"] = "skipped: publisher claims were not cryptographically verified"
+
+	switch {
+	case result.AttestationCount == 0:
+		result.Status = domain.ProvenanceStatusNone
+	case len(failed) == result.AttestationCount:
+		result.Status = domain.ProvenanceStatusError
+		result.ErrorMessage = fmt.Sprintf("failed to read provenance of %d files (first: %s: %v)",
+			len(failed), failed[0].filename, failed[0].err)
+	default:
+		result.Status = domain.ProvenanceStatusAttestations
+	}
+}
@@ -0,0 +1,129 @@
+package pypi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestApplyFileOutcomes(t *testing.T) {
+	t.Parallel()
+
+	publisher := &domain.TrustedPublisher{Kind: "GitHub", Repository: "owner/repo"}
+	const (
+		sdist = "pkg-1.0.tar.gz"
+		wheel = "pkg-1.0-py3-none-any.whl"
+		other = "pkg-1.0-cp312-cp312-manylinux_2_17_x86_64.whl"
+	)
+
+	tests := []struct {
+		name          string
+		outcomes      []fileOutcome
+		wantStatus    domain.ProvenanceStatus
+		wantCount     int
+		wantNoProv    int
+		wantSdist     string
+		wantErrSubstr string
+	}{
+		{
+			name: "all verified",
+			outcomes: []fileOutcome{
+				{filename: sdist, hasProvenance: true, publisher: publisher},
+				{filename: wheel, hasProvenance: true, publisher: publisher},
+			},
+			wantStatus: domain.ProvenanceStatusVerified,
+			wantCount:  2,
+			wantSdist:  "verified (GitHub owner/repo)",
+		},
+		{
+			name: "sdist verified but a wheel failed",
+			outcomes: []fileOutcome{
+				{filename: sdist, hasProvenance: true, publisher: publisher},
+				{filename: wheel, hasProvenance: true, err: errors.New("certificate identity mismatch")},
+			},
+			wantStatus:    domain.ProvenanceStatusAttestations,
+			wantCount:     2,
+			wantSdist:     "verified (GitHub owner/repo)",
+			wantErrSubstr: "1 of 2 files",
+		},
+		{
+			name: "files without provenance do not block verification",
+			outcomes: []fileOutcome{
+				{filename: sdist, hasProvenance: true, publisher: publisher},
+				{filename: other},
+			},
+			wantStatus: domain.ProvenanceStatusVerified,
+			wantCount:  1,
+			wantNoProv: 1,
+			wantSdist:  "verified (GitHub owner/repo)",
+		},
+		{
+			name: "all failed",
+			outcomes: []fileOutcome{
+				{filename: sdist, hasProvenance: true, err: errors.New("bad bundle")},
+			},
+			wantStatus:    domain.ProvenanceStatusAttestations,
+			wantCount:     1,
+			wantSdist:     "unverified: bad bundle",
+			wantErrSubstr: "verification failed",
+		},
+		{
+			name:       "no provenance",
+			outcomes:   []fileOutcome{{filename: sdist}, {filename: wheel}},
+			wantStatus: domain.ProvenanceStatusNone,
+			wantNoProv: 2,
+			wantSdist:  "no provenance",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := &domain.ProvenanceResult{Details: make(map[string]interface{})}
+			applyFileOutcomes(result, tt.outcomes)
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.AttestationCount != tt.wantCount {
+				t.Errorf("AttestationCount = %d, want %d", result.AttestationCount, tt.wantCount)
+			}
+			if got := result.Details["files_without_provenance"]; got != tt.wantNoProv {
+				t.Errorf("files_without_provenance = %v, want %d", got, tt.wantNoProv)
+			}
+			files, _ := result.Details["files"].(map[string]string)
+			if files[sdist] != tt.wantSdist {
+				t.Errorf("files[%s] = %q, want %q", sdist, files[sdist], tt.wantSdist)
+			}
+			if tt.wantErrSubstr == "" && result.ErrorMessage != "" {
+				t.Errorf("ErrorMessage = %q, want none", result.ErrorMessage)
+			}
+			if !strings.Contains(result.ErrorMessage, tt.wantErrSubstr) {
+				t.Errorf("ErrorMessage = %q, want it to contain %q", result.ErrorMessage, tt.wantErrSubstr)
+			}
+		})
+	}
+}
+
+func TestReleasePublished(t *testing.T) {
+	t.Parallel()
+
+	files := []File{
+		{Filename: "pkg-1.0.0-py3-none-any.whl", UploadTime: "2024-05-02T08:00:00.123456Z"},
+		{Filename: "pkg-1.0.0.tar.gz", UploadTime: "2024-05-01T12:34:56.654321Z"},
+		{Filename: "pkg-1.0.0-cp312-none-any.whl"},
+	}
+	got, ok := releasePublished(files)
+	want := time.Date(2024, 5, 1, 12, 34, 56, 654321000, time.UTC)
+	if !ok || !got.Equal(want) {
+		t.Errorf("releasePublished() = %v, %t, want %v", got, ok, want)
+	}
+
+	if _, ok := releasePublished(files[2:]); ok {
+		t.Error("releasePublished() without upload times reported a time")
+	}
+}
@@ -0,0 +1,152 @@
+package pypi
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// options holds the settings applied by NewVerifier.
+type options struct {
+	httpOptions    []httpclient.Option
+	logger         *slog.Logger
+	bundleVerifier *sigstore.BundleVerifier
+	sigstoreOpts   []sigstore.Option
+	certIdentity   sigstore.CertificateIdentity
+	maxDownload    int64
+	quick          bool
+	rawResults     bool
+	concurrency    int
+	artifactKind   ArtifactKind
+	// headers are key and value pairs sent to the index host
+	headers [][2]string
+}
+
+// Option configures a Verifier.
+type Option func(*options)
+
+// WithRateLimit throttles registry requests with a token bucket refilling at
+// requestsPerSecond and holding up to burst tokens.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithRateLimit(requestsPerSecond, burst))
+	}
+}
+
+// WithHTTPClient sets the *http.Client registry requests are sent with, e.g.
+// to route them through a proxy. Compressed responses are decoded either way.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithHTTPClient(httpClient))
+	}
+}
+
+// WithHTTPTimeout sets the timeout applied to each registry request.
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithTimeout(d))
+	}
+}
+
+// WithMaxRetries sets how many times a request answered with 429 or 503 is retried
+// before giving up.
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithMaxRetries(n))
+	}
+}
+
+// WithHeader sets a header on every request to the PyPI index host, e.g. the
+// API key of a gateway in front of it, a tracing header, or a tenant
+// selector. Files and provenance downloaded from other hosts, such as
+// files.pythonhosted.org, are requested without it. It can be given several
+// times, including for the same header. Header values are never logged.
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		o.headers = append(o.headers, [2]string{key, value})
+	}
+}
+
+// WithBundleVerifier makes the verifier use an existing Sigstore bundle
+// verifier instead of creating its own, so that several verifiers can share a
+// single trusted root fetch.
+func WithBundleVerifier(bundleVerifier *sigstore.BundleVerifier) Option {
+	return func(o *options) {
+		o.bundleVerifier = bundleVerifier
+	}
+}
+
+// WithLogger sets the logger used for debug output about registry requests,
+// version resolution, and bundle verification. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithSigstoreOptions configures the Sigstore bundle verifier the verifier
+// creates, e.g. to use a private TUF mirror and root. It has no effect
+// together with WithBundleVerifier.
+func WithSigstoreOptions(opts ...sigstore.Option) Option {
+	return func(o *options) {
+		o.sigstoreOpts = append(o.sigstoreOpts, opts...)
+	}
+}
+
+// WithMaxDownloadSize caps how many bytes of a distribution file are downloaded for
+// hashing; larger artifacts fail verification. The default is
+// httpclient.DefaultMaxDownloadSize.
+func WithMaxDownloadSize(n int64) Option {
+	return func(o *options) {
+		o.maxDownload = n
+	}
+}
+
+// WithCertificateIdentity overrides the signer identity that bundle
+// certificates must match, for callers that want to pin a specific workflow.
+// Empty fields keep the default.
+func WithCertificateIdentity(identity sigstore.CertificateIdentity) Option {
+	return func(o *options) {
+		o.certIdentity = identity
+	}
+}
+
+// WithQuick makes the verifier report the publisher that each file's
+// provenance claims without verifying it: no distribution file is downloaded
+// and no signature is checked. Results are at most ATTESTATIONS, never
+// VERIFIED, so this is meant for fast triage rather than enforcement.
+func WithQuick() Option {
+	return func(o *options) {
+		o.quick = true
+	}
+}
+
+// WithRawResults keeps the full Sigstore verification result of every file
+// whose provenance verifies on the result's RawResults, for callers that need
+// more than the summary. It has no effect together with WithQuick.
+func WithRawResults() Option {
+	return func(o *options) {
+		o.rawResults = true
+	}
+}
+
+// WithFileConcurrency sets how many files of a release are verified at once.
+// The default is 8; values below 1 keep it.
+func WithFileConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithArtifactKind limits verification to the wheels or the sdist of a
+// release, e.g. to match what the runtime installs when only the wheels carry
+// provenance. Files of the other kind are ignored, as if they were not
+// published. The default, ArtifactKindAny, verifies every file.
+func WithArtifactKind(kind ArtifactKind) Option {
+	return func(o *options) {
+		o.artifactKind = kind
+	}
+}
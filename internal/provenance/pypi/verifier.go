@@ -8,37 +8,88 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sigstore/sigstore-go/pkg/verify"
 
 	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
 	"github.com/stacklok/dockyard/internal/provenance/sigstore"
 )
 
 // Verifier implements provenance verification for PyPI packages using sigstore-go
 type Verifier struct {
-	httpClient     *http.Client
+	httpClient     *httpclient.Client
 	simpleURL      string
 	bundleVerifier *sigstore.BundleVerifier
+	certIdentity   sigstore.CertificateIdentity
+	logger         *slog.Logger
+	maxDownload    int64
+	// quick reports the publisher claims of the provenance without verifying it
+	quick bool
+	// concurrency bounds how many files of a release are verified at once
+	concurrency int
+	// rawResults keeps the Sigstore verification results on the result
+	rawResults bool
+	// artifactKind limits which files of a release are verified
+	artifactKind ArtifactKind
 }
 
+// indexHost is the host of the PyPI index, which serves the Simple API and
+// the provenance of each file
+const indexHost = "pypi.org"
+
+// defaultFileConcurrency is how many files of a release are verified at once
+// unless WithFileConcurrency says otherwise.
+const defaultFileConcurrency = 8
+
 // NewVerifier creates a new PyPI provenance verifier with sigstore support
-func NewVerifier(ctx context.Context) (*Verifier, error) {
-	bundleVerifier, err := sigstore.NewBundleVerifier(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create bundle verifier: %w", err)
+func NewVerifier(ctx context.Context, opts ...Option) (*Verifier, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// The trusted root is fetched on the first verification, so creating a
+	// verifier works offline, and quick mode never fetches it
+	bundleVerifier := o.bundleVerifier
+	if bundleVerifier == nil {
+		bundleVerifier = sigstore.NewLazyBundleVerifier(o.sigstoreOpts...)
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	concurrency := o.concurrency
+	if concurrency < 1 {
+		concurrency = defaultFileConcurrency
+	}
+
+	// Headers go only to the index
+	httpOptions := append(o.httpOptions, httpclient.WithLogger(logger))
+	for _, h := range o.headers {
+		httpOptions = append(httpOptions, httpclient.WithHeader(indexHost, h[0], h[1]))
 	}
 
 	return &Verifier{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		simpleURL:      "https://pypi.org/simple",
+		httpClient:     httpclient.New(httpOptions...),
+		simpleURL:      "https://" + indexHost + "/simple",
 		bundleVerifier: bundleVerifier,
+		certIdentity:   o.certIdentity,
+		logger:         logger,
+		maxDownload:    o.maxDownload,
+		quick:          o.quick,
+		concurrency:    concurrency,
+		rawResults:     o.rawResults,
+		artifactKind:   o.artifactKind,
 	}, nil
 }
 
@@ -47,95 +98,258 @@ func (*Verifier) SupportsProtocol(protocol domain.PackageProtocol) bool {
 	return protocol == domain.ProtocolPyPI
 }
 
+// filesURL is the host PyPI serves distribution files from
+const filesURL = "https://files.pythonhosted.org/"
+
+// Warmup fetches the Sigstore trusted root, unless in quick mode, and opens
+// connections to the PyPI index and to the file host, so the first
+// verification skips both TLS handshakes.
+func (v *Verifier) Warmup(ctx context.Context) error {
+	if !v.quick {
+		if err := v.bundleVerifier.Load(); err != nil {
+			return fmt.Errorf("failed to load Sigstore trusted root: %w", err)
+		}
+	}
+	for _, u := range []string{v.simpleURL + "/", filesURL} {
+		if err := v.httpClient.Warmup(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListVersions returns every version of a PyPI project that has a
+// distribution file, in the order the Simple API lists their files.
+func (v *Verifier) ListVersions(ctx context.Context, name string) ([]string, error) {
+	metadata, err := v.fetchSimpleMetadata(ctx, normalizeName(name))
+	if err != nil {
+		return nil, err
+	}
+	return releaseVersions(metadata.Files, name), nil
+}
+
+// ResolveVersion returns the version of a PyPI project that version names, as
+// its files spell it. latest, like an empty version, resolves to the highest
+// final release, the version pip installs by default.
+func (v *Verifier) ResolveVersion(ctx context.Context, name, version string) (string, error) {
+	versions, err := v.ListVersions(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if version == "" || version == "latest" {
+		latest, ok := latestFinalRelease(versions)
+		if !ok {
+			return "", fmt.Errorf("%w: %s has no final release", domain.ErrVersionNotFound, name)
+		}
+		return latest, nil
+	}
+	for _, candidate := range versions {
+		if normalizeVersion(candidate) == normalizeVersion(version) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: version %s not found in project %s", domain.ErrVersionNotFound, version, name)
+}
+
 // Verify checks the provenance of a PyPI package
 func (v *Verifier) Verify(ctx context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
 	if pkg.Protocol != domain.ProtocolPyPI {
 		return nil, fmt.Errorf("pypi verifier does not support protocol %s", pkg.Protocol)
 	}
 
+	var timings domain.PhaseTimings
+
+	// The Simple API only serves a project under its PEP 503 normalized name
+	normalizedName := normalizeName(pkg.Name)
+	details := map[string]interface{}{
+		"package_name":    pkg.Name,
+		"normalized_name": normalizedName,
+	}
+
 	// Fetch package metadata from PyPI Simple JSON API (PEP 691)
-	simpleMetadata, err := v.fetchSimpleMetadata(ctx, pkg.Name)
+	start := time.Now()
+	simpleMetadata, err := v.fetchSimpleMetadata(ctx, normalizedName)
+	timings.Metadata += time.Since(start)
 	if err != nil {
-		return &domain.ProvenanceResult{
+		result := &domain.ProvenanceResult{
 			PackageID:    pkg,
 			Status:       domain.ProvenanceStatusError,
 			ErrorMessage: fmt.Sprintf("failed to fetch package metadata: %v", err),
-		}, err
+			Details:      details,
+		}
+		timings.Record(result.Details)
+		return result, err
+	}
+
+	// Without a version, verify the release pip installs by default
+	version := pkg.Version
+	if version == "" || version == "latest" {
+		latest, ok := latestFinalRelease(releaseVersions(simpleMetadata.Files, pkg.Name))
+		if !ok {
+			err := fmt.Errorf("%w: %s has no final release", domain.ErrVersionNotFound, pkg.Name)
+			details["not_found"] = "version"
+			result := &domain.ProvenanceResult{
+				PackageID:    pkg,
+				Status:       domain.ProvenanceStatusError,
+				ErrorMessage: err.Error(),
+				Details:      details,
+			}
+			timings.Record(result.Details)
+			return result, err
+		}
+		version = latest
+		details["resolved_version"] = latest
 	}
 
 	result := &domain.ProvenanceResult{
-		PackageID:        pkg,
-		Details:          make(map[string]interface{}),
-		AttestationCount: 0,
+		PackageID: pkg,
+		Details:   details,
 	}
 
-	// Check for provenance in files matching the version
-	var verifiedFiles []string
-	var firstPublisher *domain.TrustedPublisher
-
+	// Verify each file of the release independently: wheels and the sdist can
+	// carry separate provenance
+	var files []File
 	for _, file := range simpleMetadata.Files {
-		// Check if this file belongs to the specified version
-		if strings.Contains(file.Filename, pkg.Version) && file.Provenance != "" {
-			result.AttestationCount++
-
-			// Try to verify the provenance
-			verified, publisher, err := v.verifyProvenance(ctx, file)
-			if err != nil {
-				// Has provenance but verification failed
-				result.Details[fmt.Sprintf("verification_error_%s", file.Filename)] = err.Error()
-				continue
-			}
+		if matchesRelease(file.Filename, pkg.Name, version) {
+			files = append(files, file)
+		}
+	}
+	if publishedAt, ok := releasePublished(files); ok {
+		domain.RecordPublished(result.Details, publishedAt, time.Now())
+	}
+	if v.artifactKind != "" && v.artifactKind != ArtifactKindAny {
+		// Only the files of the kind the runtime installs count
+		files = slices.DeleteFunc(files, func(file File) bool { return !v.artifactKind.includes(file.Filename) })
+		result.Details["artifact_kind"] = string(v.artifactKind)
+	}
+	outcomes := v.verifyFiles(ctx, pkg, files, &timings)
 
-			if verified {
-				verifiedFiles = append(verifiedFiles, file.Filename)
-				if firstPublisher == nil {
-					firstPublisher = publisher
+	timings.Record(result.Details)
+	if v.quick {
+		applyClaimedOutcomes(result, outcomes)
+	} else {
+		applyFileOutcomes(result, outcomes)
+		if v.rawResults {
+			for _, outcome := range outcomes {
+				if outcome.raw != nil {
+					result.RawResults = append(result.RawResults, outcome.raw)
 				}
 			}
 		}
 	}
+	recordFileDigests(result, outcomes)
 
-	// Determine status based on verification results
-	if len(verifiedFiles) > 0 {
-		result.Status = domain.ProvenanceStatusVerified
-		result.HasAttestations = true
-		result.TrustedPublisher = firstPublisher
-		result.Details["verified_files"] = verifiedFiles
-	} else if result.AttestationCount > 0 {
-		// Has attestations but couldn't verify them
-		result.Status = domain.ProvenanceStatusAttestations
-		result.HasAttestations = true
-		result.ErrorMessage = "attestations found but verification failed"
-	} else {
-		result.Status = domain.ProvenanceStatusNone
+	return result, nil
+}
+
+// verifyFiles verifies the provenance of files concurrently, at most
+// v.concurrency at a time, and returns their outcomes in the order of files so
+// that the aggregate status does not depend on which file finished first.
+// Phase timings are summed across files.
+func (v *Verifier) verifyFiles(
+	ctx context.Context,
+	pkg domain.PackageIdentifier,
+	files []File,
+	timings *domain.PhaseTimings,
+) []fileOutcome {
+	outcomes := make([]fileOutcome, len(files))
+	fileTimings := make([]domain.PhaseTimings, len(files))
+
+	// Verifiers built directly rather than by NewVerifier verify one at a time
+	sem := make(chan struct{}, max(v.concurrency, 1))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		outcomes[i] = fileOutcome{filename: file.Filename, hasProvenance: file.Provenance != ""}
+		if sha256Hash, ok := file.Hashes["sha256"]; ok {
+			outcomes[i].digest = "sha256:" + strings.ToLower(sha256Hash)
+		}
+		if !outcomes[i].hasProvenance {
+			continue
+		}
+		v.logger.DebugContext(ctx, "matched pypi release file with provenance",
+			"package", pkg.Name, "version", pkg.Version, "file", file.Filename)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// Each goroutine writes only its own slots
+			outcome := &outcomes[i]
+			if v.quick {
+				outcome.publisher, outcome.err = v.inspectProvenance(ctx, file, &fileTimings[i])
+			} else {
+				outcome.err = v.verifyProvenance(ctx, file, outcome, &fileTimings[i])
+			}
+		}()
 	}
+	wg.Wait()
 
-	return result, nil
+	for _, t := range fileTimings {
+		timings.Add(t)
+	}
+	return outcomes
 }
 
-// verifyProvenance verifies a file's provenance using sigstore
-func (v *Verifier) verifyProvenance(ctx context.Context, file File) (bool, *domain.TrustedPublisher, error) {
-	// Fetch the provenance object
+// fetchBundle fetches a file's provenance object and returns its first
+// attestation bundle
+func (v *Verifier) fetchBundle(ctx context.Context, file File, timings *domain.PhaseTimings) (*AttestationBundle, error) {
+	start := time.Now()
 	provenanceData, err := v.fetchProvenanceData(ctx, file.Provenance)
+	timings.Metadata += time.Since(start)
 	if err != nil {
-		return false, nil, fmt.Errorf("failed to fetch provenance: %w", err)
+		return nil, fmt.Errorf("failed to fetch provenance: %w", err)
 	}
 
 	// Extract the first attestation bundle
 	if len(provenanceData.AttestationBundles) == 0 {
-		return false, nil, fmt.Errorf("no attestation bundles in provenance")
+		return nil, fmt.Errorf("no attestation bundles in provenance")
 	}
 
 	bundle := provenanceData.AttestationBundles[0]
 	if len(bundle.Attestations) == 0 {
-		return false, nil, fmt.Errorf("no attestations in bundle")
+		return nil, fmt.Errorf("no attestations in bundle")
+	}
+	v.logger.DebugContext(ctx, "parsed pypi provenance",
+		"file", file.Filename, "bundles", len(provenanceData.AttestationBundles),
+		"attestations", len(bundle.Attestations), "publisher_kind", bundle.Publisher.Kind)
+	return &bundle, nil
+}
+
+// inspectProvenance returns the publisher a file's provenance claims without
+// verifying it: neither the signature nor the file digest is checked
+func (v *Verifier) inspectProvenance(
+	ctx context.Context,
+	file File,
+	timings *domain.PhaseTimings,
+) (*domain.TrustedPublisher, error) {
+	bundle, err := v.fetchBundle(ctx, file, timings)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Publisher.trustedPublisher(), nil
+}
+
+// verifyProvenance verifies a file's provenance using sigstore, recording on
+// outcome its publisher, the statement subject naming the file, the digest it
+// was checked against, and the certificate identity required of the signer
+func (v *Verifier) verifyProvenance(
+	ctx context.Context,
+	file File,
+	outcome *fileOutcome,
+	timings *domain.PhaseTimings,
+) error {
+	bundle, err := v.fetchBundle(ctx, file, timings)
+	if err != nil {
+		return err
 	}
 
-	// Convert the attestation to a Sigstore bundle format
-	// PEP 740 attestations are already in Sigstore bundle format
-	attestationBytes, err := json.Marshal(bundle.Attestations[0])
+	// PEP 740 attestations are DSSE envelopes, which VerifyBundle only
+	// accepts wrapped into a Sigstore bundle
+	attestationBytes, err := attestationBundle(bundle.Attestations[0])
 	if err != nil {
-		return false, nil, fmt.Errorf("failed to marshal attestation: %w", err)
+		return err
 	}
 
 	// Calculate the artifact digest from the file hashes
@@ -143,57 +357,80 @@ func (v *Verifier) verifyProvenance(ctx context.Context, file File) (bool, *doma
 	if sha256Hash, ok := file.Hashes["sha256"]; ok {
 		artifactDigest, err = hex.DecodeString(sha256Hash)
 		if err != nil {
-			return false, nil, fmt.Errorf("failed to decode sha256 hash: %w", err)
+			return fmt.Errorf("failed to decode sha256 hash: %w", err)
 		}
 	} else {
 		// Download and hash the file
+		start := time.Now()
 		artifactDigest, err = v.downloadAndHashFile(ctx, file.URL)
+		timings.Tarball += time.Since(start)
 		if err != nil {
-			return false, nil, fmt.Errorf("failed to hash file: %w", err)
+			return fmt.Errorf("failed to hash file: %w", err)
 		}
 	}
+	outcome.digest = "sha256:" + hex.EncodeToString(artifactDigest)
 
 	// Create verification policy options based on publisher info
 	var policyOpts []verify.PolicyOption
 
-	// Add certificate identity based on publisher
-	if bundle.Publisher.Kind == "GitHub" && bundle.Publisher.Repository != "" {
-		certID, err := verify.NewShortCertificateIdentity(
-			"https://token.actions.githubusercontent.com",
-			"",
-			"",
-			fmt.Sprintf("^https://github.com/%s/", bundle.Publisher.Repository),
-		)
-		if err == nil {
-			policyOpts = append(policyOpts, verify.WithCertificateIdentity(certID))
+	// Add certificate identity based on publisher, or the configured override
+	githubPublisher := bundle.Publisher.Kind == "GitHub" && bundle.Publisher.Repository != ""
+	if githubPublisher || v.certIdentity != (sigstore.CertificateIdentity{}) {
+		sanRegexp := sigstore.AnyGitHubSANRegexp
+		if githubPublisher {
+			sanRegexp = fmt.Sprintf("^https://github.com/%s/", bundle.Publisher.Repository)
+		}
+		identityPolicy, err := v.certIdentity.PolicyOption(sigstore.GitHubActionsIssuer, sanRegexp)
+		if err != nil {
+			return err
 		}
+		policyOpts = append(policyOpts, identityPolicy)
+		outcome.identity = v.certIdentity.Describe(sigstore.GitHubActionsIssuer, sanRegexp)
 	}
 
+	v.logger.DebugContext(ctx, "constructed pypi verification policy",
+		"file", file.Filename, "publisher_repository", bundle.Publisher.Repository,
+		"certificate_identity", len(policyOpts) > 0, "digest_algorithm", "sha256")
+
 	// Verify the bundle with artifact digest
+	start := time.Now()
 	verifyResult, err := v.bundleVerifier.VerifyBundle(attestationBytes, "sha256", artifactDigest, policyOpts...)
+	timings.Sigstore += time.Since(start)
 	if err != nil {
-		return false, nil, err
+		return err
 	}
 
-	// Create publisher info from the provenance data
-	publisher := &domain.TrustedPublisher{
-		Kind:       bundle.Publisher.Kind,
-		Repository: bundle.Publisher.Repository,
-		Workflow:   bundle.Publisher.Workflow,
-		Claims:     bundle.Publisher.Claims,
+	// The statement must describe this file, not merely be signed over its digest
+	subject, err := sigstore.MatchSubject(verifyResult, "sha256", artifactDigest)
+	if err != nil {
+		return err
 	}
 
+	// Create publisher info from the provenance data
+	publisher := bundle.Publisher.trustedPublisher()
+
 	// Also extract from verification result if available
 	if extractedPublisher := sigstore.ExtractPublisherInfo(verifyResult); extractedPublisher != nil {
 		if publisher.Kind == "" {
 			publisher.Kind = extractedPublisher.Kind
 		}
-		if publisher.Repository == "" {
+		// The repository the certificate attests wins over the one PyPI serves
+		if extractedPublisher.Repository != "" {
 			publisher.Repository = extractedPublisher.Repository
 		}
+		// Claims the certificate attests win over those PyPI serves
+		if len(extractedPublisher.Claims) > 0 {
+			claims := maps.Clone(publisher.Claims)
+			if claims == nil {
+				claims = make(map[string]interface{})
+			}
+			maps.Copy(claims, extractedPublisher.Claims)
+			publisher.Claims = claims
+		}
 	}
 
-	return true, publisher, nil
+	outcome.publisher, outcome.subject, outcome.raw = publisher, subject, verifyResult
+	return nil
 }
 
 // allowedHosts is the set of hostnames that the verifier is permitted to contact.
@@ -219,9 +456,15 @@ func validatePyPIURL(rawURL string) error {
 	return nil
 }
 
+// simpleProjectURL returns the Simple API URL of a project under base. The
+// name is normalized per PEP 503, as PyPI redirects or 404s any other spelling.
+func simpleProjectURL(base, packageName string) string {
+	return fmt.Sprintf("%s/%s/", base, normalizeName(packageName))
+}
+
 // fetchSimpleMetadata fetches package metadata from PyPI Simple JSON API
 func (v *Verifier) fetchSimpleMetadata(ctx context.Context, packageName string) (*SimpleMetadata, error) {
-	targetURL := fmt.Sprintf("%s/%s/", v.simpleURL, packageName)
+	targetURL := simpleProjectURL(v.simpleURL, packageName)
 
 	if err := validatePyPIURL(targetURL); err != nil {
 		return nil, fmt.Errorf("SSRF protection: %w", err)
@@ -234,6 +477,7 @@ func (v *Verifier) fetchSimpleMetadata(ctx context.Context, packageName string)
 
 	// Use PEP 691 JSON format
 	req.Header.Set("Accept", "application/vnd.pypi.simple.v1+json")
+	httpclient.AcceptCompressed(req)
 
 	resp, err := v.httpClient.Do(req) //nolint:gosec // G704 — URL validated against allowlist by validatePyPIURL
 	if err != nil {
@@ -241,19 +485,49 @@ func (v *Verifier) fetchSimpleMetadata(ctx context.Context, packageName string)
 	}
 	defer resp.Body.Close()
 
+	body, err := httpclient.DecodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		msg, _ := io.ReadAll(body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(msg))
 	}
 
 	var metadata SimpleMetadata
-	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+	if err := json.NewDecoder(body).Decode(&metadata); err != nil {
 		return nil, fmt.Errorf("failed to decode package metadata: %w", err)
 	}
 
+	// Relative URLs are relative to the project page, wherever a redirect took us
+	base, _ := url.Parse(targetURL)
+	if resp.Request != nil && resp.Request.URL != nil {
+		base = resp.Request.URL
+	}
+	resolveFileURLs(metadata.Files, base)
+
 	return &metadata, nil
 }
 
+// resolveFileURLs makes the file and provenance URLs of files absolute. PEP
+// 691 lets an index give file URLs relative to the project page, and PEP 740
+// does the same for provenance URLs, which need not be on the file's host.
+// A URL that does not parse is left as it is, to fail validation when used.
+func resolveFileURLs(files []File, base *url.URL) {
+	for i := range files {
+		for _, field := range []*string{&files[i].URL, &files[i].Provenance} {
+			if *field == "" {
+				continue
+			}
+			if ref, err := url.Parse(*field); err == nil {
+				*field = base.ResolveReference(ref).String()
+			}
+		}
+	}
+}
+
 // fetchProvenanceData fetches the provenance object from PyPI
 func (v *Verifier) fetchProvenanceData(ctx context.Context, provenanceURL string) (*ProvenanceObject, error) {
 	if err := validatePyPIURL(provenanceURL); err != nil {
@@ -264,6 +538,7 @@ func (v *Verifier) fetchProvenanceData(ctx context.Context, provenanceURL string
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	httpclient.AcceptCompressed(req)
 
 	resp, err := v.httpClient.Do(req) //nolint:gosec // G704 — URL validated against allowlist by validatePyPIURL
 	if err != nil {
@@ -275,8 +550,14 @@ func (v *Verifier) fetchProvenanceData(ctx context.Context, provenanceURL string
 		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
+	body, err := httpclient.DecodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
 	var provenance ProvenanceObject
-	if err := json.NewDecoder(resp.Body).Decode(&provenance); err != nil {
+	if err := json.NewDecoder(body).Decode(&provenance); err != nil {
 		return nil, fmt.Errorf("failed to decode provenance: %w", err)
 	}
 
@@ -304,11 +585,20 @@ func (v *Verifier) downloadAndHashFile(ctx context.Context, fileURL string) ([]b
 		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
+	body, err := httpclient.LimitBody(resp, v.maxDownload)
+	if err != nil {
+		return nil, err
+	}
+	return ArtifactDigest(body)
+}
+
+// ArtifactDigest returns the SHA256 digest PyPI attestations sign for the
+// distribution file read from r.
+func ArtifactDigest(r io.Reader) ([]byte, error) {
 	hasher := sha256.New()
-	if _, err := io.Copy(hasher, resp.Body); err != nil {
+	if _, err := io.Copy(hasher, r); err != nil {
 		return nil, fmt.Errorf("failed to hash file: %w", err)
 	}
-
 	return hasher.Sum(nil), nil
 }
 
@@ -324,6 +614,8 @@ type File struct {
 	URL        string            `json:"url"`
 	Provenance string            `json:"provenance,omitempty"`
 	Hashes     map[string]string `json:"hashes,omitempty"`
+	// UploadTime is when the file was uploaded, in RFC 3339 (PEP 700)
+	UploadTime string `json:"upload-time,omitempty"`
 }
 
 // ProvenanceObject represents PEP 740 provenance structure
@@ -334,8 +626,8 @@ type ProvenanceObject struct {
 
 // AttestationBundle contains attestations and publisher info
 type AttestationBundle struct {
-	Publisher    Publisher     `json:"publisher"`
-	Attestations []interface{} `json:"attestations"`
+	Publisher    Publisher         `json:"publisher"`
+	Attestations []json.RawMessage `json:"attestations"`
 }
 
 // Publisher contains trusted publisher information
@@ -345,3 +637,13 @@ type Publisher struct {
 	Workflow   string                 `json:"workflow,omitempty"`
 	Claims     map[string]interface{} `json:"claims,omitempty"`
 }
+
+// trustedPublisher converts the publisher to its domain representation
+func (p Publisher) trustedPublisher() *domain.TrustedPublisher {
+	return &domain.TrustedPublisher{
+		Kind:       p.Kind,
+		Repository: p.Repository,
+		Workflow:   p.Workflow,
+		Claims:     p.Claims,
+	}
+}
@@ -0,0 +1,444 @@
+package pypi
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// handlerTransport serves every request from a handler, standing in for a
+// custom client that does no transparent decompression of its own.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+func TestFetchSimpleMetadataGzip(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"name":"mcp-clickhouse","files":[{"filename":"mcp_clickhouse-0.1.0.tar.gz",` +
+		`"url":"https://files.pythonhosted.org/packages/mcp_clickhouse-0.1.0.tar.gz"}]}`
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != httpclient.AcceptEncoding {
+			t.Errorf("Accept-Encoding = %q, want %q", r.Header.Get("Accept-Encoding"), httpclient.AcceptEncoding)
+		}
+		w.Header().Set("Content-Type", "application/vnd.pypi.simple.v1+json")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		_, _ = zw.Write([]byte(body))
+		_ = zw.Close()
+	})
+
+	v := &Verifier{
+		httpClient: httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		simpleURL:  "https://pypi.org/simple",
+		logger:     slog.New(slog.DiscardHandler),
+	}
+
+	metadata, err := v.fetchSimpleMetadata(context.Background(), "mcp-clickhouse")
+	if err != nil {
+		t.Fatalf("fetchSimpleMetadata() error: %v", err)
+	}
+	if metadata.Name != "mcp-clickhouse" || len(metadata.Files) != 1 ||
+		metadata.Files[0].Filename != "mcp_clickhouse-0.1.0.tar.gz" {
+		t.Errorf("fetchSimpleMetadata() = %+v", metadata)
+	}
+}
+
+func TestVerifyQuick(t *testing.T) {
+	t.Parallel()
+
+	const simple = `{"name":"mcp-clickhouse","files":[{"filename":"mcp_clickhouse-0.1.0-py3-none-any.whl",` +
+		`"url":"https://files.pythonhosted.org/packages/mcp_clickhouse-0.1.0-py3-none-any.whl",` +
+		`"provenance":"https://pypi.org/integrity/mcp-clickhouse/0.1.0/mcp_clickhouse-0.1.0-py3-none-any.whl/provenance"}]}`
+	const provenance = `{"version":1,"attestation_bundles":[{"publisher":{"kind":"GitHub",` +
+		`"repository":"ClickHouse/mcp-clickhouse","workflow":"publish.yml"},"attestations":[{}]}]}`
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Host == "files.pythonhosted.org":
+			t.Errorf("quick mode downloaded %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(r.URL.Path, "/integrity/"):
+			_, _ = w.Write([]byte(provenance))
+		default:
+			_, _ = w.Write([]byte(simple))
+		}
+	})
+
+	// No bundle verifier: quick mode must not verify anything
+	v := &Verifier{
+		httpClient: httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		simpleURL:  "https://pypi.org/simple",
+		logger:     slog.New(slog.DiscardHandler),
+		quick:      true,
+	}
+
+	result, err := v.Verify(context.Background(), domain.PackageIdentifier{
+		Protocol: domain.ProtocolPyPI, Name: "mcp-clickhouse", Version: "0.1.0",
+	})
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if result.Status != domain.ProvenanceStatusAttestations {
+		t.Errorf("Status = %s, want %s", result.Status, domain.ProvenanceStatusAttestations)
+	}
+	if result.VerifiedAttestationCount != 0 {
+		t.Errorf("VerifiedAttestationCount = %d, want 0", result.VerifiedAttestationCount)
+	}
+	if result.TrustedPublisher == nil || result.TrustedPublisher.Repository != "ClickHouse/mcp-clickhouse" ||
+		result.TrustedPublisher.Workflow != "publish.yml" {
+		t.Errorf("TrustedPublisher = %+v", result.TrustedPublisher)
+	}
+	if _, ok := result.Details["verification"]; !ok {
+		t.Error("Details lack the verification note")
+	}
+}
+
+func TestVerifyArtifactKind(t *testing.T) {
+	t.Parallel()
+
+	// Only the wheel carries provenance, as for releases whose sdist is uploaded by hand
+	const simple = `{"name":"mcp-clickhouse","files":[` +
+		`{"filename":"mcp_clickhouse-0.1.0-py3-none-any.whl",` +
+		`"url":"https://files.pythonhosted.org/packages/mcp_clickhouse-0.1.0-py3-none-any.whl",` +
+		`"provenance":"https://pypi.org/integrity/mcp-clickhouse/0.1.0/mcp_clickhouse-0.1.0-py3-none-any.whl/provenance"},` +
+		`{"filename":"mcp_clickhouse-0.1.0.tar.gz",` +
+		`"url":"https://files.pythonhosted.org/packages/mcp_clickhouse-0.1.0.tar.gz"}]}`
+	const provenance = `{"version":1,"attestation_bundles":[{"publisher":{"kind":"GitHub",` +
+		`"repository":"ClickHouse/mcp-clickhouse","workflow":"publish.yml"},"attestations":[{}]}]}`
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/integrity/") {
+			_, _ = w.Write([]byte(provenance))
+			return
+		}
+		_, _ = w.Write([]byte(simple))
+	})
+
+	tests := []struct {
+		kind                  ArtifactKind
+		wantFiles             int
+		wantWithoutProvenance int
+		wantStatus            domain.ProvenanceStatus
+	}{
+		{kind: ArtifactKindAny, wantFiles: 2, wantWithoutProvenance: 1, wantStatus: domain.ProvenanceStatusAttestations},
+		{kind: ArtifactKindWheel, wantFiles: 1, wantStatus: domain.ProvenanceStatusAttestations},
+		{kind: ArtifactKindSdist, wantFiles: 1, wantWithoutProvenance: 1, wantStatus: domain.ProvenanceStatusNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			t.Parallel()
+
+			v := &Verifier{
+				httpClient:   httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+				simpleURL:    "https://pypi.org/simple",
+				logger:       slog.New(slog.DiscardHandler),
+				quick:        true,
+				artifactKind: tt.kind,
+			}
+			result, err := v.Verify(context.Background(), domain.PackageIdentifier{
+				Protocol: domain.ProtocolPyPI, Name: "mcp-clickhouse", Version: "0.1.0",
+			})
+			if err != nil {
+				t.Fatalf("Verify() error: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if files, _ := result.Details["files"].(map[string]string); len(files) != tt.wantFiles {
+				t.Errorf("files = %v, want %d", files, tt.wantFiles)
+			}
+			if got := result.Details["files_without_provenance"]; got != tt.wantWithoutProvenance {
+				t.Errorf("files_without_provenance = %v, want %d", got, tt.wantWithoutProvenance)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutVersion(t *testing.T) {
+	t.Parallel()
+
+	wheel := func(version string) string {
+		return `{"filename":"mcp_clickhouse-` + version + `-py3-none-any.whl",` +
+			`"url":"https://files.pythonhosted.org/packages/mcp_clickhouse-` + version + `-py3-none-any.whl",` +
+			`"provenance":"https://pypi.org/integrity/mcp-clickhouse/` + version + `/provenance"}`
+	}
+	const provenance = `{"version":1,"attestation_bundles":[{"publisher":{"kind":"GitHub",` +
+		`"repository":"ClickHouse/mcp-clickhouse","workflow":"publish.yml"},"attestations":[{}]}]}`
+
+	tests := []struct {
+		name         string
+		version      string
+		files        []string
+		wantResolved string
+	}{
+		{name: "empty", files: []string{wheel("0.1.0"), wheel("0.2.0"), wheel("0.3.0rc1")}, wantResolved: "0.2.0"},
+		{name: "latest", version: "latest", files: []string{wheel("0.2.0"), wheel("0.1.0")}, wantResolved: "0.2.0"},
+		{name: "no final release", files: []string{wheel("0.3.0rc1")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var fetched []string
+			var mu sync.Mutex
+			simple := `{"name":"mcp-clickhouse","files":[` + strings.Join(tt.files, ",") + `]}`
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/integrity/") {
+					mu.Lock()
+					fetched = append(fetched, r.URL.Path)
+					mu.Unlock()
+					_, _ = w.Write([]byte(provenance))
+					return
+				}
+				_, _ = w.Write([]byte(simple))
+			})
+			v := &Verifier{
+				httpClient: httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+				simpleURL:  "https://pypi.org/simple",
+				logger:     slog.New(slog.DiscardHandler),
+				quick:      true,
+			}
+
+			result, err := v.Verify(context.Background(), domain.PackageIdentifier{
+				Protocol: domain.ProtocolPyPI, Name: "mcp-clickhouse", Version: tt.version,
+			})
+			if tt.wantResolved == "" {
+				if !errors.Is(err, domain.ErrVersionNotFound) {
+					t.Fatalf("Verify() error = %v, want ErrVersionNotFound", err)
+				}
+				if result.Status != domain.ProvenanceStatusError {
+					t.Errorf("Status = %s, want %s", result.Status, domain.ProvenanceStatusError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error: %v", err)
+			}
+			if result.Status != domain.ProvenanceStatusAttestations {
+				t.Errorf("Status = %s, want %s", result.Status, domain.ProvenanceStatusAttestations)
+			}
+			if got := result.Details["resolved_version"]; got != tt.wantResolved {
+				t.Errorf("resolved_version = %v, want %s", got, tt.wantResolved)
+			}
+			want := []string{"/integrity/mcp-clickhouse/" + tt.wantResolved + "/provenance"}
+			if !slices.Equal(fetched, want) {
+				t.Errorf("fetched provenance %v, want %v", fetched, want)
+			}
+		})
+	}
+}
+
+func TestResolveFileURLs(t *testing.T) {
+	t.Parallel()
+
+	base, err := url.Parse("https://pypi.org/simple/mcp-clickhouse/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		file           File
+		wantURL        string
+		wantProvenance string
+	}{
+		{
+			name: "absolute on different hosts",
+			file: File{
+				URL:        "https://files.pythonhosted.org/packages/a/mcp_clickhouse-0.1.0.tar.gz",
+				Provenance: "https://pypi.org/integrity/mcp-clickhouse/0.1.0/mcp_clickhouse-0.1.0.tar.gz/provenance",
+			},
+			wantURL:        "https://files.pythonhosted.org/packages/a/mcp_clickhouse-0.1.0.tar.gz",
+			wantProvenance: "https://pypi.org/integrity/mcp-clickhouse/0.1.0/mcp_clickhouse-0.1.0.tar.gz/provenance",
+		},
+		{
+			name:           "host-relative",
+			file:           File{URL: "/packages/a/x.tar.gz", Provenance: "/integrity/mcp-clickhouse/0.1.0/x.tar.gz/provenance"},
+			wantURL:        "https://pypi.org/packages/a/x.tar.gz",
+			wantProvenance: "https://pypi.org/integrity/mcp-clickhouse/0.1.0/x.tar.gz/provenance",
+		},
+		{
+			name:           "path-relative",
+			file:           File{URL: "../../packages/a/x.tar.gz", Provenance: "x.tar.gz/provenance"},
+			wantURL:        "https://pypi.org/packages/a/x.tar.gz",
+			wantProvenance: "https://pypi.org/simple/mcp-clickhouse/x.tar.gz/provenance",
+		},
+		{
+			name:    "no provenance",
+			file:    File{URL: "https://files.pythonhosted.org/packages/a/x.tar.gz"},
+			wantURL: "https://files.pythonhosted.org/packages/a/x.tar.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := []File{tt.file}
+			resolveFileURLs(files, base)
+			if files[0].URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", files[0].URL, tt.wantURL)
+			}
+			if files[0].Provenance != tt.wantProvenance {
+				t.Errorf("Provenance = %q, want %q", files[0].Provenance, tt.wantProvenance)
+			}
+		})
+	}
+}
+
+func TestVerifyRelativeProvenanceURL(t *testing.T) {
+	t.Parallel()
+
+	const simple = `{"name":"mcp-clickhouse","files":[{"filename":"mcp_clickhouse-0.1.0-py3-none-any.whl",` +
+		`"url":"https://files.pythonhosted.org/packages/mcp_clickhouse-0.1.0-py3-none-any.whl",` +
+		`"provenance":"/integrity/mcp-clickhouse/0.1.0/mcp_clickhouse-0.1.0-py3-none-any.whl/provenance"},` +
+		`{"filename":"mcp_clickhouse-0.1.0.tar.gz","url":"/packages/mcp_clickhouse-0.1.0.tar.gz",` +
+		`"provenance":"https://cdn.example.com/mcp_clickhouse-0.1.0.tar.gz/provenance"}]}`
+	const provenance = `{"version":1,"attestation_bundles":[{"publisher":{"kind":"GitHub",` +
+		`"repository":"ClickHouse/mcp-clickhouse","workflow":"publish.yml"},"attestations":[{}]}]}`
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Host != "pypi.org":
+			t.Errorf("requested %s, outside the allowed hosts", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(r.URL.Path, "/integrity/"):
+			_, _ = w.Write([]byte(provenance))
+		default:
+			_, _ = w.Write([]byte(simple))
+		}
+	})
+	v := &Verifier{
+		httpClient: httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		simpleURL:  "https://pypi.org/simple",
+		logger:     slog.New(slog.DiscardHandler),
+		quick:      true,
+	}
+
+	result, err := v.Verify(context.Background(), domain.PackageIdentifier{
+		Protocol: domain.ProtocolPyPI, Name: "mcp-clickhouse", Version: "0.1.0",
+	})
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	files, _ := result.Details["files"].(map[string]string)
+	if got := files["mcp_clickhouse-0.1.0-py3-none-any.whl"]; !strings.HasPrefix(got, "claimed") {
+		t.Errorf("wheel status = %q, want claimed from the relative provenance URL (files: %v)", got, files)
+	}
+	if got := files["mcp_clickhouse-0.1.0.tar.gz"]; !strings.Contains(got, `disallowed host "cdn.example.com"`) {
+		t.Errorf("sdist status = %q, want provenance on an unknown host refused", got)
+	}
+}
+
+// multiFileRelease serves a release of n wheels whose provenance fetches take
+// delay, with earlier files answering more slowly when staggered so that they
+// finish last. Wheel i is published from repository org/repo-i.
+func multiFileRelease(n int, delay time.Duration, staggered bool) http.Handler {
+	var simple strings.Builder
+	simple.WriteString(`{"name":"multi","files":[`)
+	for i := range n {
+		if i > 0 {
+			simple.WriteString(",")
+		}
+		name := fmt.Sprintf("multi-1.0.0-cp3%d-none-any.whl", i)
+		fmt.Fprintf(&simple, `{"filename":%q,"url":"https://files.pythonhosted.org/packages/%s",`+
+			`"provenance":"https://pypi.org/integrity/multi/1.0.0/%s/provenance"}`, name, name, name)
+	}
+	simple.WriteString("]}")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/integrity/") {
+			_, _ = w.Write([]byte(simple.String()))
+			return
+		}
+		var i int
+		_, _ = fmt.Sscanf(path.Base(path.Dir(r.URL.Path)), "multi-1.0.0-cp3%d-none-any.whl", &i)
+		wait := delay
+		if staggered {
+			wait = time.Duration(n-i) * delay
+		}
+		time.Sleep(wait)
+		_, _ = fmt.Fprintf(w, `{"version":1,"attestation_bundles":[{"publisher":{"kind":"GitHub",`+
+			`"repository":"org/repo-%d"},"attestations":[{}]}]}`, i)
+	})
+}
+
+// newMultiFileVerifier returns a quick-mode verifier for multiFileRelease.
+func newMultiFileVerifier(handler http.Handler, concurrency int) *Verifier {
+	return &Verifier{
+		httpClient:  httpclient.New(httpclient.WithHTTPClient(&http.Client{Transport: handlerTransport{handler}})),
+		simpleURL:   "https://pypi.org/simple",
+		logger:      slog.New(slog.DiscardHandler),
+		quick:       true,
+		concurrency: concurrency,
+	}
+}
+
+func TestVerifyFilesConcurrently(t *testing.T) {
+	t.Parallel()
+
+	const files = 6
+	v := newMultiFileVerifier(multiFileRelease(files, 5*time.Millisecond, true), files)
+	result, err := v.Verify(context.Background(), domain.PackageIdentifier{
+		Protocol: domain.ProtocolPyPI, Name: "multi", Version: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+
+	// The first file finishes last, yet its publisher is still the one reported
+	if result.TrustedPublisher == nil || result.TrustedPublisher.Repository != "org/repo-0" {
+		t.Errorf("TrustedPublisher = %+v, want org/repo-0", result.TrustedPublisher)
+	}
+	if result.AttestationCount != files {
+		t.Errorf("AttestationCount = %d, want %d", result.AttestationCount, files)
+	}
+	statuses, _ := result.Details["files"].(map[string]string)
+	for i := range files {
+		name := fmt.Sprintf("multi-1.0.0-cp3%d-none-any.whl", i)
+		if want := fmt.Sprintf("claimed (GitHub org/repo-%d), not verified", i); statuses[name] != want {
+			t.Errorf("files[%s] = %q, want %q", name, statuses[name], want)
+		}
+	}
+}
+
+// BenchmarkVerifyMultiFileRelease verifies a release of 16 files whose
+// provenance each takes 2ms to fetch, one file at a time and concurrently.
+func BenchmarkVerifyMultiFileRelease(b *testing.B) {
+	handler := multiFileRelease(16, 2*time.Millisecond, false)
+	pkg := domain.PackageIdentifier{Protocol: domain.ProtocolPyPI, Name: "multi", Version: "1.0.0"}
+
+	for _, concurrency := range []int{1, defaultFileConcurrency} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			v := newMultiFileVerifier(handler, concurrency)
+			for b.Loop() {
+				if _, err := v.Verify(context.Background(), pkg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// CheckExpectedDigest compares the artifact digest a verifier computed for
+// result with expected, an algorithm:hex digest such as sha256:ab12... pinned
+// when the package was reviewed. It is a check independent of Sigstore that
+// catches a registry serving a different artifact later on.
+//
+// expected_digest, actual_digest, and digest_match are recorded in the
+// result's Details. A mismatch, or a result with no digest to compare, sets
+// the status to ERROR with a "digest mismatch" message. A result that already
+// failed is left unchanged.
+func CheckExpectedDigest(result *domain.ProvenanceResult, expected string) {
+	if result.Status == domain.ProvenanceStatusError {
+		return
+	}
+	if result.Details == nil {
+		result.Details = make(map[string]interface{})
+	}
+	expected = strings.ToLower(expected)
+	result.Details["expected_digest"] = expected
+
+	actual := artifactDigests(result.Details)
+	if len(actual) > 0 {
+		result.Details["actual_digest"] = strings.Join(actual, ", ")
+	}
+	if slices.Contains(actual, expected) {
+		result.Details["digest_match"] = true
+		return
+	}
+
+	result.Details["digest_match"] = false
+	result.Status = domain.ProvenanceStatusError
+	if len(actual) == 0 {
+		result.ErrorMessage = fmt.Sprintf("digest mismatch: expected %s, but no artifact digest was computed", expected)
+		return
+	}
+	result.ErrorMessage = fmt.Sprintf("digest mismatch: expected %s got %s", expected, strings.Join(actual, ", "))
+}
+
+// artifactDigests returns the algorithm:hex digests the verifiers record: the
+// npm tarball's artifact_digest, the OCI image_digest, or the digest of every
+// PyPI release file in file_digests, in filename order.
+func artifactDigests(details map[string]interface{}) []string {
+	var digests []string
+	for _, key := range []string{"artifact_digest", "image_digest"} {
+		if digest, ok := details[key].(string); ok && digest != "" {
+			digests = append(digests, strings.ToLower(digest))
+		}
+	}
+	if files, ok := details["file_digests"].(map[string]string); ok {
+		for _, name := range slices.Sorted(maps.Keys(files)) {
+			digests = append(digests, strings.ToLower(files[name]))
+		}
+	}
+	return digests
+}
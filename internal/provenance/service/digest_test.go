@@ -0,0 +1,103 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestCheckExpectedDigest(t *testing.T) {
+	t.Parallel()
+
+	const (
+		tarball = "sha512:aa11"
+		wheel   = "sha256:bb22"
+		sdist   = "sha256:cc33"
+	)
+	tests := []struct {
+		name        string
+		status      domain.ProvenanceStatus
+		details     map[string]interface{}
+		expected    string
+		wantStatus  domain.ProvenanceStatus
+		wantMatch   interface{}
+		wantMessage string
+	}{
+		{
+			name:       "npm match",
+			status:     domain.ProvenanceStatusVerified,
+			details:    map[string]interface{}{"artifact_digest": tarball},
+			expected:   "SHA512:AA11",
+			wantStatus: domain.ProvenanceStatusVerified,
+			wantMatch:  true,
+		},
+		{
+			name:        "npm mismatch",
+			status:      domain.ProvenanceStatusVerified,
+			details:     map[string]interface{}{"artifact_digest": tarball},
+			expected:    "sha512:ffff",
+			wantStatus:  domain.ProvenanceStatusError,
+			wantMatch:   false,
+			wantMessage: "digest mismatch: expected sha512:ffff got sha512:aa11",
+		},
+		{
+			name:       "pypi file match",
+			status:     domain.ProvenanceStatusNone,
+			details:    map[string]interface{}{"file_digests": map[string]string{"a.whl": wheel, "a.tar.gz": sdist}},
+			expected:   wheel,
+			wantStatus: domain.ProvenanceStatusNone,
+			wantMatch:  true,
+		},
+		{
+			name:        "pypi mismatch lists every file",
+			status:      domain.ProvenanceStatusVerified,
+			details:     map[string]interface{}{"file_digests": map[string]string{"a.whl": wheel, "a.tar.gz": sdist}},
+			expected:    "sha256:dd44",
+			wantStatus:  domain.ProvenanceStatusError,
+			wantMatch:   false,
+			wantMessage: "expected sha256:dd44 got sha256:cc33, sha256:bb22",
+		},
+		{
+			name:       "oci image",
+			status:     domain.ProvenanceStatusAttestations,
+			details:    map[string]interface{}{"image_digest": wheel},
+			expected:   wheel,
+			wantStatus: domain.ProvenanceStatusAttestations,
+			wantMatch:  true,
+		},
+		{
+			name:        "no digest",
+			status:      domain.ProvenanceStatusUnknown,
+			expected:    wheel,
+			wantStatus:  domain.ProvenanceStatusError,
+			wantMatch:   false,
+			wantMessage: "no artifact digest was computed",
+		},
+		{
+			name:       "already failed",
+			status:     domain.ProvenanceStatusError,
+			details:    map[string]interface{}{},
+			expected:   wheel,
+			wantStatus: domain.ProvenanceStatusError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result := &domain.ProvenanceResult{Status: tt.status, Details: tt.details}
+			CheckExpectedDigest(result, tt.expected)
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.Details["digest_match"] != tt.wantMatch {
+				t.Errorf("digest_match = %v, want %v", result.Details["digest_match"], tt.wantMatch)
+			}
+			if !strings.Contains(result.ErrorMessage, tt.wantMessage) {
+				t.Errorf("ErrorMessage = %q, want containing %q", result.ErrorMessage, tt.wantMessage)
+			}
+		})
+	}
+}
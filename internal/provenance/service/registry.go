@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// VerifierFactory creates the verifier for one protocol
+type VerifierFactory func(ctx context.Context) (domain.ProvenanceVerifier, error)
+
+// Registry maps protocols to the factories that create their verifiers
+type Registry struct {
+	factories map[domain.PackageProtocol]VerifierFactory
+	mu        sync.RWMutex
+}
+
+// NewRegistry creates an empty verifier factory registry
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[domain.PackageProtocol]VerifierFactory),
+	}
+}
+
+// RegisterFactory registers the factory for a protocol. Each protocol can be
+// registered only once.
+func (r *Registry) RegisterFactory(protocol domain.PackageProtocol, factory VerifierFactory) error {
+	if factory == nil {
+		return fmt.Errorf("factory cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.factories[protocol]; exists {
+		return fmt.Errorf("a verifier factory is already registered for protocol %s", protocol)
+	}
+	r.factories[protocol] = factory
+	return nil
+}
+
+// Protocols returns the protocols that have a registered factory, sorted
+func (r *Registry) Protocols() []domain.PackageProtocol {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	protocols := make([]domain.PackageProtocol, 0, len(r.factories))
+	for protocol := range r.factories {
+		protocols = append(protocols, protocol)
+	}
+	slices.Sort(protocols)
+	return protocols
+}
+
+// NewService creates a service with a verifier from every registered factory,
+// configured by opts
+func (r *Registry) NewService(ctx context.Context, opts ...Option) (*Service, error) {
+	svc := New(append([]Option{WithStrictRegistration()}, opts...)...)
+	for _, protocol := range r.Protocols() {
+		r.mu.RLock()
+		factory := r.factories[protocol]
+		r.mu.RUnlock()
+
+		verifier, err := factory(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s verifier: %w", protocol, err)
+		}
+		if err := svc.RegisterVerifier(protocol, verifier); err != nil {
+			return nil, fmt.Errorf("failed to register %s verifier: %w", protocol, err)
+		}
+	}
+	return svc, nil
+}
+
+// defaultRegistry backs the package-level registration functions
+var defaultRegistry = NewRegistry()
+
+// RegisterFactory registers the factory for a protocol in the default registry
+func RegisterFactory(protocol domain.PackageProtocol, factory VerifierFactory) error {
+	return defaultRegistry.RegisterFactory(protocol, factory)
+}
+
+// RegisteredProtocols returns the protocols registered in the default registry
+func RegisteredProtocols() []domain.PackageProtocol {
+	return defaultRegistry.Protocols()
+}
+
+// NewFromRegistry creates a service with a verifier for every protocol
+// registered in the default registry, configured by opts
+func NewFromRegistry(ctx context.Context, opts ...Option) (*Service, error) {
+	return defaultRegistry.NewService(ctx, opts...)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestRegistry_NewService(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	for _, protocol := range []domain.PackageProtocol{domain.ProtocolPyPI, domain.ProtocolNPM} {
+		err := registry.RegisterFactory(protocol, func(context.Context) (domain.ProvenanceVerifier, error) {
+			return &stubVerifier{protocol: protocol}, nil
+		})
+		if err != nil {
+			t.Fatalf("RegisterFactory(%s) error: %v", protocol, err)
+		}
+	}
+
+	want := []domain.PackageProtocol{domain.ProtocolNPM, domain.ProtocolPyPI}
+	if got := registry.Protocols(); !slices.Equal(got, want) {
+		t.Errorf("Protocols() = %v, want %v", got, want)
+	}
+
+	svc, err := registry.NewService(context.Background())
+	if err != nil {
+		t.Fatalf("NewService() error: %v", err)
+	}
+	for _, protocol := range want {
+		result, err := svc.VerifyProvenance(context.Background(), domain.PackageIdentifier{Protocol: protocol, Name: "pkg"})
+		if err != nil || result.Status != domain.ProvenanceStatusVerified {
+			t.Errorf("VerifyProvenance(%s) = %v, %v, want a verified result from the factory's verifier", protocol, result, err)
+		}
+	}
+}
+
+func TestRegistry_RegisterFactoryErrors(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	factory := func(context.Context) (domain.ProvenanceVerifier, error) {
+		return &stubVerifier{protocol: domain.ProtocolNPM}, nil
+	}
+
+	if err := registry.RegisterFactory(domain.ProtocolNPM, nil); err == nil {
+		t.Error("RegisterFactory(nil) = nil, want error")
+	}
+	if err := registry.RegisterFactory(domain.ProtocolNPM, factory); err != nil {
+		t.Fatalf("RegisterFactory() error: %v", err)
+	}
+	if err := registry.RegisterFactory(domain.ProtocolNPM, factory); err == nil {
+		t.Error("RegisterFactory(duplicate) = nil, want error")
+	}
+}
+
+func TestRegistry_NewServiceFactoryError(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	factoryErr := errors.New("no trusted root")
+	err := registry.RegisterFactory(domain.ProtocolNPM, func(context.Context) (domain.ProvenanceVerifier, error) {
+		return nil, factoryErr
+	})
+	if err != nil {
+		t.Fatalf("RegisterFactory() error: %v", err)
+	}
+
+	if _, err := registry.NewService(context.Background()); !errors.Is(err, factoryErr) {
+		t.Errorf("NewService() error = %v, want it to wrap %v", err, factoryErr)
+	}
+}
@@ -3,26 +3,98 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/stacklok/dockyard/internal/provenance/domain"
 )
 
+// ErrVerifierRegistered is returned by RegisterVerifier on a service created
+// with WithStrictRegistration when the protocol already has a verifier
+var ErrVerifierRegistered = errors.New("a verifier is already registered for this protocol")
+
+// ErrUnsupportedProtocol is returned by VerifyProvenance on a service created
+// with WithStrictProtocols for a protocol that has no registered verifier
+var ErrUnsupportedProtocol = errors.New("unsupported protocol")
+
 // Service coordinates provenance verification across different verifiers
 type Service struct {
 	verifiers map[domain.PackageProtocol]domain.ProvenanceVerifier
 	mu        sync.RWMutex
+	// strict rejects registering a second verifier for a protocol
+	strict bool
+	// strictProtocols makes an unregistered protocol an error rather than an UNKNOWN status
+	strictProtocols bool
+	// maxConcurrency bounds the verifications BatchVerify runs at once; zero is unbounded
+	maxConcurrency int
+	// progress is told about every verification a batch completes
+	progress ProgressFunc
+}
+
+// ProgressFunc reports the progress of a batch: done of total packages are
+// verified, last being the result of the one that just finished. Calls are
+// serialized, with done increasing by one each time.
+type ProgressFunc func(done, total int, last *domain.ProvenanceResult)
+
+// allVersionsConcurrency bounds VerifyAllVersions when the service sets no
+// limit of its own, since a package can have hundreds of versions
+const allVersionsConcurrency = 8
+
+// Option configures a Service
+type Option func(*Service)
+
+// WithStrictRegistration makes RegisterVerifier fail with
+// ErrVerifierRegistered instead of replacing a protocol's verifier, so that a
+// double registration, e.g. by two plugins, is detected rather than silently
+// changing which verifier runs.
+func WithStrictRegistration() Option {
+	return func(s *Service) {
+		s.strict = true
+	}
+}
+
+// WithStrictProtocols sets whether VerifyProvenance fails with
+// ErrUnsupportedProtocol for a protocol without a verifier. Either way the
+// result has ProvenanceStatusUnknown; by default the error is nil, so callers
+// that only check it must opt in to notice.
+func WithStrictProtocols(strict bool) Option {
+	return func(s *Service) {
+		s.strictProtocols = strict
+	}
+}
+
+// WithMaxConcurrency bounds how many verifications BatchVerify and
+// VerifyAllVersions run at once. Zero, the default, leaves BatchVerify unbounded.
+func WithMaxConcurrency(n int) Option {
+	return func(s *Service) {
+		s.maxConcurrency = n
+	}
+}
+
+// WithProgress calls progress as BatchVerify and VerifyAllVersions complete
+// each verification, e.g. to render a progress bar. The workers wait for each
+// call to return, so it should be quick. Packages cut short by ctx are not reported.
+func WithProgress(progress ProgressFunc) Option {
+	return func(s *Service) {
+		s.progress = progress
+	}
 }
 
 // New creates a new provenance service
-func New() *Service {
-	return &Service{
+func New(opts ...Option) *Service {
+	s := &Service{
 		verifiers: make(map[domain.PackageProtocol]domain.ProvenanceVerifier),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// RegisterVerifier registers a verifier for a specific protocol
+// RegisterVerifier registers a verifier for a specific protocol, replacing
+// any verifier registered for it before unless the service is strict
 func (s *Service) RegisterVerifier(protocol domain.PackageProtocol, verifier domain.ProvenanceVerifier) error {
 	if verifier == nil {
 		return fmt.Errorf("verifier cannot be nil")
@@ -35,61 +107,257 @@ func (s *Service) RegisterVerifier(protocol domain.PackageProtocol, verifier dom
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, exists := s.verifiers[protocol]; exists && s.strict {
+		return fmt.Errorf("failed to register %s verifier: %w", protocol, ErrVerifierRegistered)
+	}
 	s.verifiers[protocol] = verifier
 	return nil
 }
 
+// ListVerifiers returns the protocols that have a registered verifier, sorted
+func (s *Service) ListVerifiers() []domain.PackageProtocol {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	protocols := make([]domain.PackageProtocol, 0, len(s.verifiers))
+	for protocol := range s.verifiers {
+		protocols = append(protocols, protocol)
+	}
+	slices.Sort(protocols)
+	return protocols
+}
+
+// Warmup prepares every registered verifier that implements domain.Warmer, in
+// parallel, so that a following BatchVerify reuses warm connections instead of
+// paying connection setup on its first requests. Warm-up is only an
+// optimization: errors are joined and returned, but verification still works
+// after a failed warm-up.
+func (s *Service) Warmup(ctx context.Context) error {
+	s.mu.RLock()
+	warmers := make(map[domain.PackageProtocol]domain.Warmer)
+	for protocol, verifier := range s.verifiers {
+		if w, ok := verifier.(domain.Warmer); ok {
+			warmers[protocol] = w
+		}
+	}
+	s.mu.RUnlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for protocol, w := range warmers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.Warmup(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("failed to warm up %s verifier: %w", protocol, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
 // VerifyProvenance verifies the provenance of a package
 func (s *Service) VerifyProvenance(ctx context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	if err := ctx.Err(); err != nil {
+		return incompleteResult(pkg, err), err
+	}
+
 	s.mu.RLock()
 	verifier, ok := s.verifiers[pkg.Protocol]
 	s.mu.RUnlock()
 
 	if !ok {
-		return &domain.ProvenanceResult{
+		result := &domain.ProvenanceResult{
 			PackageID:    pkg,
 			Status:       domain.ProvenanceStatusUnknown,
 			ErrorMessage: fmt.Sprintf("no verifier registered for protocol %s", pkg.Protocol),
-		}, nil
+		}
+		if s.strictProtocols {
+			return result, fmt.Errorf("%w: no verifier registered for protocol %s", ErrUnsupportedProtocol, pkg.Protocol)
+		}
+		return result, nil
 	}
 
 	result, err := verifier.Verify(ctx, pkg)
 	if err != nil {
-		return &domain.ProvenanceResult{
-			PackageID:    pkg,
-			Status:       domain.ProvenanceStatusError,
-			ErrorMessage: err.Error(),
-		}, err
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return incompleteResult(pkg, ctxErr), err
+		}
+		// Keep the verifier's own error result, which says more than err alone
+		if result == nil {
+			result = &domain.ProvenanceResult{
+				PackageID:    pkg,
+				Status:       domain.ProvenanceStatusError,
+				ErrorMessage: err.Error(),
+			}
+		}
+		return result, err
 	}
 
 	return result, nil
 }
 
-// BatchVerify verifies multiple packages in parallel
+// BatchVerify verifies multiple packages in parallel. The error joins every
+// failed verification's error in the order of packages, so it is the same
+// from run to run. If ctx is done before every verification finishes, it
+// returns immediately; packages that did not complete are reported with
+// ProvenanceStatusUnknown and the context error.
 func (s *Service) BatchVerify(ctx context.Context, packages []domain.PackageIdentifier) ([]*domain.ProvenanceResult, error) {
+	return s.batchVerify(ctx, packages, s.maxConcurrency)
+}
+
+// ResolveVersion looks up the published version of pkg that pkg.Version
+// names, such as the newest release for "latest". The protocol's verifier
+// must be able to resolve versions.
+func (s *Service) ResolveVersion(ctx context.Context, pkg domain.PackageIdentifier) (string, error) {
+	s.mu.RLock()
+	verifier, ok := s.verifiers[pkg.Protocol]
+	s.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no verifier registered for protocol %s", pkg.Protocol)
+	}
+	resolver, ok := verifier.(domain.VersionResolver)
+	if !ok {
+		return "", fmt.Errorf("the %s verifier cannot look up package versions", pkg.Protocol)
+	}
+	return resolver.ResolveVersion(ctx, pkg.Name, pkg.Version)
+}
+
+// VerifyAllVersions verifies every published version of a package, oldest
+// first, with the bounded concurrency of BatchVerify. The protocol's verifier
+// must be able to list versions.
+func (s *Service) VerifyAllVersions(
+	ctx context.Context,
+	name string,
+	protocol domain.PackageProtocol,
+) ([]*domain.ProvenanceResult, error) {
+	s.mu.RLock()
+	verifier, ok := s.verifiers[protocol]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no verifier registered for protocol %s", protocol)
+	}
+	lister, ok := verifier.(domain.VersionLister)
+	if !ok {
+		return nil, fmt.Errorf("the %s verifier cannot list package versions", protocol)
+	}
+
+	versions, err := lister.ListVersions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of %s: %w", name, err)
+	}
+
+	packages := make([]domain.PackageIdentifier, len(versions))
+	for i, version := range versions {
+		packages[i] = domain.PackageIdentifier{Protocol: protocol, Name: name, Version: version}
+	}
+	limit := s.maxConcurrency
+	if limit <= 0 {
+		limit = allVersionsConcurrency
+	}
+	return s.batchVerify(ctx, packages, limit)
+}
+
+// batchVerify verifies packages in parallel, running at most limit
+// verifications at once when limit is positive
+func (s *Service) batchVerify(
+	ctx context.Context,
+	packages []domain.PackageIdentifier,
+	limit int,
+) ([]*domain.ProvenanceResult, error) {
 	results := make([]*domain.ProvenanceResult, len(packages))
-	errors := make([]error, len(packages))
+	errs := make([]error, len(packages))
+
+	// mu guards results, errs, and completed, and serializes progress
+	// calls; once abandoned is set, late finishers drop their results so the
+	// slices handed back to the caller never change.
+	var mu sync.Mutex
+	abandoned := false
+	completed := 0
+
+	// A nil semaphore leaves the verifications unbounded
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
 
 	var wg sync.WaitGroup
 	for i, pkg := range packages {
 		wg.Add(1)
 		go func(idx int, p domain.PackageIdentifier) {
 			defer wg.Done()
+			if sem != nil {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+				}
+			}
+			// Once ctx is done this returns an incomplete result right away
 			result, err := s.VerifyProvenance(ctx, p)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if abandoned {
+				return
+			}
 			results[idx] = result
-			errors[idx] = err
+			errs[idx] = err
+			completed++
+			if s.progress != nil {
+				s.progress(completed, len(packages), result)
+			}
 		}(i, pkg)
 	}
 
-	wg.Wait()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
 
-	// Check if any errors occurred
-	var firstError error
-	for _, err := range errors {
-		if err != nil && firstError == nil {
-			firstError = err
+	select {
+	case <-done:
+	case <-ctx.Done():
+		mu.Lock()
+		abandoned = true
+		for i, pkg := range packages {
+			if results[i] == nil {
+				results[i] = incompleteResult(pkg, ctx.Err())
+				errs[i] = ctx.Err()
+			}
 		}
+		mu.Unlock()
 	}
 
-	return results, firstError
+	return results, joinBatchErrors(packages, errs)
+}
+
+// joinBatchErrors joins the errors of a batch in the order of its packages,
+// whatever order the verifications finished in, naming the package of each.
+// It returns nil when every verification succeeded.
+func joinBatchErrors(packages []domain.PackageIdentifier, errs []error) error {
+	var joined []error
+	for i, err := range errs {
+		if err != nil {
+			joined = append(joined, fmt.Errorf("%s@%s: %w", packages[i].Name, packages[i].Version, err))
+		}
+	}
+	return errors.Join(joined...)
+}
+
+// incompleteResult describes a package whose verification was cut short by
+// context cancellation or deadline expiry.
+func incompleteResult(pkg domain.PackageIdentifier, err error) *domain.ProvenanceResult {
+	return &domain.ProvenanceResult{
+		PackageID:    pkg,
+		Status:       domain.ProvenanceStatusUnknown,
+		ErrorMessage: fmt.Sprintf("verification did not complete: %v", err),
+	}
 }
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// stubVerifier returns a VERIFIED result after the per-package delay. It
+// deliberately ignores ctx so tests can prove the service itself stops waiting.
+type stubVerifier struct {
+	protocol domain.PackageProtocol
+	delays   map[string]time.Duration
+}
+
+func (s *stubVerifier) Verify(_ context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	time.Sleep(s.delays[pkg.Name])
+	return &domain.ProvenanceResult{PackageID: pkg, Status: domain.ProvenanceStatusVerified}, nil
+}
+
+func (s *stubVerifier) SupportsProtocol(protocol domain.PackageProtocol) bool {
+	return protocol == s.protocol
+}
+
+func TestBatchVerify_ContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	svc := New()
+	stub := &stubVerifier{
+		protocol: domain.ProtocolNPM,
+		delays: map[string]time.Duration{
+			"fast": 0,
+			"slow": 10 * time.Second,
+		},
+	}
+	if err := svc.RegisterVerifier(domain.ProtocolNPM, stub); err != nil {
+		t.Fatalf("RegisterVerifier: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	packages := []domain.PackageIdentifier{
+		{Protocol: domain.ProtocolNPM, Name: "fast", Version: "1.0.0"},
+		{Protocol: domain.ProtocolNPM, Name: "slow", Version: "1.0.0"},
+	}
+
+	start := time.Now()
+	results, err := svc.BatchVerify(ctx, packages)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("BatchVerify took %v, want it to return shortly after the deadline", elapsed)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("BatchVerify error = %v, want context.DeadlineExceeded", err)
+	}
+	if len(results) != len(packages) {
+		t.Fatalf("got %d results, want %d", len(results), len(packages))
+	}
+	if got := results[0].Status; got != domain.ProvenanceStatusVerified {
+		t.Errorf("fast package status = %s, want %s", got, domain.ProvenanceStatusVerified)
+	}
+	if got := results[1].Status; got != domain.ProvenanceStatusUnknown {
+		t.Errorf("slow package status = %s, want %s", got, domain.ProvenanceStatusUnknown)
+	}
+	if results[1].ErrorMessage == "" {
+		t.Errorf("slow package has no explanatory error message")
+	}
+}
+
+func TestBatchVerify_Progress(t *testing.T) {
+	t.Parallel()
+
+	// Serialized calls need no locking, and the race detector would flag any that are not
+	var dones []int
+	var names []string
+	svc := New(WithProgress(func(done, total int, last *domain.ProvenanceResult) {
+		if total != 20 {
+			t.Errorf("total = %d, want 20", total)
+		}
+		dones = append(dones, done)
+		names = append(names, last.PackageID.Name)
+	}))
+	if err := svc.RegisterVerifier(domain.ProtocolNPM, &stubVerifier{protocol: domain.ProtocolNPM}); err != nil {
+		t.Fatalf("RegisterVerifier: %v", err)
+	}
+
+	packages := make([]domain.PackageIdentifier, 20)
+	want := make([]string, len(packages))
+	for i := range packages {
+		want[i] = string(rune('a' + i))
+		packages[i] = domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: want[i], Version: "1.0.0"}
+	}
+	if _, err := svc.BatchVerify(context.Background(), packages); err != nil {
+		t.Fatalf("BatchVerify: %v", err)
+	}
+
+	for i, done := range dones {
+		if done != i+1 {
+			t.Fatalf("progress done values = %v, want 1 through 20 in order", dones)
+		}
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, want) {
+		t.Errorf("progress reported %v, want every package once", names)
+	}
+}
+
+func TestVerifyProvenance_CanceledContext(t *testing.T) {
+	t.Parallel()
+
+	svc := New()
+	stub := &stubVerifier{protocol: domain.ProtocolNPM}
+	if err := svc.RegisterVerifier(domain.ProtocolNPM, stub); err != nil {
+		t.Fatalf("RegisterVerifier: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pkg := domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "pkg", Version: "1.0.0"}
+	result, err := svc.VerifyProvenance(ctx, pkg)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("VerifyProvenance error = %v, want context.Canceled", err)
+	}
+	if result.Status != domain.ProvenanceStatusUnknown {
+		t.Errorf("status = %s, want %s", result.Status, domain.ProvenanceStatusUnknown)
+	}
+}
+
+// warmingVerifier is a stubVerifier that also implements domain.Warmer.
+type warmingVerifier struct {
+	stubVerifier
+	warmed bool
+	err    error
+}
+
+func (w *warmingVerifier) Warmup(context.Context) error {
+	w.warmed = true
+	return w.err
+}
+
+func TestWarmup(t *testing.T) {
+	t.Parallel()
+
+	npmVerifier := &warmingVerifier{stubVerifier: stubVerifier{protocol: domain.ProtocolNPM}}
+	pypiVerifier := &warmingVerifier{
+		stubVerifier: stubVerifier{protocol: domain.ProtocolPyPI},
+		err:          errors.New("connection refused"),
+	}
+
+	svc := New()
+	for protocol, v := range map[domain.PackageProtocol]domain.ProvenanceVerifier{
+		domain.ProtocolNPM:  npmVerifier,
+		domain.ProtocolPyPI: pypiVerifier,
+		domain.ProtocolGo:   &stubVerifier{protocol: domain.ProtocolGo},
+	} {
+		if err := svc.RegisterVerifier(protocol, v); err != nil {
+			t.Fatalf("RegisterVerifier(%s): %v", protocol, err)
+		}
+	}
+
+	err := svc.Warmup(context.Background())
+	if !npmVerifier.warmed || !pypiVerifier.warmed {
+		t.Errorf("warmed npm=%v pypi=%v, want both warmed", npmVerifier.warmed, pypiVerifier.warmed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "uvx verifier") {
+		t.Errorf("Warmup() error = %v, want the pypi warm-up failure", err)
+	}
+}
+
+func TestRegisterVerifier_Overwrite(t *testing.T) {
+	t.Parallel()
+
+	first := &stubVerifier{protocol: domain.ProtocolNPM}
+	second := &stubVerifier{protocol: domain.ProtocolNPM}
+
+	svc := New()
+	for _, v := range []*stubVerifier{first, second} {
+		if err := svc.RegisterVerifier(domain.ProtocolNPM, v); err != nil {
+			t.Fatalf("RegisterVerifier() error: %v", err)
+		}
+	}
+	if svc.verifiers[domain.ProtocolNPM] != second {
+		t.Error("RegisterVerifier() did not replace the earlier verifier")
+	}
+
+	strict := New(WithStrictRegistration())
+	if err := strict.RegisterVerifier(domain.ProtocolNPM, first); err != nil {
+		t.Fatalf("RegisterVerifier() error: %v", err)
+	}
+	if err := strict.RegisterVerifier(domain.ProtocolNPM, second); !errors.Is(err, ErrVerifierRegistered) {
+		t.Errorf("RegisterVerifier(duplicate) error = %v, want ErrVerifierRegistered", err)
+	}
+	if strict.verifiers[domain.ProtocolNPM] != first {
+		t.Error("strict RegisterVerifier() replaced the registered verifier")
+	}
+}
+
+func TestListVerifiers(t *testing.T) {
+	t.Parallel()
+
+	svc := New()
+	if got := svc.ListVerifiers(); len(got) != 0 {
+		t.Errorf("ListVerifiers() = %v, want none", got)
+	}
+	for _, protocol := range []domain.PackageProtocol{domain.ProtocolPyPI, domain.ProtocolNPM, domain.ProtocolGo} {
+		if err := svc.RegisterVerifier(protocol, &stubVerifier{protocol: protocol}); err != nil {
+			t.Fatalf("RegisterVerifier(%s): %v", protocol, err)
+		}
+	}
+	want := []domain.PackageProtocol{domain.ProtocolGo, domain.ProtocolNPM, domain.ProtocolPyPI}
+	if got := svc.ListVerifiers(); !slices.Equal(got, want) {
+		t.Errorf("ListVerifiers() = %v, want %v", got, want)
+	}
+}
+
+func TestVerifyProvenance_UnsupportedProtocol(t *testing.T) {
+	t.Parallel()
+
+	pkg := domain.PackageIdentifier{Name: "example.com/mod", Version: "v1.0.0", Protocol: domain.ProtocolGo}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "soft by default"},
+		{name: "strict disabled", opts: []Option{WithStrictProtocols(false)}},
+		{name: "strict enabled", opts: []Option{WithStrictProtocols(true)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := New(tt.opts...).VerifyProvenance(context.Background(), pkg)
+			if tt.wantErr != errors.Is(err, ErrUnsupportedProtocol) {
+				t.Fatalf("VerifyProvenance() error = %v, want ErrUnsupportedProtocol: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("VerifyProvenance() error: %v", err)
+			}
+			if result == nil || result.Status != domain.ProvenanceStatusUnknown {
+				t.Errorf("VerifyProvenance() result = %+v, want status %s", result, domain.ProvenanceStatusUnknown)
+			}
+		})
+	}
+}
+
+// listingVerifier is a stubVerifier that can list versions and records the
+// most verifications it saw running at once
+type listingVerifier struct {
+	stubVerifier
+	versions []string
+
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (l *listingVerifier) ListVersions(context.Context, string) ([]string, error) {
+	return l.versions, nil
+}
+
+func (l *listingVerifier) Verify(ctx context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	l.mu.Lock()
+	l.running++
+	l.peak = max(l.peak, l.running)
+	l.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	l.mu.Lock()
+	l.running--
+	l.mu.Unlock()
+	return l.stubVerifier.Verify(ctx, pkg)
+}
+
+// resolvingVerifier is a stubVerifier that resolves "latest" to a fixed version
+type resolvingVerifier struct {
+	stubVerifier
+}
+
+func (r *resolvingVerifier) ResolveVersion(_ context.Context, name, version string) (string, error) {
+	switch version {
+	case "latest":
+		return "2.1.0", nil
+	case "1.0.0", "2.1.0":
+		return version, nil
+	}
+	return "", fmt.Errorf("%w: version %s not found in package %s", domain.ErrVersionNotFound, version, name)
+}
+
+func TestResolveVersion(t *testing.T) {
+	t.Parallel()
+
+	svc := New()
+	resolver := &resolvingVerifier{stubVerifier{protocol: domain.ProtocolNPM}}
+	if err := svc.RegisterVerifier(domain.ProtocolNPM, resolver); err != nil {
+		t.Fatalf("RegisterVerifier: %v", err)
+	}
+	if err := svc.RegisterVerifier(domain.ProtocolOCI, &stubVerifier{protocol: domain.ProtocolOCI}); err != nil {
+		t.Fatalf("RegisterVerifier: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		protocol domain.PackageProtocol
+		version  string
+		want     string
+		wantErr  string
+	}{
+		{"latest", domain.ProtocolNPM, "latest", "2.1.0", ""},
+		{"exact", domain.ProtocolNPM, "1.0.0", "1.0.0", ""},
+		{"missing version", domain.ProtocolNPM, "9.9.9", "", "version 9.9.9 not found"},
+		{"cannot resolve", domain.ProtocolOCI, "latest", "", "cannot look up package versions"},
+		{"no verifier", domain.ProtocolGo, "latest", "", "no verifier registered"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pkg := domain.PackageIdentifier{Protocol: tt.protocol, Name: "pkg", Version: tt.version}
+			got, err := svc.ResolveVersion(context.Background(), pkg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveVersion() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveVersion() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAllVersions(t *testing.T) {
+	t.Parallel()
+
+	lister := &listingVerifier{
+		stubVerifier: stubVerifier{protocol: domain.ProtocolNPM},
+		versions:     []string{"1.0.0", "1.1.0", "1.2.0", "2.0.0", "2.1.0"},
+	}
+	svc := New(WithMaxConcurrency(2))
+	if err := svc.RegisterVerifier(domain.ProtocolNPM, lister); err != nil {
+		t.Fatalf("RegisterVerifier: %v", err)
+	}
+	if err := svc.RegisterVerifier(domain.ProtocolGo, &stubVerifier{protocol: domain.ProtocolGo}); err != nil {
+		t.Fatalf("RegisterVerifier: %v", err)
+	}
+
+	results, err := svc.VerifyAllVersions(context.Background(), "pkg", domain.ProtocolNPM)
+	if err != nil {
+		t.Fatalf("VerifyAllVersions() error: %v", err)
+	}
+	if len(results) != len(lister.versions) {
+		t.Fatalf("VerifyAllVersions() returned %d results, want %d", len(results), len(lister.versions))
+	}
+	for i, result := range results {
+		if result.PackageID.Version != lister.versions[i] || result.Status != domain.ProvenanceStatusVerified {
+			t.Errorf("results[%d] = %s %s, want %s VERIFIED", i, result.PackageID.Version, result.Status, lister.versions[i])
+		}
+	}
+	if lister.peak > 2 {
+		t.Errorf("ran %d verifications at once, want at most 2", lister.peak)
+	}
+
+	if _, err := svc.VerifyAllVersions(context.Background(), "pkg", domain.ProtocolGo); err == nil {
+		t.Error("VerifyAllVersions() with a verifier that cannot list versions = nil error, want error")
+	}
+	if _, err := svc.VerifyAllVersions(context.Background(), "pkg", domain.ProtocolPyPI); err == nil {
+		t.Error("VerifyAllVersions() without a verifier = nil error, want error")
+	}
+}
+
+// failingVerifier fails every package whose name starts with "bad" after its
+// delay, so failures finish in a different order than they were submitted.
+type failingVerifier struct {
+	delays map[string]time.Duration
+}
+
+func (f *failingVerifier) Verify(_ context.Context, pkg domain.PackageIdentifier) (*domain.ProvenanceResult, error) {
+	time.Sleep(f.delays[pkg.Name])
+	if strings.HasPrefix(pkg.Name, "bad") {
+		err := errors.New("registry unavailable")
+		return &domain.ProvenanceResult{PackageID: pkg, Status: domain.ProvenanceStatusError, ErrorMessage: err.Error()}, err
+	}
+	return &domain.ProvenanceResult{PackageID: pkg, Status: domain.ProvenanceStatusVerified}, nil
+}
+
+func (f *failingVerifier) SupportsProtocol(protocol domain.PackageProtocol) bool {
+	return protocol == domain.ProtocolNPM
+}
+
+func TestBatchVerify_ErrorOrder(t *testing.T) {
+	t.Parallel()
+
+	svc := New()
+	// Later packages finish first
+	verifier := &failingVerifier{delays: map[string]time.Duration{
+		"bad-a": 30 * time.Millisecond,
+		"bad-b": 15 * time.Millisecond,
+		"bad-c": 0,
+	}}
+	if err := svc.RegisterVerifier(domain.ProtocolNPM, verifier); err != nil {
+		t.Fatalf("RegisterVerifier: %v", err)
+	}
+	packages := []domain.PackageIdentifier{
+		{Protocol: domain.ProtocolNPM, Name: "bad-a", Version: "1.0.0"},
+		{Protocol: domain.ProtocolNPM, Name: "good", Version: "1.0.0"},
+		{Protocol: domain.ProtocolNPM, Name: "bad-b", Version: "2.0.0"},
+		{Protocol: domain.ProtocolNPM, Name: "bad-c", Version: "3.0.0"},
+	}
+	want := "bad-a@1.0.0: registry unavailable\n" +
+		"bad-b@2.0.0: registry unavailable\n" +
+		"bad-c@3.0.0: registry unavailable"
+
+	for range 5 {
+		results, err := svc.BatchVerify(context.Background(), packages)
+		if err == nil || err.Error() != want {
+			t.Fatalf("BatchVerify error = %q, want %q", err, want)
+		}
+		if len(results) != len(packages) || results[1].Status != domain.ProvenanceStatusVerified {
+			t.Fatalf("results = %v, want one per package with good verified", results)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// Summary aggregates the results of a batch verification.
+type Summary struct {
+	// Total is the number of results summarized
+	Total int
+	// StatusCounts counts the results per status
+	StatusCounts map[domain.ProvenanceStatus]int
+	// VerifiedAttestations is the number of verified attestations across all packages
+	VerifiedAttestations int
+	// Failed lists the packages that do not meet the requirements, in input order
+	Failed []FailedPackage
+	// Passed reports whether every package meets the requirements
+	Passed bool
+}
+
+// FailedPackage is a package that does not meet the provenance requirements.
+type FailedPackage struct {
+	Package domain.PackageIdentifier
+	Status  domain.ProvenanceStatus
+	Reason  string
+}
+
+// Summarize tallies the results of BatchVerify and checks each of them
+// against requirements with ValidateRequirements.
+func Summarize(results []*domain.ProvenanceResult, requirements domain.ProvenanceRequirements) Summary {
+	summary := Summary{
+		Total:        len(results),
+		StatusCounts: make(map[domain.ProvenanceStatus]int),
+	}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		summary.StatusCounts[result.Status]++
+		summary.VerifiedAttestations += result.VerifiedAttestationCount
+		if err := ValidateRequirements(result, requirements); err != nil {
+			summary.Failed = append(summary.Failed, FailedPackage{
+				Package: result.PackageID,
+				Status:  result.Status,
+				Reason:  err.Error(),
+			})
+		}
+	}
+	summary.Passed = len(summary.Failed) == 0
+	return summary
+}
+
+var (
+	// ErrRequirementsNotMet is returned by ValidateRequirements for a result
+	// that does not satisfy the requirements.
+	ErrRequirementsNotMet = errors.New("provenance requirements not met")
+	// ErrPublisherNotAllowed is also wrapped by the error for a result whose
+	// publisher is missing from the requirements' AllowedPublishers.
+	ErrPublisherNotAllowed = errors.New("publisher not allowed")
+)
+
+// ValidateRequirements checks result against requirements. A result whose
+// verification errored or did not complete never meets them.
+func ValidateRequirements(result *domain.ProvenanceResult, requirements domain.ProvenanceRequirements) error {
+	switch {
+	case result.Status == domain.ProvenanceStatusError || result.Status == domain.ProvenanceStatusUnknown:
+		reason := string(result.Status)
+		if result.ErrorMessage != "" {
+			reason += ": " + result.ErrorMessage
+		}
+		return fmt.Errorf("%w: verification %s", ErrRequirementsNotMet, reason)
+	case !requirements.AllowNone && result.Status == domain.ProvenanceStatusNone:
+		return fmt.Errorf("%w: no provenance available", ErrRequirementsNotMet)
+	case requirements.RequireAttestations && !result.HasAttestations:
+		return fmt.Errorf("%w: attestations required", ErrRequirementsNotMet)
+	case requirements.RequireTrustedPublisher && result.TrustedPublisher == nil:
+		return fmt.Errorf("%w: trusted publisher required", ErrRequirementsNotMet)
+	case requirements.RequireSignatures && !result.HasSignatures && !result.HasAttestations:
+		// Attestations are signed too, so they satisfy a signature requirement
+		return fmt.Errorf("%w: signatures required", ErrRequirementsNotMet)
+	}
+	return checkAllowedPublisher(result, requirements)
+}
+
+// checkAllowedPublisher checks the result's publisher against the
+// requirements' AllowedPublishers. Only the publisher of a verified result
+// counts: one merely claimed in registry metadata, as in quick mode, could
+// name any repository. A result without a verified publisher only passes when
+// AllowNone is set.
+func checkAllowedPublisher(result *domain.ProvenanceResult, requirements domain.ProvenanceRequirements) error {
+	if len(requirements.AllowedPublishers) == 0 {
+		return nil
+	}
+	if result.Status != domain.ProvenanceStatusVerified ||
+		result.TrustedPublisher == nil || result.TrustedPublisher.Repository == "" {
+		if requirements.AllowNone {
+			return nil
+		}
+		return fmt.Errorf("%w: %w: no verified publisher information", ErrRequirementsNotMet, ErrPublisherNotAllowed)
+	}
+
+	repository := result.TrustedPublisher.Repository
+	for _, pattern := range requirements.AllowedPublishers {
+		// GitHub and GitLab names are case-insensitive
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(repository)); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %w: %s matches none of %s",
+		ErrRequirementsNotMet, ErrPublisherNotAllowed, repository, strings.Join(requirements.AllowedPublishers, ", "))
+}
+
+// ValidatePublisherPatterns checks that every AllowedPublishers entry is a
+// valid glob, so a typo is reported up front rather than as a failed match.
+func ValidatePublisherPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid publisher pattern %q: want owner/repo, optionally with * wildcards", pattern)
+		}
+	}
+	return nil
+}
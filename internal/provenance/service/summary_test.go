@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+
+	pkg := func(name string) domain.PackageIdentifier {
+		return domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: name, Version: "1.0.0"}
+	}
+	results := []*domain.ProvenanceResult{
+		{PackageID: pkg("verified"), Status: domain.ProvenanceStatusVerified, HasAttestations: true,
+			AttestationCount: 2, VerifiedAttestationCount: 2, TrustedPublisher: &domain.TrustedPublisher{Kind: "GitHub"}},
+		{PackageID: pkg("partial"), Status: domain.ProvenanceStatusAttestations, HasAttestations: true,
+			AttestationCount: 2, VerifiedAttestationCount: 1},
+		{PackageID: pkg("signed"), Status: domain.ProvenanceStatusSignatures, HasSignatures: true},
+		{PackageID: pkg("none"), Status: domain.ProvenanceStatusNone},
+		{PackageID: pkg("broken"), Status: domain.ProvenanceStatusError, ErrorMessage: "registry unavailable"},
+	}
+
+	summary := Summarize(results, domain.DefaultRequirements())
+	if summary.Total != 5 {
+		t.Errorf("Total = %d, want 5", summary.Total)
+	}
+	if summary.VerifiedAttestations != 3 {
+		t.Errorf("VerifiedAttestations = %d, want 3", summary.VerifiedAttestations)
+	}
+	for _, status := range []domain.ProvenanceStatus{
+		domain.ProvenanceStatusVerified, domain.ProvenanceStatusAttestations, domain.ProvenanceStatusSignatures,
+		domain.ProvenanceStatusNone, domain.ProvenanceStatusError,
+	} {
+		if summary.StatusCounts[status] != 1 {
+			t.Errorf("StatusCounts[%s] = %d, want 1", status, summary.StatusCounts[status])
+		}
+	}
+	// Errors fail even the permissive defaults
+	if summary.Passed || len(summary.Failed) != 1 || summary.Failed[0].Package.Name != "broken" {
+		t.Errorf("default requirements: Passed = %v, Failed = %+v", summary.Passed, summary.Failed)
+	}
+
+	strict := Summarize(results, domain.ProvenanceRequirements{RequireTrustedPublisher: true})
+	var failed []string
+	for _, f := range strict.Failed {
+		failed = append(failed, f.Package.Name)
+	}
+	if want := []string{"partial", "signed", "none", "broken"}; !slices.Equal(failed, want) {
+		t.Errorf("trusted publisher required: failed = %v, want %v", failed, want)
+	}
+}
+
+func TestValidateRequirements(t *testing.T) {
+	t.Parallel()
+
+	signed := &domain.ProvenanceResult{Status: domain.ProvenanceStatusSignatures, HasSignatures: true}
+	attested := &domain.ProvenanceResult{Status: domain.ProvenanceStatusVerified, HasAttestations: true}
+	none := &domain.ProvenanceResult{Status: domain.ProvenanceStatusNone}
+	published := &domain.ProvenanceResult{Status: domain.ProvenanceStatusVerified, HasAttestations: true,
+		TrustedPublisher: &domain.TrustedPublisher{Kind: "GitHub", Repository: "myorg/server"}}
+	// A publisher claimed in registry metadata but not verified, as in quick mode
+	claimed := &domain.ProvenanceResult{Status: domain.ProvenanceStatusAttestations, HasAttestations: true,
+		TrustedPublisher: &domain.TrustedPublisher{Kind: "GitHub", Repository: "myorg/server"}}
+
+	tests := []struct {
+		name         string
+		result       *domain.ProvenanceResult
+		requirements domain.ProvenanceRequirements
+		wantErr      bool
+	}{
+		{"none allowed", none, domain.ProvenanceRequirements{AllowNone: true}, false},
+		{"none disallowed", none, domain.ProvenanceRequirements{}, true},
+		{"signatures satisfy signatures", signed, domain.ProvenanceRequirements{RequireSignatures: true}, false},
+		{"attestations satisfy signatures", attested, domain.ProvenanceRequirements{RequireSignatures: true}, false},
+		{"signatures do not satisfy attestations", signed, domain.ProvenanceRequirements{RequireAttestations: true}, true},
+		{"unknown never passes", &domain.ProvenanceResult{Status: domain.ProvenanceStatusUnknown},
+			domain.ProvenanceRequirements{AllowNone: true}, true},
+		{"allowed publisher", published, domain.ProvenanceRequirements{AllowedPublishers: []string{"myorg/*"}}, false},
+		{"disallowed publisher", published, domain.ProvenanceRequirements{AllowedPublishers: []string{"other/*"}}, true},
+		{"no publisher against allowlist", attested,
+			domain.ProvenanceRequirements{AllowedPublishers: []string{"myorg/*"}}, true},
+		{"no publisher against allowlist with AllowNone", attested,
+			domain.ProvenanceRequirements{AllowNone: true, AllowedPublishers: []string{"myorg/*"}}, false},
+		{"claimed publisher against allowlist", claimed,
+			domain.ProvenanceRequirements{AllowedPublishers: []string{"myorg/*"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateRequirements(tt.result, tt.requirements)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRequirements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrRequirementsNotMet) {
+				t.Errorf("error %v does not wrap ErrRequirementsNotMet", err)
+			}
+		})
+	}
+}
+
+func TestValidatePublisherPatterns(t *testing.T) {
+	t.Parallel()
+
+	if err := ValidatePublisherPatterns([]string{"myorg/*", "other/exact-repo"}); err != nil {
+		t.Errorf("ValidatePublisherPatterns() error: %v", err)
+	}
+	for _, bad := range []string{"myorg/[", ""} {
+		if err := ValidatePublisherPatterns([]string{bad}); err == nil {
+			t.Errorf("ValidatePublisherPatterns(%q) succeeded, want an error", bad)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package sigstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVerifyBundle_InvalidBundle(t *testing.T) {
+	t.Parallel()
+
+	const mediaType = `"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json"`
+	const material = `"verificationMaterial": {"publicKey": {"hint": "key"}}`
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "not JSON", data: "not json"},
+		{name: "JSON array", data: `[]`},
+		{name: "unrelated object", data: `{"name": "left-pad", "version": "1.3.0"}`},
+		{name: "empty object", data: `{}`},
+		{name: "other media type", data: `{"mediaType": "application/json"}`},
+		{name: "no verification material", data: `{` + mediaType + `, "messageSignature": {"signature": "c2ln"}}`},
+		{
+			name:    "empty certificate",
+			data:    `{` + mediaType + `, "verificationMaterial": {"certificate": {}}, "messageSignature": {"signature": "c2ln"}}`,
+			wantErr: "no certificate or public key",
+		},
+		{
+			name:    "empty message signature",
+			data:    `{` + mediaType + `, ` + material + `, "messageSignature": {}}`,
+			wantErr: "the message signature is empty",
+		},
+		{
+			name:    "empty DSSE envelope",
+			data:    `{` + mediaType + `, ` + material + `, "dsseEnvelope": {"payloadType": "application/vnd.in-toto+json"}}`,
+			wantErr: "the DSSE envelope has no payload or no signatures",
+		},
+	}
+
+	// The bundle is rejected before the trusted root would be fetched
+	bv := NewLazyBundleVerifier(WithTUFMirror("https://tuf.invalid"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := bv.VerifyBundle([]byte(tt.data), "sha256", make([]byte, 32))
+			if !errors.Is(err, ErrInvalidBundle) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("VerifyBundle() error = %v, want %v containing %q", err, ErrInvalidBundle, tt.wantErr)
+			}
+		})
+	}
+}
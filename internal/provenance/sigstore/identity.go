@@ -0,0 +1,65 @@
+package sigstore
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+const (
+	// GitHubActionsIssuer is the OIDC issuer of certificates for GitHub Actions workflows
+	GitHubActionsIssuer = "https://token.actions.githubusercontent.com"
+	// AnyGitHubSANRegexp matches the certificate identity of any GitHub workflow
+	AnyGitHubSANRegexp = "^https://github.com/.*"
+)
+
+// CertificateIdentity pins the signer a bundle's certificate must name.
+// Empty fields leave the verifier's default in place.
+type CertificateIdentity struct {
+	// Issuer is the exact OIDC issuer of the signing certificate
+	Issuer string
+	// SANRegexp matches the certificate's subject alternative name, e.g. a
+	// workflow URL such as ^https://github.com/org/repo/\.github/workflows/release\.yml@
+	SANRegexp string
+}
+
+// Validate checks that SANRegexp compiles.
+func (c CertificateIdentity) Validate() error {
+	if c.SANRegexp == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(c.SANRegexp); err != nil {
+		return fmt.Errorf("invalid certificate identity regexp: %w", err)
+	}
+	return nil
+}
+
+// PolicyOption returns the policy requiring the given issuer and SAN regexp,
+// each replaced by c's value when set.
+func (c CertificateIdentity) PolicyOption(issuer, sanRegexp string) (verify.PolicyOption, error) {
+	issuer, sanRegexp = c.resolve(issuer, sanRegexp)
+	certID, err := verify.NewShortCertificateIdentity(issuer, "", "", sanRegexp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate identity: %w", err)
+	}
+	return verify.WithCertificateIdentity(certID), nil
+}
+
+// Describe renders the identity policy PolicyOption builds from issuer and
+// sanRegexp, for explaining a verification decision.
+func (c CertificateIdentity) Describe(issuer, sanRegexp string) string {
+	issuer, sanRegexp = c.resolve(issuer, sanRegexp)
+	return fmt.Sprintf("issuer %s, SAN matching %s", issuer, sanRegexp)
+}
+
+// resolve returns issuer and sanRegexp, each replaced by c's value when set.
+func (c CertificateIdentity) resolve(issuer, sanRegexp string) (string, string) {
+	if c.Issuer != "" {
+		issuer = c.Issuer
+	}
+	if c.SANRegexp != "" {
+		sanRegexp = c.SANRegexp
+	}
+	return issuer, sanRegexp
+}
@@ -0,0 +1,63 @@
+package sigstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCertificateIdentity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		identity CertificateIdentity
+		wantErr  string
+	}{
+		{name: "defaults"},
+		{name: "issuer only", identity: CertificateIdentity{Issuer: "https://gitlab.com"}},
+		{
+			name:     "workflow regexp",
+			identity: CertificateIdentity{SANRegexp: `^https://github.com/org/repo/\.github/workflows/release\.yml@`},
+		},
+		{name: "invalid regexp", identity: CertificateIdentity{SANRegexp: "^https://github.com/(org"}, wantErr: "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			validateErr := tt.identity.Validate()
+			policy, policyErr := tt.identity.PolicyOption(GitHubActionsIssuer, AnyGitHubSANRegexp)
+			if tt.wantErr == "" {
+				if validateErr != nil || policyErr != nil {
+					t.Fatalf("unexpected errors: Validate() = %v, PolicyOption() = %v", validateErr, policyErr)
+				}
+				if policy == nil {
+					t.Fatal("PolicyOption() returned a nil policy")
+				}
+				return
+			}
+			if validateErr == nil || !strings.Contains(validateErr.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", validateErr, tt.wantErr)
+			}
+			if policyErr == nil {
+				t.Error("PolicyOption() succeeded with an invalid regexp")
+			}
+		})
+	}
+}
+
+func TestCertificateIdentityDescribe(t *testing.T) {
+	t.Parallel()
+
+	const san = "^https://github.com/org/repo/"
+	if got, want := (CertificateIdentity{}).Describe(GitHubActionsIssuer, san),
+		"issuer "+GitHubActionsIssuer+", SAN matching "+san; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+	override := CertificateIdentity{Issuer: "https://gitlab.com", SANRegexp: "^https://gitlab.com/org/"}
+	if got, want := override.Describe(GitHubActionsIssuer, san),
+		"issuer https://gitlab.com, SAN matching ^https://gitlab.com/org/"; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package sigstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+// options holds the settings applied by NewBundleVerifier.
+type options struct {
+	tufMirror string
+	tufRoot   []byte
+	// rootTTL is nil for DefaultTrustedRootTTL
+	rootTTL *time.Duration
+	// tufTimeout is nil for DefaultTUFTimeout
+	tufTimeout *time.Duration
+}
+
+// Option configures a BundleVerifier.
+type Option func(*options)
+
+// WithTUFMirror fetches the trusted root from the TUF repository at mirrorURL
+// instead of the public good instance. On its own it suits a mirror of the
+// public repository; a private Sigstore deployment also needs WithTUFRoot.
+func WithTUFMirror(mirrorURL string) Option {
+	return func(o *options) {
+		o.tufMirror = mirrorURL
+	}
+}
+
+// WithTUFRoot sets the root.json that anchors trust in the TUF repository
+// given with WithTUFMirror.
+func WithTUFRoot(rootJSON []byte) Option {
+	return func(o *options) {
+		o.tufRoot = rootJSON
+	}
+}
+
+// WithTrustedRootTTL sets how long a trusted root fetched by one verifier is
+// reused by verifiers of the same TUF repository created after it, within
+// this process. A non-positive ttl fetches the root for every verifier.
+func WithTrustedRootTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.rootTTL = &ttl
+	}
+}
+
+// WithTUFTimeout sets how long fetching the trusted root from the TUF
+// repository may take. When it runs out, the trusted root in the local TUF
+// cache is used if there is one, and the fetch fails otherwise. A
+// non-positive timeout waits for the repository however long it takes.
+func WithTUFTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.tufTimeout = &timeout
+	}
+}
+
+// tufOptions returns the TUF client options for o, starting from the public
+// good defaults.
+func (o *options) tufOptions() (*tuf.Options, error) {
+	tufOpts := tuf.DefaultOptions()
+
+	if o.tufMirror != "" {
+		u, err := url.Parse(o.tufMirror)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("invalid TUF mirror URL %q: must be an absolute http(s) URL", o.tufMirror)
+		}
+		tufOpts.RepositoryBaseURL = o.tufMirror
+	}
+
+	if o.tufRoot != nil {
+		if o.tufMirror == "" {
+			return nil, fmt.Errorf("a custom TUF root requires a TUF mirror URL")
+		}
+		if err := checkTUFRoot(o.tufRoot); err != nil {
+			return nil, err
+		}
+		tufOpts.Root = o.tufRoot
+	}
+	return tufOpts, nil
+}
+
+// checkTUFRoot rejects data that is not TUF root metadata, so that a wrong
+// file is reported clearly instead of as a TUF update failure.
+func checkTUFRoot(rootJSON []byte) error {
+	var metadata struct {
+		Signed struct {
+			Type string `json:"_type"`
+		} `json:"signed"`
+	}
+	if err := json.Unmarshal(rootJSON, &metadata); err != nil {
+		return fmt.Errorf("malformed TUF root: %w", err)
+	}
+	if metadata.Signed.Type != "root" {
+		return fmt.Errorf("malformed TUF root: signed._type is %q, want \"root\"", metadata.Signed.Type)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package sigstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+func TestTUFOptions(t *testing.T) {
+	t.Parallel()
+
+	validRoot := tuf.StagingRoot()
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantURL string
+		wantErr string
+	}{
+		{name: "defaults", wantURL: tuf.DefaultMirror},
+		{name: "mirror only", opts: []Option{WithTUFMirror("https://tuf.example.com")}, wantURL: "https://tuf.example.com"},
+		{
+			name:    "mirror and root",
+			opts:    []Option{WithTUFMirror("https://tuf.example.com"), WithTUFRoot(validRoot)},
+			wantURL: "https://tuf.example.com",
+		},
+		{name: "relative mirror", opts: []Option{WithTUFMirror("tuf.example.com")}, wantErr: "invalid TUF mirror URL"},
+		{name: "root without mirror", opts: []Option{WithTUFRoot(validRoot)}, wantErr: "requires a TUF mirror"},
+		{
+			name:    "malformed root",
+			opts:    []Option{WithTUFMirror("https://tuf.example.com"), WithTUFRoot([]byte("not json"))},
+			wantErr: "malformed TUF root",
+		},
+		{
+			name:    "not a root",
+			opts:    []Option{WithTUFMirror("https://tuf.example.com"), WithTUFRoot([]byte(`{"signed":{"_type":"targets"}}`))},
+			wantErr: `signed._type is "targets"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var o options
+			for _, opt := range tt.opts {
+				opt(&o)
+			}
+			got, err := o.tufOptions()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("tufOptions() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tufOptions() error: %v", err)
+			}
+			if got.RepositoryBaseURL != tt.wantURL {
+				t.Errorf("RepositoryBaseURL = %q, want %q", got.RepositoryBaseURL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestNewBundleVerifier_MalformedRoot(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewBundleVerifier(context.Background(),
+		WithTUFMirror("https://tuf.example.com"), WithTUFRoot([]byte(`{"signed":`)))
+	if err == nil || !strings.Contains(err.Error(), "malformed TUF root") {
+		t.Errorf("NewBundleVerifier() error = %v, want a malformed root error", err)
+	}
+}
+
+func TestNewLazyBundleVerifier_DefersLoad(t *testing.T) {
+	t.Parallel()
+
+	// Creating the verifier must not fail, however broken the root is
+	bv := NewLazyBundleVerifier(WithTUFMirror("https://tuf.example.com"), WithTUFRoot([]byte(`{"signed":`)))
+
+	if err := bv.Load(); err == nil || !strings.Contains(err.Error(), "malformed TUF root") {
+		t.Errorf("Load() error = %v, want a malformed root error", err)
+	}
+	// A failed load is retried rather than remembered
+	if err := bv.Load(); err == nil {
+		t.Error("second Load() error = nil, want the malformed root error again")
+	}
+}
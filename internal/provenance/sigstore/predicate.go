@@ -0,0 +1,105 @@
+package sigstore
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+const (
+	// slsaProvenanceV1 is the predicate type of SLSA v1 provenance
+	slsaProvenanceV1 = "https://slsa.dev/provenance/v1"
+	// slsaProvenanceV02 is the predicate type of SLSA v0.2 provenance
+	slsaProvenanceV02 = "https://slsa.dev/provenance/v0.2"
+)
+
+// PredicateRepository returns the source repository that the SLSA provenance
+// statement of a verified bundle says the artifact was built from, such as
+// https://github.com/owner/repo, or "" if it names none. The statement is
+// signed, so unlike registry metadata the repository cannot be made up by
+// whoever uploaded the package.
+func PredicateRepository(result *verify.VerificationResult) string {
+	if result == nil || result.Statement == nil || result.Statement.GetPredicate() == nil {
+		return ""
+	}
+	return predicateRepository(result.Statement.GetPredicateType(), result.Statement.GetPredicate().AsMap())
+}
+
+// predicateRepository finds the source repository in a SLSA provenance
+// predicate: the workflow repository of v1 build definitions or the config
+// source of v0.2 invocations, and otherwise the first git dependency or
+// material.
+func predicateRepository(predicateType string, predicate map[string]any) string {
+	var candidates []any
+	switch predicateType {
+	case slsaProvenanceV1:
+		candidates = append(candidates, field(predicate, "buildDefinition", "externalParameters", "workflow", "repository"))
+		for _, dependency := range items(field(predicate, "buildDefinition", "resolvedDependencies")) {
+			candidates = append(candidates, field(dependency, "uri"))
+		}
+	case slsaProvenanceV02:
+		candidates = append(candidates, field(predicate, "invocation", "configSource", "uri"))
+		for _, material := range items(field(predicate, "materials")) {
+			candidates = append(candidates, field(material, "uri"))
+		}
+	}
+	for _, candidate := range candidates {
+		if repository := sourceRepository(candidate); repository != "" {
+			return repository
+		}
+	}
+	return ""
+}
+
+// field returns the value at the path of keys under v, or nil.
+func field(v any, keys ...string) any {
+	for _, key := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// items returns v as a list, or nil if it is not one.
+func items(v any) []any {
+	list, _ := v.([]any)
+	return list
+}
+
+// sourceRepository normalizes a repository reference from a predicate, such
+// as git+https://github.com/owner/repo@refs/tags/v1.0.0 or
+// https://github.com/owner/repo, to the repository's https URL. References
+// that are not https URLs with a path are ignored.
+func sourceRepository(v any) string {
+	raw, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	raw = strings.TrimPrefix(raw, "git+")
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return ""
+	}
+	// The ref follows the path after an @
+	path, _, _ := strings.Cut(u.Path, "@")
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git")
+	if strings.Trim(path, "/") == "" {
+		return ""
+	}
+	return "https://" + u.Host + path
+}
+
+// repositoryName returns the owner/repo path of a repository URL such as
+// https://github.com/owner/repo, or "" if it is not one.
+func repositoryName(repositoryURL string) string {
+	repository := sourceRepository(repositoryURL)
+	if repository == "" {
+		return ""
+	}
+	_, name, _ := strings.Cut(strings.TrimPrefix(repository, "https://"), "/")
+	return strings.Trim(name, "/")
+}
@@ -0,0 +1,85 @@
+package sigstore
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPredicateRepository(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		predicateType string
+		predicate     string
+		want          string
+	}{
+		{
+			name:          "v1 workflow repository",
+			predicateType: slsaProvenanceV1,
+			predicate: `{"buildDefinition": {
+				"externalParameters": {"workflow": {"repository": "https://github.com/upstash/context7", "ref": "refs/tags/v1.0.14"}},
+				"resolvedDependencies": [{"uri": "git+https://github.com/other/repo@refs/tags/v1.0.14"}]}}`,
+			want: "https://github.com/upstash/context7",
+		},
+		{
+			name:          "v1 resolved dependency",
+			predicateType: slsaProvenanceV1,
+			predicate: `{"buildDefinition": {"resolvedDependencies": [
+				{"uri": "pkg:npm/left-pad@1.3.0"},
+				{"uri": "git+https://github.com/upstash/context7.git@refs/tags/v1.0.14"}]}}`,
+			want: "https://github.com/upstash/context7",
+		},
+		{
+			name:          "v0.2 config source",
+			predicateType: slsaProvenanceV02,
+			predicate: `{"invocation": {"configSource": {"uri": "git+https://github.com/upstash/context7@refs/heads/main",
+				"entryPoint": ".github/workflows/release.yml"}}}`,
+			want: "https://github.com/upstash/context7",
+		},
+		{
+			name:          "v0.2 material",
+			predicateType: slsaProvenanceV02,
+			predicate:     `{"invocation": {}, "materials": [{"uri": "git+https://gitlab.com/group/sub/project@refs/tags/v2"}]}`,
+			want:          "https://gitlab.com/group/sub/project",
+		},
+		{
+			name:          "not https",
+			predicateType: slsaProvenanceV02,
+			predicate:     `{"invocation": {"configSource": {"uri": "git+ssh://git@github.com/upstash/context7"}}}`,
+		},
+		{
+			name:          "no path",
+			predicateType: slsaProvenanceV1,
+			predicate:     `{"buildDefinition": {"externalParameters": {"workflow": {"repository": "https://github.com/"}}}}`,
+		},
+		{
+			name:          "unexpected shape",
+			predicateType: slsaProvenanceV1,
+			predicate:     `{"buildDefinition": {"externalParameters": "workflow", "resolvedDependencies": {"uri": "x"}}}`,
+		},
+		{
+			name:          "publish attestation",
+			predicateType: "https://github.com/npm/attestation/tree/main/specs/publish/v0.1",
+			predicate:     `{"name": "@upstash/context7-mcp", "version": "1.0.14", "registry": "https://registry.npmjs.org"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var predicate map[string]any
+			if err := json.Unmarshal([]byte(tt.predicate), &predicate); err != nil {
+				t.Fatal(err)
+			}
+			if got := predicateRepository(tt.predicateType, predicate); got != tt.want {
+				t.Errorf("predicateRepository() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := PredicateRepository(nil); got != "" {
+		t.Errorf("PredicateRepository(nil) = %q, want empty", got)
+	}
+}
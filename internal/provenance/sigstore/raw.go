@@ -0,0 +1,23 @@
+package sigstore
+
+import (
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// VerificationResults returns the raw Sigstore verification results a
+// verifier kept on result, in the order the bundles were verified. It is nil
+// unless the verifier was configured to keep them.
+func VerificationResults(result *domain.ProvenanceResult) []*verify.VerificationResult {
+	if result == nil {
+		return nil
+	}
+	var results []*verify.VerificationResult
+	for _, raw := range result.RawResults {
+		if r, ok := raw.(*verify.VerificationResult); ok && r != nil {
+			results = append(results, r)
+		}
+	}
+	return results
+}
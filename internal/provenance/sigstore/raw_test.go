@@ -0,0 +1,52 @@
+package sigstore
+
+import (
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestVerificationResults(t *testing.T) {
+	t.Parallel()
+
+	first := &verify.VerificationResult{MediaType: "application/vnd.dev.sigstore.verificationresult+json;version=0.1"}
+	second := &verify.VerificationResult{}
+	var nilResult *verify.VerificationResult
+
+	tests := []struct {
+		name   string
+		result *domain.ProvenanceResult
+		want   []*verify.VerificationResult
+	}{
+		{name: "nil result"},
+		{name: "not kept", result: &domain.ProvenanceResult{}},
+		{
+			name:   "kept in order",
+			result: &domain.ProvenanceResult{RawResults: []interface{}{first, second}},
+			want:   []*verify.VerificationResult{first, second},
+		},
+		{
+			name:   "other types skipped",
+			result: &domain.ProvenanceResult{RawResults: []interface{}{"bundle", nilResult, second}},
+			want:   []*verify.VerificationResult{second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := VerificationResults(tt.result)
+			if len(got) != len(tt.want) {
+				t.Fatalf("VerificationResults() returned %d results, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("VerificationResults()[%d] = %p, want %p", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
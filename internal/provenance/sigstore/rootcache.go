@@ -0,0 +1,92 @@
+package sigstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// DefaultTrustedRootTTL is how long a fetched trusted root is reused by later
+// verifiers of the same TUF repository unless WithTrustedRootTTL says otherwise
+const DefaultTrustedRootTTL = time.Hour
+
+// trustedRoots is the process-wide cache of trusted roots fetched over TUF
+var trustedRoots = newRootCache(time.Now)
+
+// ResetTrustedRootCache forgets every cached trusted root, so the next
+// verifier fetches its root again. It is meant for tests.
+func ResetTrustedRootCache() {
+	trustedRoots.reset()
+}
+
+// rootCache memoizes trusted roots per TUF repository. Concurrent requests
+// for the same repository wait for a single fetch. A root is served only for
+// its TTL: once it has expired it is fetched again, and a failed refetch is
+// returned as an error rather than answered with the stale root, which could
+// lack rotated or revoked keys. Failures are never cached.
+type rootCache struct {
+	mu      sync.Mutex
+	entries map[string]*rootCacheEntry
+	now     func() time.Time
+}
+
+// rootCacheEntry is the cached root of one repository; mu serializes fetches
+type rootCacheEntry struct {
+	mu        sync.Mutex
+	root      *root.TrustedRoot
+	fetchedAt time.Time
+}
+
+func newRootCache(now func() time.Time) *rootCache {
+	return &rootCache{entries: make(map[string]*rootCacheEntry), now: now}
+}
+
+// get returns the root cached under key if it was fetched less than ttl ago,
+// and otherwise fetches, caches, and returns a new one. A non-positive ttl
+// always fetches.
+func (c *rootCache) get(key string, ttl time.Duration, fetch func() (*root.TrustedRoot, error)) (*root.TrustedRoot, error) {
+	if ttl <= 0 {
+		return fetch()
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &rootCacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.root != nil && c.now().Sub(entry.fetchedAt) < ttl {
+		return entry.root, nil
+	}
+	trustedRoot, err := fetch()
+	if err != nil {
+		entry.root = nil
+		return nil, err
+	}
+	entry.root, entry.fetchedAt = trustedRoot, c.now()
+	return trustedRoot, nil
+}
+
+func (c *rootCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*rootCacheEntry)
+}
+
+// rootCacheKey identifies a TUF repository by its URL and the root.json
+// anchoring trust in it, since the same mirror with another root is another
+// chain of trust.
+func rootCacheKey(repositoryURL string, rootJSON []byte) string {
+	if rootJSON == nil {
+		return repositoryURL
+	}
+	sum := sha256.Sum256(rootJSON)
+	return repositoryURL + "#" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+package sigstore
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+func TestRootCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache := newRootCache(func() time.Time { return now })
+	var fetches atomic.Int32
+	var fail atomic.Bool
+	fetch := func() (*root.TrustedRoot, error) {
+		fetches.Add(1)
+		if fail.Load() {
+			return nil, errors.New("TUF repository unreachable")
+		}
+		return &root.TrustedRoot{}, nil
+	}
+
+	first, err := cache.get("https://tuf.example.com", time.Hour, fetch)
+	if err != nil {
+		t.Fatalf("get() error: %v", err)
+	}
+	if again, _ := cache.get("https://tuf.example.com", time.Hour, fetch); again != first || fetches.Load() != 1 {
+		t.Errorf("get() within the TTL fetched %d times, want the cached root", fetches.Load())
+	}
+	if other, _ := cache.get("https://other.example.com", time.Hour, fetch); other == first || fetches.Load() != 2 {
+		t.Errorf("get() for another repository returned the cached root")
+	}
+
+	// An expired root is not served when refetching it fails
+	now = now.Add(time.Hour)
+	fail.Store(true)
+	if _, err := cache.get("https://tuf.example.com", time.Hour, fetch); err == nil {
+		t.Error("get() after expiry with a failing fetch succeeded, want the fetch error")
+	}
+	fail.Store(false)
+	refreshed, err := cache.get("https://tuf.example.com", time.Hour, fetch)
+	if err != nil || refreshed == first || fetches.Load() != 4 {
+		t.Errorf("get() after a failed refresh = %v, %d fetches, want a new root on the 4th fetch", err, fetches.Load())
+	}
+
+	_, _ = cache.get("https://tuf.example.com", 0, fetch)
+	if fetches.Load() != 5 {
+		t.Errorf("get() with a zero TTL did not fetch")
+	}
+}
+
+func TestRootCache_ConcurrentFetchOnce(t *testing.T) {
+	t.Parallel()
+
+	cache := newRootCache(time.Now)
+	var fetches atomic.Int32
+	fetch := func() (*root.TrustedRoot, error) {
+		fetches.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return &root.TrustedRoot{}, nil
+	}
+
+	var wg sync.WaitGroup
+	roots := make([]*root.TrustedRoot, 16)
+	for i := range roots {
+		wg.Go(func() {
+			roots[i], _ = cache.get("https://tuf.example.com", time.Hour, fetch)
+		})
+	}
+	wg.Wait()
+
+	if fetches.Load() != 1 {
+		t.Errorf("concurrent get() fetched %d times, want 1", fetches.Load())
+	}
+	for _, r := range roots {
+		if r != roots[0] {
+			t.Fatal("concurrent get() returned different roots")
+		}
+	}
+
+	cache.reset()
+	_, _ = cache.get("https://tuf.example.com", time.Hour, fetch)
+	if fetches.Load() != 2 {
+		t.Errorf("get() after reset did not fetch")
+	}
+}
+
+func TestRootCacheKey(t *testing.T) {
+	t.Parallel()
+
+	url := "https://tuf.example.com"
+	if rootCacheKey(url, nil) == rootCacheKey(url, []byte(`{"signed":{"_type":"root"}}`)) {
+		t.Error("a custom root shares the key of the mirror without it")
+	}
+	if rootCacheKey(url, []byte("a")) == rootCacheKey(url, []byte("b")) {
+		t.Error("different roots share a key")
+	}
+}
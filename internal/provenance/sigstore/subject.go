@@ -0,0 +1,56 @@
+package sigstore
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// ErrSubjectMismatch is returned when a verified attestation describes a
+// different artifact than the one being checked.
+var ErrSubjectMismatch = errors.New("subject mismatch")
+
+// Subject is the in-toto statement subject that names the verified artifact
+type Subject struct {
+	Name   string
+	Digest string // algorithm:hex, e.g. "sha512:ab12..."
+}
+
+// MatchSubject returns the subject of the verified in-toto statement whose
+// digest for algorithm equals digest. Signature-verifying the bundle against
+// the digest alone does not prove the statement is about that artifact, so
+// callers use this to reject attestations with swapped subjects. It returns
+// nil without error when the bundle carries no statement (a plain message
+// signature), and an error wrapping ErrSubjectMismatch when no subject matches.
+func MatchSubject(result *verify.VerificationResult, algorithm string, digest []byte) (*Subject, error) {
+	if result == nil || result.Statement == nil {
+		return nil, nil
+	}
+	return matchSubject(result.Statement.GetSubject(), algorithm, digest)
+}
+
+// matchSubject finds the subject with the given digest among subjects.
+func matchSubject(subjects []*in_toto.ResourceDescriptor, algorithm string, digest []byte) (*Subject, error) {
+	want := hex.EncodeToString(digest)
+	seen := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		got, ok := subject.GetDigest()[algorithm]
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(got, want) {
+			return &Subject{Name: subject.GetName(), Digest: algorithm + ":" + want}, nil
+		}
+		seen = append(seen, fmt.Sprintf("%s %s:%s", subject.GetName(), algorithm, got))
+	}
+
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("%w: attestation has no subject with a %s digest", ErrSubjectMismatch, algorithm)
+	}
+	return nil, fmt.Errorf("%w: attestation subjects [%s] do not name the artifact with %s digest %s",
+		ErrSubjectMismatch, strings.Join(seen, ", "), algorithm, want)
+}
@@ -0,0 +1,42 @@
+package sigstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+)
+
+func TestMatchSubject(t *testing.T) {
+	t.Parallel()
+
+	artifact := sha256.Sum256([]byte("pkg-1.0.tar.gz contents"))
+	other := sha256.Sum256([]byte("a different file"))
+	subject := func(name string, digest [32]byte) *in_toto.ResourceDescriptor {
+		return &in_toto.ResourceDescriptor{Name: name, Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])}}
+	}
+
+	got, err := matchSubject(
+		[]*in_toto.ResourceDescriptor{subject("other.whl", other), subject("pkg-1.0.tar.gz", artifact)},
+		"sha256", artifact[:])
+	if err != nil {
+		t.Fatalf("matchSubject(matching) error: %v", err)
+	}
+	if got.Name != "pkg-1.0.tar.gz" || got.Digest != "sha256:"+hex.EncodeToString(artifact[:]) {
+		t.Errorf("matchSubject(matching) = %+v, want pkg-1.0.tar.gz with the artifact digest", got)
+	}
+
+	swapped := []*in_toto.ResourceDescriptor{subject("other.whl", other)}
+	if _, err := matchSubject(swapped, "sha256", artifact[:]); !errors.Is(err, ErrSubjectMismatch) {
+		t.Errorf("matchSubject(swapped subject) error = %v, want ErrSubjectMismatch", err)
+	}
+	if _, err := matchSubject(swapped, "sha512", artifact[:]); !errors.Is(err, ErrSubjectMismatch) {
+		t.Errorf("matchSubject(no digest for algorithm) error = %v, want ErrSubjectMismatch", err)
+	}
+
+	if got, err := MatchSubject(nil, "sha256", artifact[:]); got != nil || err != nil {
+		t.Errorf("MatchSubject(nil) = %v, %v, want nil, nil", got, err)
+	}
+}
@@ -0,0 +1,107 @@
+package sigstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/util"
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+)
+
+// DefaultTUFTimeout is how long fetching the trusted root from the TUF
+// repository may take before the root in the local TUF cache is used instead.
+const DefaultTUFTimeout = 30 * time.Second
+
+// errOffline fails the downloads of a TUF client restricted to its cache.
+var errOffline = errors.New("the TUF repository is not contacted for a cached root")
+
+// trustedRootWithin fetches a fresh trusted root with fetch, cancelling its
+// context after timeout, and falls back to cached if the fetch fails because
+// it ran out of time. stale reports that the cached root was returned. A
+// non-positive timeout lets fetch run to completion.
+func trustedRootWithin(
+	timeout time.Duration,
+	fetch func(ctx context.Context) (*root.TrustedRoot, error),
+	cached func() (*root.TrustedRoot, error),
+) (trustedRoot *root.TrustedRoot, stale bool, err error) {
+	if timeout <= 0 {
+		trustedRoot, err = fetch(context.Background())
+		return trustedRoot, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	trustedRoot, err = fetch(ctx)
+	if err == nil || ctx.Err() == nil {
+		return trustedRoot, false, err
+	}
+
+	trustedRoot, cacheErr := cached()
+	if cacheErr != nil {
+		return nil, false, fmt.Errorf("TUF repository did not respond within %s and no cached trusted root is available (%v): %w",
+			timeout, cacheErr, err)
+	}
+	return trustedRoot, true, nil
+}
+
+// boundedTUFOptions returns a copy of tufOpts whose downloads are all
+// abandoned once ctx is done.
+func boundedTUFOptions(ctx context.Context, tufOpts *tuf.Options) *tuf.Options {
+	f := fetcher.NewDefaultFetcher()
+	f.SetHTTPUserAgent(util.ConstructUserAgent())
+	f.SetHTTPClient(&http.Client{Transport: contextTransport{ctx: ctx, base: http.DefaultTransport}})
+
+	bounded := *tufOpts
+	bounded.Fetcher = f
+	return &bounded
+}
+
+// cachedTUFOptions returns a copy of tufOpts that loads the trusted root
+// from the local TUF cache without contacting the repository. The cached
+// metadata is still verified and must not have expired.
+func cachedTUFOptions(tufOpts *tuf.Options) *tuf.Options {
+	cached := *tufOpts
+	cached.ForceCache = true
+	cached.Fetcher = offlineFetcher{}
+	return &cached
+}
+
+// cacheUpdatedAt returns when the local TUF cache of tufOpts was last
+// refreshed from the repository, or the zero time if that is unknown.
+func cacheUpdatedAt(tufOpts *tuf.Options) time.Time {
+	if tufOpts.CachePath == "" {
+		return time.Time{}
+	}
+	cfg, err := tuf.LoadConfig(filepath.Join(tufOpts.CachePath, tuf.URLToPath(tufOpts.RepositoryBaseURL)+".json"))
+	if err != nil {
+		return time.Time{}
+	}
+	return cfg.LastTimestamp
+}
+
+// contextTransport sends every request with ctx, bounding a whole TUF update
+// rather than each of its downloads.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// offlineFetcher fails every download, so a TUF client using it is limited
+// to what is already in its local cache.
+type offlineFetcher struct{}
+
+// DownloadFile implements fetcher.Fetcher.
+func (offlineFetcher) DownloadFile(urlPath string, _ int64, _ time.Duration) ([]byte, error) {
+	return nil, fmt.Errorf("%w: %s", errOffline, urlPath)
+}
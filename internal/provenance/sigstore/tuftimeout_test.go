@@ -0,0 +1,109 @@
+package sigstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+func TestTrustedRootWithin(t *testing.T) {
+	t.Parallel()
+
+	fresh, cachedRoot := &root.TrustedRoot{}, &root.TrustedRoot{}
+	// hang blocks until the fetch runs out of time, as a slow TUF server does
+	hang := func(ctx context.Context) (*root.TrustedRoot, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	cached := func() (*root.TrustedRoot, error) { return cachedRoot, nil }
+	noCache := func() (*root.TrustedRoot, error) { return nil, errors.New("no cached metadata") }
+
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		fetch     func(ctx context.Context) (*root.TrustedRoot, error)
+		cached    func() (*root.TrustedRoot, error)
+		want      *root.TrustedRoot
+		wantStale bool
+		wantErr   string
+	}{
+		{
+			name:    "fresh",
+			timeout: time.Minute,
+			fetch:   func(context.Context) (*root.TrustedRoot, error) { return fresh, nil },
+			cached:  cached,
+			want:    fresh,
+		},
+		{
+			name:    "fetch error without timeout",
+			timeout: time.Minute,
+			fetch:   func(context.Context) (*root.TrustedRoot, error) { return nil, errors.New("invalid signature") },
+			cached:  cached,
+			wantErr: "invalid signature",
+		},
+		{name: "timeout with cache", timeout: time.Millisecond, fetch: hang, cached: cached, want: cachedRoot, wantStale: true},
+		{name: "timeout without cache", timeout: time.Millisecond, fetch: hang, cached: noCache, wantErr: "no cached metadata"},
+		{
+			name: "no timeout",
+			fetch: func(ctx context.Context) (*root.TrustedRoot, error) {
+				if _, ok := ctx.Deadline(); ok {
+					return nil, errors.New("fetch has a deadline")
+				}
+				return fresh, nil
+			},
+			cached: noCache,
+			want:   fresh,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, stale, err := trustedRootWithin(tt.timeout, tt.fetch, tt.cached)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("trustedRootWithin() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("trustedRootWithin() error: %v", err)
+			}
+			if got != tt.want || stale != tt.wantStale {
+				t.Errorf("trustedRootWithin() = %p, stale %v, want %p, stale %v", got, stale, tt.want, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestFetchTrustedRoot_SlowRepository(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	tufOpts := tuf.DefaultOptions()
+	tufOpts.RepositoryBaseURL = server.URL
+	tufOpts.Root = tuf.StagingRoot()
+	tufOpts.CachePath = t.TempDir()
+
+	timeout := 100 * time.Millisecond
+	start := time.Now()
+	_, err := fetchTrustedRoot(options{tufMirror: server.URL, tufTimeout: &timeout}, tufOpts)
+	if err == nil || !strings.Contains(err.Error(), "did not respond within 100ms") {
+		t.Fatalf("fetchTrustedRoot() error = %v, want a timeout without a cached root", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("fetchTrustedRoot() took %s, want it to give up after the timeout", elapsed)
+	}
+}
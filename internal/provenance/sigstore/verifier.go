@@ -4,7 +4,11 @@ package sigstore
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/sigstore/sigstore-go/pkg/bundle"
 	"github.com/sigstore/sigstore-go/pkg/root"
@@ -14,42 +18,152 @@ import (
 	"github.com/stacklok/dockyard/internal/provenance/domain"
 )
 
-// BundleVerifier wraps sigstore-go verification functionality
+// ErrInvalidBundle is returned by VerifyBundle for input that is not a
+// Sigstore bundle, e.g. a malformed attestation payload from a registry.
+var ErrInvalidBundle = errors.New("input is not a valid Sigstore bundle")
+
+// BundleVerifier wraps sigstore-go verification functionality. It is safe for
+// concurrent use and can be shared by every protocol verifier.
 type BundleVerifier struct {
-	trustedRoot      *root.TrustedRoot
+	// mu guards loading the trusted root; load is nil once it has succeeded
+	mu   sync.Mutex
+	load func() (*BundleVerifier, error)
+
+	trustedMaterial  root.TrustedMaterial
 	verifier         *verify.Verifier
 	enabledVerifiers []verify.VerifierOption
 }
 
-// NewBundleVerifier creates a new Sigstore bundle verifier
-func NewBundleVerifier(_ context.Context) (*BundleVerifier, error) {
-	// Initialize TUF client with default options
-	opts := tuf.DefaultOptions()
-	tufClient, err := tuf.New(opts)
+// NewBundleVerifier creates a new Sigstore bundle verifier. By default the
+// trusted root comes from the public good instance's TUF repository; the
+// options point it at a private Sigstore deployment instead.
+func NewBundleVerifier(_ context.Context, opts ...Option) (*BundleVerifier, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return loadBundleVerifier(o)
+}
+
+// NewLazyBundleVerifier creates a Sigstore bundle verifier that fetches its
+// trusted root on the first verification instead of up front, so creating it
+// never touches the network. A failed fetch is returned by that verification
+// and retried by the next one. Options are as for NewBundleVerifier.
+func NewLazyBundleVerifier(opts ...Option) *BundleVerifier {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &BundleVerifier{load: func() (*BundleVerifier, error) {
+		return loadBundleVerifier(o)
+	}}
+}
+
+// Load fetches the trusted root of a lazy verifier if it has not been
+// fetched yet, e.g. to warm it up before verifying. It is a no-op otherwise.
+func (bv *BundleVerifier) Load() error {
+	bv.mu.Lock()
+	defer bv.mu.Unlock()
+
+	if bv.load == nil {
+		return nil
+	}
+	loaded, err := bv.load()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create TUF client: %w", err)
+		return err
 	}
+	bv.trustedMaterial = loaded.trustedMaterial
+	bv.verifier = loaded.verifier
+	bv.enabledVerifiers = loaded.enabledVerifiers
+	bv.load = nil
+	return nil
+}
 
-	// Get trusted root from TUF
-	trustedRoot, err := root.GetTrustedRoot(tufClient)
+// loadBundleVerifier fetches the trusted root over TUF and creates a verifier over it
+func loadBundleVerifier(o options) (*BundleVerifier, error) {
+	tufOpts, err := o.tufOptions()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get trusted root: %w", err)
+		return nil, err
+	}
+
+	// Verifiers of the same repository share a trusted root for its TTL
+	ttl := DefaultTrustedRootTTL
+	if o.rootTTL != nil {
+		ttl = *o.rootTTL
+	}
+	trustedRoot, err := trustedRoots.get(rootCacheKey(tufOpts.RepositoryBaseURL, o.tufRoot), ttl, func() (*root.TrustedRoot, error) {
+		return fetchTrustedRoot(o, tufOpts)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Create verifier with standard options
-	verifierOpts := []verify.VerifierOption{
+	return newBundleVerifier(trustedRoot,
 		verify.WithSignedCertificateTimestamps(1), // Require at least 1 SCT
 		verify.WithTransparencyLog(1),             // Require at least 1 transparency log entry
 		verify.WithObserverTimestamps(1),          // Require at least 1 observer timestamp
+	)
+}
+
+// fetchTrustedRoot fetches the trusted root from the TUF repository of
+// tufOpts. If the repository does not respond within the TUF timeout, the
+// root in the local TUF cache is used instead, with a warning that it may be
+// stale.
+func fetchTrustedRoot(o options, tufOpts *tuf.Options) (*root.TrustedRoot, error) {
+	timeout := DefaultTUFTimeout
+	if o.tufTimeout != nil {
+		timeout = *o.tufTimeout
+	}
+	trustedRoot, stale, err := trustedRootWithin(timeout,
+		func(ctx context.Context) (*root.TrustedRoot, error) {
+			return fetchTrustedRootFrom(o, boundedTUFOptions(ctx, tufOpts))
+		},
+		func() (*root.TrustedRoot, error) {
+			return fetchTrustedRootFrom(o, cachedTUFOptions(tufOpts))
+		})
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		attrs := []any{"repository", tufOpts.RepositoryBaseURL, "timeout", timeout}
+		if updated := cacheUpdatedAt(tufOpts); !updated.IsZero() {
+			attrs = append(attrs, "cache_updated", updated.Format(time.RFC3339))
+		}
+		slog.Warn("TUF repository timed out; using the cached trusted root, which may miss recent key changes", attrs...)
 	}
+	return trustedRoot, nil
+}
 
-	verifier, err := verify.NewVerifier(trustedRoot, verifierOpts...)
+// fetchTrustedRootFrom creates a TUF client with tufOpts and gets the trusted root through it
+func fetchTrustedRootFrom(o options, tufOpts *tuf.Options) (*root.TrustedRoot, error) {
+	tufClient, err := tuf.New(tufOpts)
+	if err != nil {
+		if o.tufRoot != nil {
+			return nil, fmt.Errorf("failed to create TUF client for %s with the custom root: %w", tufOpts.RepositoryBaseURL, err)
+		}
+		return nil, fmt.Errorf("failed to create TUF client: %w", err)
+	}
+
+	trustedRoot, err := root.GetTrustedRoot(tufClient)
+	if err != nil {
+		if o.tufMirror != "" {
+			return nil, fmt.Errorf("failed to get trusted root from TUF mirror %s: %w", o.tufMirror, err)
+		}
+		return nil, fmt.Errorf("failed to get trusted root: %w", err)
+	}
+	return trustedRoot, nil
+}
+
+// newBundleVerifier creates a BundleVerifier over the given trusted material
+func newBundleVerifier(trustedMaterial root.TrustedMaterial, verifierOpts ...verify.VerifierOption) (*BundleVerifier, error) {
+	verifier, err := verify.NewVerifier(trustedMaterial, verifierOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create verifier: %w", err)
 	}
 
 	return &BundleVerifier{
-		trustedRoot:      trustedRoot,
+		trustedMaterial:  trustedMaterial,
 		verifier:         verifier,
 		enabledVerifiers: verifierOpts,
 	}, nil
@@ -65,14 +179,53 @@ func (bv *BundleVerifier) VerifyBundle(
 	// Parse the bundle
 	b := &bundle.Bundle{}
 	if err := json.Unmarshal(bundleData, b); err != nil {
-		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	if err := checkBundleContent(b); err != nil {
+		return nil, err
+	}
+
+	return bv.verifyEntity(b, artifactDigest, digestBytes, opts...)
+}
+
+// checkBundleContent rejects a parsed bundle whose verification material
+// or signature is empty. Parsing checks that they are present, but not that
+// they hold anything, which would otherwise only surface as an obscure
+// failure deep inside verification.
+func checkBundleContent(b *bundle.Bundle) error {
+	material := b.GetVerificationMaterial()
+	if len(material.GetCertificate().GetRawBytes()) == 0 &&
+		len(material.GetX509CertificateChain().GetCertificates()) == 0 && material.GetPublicKey() == nil {
+		return fmt.Errorf("%w: the verification material has no certificate or public key", ErrInvalidBundle)
+	}
+	if envelope := b.GetDsseEnvelope(); envelope != nil {
+		if len(envelope.GetPayload()) == 0 || len(envelope.GetSignatures()) == 0 {
+			return fmt.Errorf("%w: the DSSE envelope has no payload or no signatures", ErrInvalidBundle)
+		}
+		return nil
+	}
+	if len(b.GetMessageSignature().GetSignature()) == 0 {
+		return fmt.Errorf("%w: the message signature is empty", ErrInvalidBundle)
+	}
+	return nil
+}
+
+// verifyEntity verifies a parsed signed entity with artifact digest and additional options
+func (bv *BundleVerifier) verifyEntity(
+	entity verify.SignedEntity,
+	artifactDigest string,
+	digestBytes []byte,
+	opts ...verify.PolicyOption,
+) (*verify.VerificationResult, error) {
+	if err := bv.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load Sigstore trusted root: %w", err)
 	}
 
 	// Create the artifact policy
 	artifactPolicy := verify.WithArtifactDigest(artifactDigest, digestBytes)
 
 	// Verify the bundle
-	result, err := bv.verifier.Verify(b, verify.NewPolicy(artifactPolicy, opts...))
+	result, err := bv.verifier.Verify(entity, verify.NewPolicy(artifactPolicy, opts...))
 	if err != nil {
 		return nil, fmt.Errorf("bundle verification failed: %w", err)
 	}
@@ -80,24 +233,35 @@ func (bv *BundleVerifier) VerifyBundle(
 	return result, nil
 }
 
-// ExtractPublisherInfo extracts basic publisher information from verification result
-// Note: Detailed publisher info is better extracted from the provenance metadata itself
+// ExtractPublisherInfo extracts the publisher a verified result attests: the
+// source repository, ref, and commit named by the Fulcio certificate, falling
+// back to the repository of the signed provenance statement.
 func ExtractPublisherInfo(result *verify.VerificationResult) *domain.TrustedPublisher {
 	if result == nil {
 		return nil
 	}
 
+	// The verification itself proves the identity via certificate matching,
+	// so if verification succeeds, we know the publisher info is trustworthy
 	publisher := &domain.TrustedPublisher{
+		Kind:   "Verified",
 		Claims: make(map[string]interface{}),
 	}
 
-	// For now, we rely on the publisher information from the provenance metadata
-	// (PyPI attestation bundles or npm metadata) rather than extracting from certificates
-	// This is simpler and more reliable
-
-	// The verification itself proves the identity via certificate matching,
-	// so if verification succeeds, we know the publisher info is trustworthy
-	publisher.Kind = "Verified"
+	// The Fulcio certificate does attest the source the release was built from
+	if result.Signature != nil && result.Signature.Certificate != nil {
+		extensions := result.Signature.Certificate.Extensions
+		publisher.Repository = repositoryName(extensions.SourceRepositoryURI)
+		if extensions.SourceRepositoryRef != "" {
+			publisher.Claims[domain.ClaimSourceRef] = extensions.SourceRepositoryRef
+		}
+		if extensions.SourceRepositoryDigest != "" {
+			publisher.Claims[domain.ClaimSourceDigest] = extensions.SourceRepositoryDigest
+		}
+	}
+	if publisher.Repository == "" {
+		publisher.Repository = repositoryName(PredicateRepository(result))
+	}
 
 	return publisher
 }
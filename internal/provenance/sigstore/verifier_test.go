@@ -0,0 +1,128 @@
+package sigstore
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestBundleVerifier_ConcurrentVerify(t *testing.T) {
+	t.Parallel()
+
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	if err != nil {
+		t.Fatalf("failed to create virtual sigstore: %v", err)
+	}
+
+	bv, err := newBundleVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	if err != nil {
+		t.Fatalf("newBundleVerifier() error: %v", err)
+	}
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			artifact := []byte(fmt.Sprintf("artifact %d", i))
+			entity, err := virtualSigstore.Sign("release@example.com", "https://issuer.example.com", artifact)
+			if err != nil {
+				errs <- fmt.Errorf("sign artifact %d: %w", i, err)
+				return
+			}
+			digest := sha256.Sum256(artifact)
+
+			if _, err := bv.verifyEntity(entity, "sha256", digest[:], verify.WithoutIdentitiesUnsafe()); err != nil {
+				errs <- fmt.Errorf("verify artifact %d: %w", i, err)
+				return
+			}
+
+			// A digest for a different artifact must still be rejected under concurrency.
+			other := sha256.Sum256([]byte("something else"))
+			if _, err := bv.verifyEntity(entity, "sha256", other[:], verify.WithoutIdentitiesUnsafe()); err == nil {
+				errs <- fmt.Errorf("verify artifact %d with wrong digest succeeded", i)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestExtractPublisherInfo_SourceRef(t *testing.T) {
+	t.Parallel()
+
+	result := &verify.VerificationResult{Signature: &verify.SignatureVerificationResult{
+		Certificate: &certificate.Summary{Extensions: certificate.Extensions{
+			SourceRepositoryRef:    "refs/tags/v1.2.0",
+			SourceRepositoryDigest: "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+		}},
+	}}
+	provenance := &domain.ProvenanceResult{TrustedPublisher: ExtractPublisherInfo(result)}
+	ref, commit := provenance.SourceRef()
+	if ref != "refs/tags/v1.2.0" || commit != "4b825dc642cb6eb9a060e54bf8d69288fbee4904" {
+		t.Errorf("SourceRef() = %q, %q, want the certificate's ref and commit", ref, commit)
+	}
+
+	// A result signed with a public key has no certificate to claim a source
+	provenance.TrustedPublisher = ExtractPublisherInfo(&verify.VerificationResult{})
+	if ref, commit := provenance.SourceRef(); ref != "" || commit != "" {
+		t.Errorf("SourceRef() = %q, %q, want none", ref, commit)
+	}
+}
+
+func TestExtractPublisherInfo_Repository(t *testing.T) {
+	t.Parallel()
+
+	predicate, err := structpb.NewStruct(map[string]any{"buildDefinition": map[string]any{
+		"externalParameters": map[string]any{"workflow": map[string]any{"repository": "https://github.com/upstash/context7"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := &in_toto.Statement{PredicateType: slsaProvenanceV1, Predicate: predicate}
+	signedBy := func(sourceRepository string) *verify.SignatureVerificationResult {
+		return &verify.SignatureVerificationResult{Certificate: &certificate.Summary{
+			Extensions: certificate.Extensions{SourceRepositoryURI: sourceRepository},
+		}}
+	}
+
+	tests := []struct {
+		name   string
+		result *verify.VerificationResult
+		want   string
+	}{
+		{"certificate", &verify.VerificationResult{Signature: signedBy("https://github.com/upstash/context7")}, "upstash/context7"},
+		{"certificate wins over predicate",
+			&verify.VerificationResult{Signature: signedBy("https://gitlab.com/group/sub/project"), Statement: statement},
+			"group/sub/project"},
+		{"predicate fallback", &verify.VerificationResult{Signature: signedBy(""), Statement: statement}, "upstash/context7"},
+		{"public key signature", &verify.VerificationResult{Statement: statement}, "upstash/context7"},
+		{"no source", &verify.VerificationResult{Signature: signedBy("")}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ExtractPublisherInfo(tt.result).Repository; got != tt.want {
+				t.Errorf("ExtractPublisherInfo().Repository = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package vuln looks up the known vulnerabilities of package versions in the
+// OSV database (https://osv.dev), by the Package URL of the version.
+package vuln
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+const (
+	// DefaultQueryURL is the query endpoint of the public OSV API
+	DefaultQueryURL = "https://api.osv.dev/v1/query"
+	// maxResponseSize caps each page of an OSV response
+	maxResponseSize = 10 << 20
+	// maxPages caps how many pages of vulnerabilities are fetched for a version
+	maxPages = 20
+)
+
+// Vulnerability is a known vulnerability affecting a package version.
+type Vulnerability struct {
+	// ID is the OSV identifier, e.g. "GHSA-xxxx-xxxx-xxxx" or "PYSEC-2024-1"
+	ID string `json:"id"`
+	// Summary is a one-line description, if the advisory has one
+	Summary string `json:"summary,omitempty"`
+	// Aliases are other identifiers of the vulnerability, such as its CVE
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Client queries OSV for vulnerabilities. It is safe for concurrent use.
+type Client struct {
+	httpClient *httpclient.Client
+	queryURL   string
+	logger     *slog.Logger
+}
+
+// NewClient creates an OSV client.
+func NewClient(opts ...Option) *Client {
+	o := options{queryURL: DefaultQueryURL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	logger := o.logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	return &Client{
+		httpClient: httpclient.New(append(o.httpOptions, httpclient.WithLogger(logger))...),
+		queryURL:   o.queryURL,
+		logger:     logger,
+	}
+}
+
+// query is the body of an OSV query request
+type query struct {
+	Package   queryPackage `json:"package"`
+	PageToken string       `json:"page_token,omitempty"`
+}
+
+// queryPackage identifies the package version of a query by its Package URL
+type queryPackage struct {
+	PURL string `json:"purl"`
+}
+
+// queryResponse is a page of an OSV query response
+type queryResponse struct {
+	Vulns         []Vulnerability `json:"vulns"`
+	NextPageToken string          `json:"next_page_token"`
+}
+
+// Query returns the known vulnerabilities of the exact version of pkg, which
+// must have a Package URL. No vulnerabilities is an empty result, not an error.
+func (c *Client) Query(ctx context.Context, pkg domain.PackageIdentifier) ([]Vulnerability, error) {
+	purl, err := PackageURL(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	var vulns []Vulnerability
+	q := query{Package: queryPackage{PURL: purl}}
+	for range maxPages {
+		page, err := c.queryPage(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query OSV for %s: %w", purl, err)
+		}
+		vulns = append(vulns, page.Vulns...)
+		if page.NextPageToken == "" {
+			c.logger.DebugContext(ctx, "queried OSV", "purl", purl, "vulnerabilities", len(vulns))
+			return vulns, nil
+		}
+		q.PageToken = page.NextPageToken
+	}
+	return nil, fmt.Errorf("failed to query OSV for %s: more than %d pages of vulnerabilities", purl, maxPages)
+}
+
+// queryPage sends one query and decodes the page it returns
+func (c *Client) queryPage(ctx context.Context, q query) (*queryResponse, error) {
+	body, err := json.Marshal(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.queryURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	reader, err := httpclient.LimitBody(resp, maxResponseSize)
+	if err != nil {
+		return nil, err
+	}
+	var page queryResponse
+	if err := json.NewDecoder(reader).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &page, nil
+}
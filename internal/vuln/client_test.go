@@ -0,0 +1,60 @@
+package vuln
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestClient_Query(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q query
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch {
+		case q.Package.PURL == "pkg:npm/%40scope/clean@1.0.0":
+			_, _ = w.Write([]byte(`{}`))
+		case q.Package.PURL == "pkg:npm/%40scope/vulnerable@1.0.0" && q.PageToken == "":
+			_, _ = w.Write([]byte(`{"vulns":[{"id":"GHSA-aaaa-bbbb-cccc","summary":"Prototype pollution",` +
+				`"aliases":["CVE-2025-0001"]}],"next_page_token":"page2"}`))
+		case q.Package.PURL == "pkg:npm/%40scope/vulnerable@1.0.0" && q.PageToken == "page2":
+			_, _ = w.Write([]byte(`{"vulns":[{"id":"GHSA-dddd-eeee-ffff"}]}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(WithQueryURL(server.URL), WithHTTPClient(server.Client()))
+
+	pkg := func(name string) domain.PackageIdentifier {
+		return domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: name, Version: "1.0.0"}
+	}
+
+	vulns, err := client.Query(context.Background(), pkg("@scope/clean"))
+	if err != nil || len(vulns) != 0 {
+		t.Errorf("Query(clean) = %v, %v, want no vulnerabilities", vulns, err)
+	}
+
+	vulns, err = client.Query(context.Background(), pkg("@scope/vulnerable"))
+	if err != nil {
+		t.Fatalf("Query(vulnerable) error: %v", err)
+	}
+	if len(vulns) != 2 || vulns[0].ID != "GHSA-aaaa-bbbb-cccc" || vulns[0].Aliases[0] != "CVE-2025-0001" ||
+		vulns[1].ID != "GHSA-dddd-eeee-ffff" {
+		t.Errorf("Query(vulnerable) = %+v, want both pages", vulns)
+	}
+
+	if _, err := client.Query(context.Background(), pkg("@scope/broken")); err == nil ||
+		!strings.Contains(err.Error(), "unexpected status code: 500") {
+		t.Errorf("Query(broken) error = %v, want the status code", err)
+	}
+}
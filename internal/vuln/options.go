@@ -0,0 +1,49 @@
+package vuln
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/stacklok/dockyard/internal/provenance/httpclient"
+)
+
+// options holds the settings applied by NewClient.
+type options struct {
+	httpOptions []httpclient.Option
+	logger      *slog.Logger
+	queryURL    string
+}
+
+// Option configures a Client.
+type Option func(*options)
+
+// WithHTTPClient sets the *http.Client OSV requests are sent with, e.g. to
+// route them through a proxy.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithHTTPClient(httpClient))
+	}
+}
+
+// WithHTTPTimeout sets the timeout applied to each OSV request.
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.httpOptions = append(o.httpOptions, httpclient.WithTimeout(d))
+	}
+}
+
+// WithLogger sets the logger for debug output.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithQueryURL sets the OSV query endpoint, e.g. of a mirror. It defaults to
+// DefaultQueryURL.
+func WithQueryURL(queryURL string) Option {
+	return func(o *options) {
+		o.queryURL = queryURL
+	}
+}
@@ -0,0 +1,136 @@
+package vuln
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// pypiSeparatorRe matches the runs of separators PEP 503 normalizes to a dash
+var pypiSeparatorRe = regexp.MustCompile(`[-_.]+`)
+
+// PackageURL returns the Package URL of an exact package version, e.g.
+// "pkg:npm/%40upstash/context7-mcp@1.0.14", which OSV is queried by. npx,
+// uvx, and go packages have one; a Go package may carry its version inline.
+func PackageURL(pkg domain.PackageIdentifier) (string, error) {
+	name, version := pkg.Name, pkg.Version
+	var purlType string
+	switch pkg.Protocol {
+	case domain.ProtocolNPM:
+		purlType = "npm"
+	case domain.ProtocolPyPI:
+		purlType = "pypi"
+		// Extras select optional dependencies, not another package
+		name, _, _ = strings.Cut(name, "[")
+		name = pypiSeparatorRe.ReplaceAllString(strings.ToLower(name), "-")
+	case domain.ProtocolGo:
+		purlType = "golang"
+		var inline string
+		var found bool
+		if name, inline, found = strings.Cut(name, "@"); found && version == "" {
+			version = inline
+		}
+	default:
+		return "", fmt.Errorf("%s packages have no Package URL", pkg.Protocol)
+	}
+	if name == "" {
+		return "", fmt.Errorf("package name is empty")
+	}
+	if version == "" || version == "latest" {
+		return "", fmt.Errorf("%s has no exact version to look up", pkg.Name)
+	}
+
+	// Each path segment is escaped on its own, so a scope's @ becomes %40
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	segments[0] = strings.ReplaceAll(segments[0], "@", "%40")
+	return fmt.Sprintf("pkg:%s/%s@%s", purlType, strings.Join(segments, "/"), url.PathEscape(version)), nil
+}
+
+// purlProtocols maps the Package URL types ParsePackageURL accepts to protocols
+var purlProtocols = map[string]domain.PackageProtocol{
+	"npm":    domain.ProtocolNPM,
+	"pypi":   domain.ProtocolPyPI,
+	"golang": domain.ProtocolGo,
+}
+
+// ParsePackageURL returns the package version a Package URL names, the
+// inverse of PackageURL, e.g. "pkg:npm/%40upstash/context7-mcp@1.0.14" is
+// @upstash/context7-mcp 1.0.14 for npx. The types npm, pypi, and golang are
+// supported, and the version is required. The subpath of a golang URL names
+// a package within the module. Qualifiers describe the artifact and are
+// ignored, except repository_url: a package from another registry cannot be
+// verified as if it came from the default one.
+func ParsePackageURL(purl string) (domain.PackageIdentifier, error) {
+	scheme, rest, ok := strings.Cut(purl, ":")
+	if !ok || !strings.EqualFold(scheme, "pkg") {
+		return domain.PackageIdentifier{}, fmt.Errorf("%q is not a Package URL: it must start with pkg:", purl)
+	}
+	rest, subpath, _ := strings.Cut(rest, "#")
+	rest, rawQualifiers, _ := strings.Cut(rest, "?")
+	if rawQualifiers != "" {
+		qualifiers, err := url.ParseQuery(rawQualifiers)
+		if err != nil {
+			return domain.PackageIdentifier{}, fmt.Errorf("invalid qualifiers in %q: %w", purl, err)
+		}
+		if qualifiers.Has("repository_url") {
+			return domain.PackageIdentifier{}, fmt.Errorf(
+				"%q names another registry with repository_url, which is not supported", purl)
+		}
+	}
+
+	// pkg:// is tolerated like pkg:
+	rest = strings.TrimLeft(rest, "/")
+	purlType, rest, ok := strings.Cut(rest, "/")
+	if !ok {
+		return domain.PackageIdentifier{}, fmt.Errorf("%q has no package name", purl)
+	}
+	protocol, ok := purlProtocols[strings.ToLower(purlType)]
+	if !ok {
+		return domain.PackageIdentifier{}, fmt.Errorf("unsupported Package URL type %q: must be npm, pypi, or golang", purlType)
+	}
+
+	at := strings.LastIndex(rest, "@")
+	if at < 0 {
+		return domain.PackageIdentifier{}, fmt.Errorf("%q has no version", purl)
+	}
+	version, err := url.PathUnescape(rest[at+1:])
+	if err != nil || version == "" {
+		return domain.PackageIdentifier{}, fmt.Errorf("%q has an invalid version", purl)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(rest[:at], "/"), "/") {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil || decoded == "" {
+			return domain.PackageIdentifier{}, fmt.Errorf("%q has an invalid name", purl)
+		}
+		segments = append(segments, decoded)
+	}
+	switch {
+	case protocol == domain.ProtocolNPM && len(segments) > 2,
+		protocol == domain.ProtocolPyPI && len(segments) > 1:
+		return domain.PackageIdentifier{}, fmt.Errorf("%q has too many name segments for %s", purl, purlType)
+	case protocol == domain.ProtocolNPM && len(segments) == 2 && !strings.HasPrefix(segments[0], "@"):
+		// The namespace of an npm package is its scope, whose @ may have been left out
+		segments[0] = "@" + segments[0]
+	}
+	name := strings.Join(segments, "/")
+
+	if subpath = strings.Trim(subpath, "/"); subpath != "" {
+		if protocol != domain.ProtocolGo {
+			return domain.PackageIdentifier{}, fmt.Errorf("%q has a subpath, which only golang Package URLs support", purl)
+		}
+		decoded, err := url.PathUnescape(subpath)
+		if err != nil {
+			return domain.PackageIdentifier{}, fmt.Errorf("%q has an invalid subpath", purl)
+		}
+		name += "/" + decoded
+	}
+	return domain.PackageIdentifier{Protocol: protocol, Name: name, Version: version}, nil
+}
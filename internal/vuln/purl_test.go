@@ -0,0 +1,151 @@
+package vuln
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+func TestPackageURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pkg     domain.PackageIdentifier
+		want    string
+		wantErr string
+	}{
+		{
+			name: "npm",
+			pkg:  domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "agentql-mcp", Version: "1.0.1"},
+			want: "pkg:npm/agentql-mcp@1.0.1",
+		},
+		{
+			name: "scoped npm",
+			pkg:  domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14"},
+			want: "pkg:npm/%40upstash/context7-mcp@1.0.14",
+		},
+		{
+			name: "pypi with extras",
+			pkg:  domain.PackageIdentifier{Protocol: domain.ProtocolPyPI, Name: "MCP_Server.Fetch[cli]", Version: "2025.4.7"},
+			want: "pkg:pypi/mcp-server-fetch@2025.4.7",
+		},
+		{
+			name: "go with inline version",
+			pkg:  domain.PackageIdentifier{Protocol: domain.ProtocolGo, Name: "github.com/org/tool/cmd/server@v1.2.0"},
+			want: "pkg:golang/github.com/org/tool/cmd/server@v1.2.0",
+		},
+		{
+			name:    "unpinned",
+			pkg:     domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "agentql-mcp", Version: "latest"},
+			wantErr: "no exact version",
+		},
+		{
+			name:    "oci",
+			pkg:     domain.PackageIdentifier{Protocol: domain.ProtocolOCI, Name: "ghcr.io/org/server", Version: "1.0.0"},
+			wantErr: "have no Package URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := PackageURL(tt.pkg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PackageURL() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PackageURL() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PackageURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePackageURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		purl    string
+		want    domain.PackageIdentifier
+		wantErr string
+	}{
+		{
+			name: "scoped npm",
+			purl: "pkg:npm/%40upstash/context7-mcp@1.0.14",
+			want: domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14"},
+		},
+		{
+			name: "scope without @",
+			purl: "pkg:npm/upstash/context7-mcp@1.0.14",
+			want: domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14"},
+		},
+		{
+			name: "unescaped scope and qualifiers",
+			purl: "pkg:NPM/@upstash/context7-mcp@1.0.14?vcs_url=git%2Bhttps%3A%2F%2Fgithub.com%2Fupstash%2Fcontext7",
+			want: domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "@upstash/context7-mcp", Version: "1.0.14"},
+		},
+		{
+			name: "pypi",
+			purl: "pkg:pypi/mcp-server-fetch@2025.4.7?file_name=mcp_server_fetch-2025.4.7-py3-none-any.whl",
+			want: domain.PackageIdentifier{Protocol: domain.ProtocolPyPI, Name: "mcp-server-fetch", Version: "2025.4.7"},
+		},
+		{
+			name: "golang with subpath",
+			purl: "pkg://golang/github.com/org/tool@v1.2.3#cmd/server",
+			want: domain.PackageIdentifier{Protocol: domain.ProtocolGo, Name: "github.com/org/tool/cmd/server", Version: "v1.2.3"},
+		},
+		{
+			name: "escaped version",
+			purl: "pkg:golang/github.com/org/tool@v0.0.0-20250102030405-abcdef012345%2Bincompatible",
+			want: domain.PackageIdentifier{
+				Protocol: domain.ProtocolGo, Name: "github.com/org/tool", Version: "v0.0.0-20250102030405-abcdef012345+incompatible",
+			},
+		},
+		{
+			name: "unscoped npm",
+			purl: "pkg:npm/agentql-mcp@1.0.1",
+			want: domain.PackageIdentifier{Protocol: domain.ProtocolNPM, Name: "agentql-mcp", Version: "1.0.1"},
+		},
+		{name: "not a purl", purl: "npm/context7-mcp@1.0.0", wantErr: "must start with pkg:"},
+		{name: "unsupported type", purl: "pkg:maven/org.apache/commons@1.0", wantErr: `unsupported Package URL type "maven"`},
+		{name: "no version", purl: "pkg:npm/context7-mcp", wantErr: "has no version"},
+		{name: "empty version", purl: "pkg:npm/context7-mcp@", wantErr: "invalid version"},
+		{name: "no name", purl: "pkg:npm", wantErr: "no package name"},
+		{name: "other registry", purl: "pkg:npm/pkg@1.0.0?repository_url=https://npm.example.com", wantErr: "repository_url"},
+		{name: "subpath outside golang", purl: "pkg:npm/pkg@1.0.0#lib", wantErr: "only golang"},
+		{name: "pypi namespace", purl: "pkg:pypi/org/pkg@1.0.0", wantErr: "too many name segments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParsePackageURL(tt.purl)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePackageURL(%q) error = %v, want it to contain %q", tt.purl, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePackageURL(%q) error: %v", tt.purl, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePackageURL(%q) = %+v, want %+v", tt.purl, got, tt.want)
+			}
+			// A parsed Package URL renders back to its canonical form
+			if _, err := PackageURL(got); err != nil {
+				t.Errorf("PackageURL(%+v) error: %v", got, err)
+			}
+		})
+	}
+}
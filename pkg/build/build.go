@@ -0,0 +1,262 @@
+// Package build generates the Dockerfile of an MCP server image from its
+// dockyard spec, the same way `dockhand build` does:
+//
+//	dockerfile, err := build.GenerateDockerfile(ctx, &build.MCPServerSpec{
+//		Metadata: build.MCPServerMetadata{Name: "context7", Protocol: "npx"},
+//		Spec:     build.MCPServerPackageSpec{Package: "@upstash/context7-mcp", Version: "1.0.14"},
+//	})
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/stacklok/toolhive/pkg/container/images"
+	"github.com/stacklok/toolhive/pkg/runner"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+)
+
+// DefaultRegistry is the image repository prefix of generated image tags
+// unless WithRegistry gives another.
+const DefaultRegistry = "ghcr.io/stacklok/dockyard"
+
+// options holds the configuration for GenerateDockerfile
+type options struct {
+	imageTag   string
+	registry   string
+	caCertPath string
+	args       []string
+}
+
+// Option configures GenerateDockerfile
+type Option func(*options)
+
+// WithImageTag sets the image tag the Dockerfile is generated for instead of
+// the one derived from the spec.
+func WithImageTag(tag string) Option {
+	return func(o *options) {
+		o.imageTag = tag
+	}
+}
+
+// WithRegistry sets the image repository prefix of the derived image tag. The
+// default is DefaultRegistry.
+func WithRegistry(registry string) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// WithCACertPath adds the PEM CA certificate at path to the image's trust
+// store, for package registries behind a TLS-intercepting proxy. It overrides
+// the spec's ca_cert.
+func WithCACertPath(path string) Option {
+	return func(o *options) {
+		o.caCertPath = path
+	}
+}
+
+// WithArgs appends arguments to the spec's args, which the image passes to the
+// MCP server after the package on every start.
+func WithArgs(args ...string) Option {
+	return func(o *options) {
+		o.args = append(o.args, args...)
+	}
+}
+
+// GenerateDockerfile generates the Dockerfile for spec with toolhive, without
+// building it, and appends the spec's dockerfile_append lines and healthcheck.
+func GenerateDockerfile(ctx context.Context, spec *MCPServerSpec, opts ...Option) (string, error) {
+	o := options{registry: DefaultRegistry}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	protocolScheme, err := ProtocolScheme(spec)
+	if err != nil {
+		return "", err
+	}
+	imageTag := o.imageTag
+	if imageTag == "" {
+		imageTag = ImageTag(spec, o.registry)
+	}
+	args := append(slices.Clone(spec.Spec.Args), o.args...)
+	if err := ValidateArgs(args); err != nil {
+		return "", err
+	}
+	caCertPath := o.caCertPath
+	if caCertPath == "" {
+		caCertPath = spec.Spec.CACert
+	}
+	if caCertPath != "" {
+		if err := ValidateCACert(caCertPath); err != nil {
+			return "", err
+		}
+	}
+
+	// Create image manager
+	imageManager := images.NewImageManager(ctx)
+
+	// Generate Dockerfile using toolhive's BuildFromProtocolSchemeWithName function with dryRun=true
+	dockerfile, err := runner.BuildFromProtocolSchemeWithName(
+		ctx,
+		imageManager,
+		protocolScheme,
+		caCertPath,
+		imageTag,
+		args, // baked into the entrypoint after the package
+		nil,  // runtimeOverride - use defaults
+		true, // always dryRun to generate Dockerfile
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate Dockerfile for protocol scheme %s: %w", protocolScheme, err)
+	}
+
+	return appendDockerfileCustomizations(dockerfile, &spec.Spec)
+}
+
+// ValidateArgs checks that every server argument is a non-empty single line.
+func ValidateArgs(args []string) error {
+	for i, arg := range args {
+		if strings.TrimSpace(arg) == "" {
+			return fmt.Errorf("argument %d is empty", i+1)
+		}
+		if strings.ContainsAny(arg, "\r\n\x00") {
+			return fmt.Errorf("argument %d (%q) must be a single line", i+1, arg)
+		}
+	}
+	return nil
+}
+
+// ProtocolScheme creates the protocol scheme string passed to toolhive,
+// e.g. npx://@upstash/context7-mcp@1.0.14, after validating the package
+// reference against the ecosystem's naming rules. A spec's protocol_scheme is
+// used as given instead, once checked against metadata.protocol.
+func ProtocolScheme(spec *MCPServerSpec) (string, error) {
+	if spec.Spec.ProtocolScheme != "" {
+		return overrideProtocolScheme(spec)
+	}
+
+	name, version, err := specPackageRef(spec)
+	if err != nil {
+		return "", err
+	}
+	if err := validatePackageRef(spec.Metadata.Protocol, name, version); err != nil {
+		return "", err
+	}
+	if spec.Metadata.Protocol == string(domain.ProtocolOCI) {
+		return "", fmt.Errorf("%s specs reference a prebuilt image, which has no protocol scheme to build from",
+			domain.ProtocolOCI)
+	}
+
+	if version == "" {
+		version = defaultSchemeVersion(spec.Metadata.Protocol)
+	}
+	packageRef := name
+	if version != "" {
+		packageRef = fmt.Sprintf("%s@%s", packageRef, version)
+	}
+	return fmt.Sprintf("%s://%s", spec.Metadata.Protocol, packageRef), nil
+}
+
+// unsafeSchemeChars are the characters a protocol_scheme may not contain:
+// whitespace and anything a shell would interpret in the generated Dockerfile
+const unsafeSchemeChars = " \t\r\n\x00`$'\"\\;|<>"
+
+// overrideProtocolScheme validates the protocol_scheme of spec, which must
+// name the spec's own protocol and a package after it
+func overrideProtocolScheme(spec *MCPServerSpec) (string, error) {
+	scheme := spec.Spec.ProtocolScheme
+	if spec.Metadata.Protocol == string(domain.ProtocolOCI) {
+		return "", fmt.Errorf("%s specs reference a prebuilt image, which has no protocol scheme to build from",
+			domain.ProtocolOCI)
+	}
+	prefix := spec.Metadata.Protocol + "://"
+	ref, ok := strings.CutPrefix(scheme, prefix)
+	if !ok {
+		return "", fmt.Errorf("protocol_scheme %q must start with %s to match metadata.protocol", scheme, prefix)
+	}
+	if ref == "" {
+		return "", fmt.Errorf("protocol_scheme %q names no package", scheme)
+	}
+	if strings.ContainsAny(ref, unsafeSchemeChars) {
+		return "", fmt.Errorf("protocol_scheme %q must not contain whitespace, quotes, or shell metacharacters", scheme)
+	}
+	return scheme, nil
+}
+
+// ImageTag creates a container image tag based on the repository structure
+// Following the pattern: {registry}/{protocol}/{name}:{version}
+func ImageTag(spec *MCPServerSpec, registry string) string {
+	name := ImageName(spec)
+
+	// Use version from spec, fallback to latest whatever the protocol scheme
+	// installs. An invalid inline Go version fails ProtocolScheme, so the
+	// spec version is good enough here.
+	_, version, err := specPackageRef(spec)
+	if err != nil {
+		version = spec.Spec.Version
+	}
+	if version == "" {
+		version = latestVersion
+	}
+
+	return fmt.Sprintf("%s/%s/%s:%s", registry, spec.Metadata.Protocol, name, version)
+}
+
+// ImageName returns the cleaned image name of spec. A Go spec named
+// after its module path, e.g. github.com/org/tool/cmd/server, is named after
+// the path's last element instead of the whole dashed path.
+func ImageName(spec *MCPServerSpec) string {
+	name := spec.Metadata.Name
+	if spec.Metadata.Protocol == string(domain.ProtocolGo) && strings.Contains(name, "/") {
+		name = goImageBaseName(name)
+	}
+	return cleanPackageName(name)
+}
+
+// maxImageNameLength caps the readable part of a cleaned image name, leaving
+// room for the registry prefix and protocol within OCI's 255-character limit.
+const maxImageNameLength = 100
+
+var (
+	// canonicalImageNameRe matches names that are already valid OCI path
+	// components in dockyard's dash-separated style and are used verbatim.
+	canonicalImageNameRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	// invalidImageNameCharsRe matches runs of characters not allowed in a cleaned name.
+	invalidImageNameCharsRe = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// cleanPackageName converts a package name to a valid container image name.
+// Names that are already lowercase, dash-separated alphanumerics are returned
+// unchanged. Any other name is lowercased, has each run of other characters
+// replaced with a single dash, and gets a short hash of the original name
+// appended, so that names differing only in punctuation or case (a/b, a_b,
+// A-B) do not collide on the same image.
+func cleanPackageName(packageName string) string {
+	if len(packageName) <= maxImageNameLength && canonicalImageNameRe.MatchString(packageName) {
+		return packageName
+	}
+
+	name := strings.TrimPrefix(strings.ToLower(packageName), "@")
+	name = invalidImageNameCharsRe.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-")
+	if len(name) > maxImageNameLength {
+		name = strings.TrimRight(name[:maxImageNameLength], "-")
+	}
+	if name == "" {
+		name = "mcp-server"
+	}
+	if packageName == "" {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(packageName))
+	return name + "-" + hex.EncodeToString(sum[:4])
+}
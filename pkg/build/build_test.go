@@ -0,0 +1,100 @@
+package build
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestImageTag(t *testing.T) {
+	t.Parallel()
+
+	spec := &MCPServerSpec{
+		Metadata: MCPServerMetadata{Name: "context7", Protocol: "npx"},
+		Spec:     MCPServerPackageSpec{Package: "@upstash/context7-mcp", Version: "1.0.14"},
+	}
+
+	if got, want := ImageTag(spec, DefaultRegistry), "ghcr.io/stacklok/dockyard/npx/context7:1.0.14"; got != want {
+		t.Errorf("ImageTag(default) = %q, want %q", got, want)
+	}
+	if got, want := ImageTag(spec, "registry.example.com/mcp"), "registry.example.com/mcp/npx/context7:1.0.14"; got != want {
+		t.Errorf("ImageTag(override) = %q, want %q", got, want)
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"serve", "--config=/etc/mcp.yaml", "--read-only"}, false},
+		{[]string{"--name", "two words"}, false},
+		{[]string{"serve", ""}, true},
+		{[]string{"  "}, true},
+		{[]string{"--flag\nRUN id"}, true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateArgs(tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCleanPackageName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"canonical", "context7"},
+		{"canonical with dashes", "mcp-clickhouse"},
+		{"scoped npm", "@upstash/context7-mcp"},
+		{"slash", "a/b"},
+		{"underscore", "a_b"},
+		{"uppercase", "A-B"},
+		{"dots", "mcp.server"},
+		{"unicode", "café-mcp"},
+		{"only invalid", "@@@"},
+		{"too long", strings.Repeat("a", 120)},
+		{"empty", ""},
+	}
+
+	seen := make(map[string]string)
+	for _, tt := range tests {
+		got := cleanPackageName(tt.input)
+		if !validImageNameRe.MatchString(got) {
+			t.Errorf("cleanPackageName(%q) = %q, not a valid OCI path component", tt.input, got)
+		}
+		if len(got) > maxImageNameLength+9 {
+			t.Errorf("cleanPackageName(%q) = %q, longer than %d characters", tt.input, got, maxImageNameLength+9)
+		}
+		if prev, ok := seen[got]; ok {
+			t.Errorf("cleanPackageName(%q) = %q, collides with %q", tt.input, got, prev)
+		}
+		seen[got] = tt.input
+		if again := cleanPackageName(tt.input); again != got {
+			t.Errorf("cleanPackageName(%q) is not deterministic: %q then %q", tt.input, got, again)
+		}
+	}
+
+	for input, want := range map[string]string{
+		"context7":       "context7",
+		"mcp-clickhouse": "mcp-clickhouse",
+		"":               "mcp-server",
+	} {
+		if got := cleanPackageName(input); got != want {
+			t.Errorf("cleanPackageName(%q) = %q, want %q", input, got, want)
+		}
+	}
+	if got := cleanPackageName("@upstash/context7-mcp"); !strings.HasPrefix(got, "upstash-context7-mcp-") {
+		t.Errorf("cleanPackageName(@upstash/context7-mcp) = %q, want upstash-context7-mcp-<hash>", got)
+	}
+}
+
+// validImageNameRe is the OCI distribution grammar for a repository path component.
+var validImageNameRe = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*$`)
@@ -0,0 +1,41 @@
+package build
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ValidateCACert checks that path is a PEM file holding at least one X.509
+// certificate and nothing else, so a mistyped path or the wrong file fails
+// before toolhive embeds it in an image.
+func ValidateCACert(path string) error {
+	data, err := os.ReadFile(path) //#nosec G304 -- path is chosen by the user running the build
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("CA certificate %s does not exist", path)
+	} else if err != nil {
+		return fmt.Errorf("failed to read CA certificate %s: %w", path, err)
+	}
+
+	certificates := 0
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("CA certificate %s contains a %s block, want only CERTIFICATE blocks", path, block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("CA certificate %s is not a valid certificate: %w", path, err)
+		}
+		certificates++
+	}
+	if certificates == 0 {
+		return fmt.Errorf("CA certificate %s is not valid PEM: no CERTIFICATE block found", path)
+	}
+	return nil
+}
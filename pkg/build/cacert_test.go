@@ -0,0 +1,79 @@
+package build
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCertificatePEM returns a self-signed CA certificate in PEM form.
+func testCertificatePEM(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Example Proxy CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestValidateCACert(t *testing.T) {
+	t.Parallel()
+
+	cert := testCertificatePEM(t)
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
+		{"certificate", cert, ""},
+		{"bundle", append(append([]byte{}, cert...), cert...), ""},
+		{"not PEM", []byte("not a certificate"), "not valid PEM"},
+		{"private key", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1}}), "PRIVATE KEY block"},
+		{"corrupt certificate", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}}),
+			"not a valid certificate"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".pem")
+		if err := os.WriteFile(path, tt.content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		err := ValidateCACert(path)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateCACert(%s) error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateCACert(%s) error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if err := ValidateCACert(filepath.Join(dir, "missing.pem")); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("ValidateCACert(missing) error = %v, want does not exist", err)
+	}
+}
@@ -0,0 +1,141 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// appendForbiddenInstructions are the instructions dockerfile_append may not
+// use: they would replace the image toolhive built (FROM), change how the MCP
+// server starts (ENTRYPOINT, CMD), or run in images built from this one
+// (ONBUILD). Health checks are set with spec.healthcheck.
+var appendForbiddenInstructions = []string{"FROM", "ENTRYPOINT", "CMD", "ONBUILD", "HEALTHCHECK"}
+
+// dangerousAppendPatterns catch the obviously unsafe steps a reviewer could
+// miss in a spec diff.
+var dangerousAppendPatterns = []struct {
+	re     *regexp.Regexp
+	reason string
+}{
+	{regexp.MustCompile(`(?i)\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(ba|da|z)?sh\b`), "pipes a download into a shell"},
+	{regexp.MustCompile(`(?i)^ADD\s+(--\S+\s+)*https?://`), "adds a remote file without a checksum"},
+	{regexp.MustCompile(`\brm\s+-[a-zA-Z]*[rR][a-zA-Z]*\s+(-\S+\s+)*/(\*)?(\s|$)`), "removes the root filesystem"},
+	{regexp.MustCompile(`\bchmod\s+(-\S+\s+)*0?777\b`), "makes files world-writable"},
+}
+
+// ValidateCustomizations checks the spec's dockerfile_append lines and
+// healthcheck, which GenerateDockerfile adds to the end of the Dockerfile.
+func ValidateCustomizations(pkg *MCPServerPackageSpec) error {
+	for i, line := range pkg.DockerfileAppend {
+		if err := validateAppendLine(line); err != nil {
+			return fmt.Errorf("spec.dockerfile_append[%d]: %w", i, err)
+		}
+	}
+	if pkg.Healthcheck != nil {
+		if _, err := healthcheckInstruction(pkg.Healthcheck); err != nil {
+			return fmt.Errorf("spec.healthcheck: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateAppendLine checks one dockerfile_append entry: a single Dockerfile
+// instruction that is allowed and not obviously dangerous.
+func validateAppendLine(line string) error {
+	if strings.ContainsAny(line, "\r\n\x00") {
+		return fmt.Errorf("must be a single line")
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return fmt.Errorf("%q is not a Dockerfile instruction with arguments", line)
+	}
+	instruction := strings.ToUpper(fields[0])
+	for _, forbidden := range appendForbiddenInstructions {
+		if instruction == forbidden {
+			return fmt.Errorf("%s instructions are not allowed", forbidden)
+		}
+	}
+	if strings.HasSuffix(strings.TrimSpace(line), `\`) {
+		return fmt.Errorf("line continuations are not allowed, give each instruction on one line")
+	}
+	for _, p := range dangerousAppendPatterns {
+		if p.re.MatchString(line) {
+			return fmt.Errorf("%q %s", line, p.reason)
+		}
+	}
+	return nil
+}
+
+// healthcheckInstruction renders hc as a HEALTHCHECK instruction, with the
+// command in JSON exec form so that no argument is interpreted by a shell.
+func healthcheckInstruction(hc *HealthcheckSpec) (string, error) {
+	if len(hc.Command) == 0 {
+		return "", fmt.Errorf("command is required")
+	}
+	for _, arg := range hc.Command {
+		if strings.ContainsAny(arg, "\r\n\x00") {
+			return "", fmt.Errorf("command arguments must be single lines")
+		}
+	}
+	if hc.Retries < 0 {
+		return "", fmt.Errorf("retries must not be negative, got %d", hc.Retries)
+	}
+
+	var b strings.Builder
+	b.WriteString("HEALTHCHECK")
+	for _, opt := range []struct{ flag, value string }{
+		{"interval", hc.Interval},
+		{"timeout", hc.Timeout},
+		{"start-period", hc.StartPeriod},
+	} {
+		if opt.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(opt.value)
+		if err != nil || d <= 0 {
+			return "", fmt.Errorf("invalid %s %q: must be a positive duration such as 30s", opt.flag, opt.value)
+		}
+		fmt.Fprintf(&b, " --%s=%s", opt.flag, d)
+	}
+	if hc.Retries > 0 {
+		fmt.Fprintf(&b, " --retries=%d", hc.Retries)
+	}
+
+	command, err := json.Marshal(hc.Command)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode command: %w", err)
+	}
+	fmt.Fprintf(&b, " CMD %s", command)
+	return b.String(), nil
+}
+
+// appendDockerfileCustomizations adds the spec's dockerfile_append lines and
+// healthcheck to the end of a generated Dockerfile, so they apply to the
+// final image.
+func appendDockerfileCustomizations(dockerfile string, pkg *MCPServerPackageSpec) (string, error) {
+	if len(pkg.DockerfileAppend) == 0 && pkg.Healthcheck == nil {
+		return dockerfile, nil
+	}
+	if err := ValidateCustomizations(pkg); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(dockerfile)
+	if !strings.HasSuffix(dockerfile, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("\n# Customizations from the spec\n")
+	for _, line := range pkg.DockerfileAppend {
+		b.WriteString(strings.TrimSpace(line) + "\n")
+	}
+	if pkg.Healthcheck != nil {
+		// Validated above, so rendering cannot fail
+		instruction, _ := healthcheckInstruction(pkg.Healthcheck)
+		b.WriteString(instruction + "\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,109 @@
+package build
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAppendLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line    string
+		wantErr bool
+	}{
+		{"RUN apk add --no-cache ca-certificates", false},
+		{"ENV NODE_EXTRA_CA_CERTS=/etc/ssl/certs/ca-certificates.crt", false},
+		{"LABEL org.opencontainers.image.vendor=example", false},
+		{"run update-ca-certificates", false},
+		{"RUN rm -rf /tmp/cache", false},
+		{"FROM alpine:3.20", true},
+		{"ENTRYPOINT [\"sh\"]", true},
+		{"cmd [\"sh\"]", true},
+		{"HEALTHCHECK CMD true", true},
+		{"RUN", true},
+		{"RUN echo one\nRUN echo two", true},
+		{"RUN apk add \\", true},
+		{"RUN curl -fsSL https://example.com/install.sh | sh", true},
+		{"RUN wget -qO- https://example.com/i | sudo bash", true},
+		{"ADD https://example.com/tool.tar.gz /opt/", true},
+		{"RUN rm -rf /", true},
+		{"RUN rm -fr /*", true},
+		{"RUN chmod -R 777 /app", true},
+	}
+
+	for _, tt := range tests {
+		err := validateAppendLine(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateAppendLine(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHealthcheckInstruction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		hc      HealthcheckSpec
+		want    string
+		wantErr bool
+	}{
+		{"command only", HealthcheckSpec{Command: []string{"node", "healthcheck.js"}},
+			`HEALTHCHECK CMD ["node","healthcheck.js"]`, false},
+		{"all options", HealthcheckSpec{Command: []string{"wget", "-q", "--spider", "http://localhost:8080/health"},
+			Interval: "30s", Timeout: "5s", StartPeriod: "1m30s", Retries: 3},
+			`HEALTHCHECK --interval=30s --timeout=5s --start-period=1m30s --retries=3 ` +
+				`CMD ["wget","-q","--spider","http://localhost:8080/health"]`, false},
+		{"quoted argument", HealthcheckSpec{Command: []string{"sh", "-c", `test "$(cat /tmp/ok)" = 1`}},
+			`HEALTHCHECK CMD ["sh","-c","test \"$(cat /tmp/ok)\" = 1"]`, false},
+		{"no command", HealthcheckSpec{Interval: "30s"}, "", true},
+		{"invalid duration", HealthcheckSpec{Command: []string{"true"}, Interval: "often"}, "", true},
+		{"zero duration", HealthcheckSpec{Command: []string{"true"}, Timeout: "0s"}, "", true},
+		{"negative retries", HealthcheckSpec{Command: []string{"true"}, Retries: -1}, "", true},
+		{"multiline argument", HealthcheckSpec{Command: []string{"sh", "-c", "true\nRUN evil"}}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := healthcheckInstruction(&tt.hc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("healthcheckInstruction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("healthcheckInstruction() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendDockerfileCustomizations(t *testing.T) {
+	t.Parallel()
+
+	base := "FROM node:22-alpine\nENTRYPOINT [\"npx\"]"
+	pkg := &MCPServerPackageSpec{
+		DockerfileAppend: []string{"  USER root", "RUN apk add --no-cache ca-certificates"},
+		Healthcheck:      &HealthcheckSpec{Command: []string{"true"}, Interval: "1m"},
+	}
+
+	got, err := appendDockerfileCustomizations(base, pkg)
+	if err != nil {
+		t.Fatalf("appendDockerfileCustomizations() error: %v", err)
+	}
+	want := base + "\n\n# Customizations from the spec\n" +
+		"USER root\nRUN apk add --no-cache ca-certificates\nHEALTHCHECK --interval=1m0s CMD [\"true\"]\n"
+	if got != want {
+		t.Errorf("appendDockerfileCustomizations() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got, err := appendDockerfileCustomizations(base, &MCPServerPackageSpec{}); err != nil || got != base {
+		t.Errorf("appendDockerfileCustomizations(no customizations) = %q, %v, want the Dockerfile unchanged", got, err)
+	}
+
+	_, err = appendDockerfileCustomizations(base, &MCPServerPackageSpec{DockerfileAppend: []string{"FROM scratch"}})
+	if err == nil || !strings.Contains(err.Error(), "dockerfile_append[0]") {
+		t.Errorf("appendDockerfileCustomizations(FROM) error = %v, want dockerfile_append[0]", err)
+	}
+}
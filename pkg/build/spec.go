@@ -0,0 +1,111 @@
+package build
+
+// MCPServerSpec defines the structure of our YAML configuration files
+type MCPServerSpec struct {
+	// Metadata about the MCP server
+	Metadata MCPServerMetadata `yaml:"metadata"`
+	// Spec defines the package and build configuration
+	Spec MCPServerPackageSpec `yaml:"spec"`
+	// Provenance information for supply chain security
+	Provenance MCPServerProvenance `yaml:"provenance,omitempty"`
+	// Security configures the MCP security scan run in CI; dockhand ignores it
+	Security *SecurityConfig `yaml:"security,omitempty"`
+}
+
+// MCPServerMetadata contains basic information about the MCP server
+type MCPServerMetadata struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Protocol    string `yaml:"protocol"` // npx, uvx, go, oci
+}
+
+// MCPServerPackageSpec defines the package to be containerized
+type MCPServerPackageSpec struct {
+	Package string   `yaml:"package"`           // e.g., "@upstash/context7-mcp"
+	Version string   `yaml:"version,omitempty"` // e.g., "1.0.14"
+	Args    []string `yaml:"args,omitempty"`    // Additional arguments for the package
+
+	// DockerfileAppend are raw instructions appended to the generated Dockerfile
+	DockerfileAppend []string `yaml:"dockerfile_append,omitempty"`
+	// Healthcheck adds a HEALTHCHECK instruction to the generated Dockerfile
+	Healthcheck *HealthcheckSpec `yaml:"healthcheck,omitempty"`
+	// CACert is a PEM CA certificate to trust during the build and in the image
+	CACert string `yaml:"ca_cert,omitempty"`
+	// ExpectedDigest pins the artifact digest verification must compute, e.g.
+	// "sha512:<hex>" of an npm tarball or "sha256:<hex>" of a wheel or image
+	ExpectedDigest string `yaml:"expected_digest,omitempty"`
+	// ProtocolScheme replaces the protocol scheme assembled from package and
+	// version, e.g. "uvx://pkg[extra]@1.0.0", for forms the assembly cannot express
+	ProtocolScheme string `yaml:"protocol_scheme,omitempty"`
+}
+
+// MCPServerProvenance contains supply chain provenance information
+type MCPServerProvenance struct {
+	// Expected source repository for verification
+	RepositoryURI string `yaml:"repository_uri,omitempty"`
+	RepositoryRef string `yaml:"repository_ref,omitempty"`
+
+	// GoSum is the go.sum line of a Go package's module, e.g.
+	// "github.com/org/tool v1.2.3 h1:...", pinning the hash verification expects
+	GoSum string `yaml:"go_sum,omitempty"`
+
+	// Attestation information
+	Attestations *AttestationInfo `yaml:"attestations,omitempty"`
+
+	// Legacy fields (kept for backwards compatibility). Deprecated: the loader
+	// maps them onto Attestations and the repository fields with a warning.
+	SigstoreURL       string `yaml:"sigstore_url,omitempty"`
+	SignerIdentity    string `yaml:"signer_identity,omitempty"`
+	RunnerEnvironment string `yaml:"runner_environment,omitempty"`
+	CertIssuer        string `yaml:"cert_issuer,omitempty"`
+}
+
+// AttestationInfo contains information about package attestations
+type AttestationInfo struct {
+	Available bool           `yaml:"available"`
+	Publisher *PublisherInfo `yaml:"publisher,omitempty"`
+	Verified  bool           `yaml:"verified,omitempty"`
+}
+
+// PublisherInfo contains trusted publisher information
+type PublisherInfo struct {
+	Kind       string `yaml:"kind"`       // e.g., "GitHub", "GitLab"
+	Repository string `yaml:"repository"` // e.g., "owner/repo"
+	Workflow   string `yaml:"workflow,omitempty"`
+}
+
+// HealthcheckSpec describes the HEALTHCHECK instruction of an image. Durations
+// use Go syntax, e.g. 30s or 1m30s, which Docker accepts as well.
+type HealthcheckSpec struct {
+	// Command is run in exec form, e.g. ["node", "healthcheck.js"]
+	Command     []string `yaml:"command"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+}
+
+// SecurityConfig configures the security scan of the server's image
+type SecurityConfig struct {
+	// InsecureIgnore reports scan findings without failing the scan
+	InsecureIgnore bool `yaml:"insecure_ignore,omitempty"`
+	// MockEnv are environment variables set so the server starts for scanning
+	MockEnv []MockEnvVar `yaml:"mock_env,omitempty"`
+	// AllowedIssues are scan findings accepted as false positives
+	AllowedIssues []AllowedIssue `yaml:"allowed_issues,omitempty"`
+}
+
+// MockEnvVar is a placeholder environment variable for the security scan
+type MockEnvVar struct {
+	Name        string `yaml:"name"`
+	Value       string `yaml:"value"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// AllowedIssue is a security scan finding accepted for this server
+type AllowedIssue struct {
+	Code   string `yaml:"code,omitempty"`    // e.g., "AITech-1.1"
+	RuleID string `yaml:"rule_id,omitempty"` // scanner rule, matched exactly
+	Tool   string `yaml:"tool,omitempty"`    // MCP tool the finding is about
+	Reason string `yaml:"reason"`
+}
@@ -0,0 +1,267 @@
+// Package provenance is the importable entrypoint to dockyard's package
+// provenance verification. It wires up the built-in npm, PyPI, Go, and OCI
+// verifiers, the Sigstore-based ones sharing one trusted root, and re-exports
+// the types needed to use them:
+//
+//	svc, err := provenance.New(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	result, err := svc.VerifyProvenance(ctx, provenance.PackageIdentifier{
+//		Protocol: provenance.ProtocolNPM,
+//		Name:     "@upstash/context7-mcp",
+//		Version:  "2.2.4",
+//	})
+package provenance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/dockyard/internal/provenance/domain"
+	"github.com/stacklok/dockyard/internal/provenance/gomod"
+	"github.com/stacklok/dockyard/internal/provenance/npm"
+	"github.com/stacklok/dockyard/internal/provenance/oci"
+	"github.com/stacklok/dockyard/internal/provenance/pypi"
+	"github.com/stacklok/dockyard/internal/provenance/service"
+	"github.com/stacklok/dockyard/internal/provenance/sigstore"
+)
+
+// Service coordinates provenance verification across protocols. It is safe
+// for concurrent use.
+type Service = service.Service
+
+// PackageIdentifier uniquely identifies a package in its ecosystem
+type PackageIdentifier = domain.PackageIdentifier
+
+// PackageProtocol represents the package protocol/ecosystem
+type PackageProtocol = domain.PackageProtocol
+
+// Result contains the result of a provenance verification
+type Result = domain.ProvenanceResult
+
+// Status represents the provenance verification status
+type Status = domain.ProvenanceStatus
+
+// TrustedPublisher contains information about the trusted publisher
+type TrustedPublisher = domain.TrustedPublisher
+
+// Verifier is implemented by the verifier of one protocol
+type Verifier = domain.ProvenanceVerifier
+
+// Requirements defines what provenance a package must have to pass
+type Requirements = domain.ProvenanceRequirements
+
+// ProgressFunc reports that done of total packages in a batch are verified,
+// last being the result that just finished
+type ProgressFunc = service.ProgressFunc
+
+// Summary aggregates the results of a batch verification
+type Summary = service.Summary
+
+// Summarize tallies the results of BatchVerify and checks each against requirements.
+func Summarize(results []*Result, requirements Requirements) Summary {
+	return service.Summarize(results, requirements)
+}
+
+// Protocols with a built-in verifier.
+const (
+	ProtocolNPM  = domain.ProtocolNPM
+	ProtocolPyPI = domain.ProtocolPyPI
+	ProtocolGo   = domain.ProtocolGo
+	ProtocolOCI  = domain.ProtocolOCI
+)
+
+// Verification statuses, from strongest to weakest evidence.
+const (
+	StatusVerified         = domain.ProvenanceStatusVerified
+	StatusTrustedPublisher = domain.ProvenanceStatusTrustedPublisher
+	StatusAttestations     = domain.ProvenanceStatusAttestations
+	StatusSignatures       = domain.ProvenanceStatusSignatures
+	StatusNone             = domain.ProvenanceStatusNone
+	StatusUnknown          = domain.ProvenanceStatusUnknown
+	StatusError            = domain.ProvenanceStatusError
+)
+
+// options holds the settings applied by New.
+type options struct {
+	logger      *slog.Logger
+	httpTimeout time.Duration
+	sigstore    []sigstore.Option
+	progress    ProgressFunc
+	// downloadTarball hashes every npm tarball instead of trusting dist.integrity
+	downloadTarball bool
+	// rawResults keeps the Sigstore verification results on each result
+	rawResults bool
+	// headers are set on every npm and PyPI request
+	headers [][2]string
+}
+
+// Option configures the service created by New.
+type Option func(*options)
+
+// WithLogger sets the logger the verifiers write debug output to. By default
+// nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithHTTPTimeout sets the timeout applied to each registry request.
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.httpTimeout = d
+	}
+}
+
+// WithProgress calls progress as BatchVerify completes each verification. Calls
+// are serialized, so progress needs no locking, but it should return quickly.
+func WithProgress(progress ProgressFunc) Option {
+	return func(o *options) {
+		o.progress = progress
+	}
+}
+
+// WithTarballDownload downloads and hashes every npm tarball, recomputing its
+// sha512 integrity rather than taking the registry's dist.integrity on trust.
+// The result's Details record the comparison as integrity_match, and a
+// mismatch, such as from a compromised mirror, fails verification.
+func WithTarballDownload() Option {
+	return func(o *options) {
+		o.downloadTarball = true
+	}
+}
+
+// WithHeader sets a header on every request to the npm registry and the PyPI
+// index, e.g. the API key of a gateway in front of them. Downloads from other
+// hosts are requested without it. It can be given several times. Header
+// values are never logged.
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		o.headers = append(o.headers, [2]string{key, value})
+	}
+}
+
+// WithRawResults keeps the full Sigstore verification result of every bundle
+// that verifies on the Result's RawResults. Results stay lightweight by
+// default; VerificationResults returns the kept ones typed.
+func WithRawResults() Option {
+	return func(o *options) {
+		o.rawResults = true
+	}
+}
+
+// VerificationResults returns the Sigstore verification results kept on
+// result by a service created WithRawResults, or nil.
+func VerificationResults(result *Result) []*verify.VerificationResult {
+	return sigstore.VerificationResults(result)
+}
+
+// WithTUFMirror fetches the Sigstore trusted root from the TUF repository at
+// mirrorURL instead of the public good instance.
+func WithTUFMirror(mirrorURL string) Option {
+	return func(o *options) {
+		o.sigstore = append(o.sigstore, sigstore.WithTUFMirror(mirrorURL))
+	}
+}
+
+// WithTUFRoot sets the root.json of the WithTUFMirror repository, for a
+// private Sigstore deployment.
+func WithTUFRoot(rootJSON []byte) Option {
+	return func(o *options) {
+		o.sigstore = append(o.sigstore, sigstore.WithTUFRoot(rootJSON))
+	}
+}
+
+// WithTrustedRootTTL sets how long the Sigstore trusted root fetched for one
+// service is reused by services created after it in this process, for the
+// same TUF repository. It defaults to an hour; a non-positive ttl fetches the
+// root for every service.
+func WithTrustedRootTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.sigstore = append(o.sigstore, sigstore.WithTrustedRootTTL(ttl))
+	}
+}
+
+// WithTUFTimeout sets how long fetching the Sigstore trusted root may take
+// before the root in the local TUF cache is used instead, with a warning that
+// it may be stale. It defaults to 30 seconds; a non-positive timeout waits for
+// the TUF repository however long it takes.
+func WithTUFTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.sigstore = append(o.sigstore, sigstore.WithTUFTimeout(timeout))
+	}
+}
+
+// ResetTrustedRootCache forgets the Sigstore trusted roots cached for
+// WithTrustedRootTTL, so the next service fetches its root again. It is meant
+// for tests.
+func ResetTrustedRootCache() {
+	sigstore.ResetTrustedRootCache()
+}
+
+// New creates a service with the built-in npm, PyPI, Go, and OCI verifiers registered,
+// as the dockhand CLI uses. The Sigstore trusted root is fetched over TUF on
+// the first verification, not here, so New itself needs no network access.
+func New(ctx context.Context, opts ...Option) (*Service, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	bundleVerifier := sigstore.NewLazyBundleVerifier(o.sigstore...)
+	var serviceOpts []service.Option
+	if o.progress != nil {
+		serviceOpts = append(serviceOpts, service.WithProgress(o.progress))
+	}
+	return newRegistry(bundleVerifier, o).NewService(ctx, serviceOpts...)
+}
+
+// newRegistry returns a registry of the built-in verifiers, all sharing
+// bundleVerifier.
+func newRegistry(bundleVerifier *sigstore.BundleVerifier, o options) *service.Registry {
+	registry := service.NewRegistry()
+	// Registering distinct protocols in an empty registry cannot fail
+	_ = registry.RegisterFactory(ProtocolNPM, func(ctx context.Context) (domain.ProvenanceVerifier, error) {
+		npmOpts := []npm.Option{
+			npm.WithBundleVerifier(bundleVerifier), npm.WithLogger(o.logger), npm.WithHTTPTimeout(o.httpTimeout),
+		}
+		if o.downloadTarball {
+			npmOpts = append(npmOpts, npm.WithTarballDownload())
+		}
+		if o.rawResults {
+			npmOpts = append(npmOpts, npm.WithRawResults())
+		}
+		for _, h := range o.headers {
+			npmOpts = append(npmOpts, npm.WithHeader(h[0], h[1]))
+		}
+		return npm.NewVerifier(ctx, npmOpts...)
+	})
+	_ = registry.RegisterFactory(ProtocolPyPI, func(ctx context.Context) (domain.ProvenanceVerifier, error) {
+		pypiOpts := []pypi.Option{
+			pypi.WithBundleVerifier(bundleVerifier), pypi.WithLogger(o.logger), pypi.WithHTTPTimeout(o.httpTimeout),
+		}
+		if o.rawResults {
+			pypiOpts = append(pypiOpts, pypi.WithRawResults())
+		}
+		for _, h := range o.headers {
+			pypiOpts = append(pypiOpts, pypi.WithHeader(h[0], h[1]))
+		}
+		return pypi.NewVerifier(ctx, pypiOpts...)
+	})
+	_ = registry.RegisterFactory(ProtocolGo, func(ctx context.Context) (domain.ProvenanceVerifier, error) {
+		return gomod.NewVerifier(ctx, gomod.WithLogger(o.logger), gomod.WithHTTPTimeout(o.httpTimeout))
+	})
+	_ = registry.RegisterFactory(ProtocolOCI, func(ctx context.Context) (domain.ProvenanceVerifier, error) {
+		ociOpts := []oci.Option{oci.WithBundleVerifier(bundleVerifier), oci.WithLogger(o.logger)}
+		if o.rawResults {
+			ociOpts = append(ociOpts, oci.WithRawResults())
+		}
+		return oci.NewVerifier(ctx, ociOpts...)
+	})
+	return registry
+}
@@ -0,0 +1,16 @@
+package provenance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewRegistry(t *testing.T) {
+	t.Parallel()
+
+	got := newRegistry(nil, options{}).Protocols()
+	want := []PackageProtocol{ProtocolGo, ProtocolNPM, ProtocolOCI, ProtocolPyPI}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Protocols() = %v, want %v", got, want)
+	}
+}